@@ -9,7 +9,7 @@ VALUES
 (
     'Central Medical Hub',
     'kesehatan',
-    'operational',
+    'operasional',
     ST_SetSRID(ST_MakePoint(100.3543, -0.9471), 4326),
     '{"altitude": 15.5, "accuracy": 3.2}'::jsonb,
     '{
@@ -56,7 +56,7 @@ VALUES
 (
     'Shelter Beta-2',
     'posko',
-    'operational',
+    'operasional',
     ST_SetSRID(ST_MakePoint(100.3621, -0.9512), 4326),
     '{"altitude": 12.3, "accuracy": 2.8}'::jsonb,
     '{
@@ -103,7 +103,7 @@ VALUES
 (
     'Water Point Delta',
     'air_bersih',
-    'operational',
+    'operasional',
     ST_SetSRID(ST_MakePoint(100.3489, -0.9589), 4326),
     '{"altitude": 8.7, "accuracy": 4.1}'::jsonb,
     '{
@@ -138,7 +138,7 @@ VALUES
 (
     'Shelter Alpha',
     'posko',
-    'operational',
+    'operasional',
     ST_SetSRID(ST_MakePoint(100.3712, -0.9438), 4326),
     '{"altitude": 18.2, "accuracy": 2.5}'::jsonb,
     '{
@@ -232,7 +232,7 @@ VALUES
 (
     'Emergency Shelter C',
     'posko',
-    'operational',
+    'operasional',
     ST_SetSRID(ST_MakePoint(100.3256, -0.9678), 4326),
     '{"altitude": 5.3, "accuracy": 4.2}'::jsonb,
     '{
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -8,23 +11,31 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/leksa/datamapper-senyar/internal/config"
+	"github.com/leksa/datamapper-senyar/internal/dto"
 	"github.com/leksa/datamapper-senyar/internal/handler"
 	"github.com/leksa/datamapper-senyar/internal/middleware"
+	"github.com/leksa/datamapper-senyar/internal/migrate"
 	"github.com/leksa/datamapper-senyar/internal/odk"
 	"github.com/leksa/datamapper-senyar/internal/repository"
 	"github.com/leksa/datamapper-senyar/internal/scheduler"
 	"github.com/leksa/datamapper-senyar/internal/service"
 	"github.com/leksa/datamapper-senyar/internal/sse"
 	"github.com/leksa/datamapper-senyar/internal/storage"
+	"github.com/leksa/datamapper-senyar/migrations"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 func main() {
+	// Parse command line flags
+	runMigrations := flag.Bool("migrate", false, "Apply pending database migrations and exit")
+	migrationStatus := flag.Bool("migrate-status", false, "Show database migration status and exit")
+	migrationBaseline := flag.Int64("migrate-baseline", 0, "Record migrations up to this version as applied on a database set up before schema_migrations existed, and exit")
+	flag.Parse()
+
 	// Load configuration
 	cfg := config.Load()
 
@@ -62,61 +73,201 @@ func main() {
 
 	log.Println("Connected to database successfully")
 
+	// Database migrations (run as a one-off command, then exit)
+	if *runMigrations || *migrationStatus || *migrationBaseline > 0 {
+		runner := migrate.NewRunner(db, migrations.FS)
+		if *migrationBaseline > 0 {
+			recorded, err := runner.Baseline(*migrationBaseline)
+			if err != nil {
+				log.Fatalf("Failed to baseline migrations: %v", err)
+			}
+			log.Printf("Migration baseline: %d recorded as applied", len(recorded))
+			return
+		}
+		if *migrationStatus {
+			statuses, err := runner.Status()
+			if err != nil {
+				log.Fatalf("Failed to read migration status: %v", err)
+			}
+			for _, st := range statuses {
+				state := "pending"
+				if st.Applied {
+					state = "applied " + st.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Printf("%06d_%s\t%s\n", st.Version, st.Name, state)
+			}
+			return
+		}
+
+		applied, err := runner.Up()
+		if err != nil {
+			log.Fatalf("Migration failed after applying %d migration(s): %v", len(applied), err)
+		}
+		log.Printf("Migrations complete: %d applied", len(applied))
+		return
+	}
+
+	// Dev-only schema sync from model structs (never enable in production)
+	if cfg.AutoMigrate {
+		if cfg.Environment == "production" {
+			log.Println("Warning: AUTO_MIGRATE is enabled in production; skipping (use -migrate instead)")
+		} else if err := migrate.AutoMigrateModels(db); err != nil {
+			log.Fatalf("AutoMigrate failed: %v", err)
+		}
+	}
+
 	// Initialize repositories
 	locationRepo := repository.NewLocationRepository(db)
 	feedRepo := repository.NewFeedRepository(db)
 	faskesRepo := repository.NewFaskesRepository(db)
 	infrastrukturRepo := repository.NewInfrastrukturRepository(db)
+	activityRepo := repository.NewActivityRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	// List totals are shared by all pages of the same filter
+	countCache := repository.NewCountCache(time.Duration(cfg.ListCountCacheSeconds)*time.Second, int64(cfg.ListCountEstimateAbove))
+	locationRepo.SetCountCache(countCache)
+	feedRepo.SetCountCache(countCache)
+	faskesRepo.SetCountCache(countCache)
+	infrastrukturRepo.SetCountCache(countCache)
+
+	// The form clients share one ODK Central session instead of logging in separately
+	odkTokens := odk.NewTokenCache()
 
 	// Initialize ODK client for posko form
-	odkPoskoConfig := &odk.ODKConfig{
-		BaseURL:   cfg.ODKBaseURL,
-		Email:     cfg.ODKEmail,
-		Password:  cfg.ODKPassword,
-		ProjectID: cfg.ODKProjectID,
-		FormID:    cfg.ODKFormID,
+	odkPoskoConfig := cfg.ODKClientConfig(cfg.ODKFormID, odkTokens)
+	switch mode, err := odkPoskoConfig.AuthMode(); {
+	case errors.Is(err, odk.ErrNoCredentials):
+		log.Println("Warning: no ODK credentials configured, syncs will fail")
+	case err != nil:
+		log.Fatalf("Invalid ODK authentication config: %v", err)
+	default:
+		log.Printf("ODK authentication mode: %s", mode)
 	}
 	odkPoskoClient := odk.NewClient(odkPoskoConfig)
+	odkPoskoClient.SetEntityMappingOptions(odk.EntityMappingOptions{
+		Concurrency:   cfg.EntityMappingConcurrency,
+		ProgressEvery: cfg.EntityMappingProgressEvery,
+	})
 
 	// Initialize ODK client for feed form
-	odkFeedConfig := &odk.ODKConfig{
-		BaseURL:   cfg.ODKBaseURL,
-		Email:     cfg.ODKEmail,
-		Password:  cfg.ODKPassword,
-		ProjectID: cfg.ODKProjectID,
-		FormID:    cfg.ODKFeedFormID,
-	}
+	odkFeedConfig := cfg.ODKClientConfig(cfg.ODKFeedFormID, odkTokens)
 	odkFeedClient := odk.NewClient(odkFeedConfig)
 
 	// Initialize ODK client for faskes form
-	odkFaskesConfig := &odk.ODKConfig{
-		BaseURL:   cfg.ODKBaseURL,
-		Email:     cfg.ODKEmail,
-		Password:  cfg.ODKPassword,
-		ProjectID: cfg.ODKProjectID,
-		FormID:    cfg.ODKFaskesFormID,
-	}
+	odkFaskesConfig := cfg.ODKClientConfig(cfg.ODKFaskesFormID, odkTokens)
 	odkFaskesClient := odk.NewClient(odkFaskesConfig)
 
 	// Initialize ODK client for infrastruktur form
-	odkInfrastrukturConfig := &odk.ODKConfig{
-		BaseURL:   cfg.ODKBaseURL,
-		Email:     cfg.ODKEmail,
-		Password:  cfg.ODKPassword,
-		ProjectID: cfg.ODKProjectID,
-		FormID:    cfg.ODKInfrastrukturFormID,
-	}
+	odkInfrastrukturConfig := cfg.ODKClientConfig(cfg.ODKInfrastrukturFormID, odkTokens)
 	odkInfrastrukturClient := odk.NewClient(odkInfrastrukturConfig)
 
 	// Initialize services
 	syncService := service.NewSyncService(db, odkPoskoClient, cfg.ODKFormID)
+	syncService.SetNameFields(cfg.PoskoNameFields)
+	mappingProfiles, err := service.ParseMappingProfiles(cfg.PoskoMappingProfiles, cfg.PoskoMappingFields)
+	if err != nil {
+		log.Fatalf("Invalid posko mapping profiles: %v", err)
+	}
+	syncService.SetMappingProfiles(mappingProfiles)
+	if err := syncService.SetPhotoDuplicatePolicy(cfg.PhotoDuplicatePolicy); err != nil {
+		log.Fatalf("Invalid PHOTO_DUPLICATE_POLICY: %v", err)
+	}
+	syncService.SetEntityMappingCache(
+		time.Duration(cfg.EntityMappingTTLMinutes)*time.Minute,
+		time.Duration(cfg.EntityMappingTimeoutSeconds)*time.Second,
+	)
+	if err := syncService.SetEntityFallback(cfg.EntityDatasetFallback); err != nil {
+		log.Fatalf("Invalid ENTITY_DATASET_FALLBACK: %v", err)
+	}
 	feedSyncService := service.NewFeedSyncService(db, odkFeedClient, cfg.ODKFeedFormID)
+	feedSyncService.SetInheritLocationGeometry(cfg.FeedInheritLocationGeom)
+	if err := feedSyncService.SetContentPolicy(service.FeedContentPolicy{
+		MaxLength: cfg.FeedContentMaxLength,
+		Sanitize:  cfg.FeedContentSanitize,
+	}); err != nil {
+		log.Fatalf("Invalid FEED_CONTENT_SANITIZE: %v", err)
+	}
 	faskesSyncService := service.NewFaskesSyncService(db, odkFaskesClient, cfg.ODKFaskesFormID)
+	if len(cfg.FaskesPhotoFields) > 0 {
+		photoFields, err := service.ParsePhotoFields(cfg.FaskesPhotoFields)
+		if err != nil {
+			log.Fatalf("Invalid FASKES_PHOTO_FIELDS: %v", err)
+		}
+		faskesSyncService.SetPhotoFields(photoFields)
+	}
 	infrastrukturSyncService := service.NewInfrastrukturSyncService(db, odkInfrastrukturClient, cfg.ODKInfrastrukturFormID)
 
+	// Entity key strategies: which submission field names the record an update belongs to
+	poskoEntityKeys, err := service.ParseEntityKeyStrategy(cfg.PoskoEntityKeys, service.DefaultPoskoEntityKeys)
+	if err != nil {
+		log.Fatalf("Invalid POSKO_ENTITY_KEYS: %v", err)
+	}
+	syncService.SetEntityKeys(poskoEntityKeys)
+	faskesEntityKeys, err := service.ParseEntityKeyStrategy(cfg.FaskesEntityKeys, service.DefaultFaskesEntityKeys)
+	if err != nil {
+		log.Fatalf("Invalid FASKES_ENTITY_KEYS: %v", err)
+	}
+	faskesSyncService.SetEntityKeys(faskesEntityKeys)
+	infrastrukturEntityKeys, err := service.ParseEntityKeyStrategy(cfg.InfrastrukturEntityKeys, service.DefaultInfrastrukturEntityKeys)
+	if err != nil {
+		log.Fatalf("Invalid INFRASTRUKTUR_ENTITY_KEYS: %v", err)
+	}
+	infrastrukturSyncService.SetEntityKeys(infrastrukturEntityKeys)
+
+	// Hard syncs skip their deletes when ODK returns far fewer records than are stored
+	if cfg.HardSyncMinPercent < 0 || cfg.HardSyncMinPercent > 100 {
+		log.Fatalf("Invalid HARD_SYNC_MIN_PERCENT: %d (want 0-100)", cfg.HardSyncMinPercent)
+	}
+	syncService.SetHardSyncMinPercent(cfg.HardSyncMinPercent)
+	feedSyncService.SetHardSyncMinPercent(cfg.HardSyncMinPercent)
+	faskesSyncService.SetHardSyncMinPercent(cfg.HardSyncMinPercent)
+	infrastrukturSyncService.SetHardSyncMinPercent(cfg.HardSyncMinPercent)
+
+	// Mapped coordinates outside these bounds are dropped instead of stored
+	coordinateBounds, err := service.ParseCoordinateBounds(cfg.CoordinateBounds)
+	if err != nil {
+		log.Fatalf("Invalid COORDINATE_BOUNDS: %v", err)
+	}
+	service.SetCoordinateBounds(coordinateBounds)
+
+	// Sync results keep this many error messages, counting the rest in truncated_errors
+	if cfg.ErrorDetailsMax < 0 {
+		log.Fatalf("Invalid ERROR_DETAILS_MAX: %d (want 0 or more)", cfg.ErrorDetailsMax)
+	}
+	service.SetMaxErrorDetails(cfg.ErrorDetailsMax)
+
+	// Syncs run under a context cancelled on shutdown, stopping their ODK requests
+	syncCtx, cancelSyncs := context.WithCancel(context.Background())
+	syncService.SetContext(syncCtx)
+	feedSyncService.SetContext(syncCtx)
+	faskesSyncService.SetContext(syncCtx)
+	infrastrukturSyncService.SetContext(syncCtx)
+
+	// Sync run history (GET /sync/:form/delta) older than the retention is pruned
+	if cfg.SyncRunsRetentionDays < 0 {
+		log.Fatalf("Invalid SYNC_RUNS_RETENTION_DAYS: %d (want 0 or more)", cfg.SyncRunsRetentionDays)
+	}
+	syncRunRetention := time.Duration(cfg.SyncRunsRetentionDays) * 24 * time.Hour
+	syncService.SetSyncRunRetention(syncRunRetention)
+	feedSyncService.SetSyncRunRetention(syncRunRetention)
+	faskesSyncService.SetSyncRunRetention(syncRunRetention)
+	infrastrukturSyncService.SetSyncRunRetention(syncRunRetention)
+
+	// Prometheus metrics (GET /metrics): HTTP requests and posko entity ID fallbacks
+	metrics := middleware.NewMetrics()
+	syncService.SetEntityFallbackCounter(metrics.CountEntityResolutions)
+
 	// Initialize photo service (with optional S3 storage)
 	var photoService *service.PhotoService
 	if cfg.S3Enabled {
+		prefixPrivate := make(map[string]bool)
+		for _, prefix := range cfg.S3PublicPrefixes {
+			prefixPrivate[prefix] = false
+		}
+		for _, prefix := range cfg.S3PrivatePrefixes {
+			prefixPrivate[prefix] = true
+		}
 		s3Config := storage.S3Config{
 			Endpoint:        cfg.S3Endpoint,
 			Bucket:          cfg.S3Bucket,
@@ -125,6 +276,10 @@ func main() {
 			Region:          cfg.S3Region,
 			PathPrefix:      cfg.S3PathPrefix,
 			UsePathStyle:    true, // Required for S3-compatible storage like CloudHost
+			Private:         cfg.S3Private,
+			Timeout:         time.Duration(cfg.S3TimeoutSeconds) * time.Second,
+			PrefixPrivate:   prefixPrivate,
+			CacheControl:    cfg.S3CacheControl,
 		}
 		s3Storage, err := storage.NewS3Storage(s3Config)
 		if err != nil {
@@ -132,18 +287,60 @@ func main() {
 		}
 		photoService = service.NewPhotoServiceWithS3(db, odkPoskoClient, cfg.PhotoStoragePath, s3Storage)
 		log.Printf("S3 storage enabled: %s/%s", cfg.S3Endpoint, cfg.S3Bucket)
+
+		// A migration job still marked running was cut off by the previous shutdown
+		if err := photoService.CloseInterruptedMigrationJobs(); err != nil {
+			log.Printf("Warning: failed to close interrupted S3 migration jobs: %v", err)
+		}
 	} else {
 		photoService = service.NewPhotoService(db, odkPoskoClient, cfg.PhotoStoragePath)
 		log.Println("Using local filesystem for photo storage")
 	}
+	photoService.SetAllowedContentTypes(cfg.AttachmentAllowedTypes)
+	photoService.SetSignedURLTTL(time.Duration(cfg.S3SignedURLTTLMinutes) * time.Minute)
+	photoService.SetPublicBaseURL(cfg.PublicBaseURL)
+	photoService.SetFormIDs(service.PhotoFormIDs{
+		Feed:          cfg.ODKFeedFormID,
+		Faskes:        cfg.ODKFaskesFormID,
+		Infrastruktur: cfg.ODKInfrastrukturFormID,
+	})
+
+	// Optional background photo downloads: each completed sync enqueues the uncached photos
+	// of the records it created or updated
+	var photoQueue *service.PhotoQueue
+	if cfg.PhotoAutoDownload {
+		photoQueue = service.NewPhotoQueue(photoService, cfg.PhotoQueueSize, cfg.PhotoQueueWorkers)
+		photoQueue.Start()
+		syncService.SetAfterSyncHook(func() { photoQueue.EnqueueChanged(service.PhotoKindLocation) })
+		feedSyncService.SetAfterSyncHook(func() { photoQueue.EnqueueChanged(service.PhotoKindFeed) })
+		faskesSyncService.SetAfterSyncHook(func() { photoQueue.EnqueueChanged(service.PhotoKindFaskes) })
+		infrastrukturSyncService.SetAfterSyncHook(func() { photoQueue.EnqueueChanged(service.PhotoKindInfrastruktur) })
+	}
 
 	// Initialize SSE Hub for real-time updates
 	sseHub := sse.NewHub()
 
+	// Publish sync lifecycle events (sync_started, sync_progress, sync_completed, sync_failed)
+	syncService.SetEventHub(sseHub)
+	feedSyncService.SetEventHub(sseHub)
+	faskesSyncService.SetEventHub(sseHub)
+
 	// Initialize Scheduler
 	schedulerConfig := scheduler.DefaultConfig()
+	schedulerConfig.MaxJitter = time.Duration(cfg.SchedulerMaxJitterSeconds) * time.Second
 	autoScheduler := scheduler.NewScheduler(schedulerConfig, syncService, feedSyncService, sseHub)
 
+	// Read-only maintenance mode: sync and write endpoints answer 503, the scheduler skips its
+	// cycles and the photo queue its downloads
+	maintenance := middleware.NewMaintenance(cfg.MaintenanceMode)
+	autoScheduler.SetMaintenanceCheck(maintenance.Enabled)
+	if photoQueue != nil {
+		photoQueue.SetMaintenanceCheck(maintenance.Enabled)
+	}
+	if cfg.MaintenanceMode {
+		log.Println("Starting in maintenance mode: syncs and writes are disabled")
+	}
+
 	// Start scheduler if enabled
 	if os.Getenv("SCHEDULER_ENABLED") != "false" {
 		autoScheduler.Start()
@@ -153,17 +350,69 @@ func main() {
 	// Initialize handlers
 	locationHandler := handler.NewLocationHandler(locationRepo, feedRepo)
 	feedHandler := handler.NewFeedHandler(feedRepo)
+	feedHandler.SetIncludePhotosDefault(cfg.FeedIncludePhotosDefault)
 	faskesHandler := handler.NewFaskesHandler(faskesRepo)
+	adminHandler := handler.NewAdminHandler(locationRepo, faskesRepo)
+	activityHandler := handler.NewActivityHandler(activityRepo)
+	auditHandler := handler.NewAuditHandler(auditRepo)
 	infrastrukturHandler := handler.NewInfrastrukturHandler(infrastrukturRepo)
+	staleChecker := service.NewStaleChecker(db, time.Duration(cfg.StaleDataMaxAgeMinutes)*time.Minute)
+	locationHandler.SetStaleChecker(staleChecker, cfg.ODKFormID)
+	feedHandler.SetStaleChecker(staleChecker, cfg.ODKFeedFormID)
+	faskesHandler.SetStaleChecker(staleChecker, cfg.ODKFaskesFormID)
+	infrastrukturHandler.SetStaleChecker(staleChecker, cfg.ODKInfrastrukturFormID)
+	locationHandler.SetPublicBaseURL(cfg.PublicBaseURL)
+	feedHandler.SetPublicBaseURL(cfg.PublicBaseURL)
+	faskesHandler.SetPublicBaseURL(cfg.PublicBaseURL)
+	infrastrukturHandler.SetPublicBaseURL(cfg.PublicBaseURL)
+	locationHandler.SetHiddenPhotoTypes(cfg.PhotoHiddenTypes)
+	feedHandler.SetHiddenPhotoTypes(cfg.PhotoHiddenTypes)
+	faskesHandler.SetHiddenPhotoTypes(cfg.PhotoHiddenTypes)
+	infrastrukturHandler.SetHiddenPhotoTypes(cfg.PhotoHiddenTypes)
 	healthHandler := handler.NewHealthHandler(db)
+	healthHandler.SetDBLatencyThreshold(time.Duration(cfg.HealthDBLatencyThresholdMs) * time.Millisecond)
 	syncHandler := handler.NewSyncHandlerWithInfrastruktur(syncService, feedSyncService, faskesSyncService, infrastrukturSyncService)
+	syncHandler.SetStaleThreshold(time.Duration(cfg.SyncStaleThresholdMinutes) * time.Minute)
+	if cfg.PurgeDeletedAfterDays < 1 {
+		log.Fatalf("Invalid PURGE_DELETED_AFTER_DAYS: %d (want 1 or more)", cfg.PurgeDeletedAfterDays)
+	}
+	syncHandler.SetPurgeRetention(time.Duration(cfg.PurgeDeletedAfterDays) * 24 * time.Hour)
+	syncHandler.SetPhotoService(photoService)
 	photoHandler := handler.NewPhotoHandler(photoService)
+	photoHandler.SetHiddenPhotoTypes(cfg.PhotoHiddenTypes)
+	photoHandler.SetPublicBaseURL(cfg.PublicBaseURL)
+	facetsHandler := handler.NewFacetsHandler(feedRepo)
+	configHandler := handler.NewConfigHandler(dto.FormsConfigResponse{
+		Posko:         dto.FormConfig{FormID: cfg.ODKFormID, ProjectID: cfg.ODKProjectID},
+		Feed:          dto.FormConfig{FormID: cfg.ODKFeedFormID, ProjectID: cfg.ODKProjectID},
+		Faskes:        dto.FormConfig{FormID: cfg.ODKFaskesFormID, ProjectID: cfg.ODKProjectID},
+		Infrastruktur: dto.FormConfig{FormID: cfg.ODKInfrastrukturFormID, ProjectID: cfg.ODKProjectID},
+	})
+	odkHandler := handler.NewODKHandler(*odkPoskoConfig, map[string]string{
+		"posko":         cfg.ODKFormID,
+		"feed":          cfg.ODKFeedFormID,
+		"faskes":        cfg.ODKFaskesFormID,
+		"infrastruktur": cfg.ODKInfrastrukturFormID,
+	}, []string{syncService.EntityDataset(), infrastrukturSyncService.EntityDataset()})
+	odkHandler.SetSyncService(syncService)
+	photoHandler.SetPhotoQueue(photoQueue)
 	sseHandler := handler.NewSSEHandler(sseHub)
 	schedulerHandler := handler.NewSchedulerHandler(autoScheduler)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenance)
 
 	// Initialize middleware
 	rateLimiter := middleware.DefaultRateLimiter()
 	cache := middleware.DefaultCache()
+	locationHandler.SetResponseCache(cache)
+
+	// Named API keys; the legacy SYNC_API_KEY keeps full access
+	apiKeys, err := middleware.ParseAPIKeys(cfg.APIKeys)
+	if err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+	if cfg.SyncAPIKey != "" {
+		apiKeys = append(apiKeys, middleware.APIKey{Label: "default", Key: cfg.SyncAPIKey, Scopes: []string{middleware.ScopeAll}})
+	}
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -172,32 +421,41 @@ func main() {
 
 	r := gin.Default()
 
+	// Request count and latency per route template, scraped from /metrics
+	r.Use(metrics.Middleware())
+
 	// Configure CORS
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000", "https://dayawarga.com", "https://www.dayawarga.com"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "X-Cache", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	corsMiddleware, err := middleware.CORS(cfg.CORSOrigins, cfg.CORSAllowCredentials, cfg.CORSWildcardPolicy)
+	if err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
+	}
+	r.Use(corsMiddleware)
 
 	// Apply global middleware
 	r.Use(rateLimiter.Middleware())
+	r.Use(middleware.BodyLimit(int64(cfg.MaxRequestBodyBytes)))
 
 	// Health endpoints (no cache, no rate limit heavy)
 	r.GET("/health", healthHandler.Check)
 	r.GET("/ready", healthHandler.Ready)
+	r.GET("/metrics", metrics.Handler)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
+	v1.Use(middleware.OptionalAPIKey(apiKeys)) // a valid key also shows non-public photo types
 	{
 		// Apply cache middleware to read endpoints
 		cached := v1.Group("")
 		cached.Use(cache.Middleware())
 		{
+			// Canonical filter values (cached)
+			cached.GET("/facets", facetsHandler.GetFacets)
+			cached.GET("/config/forms", configHandler.GetForms)
+
 			// Locations (cached)
 			cached.GET("/locations", locationHandler.GetLocations)
+			cached.GET("/locations/by-region", locationHandler.GetLocationsByRegion) // ?level=provinsi|kota_kab|kecamatan
+			cached.GET("/locations/stats", locationHandler.GetLocationStats)         // ?provinsi=kode|nama
 			cached.GET("/locations/:id", locationHandler.GetLocationByID)
 
 			// Faskes - Health facilities (cached)
@@ -207,8 +465,12 @@ func main() {
 			// Infrastruktur - Roads/Bridges (cached)
 			cached.GET("/infrastruktur", infrastrukturHandler.GetInfrastruktur)
 			cached.GET("/infrastruktur/:id", infrastrukturHandler.GetInfrastrukturByID)
+			cached.GET("/infrastruktur/:id/timeline", infrastrukturHandler.GetInfrastrukturTimeline)
 			cached.GET("/infrastruktur/stats", infrastrukturHandler.GetInfrastrukturStats)
 
+			// Activity ticker: recent changes across all record types (cached)
+			cached.GET("/activity", activityHandler.GetActivity)
+
 			// Feeds (cached)
 			cached.GET("/feeds", feedHandler.GetFeeds)
 			cached.GET("/locations/:id/feeds", feedHandler.GetFeedsByLocation)
@@ -217,53 +479,123 @@ func main() {
 			// Posko photos
 			cached.GET("/locations/:id/photos", photoHandler.GetPhotosByLocation)
 			cached.GET("/photos/:id/file", photoHandler.GetPhotoFile)
+			cached.GET("/photos/:id/thumb", photoHandler.GetPhotoThumbnail)
 			// Feed photos
 			cached.GET("/feeds/photos/:id/file", photoHandler.GetFeedPhotoFile)
 			// Faskes photos
 			cached.GET("/faskes/:id/photos", photoHandler.GetPhotosByFaskes)
 			cached.GET("/faskes/photos/:id/file", photoHandler.GetFaskesPhotoFile)
+			// Infrastruktur photos
+			cached.GET("/infrastruktur/photos/:id/file", photoHandler.GetInfraPhotoFile)
 		}
 
+		// Photo URLs (no cache: signed URLs expire)
+		v1.GET("/locations/:id/photos/urls", photoHandler.GetPhotoURLsByLocation)
+		v1.GET("/faskes/:id/photos/urls", photoHandler.GetPhotoURLsByFaskes)
+
+		// Full faskes CSV (no cache: streamed row by row)
+		v1.GET("/faskes/full.csv", faskesHandler.ExportFaskesCSV) // ?groups=sdm,perbekalan
+
 		// SSE Events (no cache, streaming)
 		v1.GET("/events", sseHandler.Stream)
 
 		// Protected endpoints - require API key
 		protected := v1.Group("")
-		protected.Use(middleware.APIKeyAuth(cfg.SyncAPIKey))
+		protected.Use(middleware.APIKeyAuth(apiKeys), middleware.AuditLog(auditRepo)) // audit records mutating requests only
+		// Scoped groups: a key may only call the routes of its scopes (API_KEYS label:key:scopes)
+		// In maintenance mode their non-GET routes answer 503
+		syncScope := protected.Group("", middleware.RequireScope(middleware.ScopeSync), maintenance.BlockWrites())
+		adminScope := protected.Group("", middleware.RequireScope(middleware.ScopeAdmin), maintenance.BlockWrites())
+		writeScope := protected.Group("", middleware.RequireScope(middleware.ScopeWrite), maintenance.BlockWrites())
+		// Admin routes that keep working in maintenance mode: the switch itself and scheduler control
+		adminControl := protected.Group("", middleware.RequireScope(middleware.ScopeAdmin))
 		{
 			// Sync endpoints
-			protected.POST("/sync/posko", syncHandler.SyncAll)
-			protected.POST("/sync/feed", syncHandler.SyncFeeds)
-			protected.POST("/sync/faskes", syncHandler.SyncFaskes)
-			protected.POST("/sync/infrastruktur", syncHandler.SyncInfrastruktur)
-			protected.POST("/sync/photos", photoHandler.SyncPhotos)              // Posko photos
-			protected.POST("/sync/feed-photos", photoHandler.SyncFeedPhotos)     // Feed photos
-			protected.POST("/sync/faskes-photos", photoHandler.SyncFaskesPhotos) // Faskes photos
-			protected.POST("/migrate/s3", photoHandler.MigrateToS3)              // Migrate local photos to S3
-			protected.POST("/photos/reset-cache", photoHandler.ResetCache)       // Reset cache for missing files
+			syncScope.POST("/sync/all", syncHandler.SyncAllForms) // posko+faskes in parallel, then feed, then infrastruktur
+			syncScope.POST("/sync/posko", syncHandler.SyncAll)
+			syncScope.POST("/sync/feed", syncHandler.SyncFeeds)
+			syncScope.POST("/sync/faskes", syncHandler.SyncFaskes)
+			syncScope.POST("/sync/infrastruktur", syncHandler.SyncInfrastruktur)
+			syncScope.POST("/sync/posko/submissions/:submissionId", syncHandler.SyncPoskoSubmission) // ?photos=true to also download photos
+			syncScope.POST("/sync/photos", photoHandler.SyncPhotos)                                  // Posko photos
+			syncScope.POST("/sync/photos/all", photoHandler.SyncAllPhotoTypes)                       // All photo types, ?workers=1-4
+			syncScope.POST("/sync/feed-photos", photoHandler.SyncFeedPhotos)                         // Feed photos
+			syncScope.POST("/sync/faskes-photos", photoHandler.SyncFaskesPhotos)                     // Faskes photos
+			syncScope.POST("/sync/infra-photos", photoHandler.SyncInfraPhotos)                       // Infrastruktur photos
+			writeScope.POST("/migrate/s3", photoHandler.MigrateToS3)                                 // Migrate local photos to S3
+			writeScope.GET("/migrate/s3/status", photoHandler.GetMigrationStatus)                    // Progress of the latest S3 migration
+			writeScope.POST("/photos/reset-cache", photoHandler.ResetCache)                          // Reset cache for missing files
+			writeScope.PATCH("/locations/:id", locationHandler.UpdateLocationStatus)                 // Manual status override, kept until the ODK status changes
+			syncScope.GET("/photos/queue", photoHandler.GetQueueStatus)                              // Background download queue status
+			syncScope.GET("/photos/:id/original", photoHandler.GetPhotoOriginal)                     // Proxied from ODK Central when not cached yet, ?cache=true to queue a download
 
 			// Hard sync endpoints - sync AND delete records not in ODK Central
-			protected.POST("/sync/posko/hard", syncHandler.HardSyncPosko)
-			protected.POST("/sync/feed/hard", syncHandler.HardSyncFeeds)
-			protected.POST("/sync/faskes/hard", syncHandler.HardSyncFaskes)
-			protected.POST("/sync/infrastruktur/hard", syncHandler.HardSyncInfrastruktur)
+			syncScope.POST("/sync/posko/hard", syncHandler.HardSyncPosko)
+			syncScope.POST("/sync/feed/hard", syncHandler.HardSyncFeeds)
+			syncScope.POST("/sync/faskes/hard", syncHandler.HardSyncFaskes)
+			syncScope.POST("/sync/infrastruktur/hard", syncHandler.HardSyncInfrastruktur)
+
+			// Admin: re-run mappers over stored raw_data (no ODK fetch)
+			adminScope.POST("/admin/remap", syncHandler.Remap)
+			adminScope.POST("/admin/enrich-wilayah", syncHandler.EnrichWilayah) // fill region names/IDs from wilayah tables
+			adminScope.POST("/admin/entity-mapping/refresh", syncHandler.RefreshEntityMapping)
+			adminScope.POST("/admin/rebuild", syncHandler.Rebuild) // ?form=posko|faskes|infrastruktur&confirm=true, purges then full sync
+
+			// Admin: soft-deleted records
+			adminScope.POST("/admin/purge-deleted", syncHandler.PurgeDeleted) // ?form=posko|feed|faskes|infrastruktur&days=30, removes them for good
+
+			// Admin: storage
+			adminScope.POST("/admin/cleanup-local-migrated", photoHandler.CleanupLocalMigrated) // remove local copies of photos already on S3
+
+			// Admin: photo metadata
+			adminScope.POST("/admin/photos/backfill-dimensions", photoHandler.BackfillDimensions) // width/height of photos cached before they were recorded
+
+			// Admin: data-quality worklists
+			adminScope.GET("/admin/missing-geometry", adminHandler.GetMissingGeometry) // ?form=posko|faskes
+
+			// Admin: accountability
+			adminScope.GET("/admin/audit", auditHandler.GetAuditLog) // ?limit=100
+
+			// Admin: deployment checks
+			adminScope.POST("/admin/odk/test", odkHandler.TestConnection)             // optional body overrides configured credentials
+			adminScope.GET("/admin/submissions/raw", odkHandler.StreamRawSubmissions) // ?form=posko|feed|faskes|infrastruktur, NDJSON
+			adminScope.GET("/admin/odk/entities", odkHandler.ListEntities)            // ?dataset=posko_entities
 
 			// Scheduler endpoints
-			protected.GET("/scheduler/status", schedulerHandler.GetStatus)
-			protected.POST("/scheduler/start", schedulerHandler.Start)
-			protected.POST("/scheduler/stop", schedulerHandler.Stop)
-			protected.POST("/scheduler/trigger", schedulerHandler.TriggerSync)
-			protected.POST("/scheduler/mode/:mode", schedulerHandler.SetMode)
-			protected.POST("/scheduler/mode/auto", schedulerHandler.ClearManualMode)
+			adminControl.GET("/scheduler/status", schedulerHandler.GetStatus)
+			adminControl.POST("/scheduler/start", schedulerHandler.Start)
+			adminControl.POST("/scheduler/stop", schedulerHandler.Stop)
+			adminControl.POST("/scheduler/pause", schedulerHandler.Pause) // ?minutes=30
+			adminScope.POST("/scheduler/trigger", schedulerHandler.TriggerSync)
+			adminControl.POST("/scheduler/mode/:mode", schedulerHandler.SetMode)
+			adminControl.POST("/scheduler/mode/auto", schedulerHandler.ClearManualMode)
+
+			// Maintenance mode (read-only API during migrations)
+			adminControl.GET("/admin/maintenance", maintenanceHandler.GetStatus)
+			adminControl.POST("/admin/maintenance", maintenanceHandler.SetMode) // {"enabled": true, "message": "..."}
 		}
 
-		// Sync status endpoints (read-only, no auth required)
-		v1.GET("/sync/status", syncHandler.GetSyncStatus)
-		v1.GET("/sync/feed/status", syncHandler.GetFeedSyncStatus)
-		v1.GET("/sync/faskes/status", syncHandler.GetFaskesSyncStatus)
-		v1.GET("/sync/infrastruktur/status", syncHandler.GetInfrastrukturSyncStatus)
+		// Sync status endpoints (read-only): public unless EXPOSE_SYNC_STATUS_PUBLIC=false
+		syncStatus := v1
+		if !cfg.ExposeSyncStatusPublic {
+			syncStatus = protected
+		}
+		syncStatus.GET("/sync/status", syncHandler.GetSyncStatus)
+		syncStatus.GET("/sync/feed/status", syncHandler.GetFeedSyncStatus)
+		syncStatus.GET("/sync/faskes/status", syncHandler.GetFaskesSyncStatus)
+		syncStatus.GET("/sync/infrastruktur/status", syncHandler.GetInfrastrukturSyncStatus)
+		syncStatus.GET("/sync/:form/delta", syncHandler.GetSyncDelta)
+		syncStatus.GET("/sync/freshness", syncHandler.GetFreshness)
 	}
 
+	// Clear the response cache after each sync, optionally re-warming the common queries
+	cacheWarmer := middleware.NewCacheWarmer(cache, r, cfg.CacheWarmPaths, cfg.CacheWarmAfterSync)
+	cacheWarmer.Start()
+	syncService.AddAfterSyncHook(cacheWarmer.Trigger)
+	feedSyncService.AddAfterSyncHook(cacheWarmer.Trigger)
+	faskesSyncService.AddAfterSyncHook(cacheWarmer.Trigger)
+	infrastrukturSyncService.AddAfterSyncHook(cacheWarmer.Trigger)
+
 	// Graceful shutdown
 	go func() {
 		sigChan := make(chan os.Signal, 1)
@@ -271,7 +603,28 @@ func main() {
 		<-sigChan
 
 		log.Println("Shutting down gracefully...")
+		cancelSyncs()
 		autoScheduler.Stop()
+
+		// Running syncs stop at their next ODK request; let them record it before the DB closes
+		syncsDone := make(chan struct{})
+		go func() {
+			syncService.WaitSync()
+			feedSyncService.WaitSync()
+			faskesSyncService.WaitSync()
+			infrastrukturSyncService.WaitSync()
+			close(syncsDone)
+		}()
+		select {
+		case <-syncsDone:
+		case <-time.After(10 * time.Second):
+			log.Println("Timed out waiting for running syncs to stop")
+		}
+
+		cacheWarmer.Stop()
+		if photoQueue != nil {
+			photoQueue.Stop()
+		}
 		sqlDB.Close()
 		os.Exit(0)
 	}()
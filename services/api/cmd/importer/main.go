@@ -23,6 +23,7 @@ func main() {
 	dryRun := flag.Bool("dry-run", false, "Show what would be done without making changes")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
 	locationID := flag.String("location", "", "Sync photos for specific location UUID")
+	sinceFlag := flag.String("since", "", "Only sync photos of records submitted after this time (RFC3339, or a duration like 24h)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `ODK Data Importer - Import data and images from ODK Central
@@ -50,9 +51,13 @@ Examples:
   # Sync photos for specific location
   importer -photos -location=<uuid>
 
+  # Sync photos of records submitted in the last day
+  importer -photos -since=24h
+
 Environment Variables:
   DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME
-  ODK_BASE_URL, ODK_EMAIL, ODK_PASSWORD, ODK_PROJECT_ID, ODK_FORM_ID
+  ODK_BASE_URL, ODK_EMAIL, ODK_PASSWORD (or ODK_TOKEN), ODK_PROJECT_ID, ODK_FORM_ID
+  ODK_MAX_RETRIES, ODK_RETRY_BASE_DELAY_MS
   PHOTO_STORAGE_PATH
 `)
 	}
@@ -64,6 +69,14 @@ Environment Variables:
 		os.Exit(1)
 	}
 
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		if since, err = parseSince(*sinceFlag); err != nil {
+			log.Fatalf("Invalid -since: %v", err)
+		}
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
@@ -89,13 +102,12 @@ Environment Variables:
 	log.Println("Connected to database")
 
 	// Create ODK client
-	odkConfig := &odk.ODKConfig{
-		BaseURL:   cfg.ODKBaseURL,
-		Email:     cfg.ODKEmail,
-		Password:  cfg.ODKPassword,
-		ProjectID: cfg.ODKProjectID,
-		FormID:    cfg.ODKFormID,
+	odkConfig := cfg.ODKClientConfig(cfg.ODKFormID, nil) // one client, its own session
+	mode, err := odkConfig.AuthMode()
+	if err != nil {
+		log.Fatalf("Invalid ODK authentication config: %v", err)
 	}
+	log.Printf("ODK authentication mode: %s", mode)
 	odkClient := odk.NewClient(odkConfig)
 
 	// Run requested operations
@@ -108,7 +120,12 @@ Environment Variables:
 	}
 
 	if *syncAll || *syncPhotos {
-		if err := runPhotoSync(db, odkClient, cfg.PhotoStoragePath, *dryRun, *verbose, *locationID); err != nil {
+		formIDs := service.PhotoFormIDs{
+			Feed:          cfg.ODKFeedFormID,
+			Faskes:        cfg.ODKFaskesFormID,
+			Infrastruktur: cfg.ODKInfrastrukturFormID,
+		}
+		if err := runPhotoSync(db, odkClient, cfg.PhotoStoragePath, cfg.AttachmentAllowedTypes, formIDs, *dryRun, *verbose, *locationID, since); err != nil {
 			log.Printf("Photo sync error: %v", err)
 		}
 	}
@@ -150,19 +167,53 @@ func runPoskoSync(db *gorm.DB, odkClient *odk.Client, formID string, dryRun, ver
 	return nil
 }
 
-func runPhotoSync(db *gorm.DB, odkClient *odk.Client, storagePath string, dryRun, verbose bool, locationID string) error {
+// parseSince parses an RFC3339 time, or a duration counted back from now
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time nor a duration", value)
+	}
+	return t, nil
+}
+
+func runPhotoSync(db *gorm.DB, odkClient *odk.Client, storagePath string, allowedTypes []string, formIDs service.PhotoFormIDs, dryRun, verbose bool, locationID string, since time.Time) error {
 	log.Println("=== Starting Photo Sync ===")
 
 	photoService := service.NewPhotoService(db, odkClient, storagePath)
+	photoService.SetAllowedContentTypes(allowedTypes)
+	photoService.SetFormIDs(formIDs)
 
 	if dryRun {
-		// Count uncached photos
+		// Count uncached photos of every type the sync downloads; -location narrows the
+		// count to that location's photos
+		photoTables := []struct {
+			table, parent, parentID string
+		}{
+			{"location_photos", "locations", "location_id"},
+			{"feed_photos", "information_feeds", "feed_id"},
+			{"faskes_photos", "faskes", "faskes_id"},
+			{"infrastruktur_photos", "infrastruktur", "infrastruktur_id"},
+		}
 		var count int64
-		query := db.Table("location_photos").Where("is_cached = false")
-		if locationID != "" {
-			query = query.Where("location_id = ?", locationID)
+		for _, t := range photoTables {
+			if locationID != "" && t.table != "location_photos" {
+				continue
+			}
+			var n int64
+			query := db.Table(t.table).Where("is_cached = false")
+			if locationID != "" {
+				query = query.Where("location_id = ?", locationID)
+			}
+			if !since.IsZero() {
+				query = query.Where(t.parentID+" IN (?)", db.Table(t.parent).Select("id").Where("submitted_at > ?", since))
+			}
+			query.Count(&n)
+			log.Printf("[DRY-RUN] %s: %d uncached", t.table, n)
+			count += n
 		}
-		query.Count(&count)
 
 		log.Printf("[DRY-RUN] Found %d uncached photos to download", count)
 
@@ -190,7 +241,14 @@ func runPhotoSync(db *gorm.DB, odkClient *odk.Client, storagePath string, dryRun
 		return nil
 	}
 
-	result, err := photoService.SyncAllPhotos()
+	var result *service.PhotoSyncResult
+	var err error
+	if since.IsZero() {
+		result, err = photoService.SyncAllPhotos()
+	} else {
+		log.Printf("Only photos of records submitted after %s", since.Format(time.RFC3339))
+		result, err = photoService.SyncPhotosSince(since)
+	}
 	if err != nil {
 		return err
 	}
@@ -198,6 +256,7 @@ func runPhotoSync(db *gorm.DB, odkClient *odk.Client, storagePath string, dryRun
 	log.Printf("Photo sync completed:")
 	log.Printf("  - Total found: %d", result.TotalFound)
 	log.Printf("  - Downloaded: %d", result.Downloaded)
+	log.Printf("  - Skipped (type not allowed): %d", result.Skipped)
 	log.Printf("  - Errors: %d", result.Errors)
 	log.Printf("  - Duration: %s", result.Duration)
 
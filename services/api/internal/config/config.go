@@ -3,6 +3,10 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leksa/datamapper-senyar/internal/odk"
 )
 
 type Config struct {
@@ -17,73 +21,265 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// AutoMigrate runs GORM AutoMigrate at startup (DEV ONLY - production uses SQL migrations)
+	AutoMigrate bool
 
 	// Cache
 	CacheHost string
 	CachePort int
 
-	// CORS
-	CORSOrigins string
+	// CORS: allowed origins (exact, https://*.domain or *), whether credentials are
+	// allowed and what to do with * plus credentials (reject or warn)
+	CORSOrigins          []string
+	CORSAllowCredentials bool
+	CORSWildcardPolicy   string
+
+	// PublicBaseURL prefixes generated photo/feed URLs; empty keeps them relative
+	PublicBaseURL string
+
+	// MaxRequestBodyBytes caps request bodies on write endpoints
+	MaxRequestBodyBytes int
+
+	// HealthDBLatencyThresholdMs marks /ready degraded when the DB round-trip is slower
+	HealthDBLatencyThresholdMs int
 
 	// ODK Central
-	ODKBaseURL            string
-	ODKEmail              string
-	ODKPassword           string
-	ODKProjectID          int
-	ODKFormID             string
-	ODKFeedFormID         string
-	ODKFaskesFormID       string
+	ODKBaseURL             string
+	ODKEmail               string
+	ODKPassword            string
+	ODKToken               string // pre-issued App User token, used instead of email/password
+	ODKProjectID           int
+	ODKFormID              string
+	ODKFeedFormID          string
+	ODKFaskesFormID        string
 	ODKInfrastrukturFormID string
+	// ODK requests failing with a connection error or 429/502/503/504 are retried with backoff
+	ODKMaxRetries       int
+	ODKRetryBaseDelayMs int
+	// Per-request ODK timeouts: session login, attachment download, other API calls
+	ODKAuthTimeoutSeconds       int
+	ODKAttachmentTimeoutSeconds int
+	ODKQueryTimeoutSeconds      int
+	// PoskoNameFields overrides the posko submission fields tried in order for nama
+	PoskoNameFields []string
+	// PoskoMappingProfiles selects the field source per form version ("formVersion=auto|final|grp")
+	PoskoMappingProfiles []string
+	// PoskoMappingFields overrides single fields per form version ("formVersion:key=path")
+	PoskoMappingFields []string
+	// Entity key strategies per form ("mode_field=..;update_mode=..;update_keys=a|b;new_keys=..", empty = default)
+	PoskoEntityKeys         string
+	FaskesEntityKeys        string
+	InfrastrukturEntityKeys string
+	// Entity mapping (entity UUID -> source submission) fetch and reuse
+	EntityMappingConcurrency    int
+	EntityMappingProgressEvery  int
+	EntityMappingTimeoutSeconds int
+	EntityMappingTTLMinutes     int // 0 always refetches from ODK
+	// EntityDatasetFallback is "submission" (sync with submission IDs, flagged) or "fail"
+	// when the posko entity dataset does not exist in ODK Central
+	EntityDatasetFallback string
+	// HardSyncMinPercent is the share of stored records a hard sync must fetch from ODK
+	// before deleting the rest (0 = always delete)
+	HardSyncMinPercent int
+	// PurgeDeletedAfterDays is the default age of soft-deleted records removed for good
+	// by POST /admin/purge-deleted
+	PurgeDeletedAfterDays int
+	// SyncRunsRetentionDays is how long sync run history is kept (0 = forever)
+	SyncRunsRetentionDays int
+	// CoordinateBounds is "min_lat,max_lat,min_lon,max_lon" for mapped points (empty = Indonesia)
+	CoordinateBounds string
+	// ErrorDetailsMax caps the error messages kept in a sync result (0 = no cap)
+	ErrorDetailsMax int
 
 	// Storage
 	PhotoStoragePath string
+	// Background photo downloads after sync (optional)
+	PhotoAutoDownload bool
+	PhotoQueueSize    int
+	PhotoQueueWorkers int
+	// AttachmentAllowedTypes is the MIME allowlist for cached attachments (e.g. "image/*,application/pdf")
+	AttachmentAllowedTypes []string
+	// FaskesPhotoFields overrides the faskes grp_foto photo slots ("field:photo_type", ...)
+	FaskesPhotoFields []string
+	// PhotoDuplicatePolicy is "skip" or "replace" for posko photos whose filename is already known
+	PhotoDuplicatePolicy string
+	// PhotoHiddenTypes are photo types (e.g. "sampah") listed only for requests with a valid API key
+	PhotoHiddenTypes []string
 
 	// S3 Storage (optional - if enabled, photos stored in S3)
-	S3Enabled         bool
-	S3Endpoint        string
-	S3Bucket          string
-	S3AccessKeyID     string
-	S3SecretAccessKey string
-	S3Region          string
-	S3PathPrefix      string
+	S3Enabled             bool
+	S3Endpoint            string
+	S3Bucket              string
+	S3AccessKeyID         string
+	S3SecretAccessKey     string
+	S3Region              string
+	S3PathPrefix          string
+	S3Private             bool // Private bucket: photos served via signed URLs
+	S3SignedURLTTLMinutes int
+	S3TimeoutSeconds      int // Per-operation deadline for S3 calls
+	// Key prefixes (locations, feeds, faskes) stored private / public-read regardless of S3Private
+	S3PrivatePrefixes []string
+	S3PublicPrefixes  []string
+	// Cache-Control metadata set on uploaded objects
+	S3CacheControl string
 
 	// API Key for protected endpoints (sync, scheduler, etc.)
 	SyncAPIKey string
+
+	// APIKeys are named keys with scopes, "label:key:scopes" (scopes: sync|admin|write or *)
+	APIKeys []string
+
+	// SyncStaleThresholdMinutes is the age after which synced data is reported as stale
+	SyncStaleThresholdMinutes int
+
+	// StaleDataMaxAgeMinutes flags list responses with meta.stale once a form's sync is failing
+	// and its last successful sync is older than this (0 = off)
+	StaleDataMaxAgeMinutes int
+
+	// ExposeSyncStatusPublic serves the /sync/*/status endpoints without the API key
+	ExposeSyncStatusPublic bool
+
+	// MaintenanceMode starts the API read-only: syncs and writes answer 503 until it is
+	// switched off via POST /admin/maintenance
+	MaintenanceMode bool
+
+	// ListCountCacheSeconds keeps list totals per filter so paging does not recount (0 = off)
+	ListCountCacheSeconds int
+	// ListCountEstimateAbove returns the planner's row estimate as the total above this many rows (0 = always exact)
+	ListCountEstimateAbove int
+
+	// Feeds
+	FeedIncludePhotosDefault bool // include photos in feed lists when ?include is not given
+	FeedInheritLocationGeom  bool // feeds without coordinates take their linked posko's geometry
+	// Feed content is capped (characters, 0 = unlimited) and sanitized (none, strip, escape) at sync
+	FeedContentMaxLength int
+	FeedContentSanitize  string
+
+	// SchedulerMaxJitterSeconds is the max random delay added to each form's scheduled sync start
+	SchedulerMaxJitterSeconds int
+
+	// Response cache: re-issue common queries right after a sync clears the cache
+	CacheWarmAfterSync bool
+	CacheWarmPaths     []string
 }
 
 func Load() *Config {
 	return &Config{
-		Port:        getEnv("API_PORT", "8080"),
-		LogLevel:    getEnv("LOG_LEVEL", "debug"),
-		Environment: getEnv("ENVIRONMENT", "development"),
-		DBHost:      getEnv("DB_HOST", "localhost"),
-		DBPort:      getEnv("DB_PORT", "5432"),
-		DBUser:      getEnv("DB_USER", "senyar"),
-		DBPassword:  getEnv("DB_PASSWORD", "senyar123"),
-		DBName:      getEnv("DB_NAME", "senyar"),
-		CacheHost:   getEnv("CACHE_HOST", "localhost"),
-		CachePort:   getEnvInt("CACHE_PORT", 6379),
-		CORSOrigins: getEnv("CORS_ORIGINS", "http://localhost:5173,http://localhost:3000"),
+		Port:                       getEnv("API_PORT", "8080"),
+		LogLevel:                   getEnv("LOG_LEVEL", "debug"),
+		Environment:                getEnv("ENVIRONMENT", "development"),
+		DBHost:                     getEnv("DB_HOST", "localhost"),
+		DBPort:                     getEnv("DB_PORT", "5432"),
+		DBUser:                     getEnv("DB_USER", "senyar"),
+		DBPassword:                 getEnv("DB_PASSWORD", "senyar123"),
+		DBName:                     getEnv("DB_NAME", "senyar"),
+		AutoMigrate:                getEnvBool("AUTO_MIGRATE", false),
+		CacheHost:                  getEnv("CACHE_HOST", "localhost"),
+		CachePort:                  getEnvInt("CACHE_PORT", 6379),
+		CORSOrigins:                getEnvList("CORS_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000", "https://dayawarga.com", "https://www.dayawarga.com"}),
+		CORSAllowCredentials:       getEnvBool("CORS_ALLOW_CREDENTIALS", true),
+		CORSWildcardPolicy:         getEnv("CORS_WILDCARD_POLICY", "reject"),
+		PublicBaseURL:              getEnv("PUBLIC_BASE_URL", ""),
+		MaxRequestBodyBytes:        getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20),
+		HealthDBLatencyThresholdMs: getEnvInt("HEALTH_DB_LATENCY_THRESHOLD_MS", 500),
 		// ODK Central
-		ODKBaseURL:    getEnv("ODK_BASE_URL", "https://data.dayawarga.com"),
-		ODKEmail:      getEnv("ODK_EMAIL", ""),
-		ODKPassword:   getEnv("ODK_PASSWORD", ""),
-		ODKProjectID:  getEnvInt("ODK_PROJECT_ID", 3),
+		ODKBaseURL:             getEnv("ODK_BASE_URL", "https://data.dayawarga.com"),
+		ODKEmail:               getEnv("ODK_EMAIL", ""),
+		ODKPassword:            getEnv("ODK_PASSWORD", ""),
+		ODKToken:               getEnv("ODK_TOKEN", ""),
+		ODKProjectID:           getEnvInt("ODK_PROJECT_ID", 3),
 		ODKFormID:              getEnv("ODK_FORM_ID", "form_posko_v1"),
 		ODKFeedFormID:          getEnv("ODK_FEED_FORM_ID", "form_feed_v1"),
 		ODKFaskesFormID:        getEnv("ODK_FASKES_FORM_ID", "form_faskes_v1"),
 		ODKInfrastrukturFormID: getEnv("ODK_INFRASTRUKTUR_FORM_ID", "form_jembatan_v1"),
+		ODKMaxRetries:          getEnvInt("ODK_MAX_RETRIES", 3),
+		ODKRetryBaseDelayMs:    getEnvInt("ODK_RETRY_BASE_DELAY_MS", 500),
+		PoskoNameFields:        getEnvList("POSKO_NAME_FIELDS", nil),
+		PoskoMappingProfiles:   getEnvList("POSKO_MAPPING_PROFILES", nil),
+		PoskoMappingFields:     getEnvList("POSKO_MAPPING_FIELDS", nil),
+		// ODK per-request timeouts
+		ODKAuthTimeoutSeconds:       getEnvInt("ODK_AUTH_TIMEOUT_SECONDS", 30),
+		ODKAttachmentTimeoutSeconds: getEnvInt("ODK_ATTACHMENT_TIMEOUT_SECONDS", 30),
+		ODKQueryTimeoutSeconds:      getEnvInt("ODK_QUERY_TIMEOUT_SECONDS", 30),
+		// Entity keys
+		PoskoEntityKeys:         getEnv("POSKO_ENTITY_KEYS", ""),
+		FaskesEntityKeys:        getEnv("FASKES_ENTITY_KEYS", ""),
+		InfrastrukturEntityKeys: getEnv("INFRASTRUKTUR_ENTITY_KEYS", ""),
+		// Entity mapping
+		EntityMappingConcurrency:    getEnvInt("ENTITY_MAPPING_CONCURRENCY", 8),
+		EntityMappingProgressEvery:  getEnvInt("ENTITY_MAPPING_PROGRESS_EVERY", 100),
+		EntityMappingTimeoutSeconds: getEnvInt("ENTITY_MAPPING_TIMEOUT_SECONDS", 300),
+		EntityMappingTTLMinutes:     getEnvInt("ENTITY_MAPPING_TTL_MINUTES", 360),
+		EntityDatasetFallback:       getEnv("ENTITY_DATASET_FALLBACK", "submission"),
+		HardSyncMinPercent:          getEnvInt("HARD_SYNC_MIN_PERCENT", 50),
+		PurgeDeletedAfterDays:       getEnvInt("PURGE_DELETED_AFTER_DAYS", 30),
+		SyncRunsRetentionDays:       getEnvInt("SYNC_RUNS_RETENTION_DAYS", 90),
+		CoordinateBounds:            getEnv("COORDINATE_BOUNDS", ""),
+		ErrorDetailsMax:             getEnvInt("ERROR_DETAILS_MAX", 50),
+		// Storage
 		PhotoStoragePath:       getEnv("PHOTO_STORAGE_PATH", "./storage/photos"),
+		PhotoAutoDownload:      getEnvBool("PHOTO_AUTO_DOWNLOAD", false),
+		PhotoQueueSize:         getEnvInt("PHOTO_QUEUE_SIZE", 500),
+		PhotoQueueWorkers:      getEnvInt("PHOTO_QUEUE_WORKERS", 2),
+		AttachmentAllowedTypes: getEnvList("ATTACHMENT_ALLOWED_TYPES", []string{"image/*", "application/pdf"}),
+		FaskesPhotoFields:      getEnvList("FASKES_PHOTO_FIELDS", nil),
+		PhotoDuplicatePolicy:   getEnv("PHOTO_DUPLICATE_POLICY", "skip"),
+		PhotoHiddenTypes:       getEnvList("PHOTO_HIDDEN_TYPES", nil),
 		// S3 Storage
-		S3Enabled:         getEnvBool("S3_ENABLED", false),
-		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
-		S3Bucket:          getEnv("S3_BUCKET", ""),
-		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
-		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
-		S3Region:          getEnv("S3_REGION", "auto"),
-		S3PathPrefix:      getEnv("S3_PATH_PREFIX", ""),
+		S3Enabled:             getEnvBool("S3_ENABLED", false),
+		S3Endpoint:            getEnv("S3_ENDPOINT", ""),
+		S3Bucket:              getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:         getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:     getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3Region:              getEnv("S3_REGION", "auto"),
+		S3PathPrefix:          getEnv("S3_PATH_PREFIX", ""),
+		S3Private:             getEnvBool("S3_PRIVATE", false),
+		S3PrivatePrefixes:     getEnvList("S3_PRIVATE_PREFIXES", nil),
+		S3PublicPrefixes:      getEnvList("S3_PUBLIC_PREFIXES", nil),
+		S3SignedURLTTLMinutes: getEnvInt("S3_SIGNED_URL_TTL_MINUTES", 15),
+		S3TimeoutSeconds:      getEnvInt("S3_TIMEOUT_SECONDS", 60),
+		S3CacheControl:        getEnv("S3_CACHE_CONTROL", "public, max-age=604800"),
 		// API Key
-		SyncAPIKey:        getEnv("SYNC_API_KEY", ""),
+		SyncAPIKey:                getEnv("SYNC_API_KEY", ""),
+		APIKeys:                   getEnvList("API_KEYS", nil),
+		SyncStaleThresholdMinutes: getEnvInt("SYNC_STALE_THRESHOLD_MINUTES", 30),
+		StaleDataMaxAgeMinutes:    getEnvInt("STALE_DATA_MAX_AGE_MINUTES", 60),
+		ExposeSyncStatusPublic:    getEnvBool("EXPOSE_SYNC_STATUS_PUBLIC", true),
+		MaintenanceMode:           getEnvBool("MAINTENANCE_MODE", false),
+		// List totals
+		ListCountCacheSeconds:  getEnvInt("LIST_COUNT_CACHE_SECONDS", 30),
+		ListCountEstimateAbove: getEnvInt("LIST_COUNT_ESTIMATE_ABOVE", 0),
+		// Feeds
+		FeedIncludePhotosDefault: getEnvBool("FEED_INCLUDE_PHOTOS_DEFAULT", true),
+		FeedInheritLocationGeom:  getEnvBool("FEED_INHERIT_LOCATION_GEOM", false),
+		FeedContentMaxLength:     getEnvInt("FEED_CONTENT_MAX_LENGTH", 10000),
+		FeedContentSanitize:      getEnv("FEED_CONTENT_SANITIZE", "strip"),
+		// Scheduler
+		SchedulerMaxJitterSeconds: getEnvInt("SCHEDULER_MAX_JITTER_SECONDS", 10),
+		// Response cache
+		CacheWarmAfterSync: getEnvBool("CACHE_WARM_AFTER_SYNC", false),
+		CacheWarmPaths:     getEnvList("CACHE_WARM_PATHS", nil),
+	}
+}
+
+// ODKClientConfig returns the ODK client config for formID, shared by the API and the importer
+// so both authenticate, retry and time out the same way. tokens may be nil for a client's own session.
+func (c *Config) ODKClientConfig(formID string, tokens *odk.TokenCache) *odk.ODKConfig {
+	return &odk.ODKConfig{
+		BaseURL:   c.ODKBaseURL,
+		Email:     c.ODKEmail,
+		Password:  c.ODKPassword,
+		Token:     c.ODKToken,
+		ProjectID: c.ODKProjectID,
+		FormID:    formID,
+
+		MaxRetries:     c.ODKMaxRetries,
+		RetryBaseDelay: time.Duration(c.ODKRetryBaseDelayMs) * time.Millisecond,
+		TokenCache:     tokens,
+
+		AuthTimeout:       time.Duration(c.ODKAuthTimeoutSeconds) * time.Second,
+		AttachmentTimeout: time.Duration(c.ODKAttachmentTimeoutSeconds) * time.Second,
+		QueryTimeout:      time.Duration(c.ODKQueryTimeoutSeconds) * time.Second,
 	}
 }
 
@@ -94,6 +290,21 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvList(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
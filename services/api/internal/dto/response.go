@@ -21,6 +21,20 @@ type MetaInfo struct {
 	Page      int       `json:"page,omitempty"`
 	Limit     int       `json:"limit,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// TotalEstimated is set when Total is the query planner's estimate for a large result
+	// set instead of an exact count
+	TotalEstimated bool `json:"total_estimated,omitempty"`
+
+	// Stale is set when the form's sync has been failing for longer than the configured
+	// maximum age; the data is the last successful sync from StaleSince
+	Stale      bool       `json:"stale,omitempty"`
+	StaleSince *time.Time `json:"stale_since,omitempty"`
+}
+
+// CountResponse is returned by list endpoints with ?count_only=true
+type CountResponse struct {
+	Total int64 `json:"total"`
 }
 
 // GeoJSON types
@@ -36,6 +50,13 @@ type GeoJSONFeature struct {
 	Properties map[string]interface{} `json:"properties"`
 }
 
+// GeoJSONCRS is the "crs" member of a FeatureCollection whose coordinates are not
+// WGS 84 (?srid=); RFC 7946 dropped it, but GIS tools still read it
+type GeoJSONCRS struct {
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties"`
+}
+
 type GeoJSONGeometry struct {
 	Type        string    `json:"type"`
 	Coordinates []float64 `json:"coordinates"`
@@ -45,6 +66,7 @@ type GeoJSONGeometry struct {
 type LocationListResponse struct {
 	Type     string                    `json:"type"`
 	Features []LocationFeatureResponse `json:"features"`
+	CRS      *GeoJSONCRS               `json:"crs,omitempty"`
 }
 
 type LocationFeatureResponse struct {
@@ -77,24 +99,36 @@ type LocationListProperties struct {
 	KebutuhanAirLiter  int       `json:"kebutuhan_air_liter"`
 	BaselineSumber     string    `json:"baseline_sumber,omitempty"`
 	UpdatedAt          time.Time `json:"updated_at"`
+
+	PhotoCount PhotoCount `json:"photo_count"`
+}
+
+// PhotoCount tells list views whether a record has imagery without fetching its detail
+type PhotoCount struct {
+	Cached int `json:"cached"`
+	Total  int `json:"total"`
 }
 
 // LocationDetailResponse for GET /locations/:id
 type LocationDetailResponse struct {
-	ID              string                 `json:"id"`
-	ODKSubmissionID string                 `json:"odk_submission_id,omitempty"`
-	Type            string                 `json:"type"`
-	Status          string                 `json:"status"`
-	BaselineSumber  string                 `json:"baseline_sumber,omitempty"`
-	Geometry        *LocationGeometry      `json:"geometry"`
-	Identitas       map[string]interface{} `json:"identitas"`
-	Alamat          map[string]interface{} `json:"alamat"`
-	DataPengungsi   map[string]interface{} `json:"data_pengungsi"`
-	Fasilitas       map[string]interface{} `json:"fasilitas"`
-	Komunikasi      map[string]interface{} `json:"komunikasi,omitempty"`
-	Akses           map[string]interface{} `json:"akses,omitempty"`
-	Photos          []PhotoResponse        `json:"photos"`
-	Meta            LocationMeta           `json:"meta"`
+	ID              string                  `json:"id"`
+	ODKSubmissionID string                  `json:"odk_submission_id,omitempty"`
+	Type            string                  `json:"type"`
+	Status          string                  `json:"status"`
+	BaselineSumber  string                  `json:"baseline_sumber,omitempty"`
+	Source          string                  `json:"source,omitempty"`
+	Geometry        *LocationGeometry       `json:"geometry"`
+	Identitas       *map[string]interface{} `json:"identitas,omitempty"`
+	Alamat          *map[string]interface{} `json:"alamat,omitempty"`
+	DataPengungsi   *map[string]interface{} `json:"data_pengungsi,omitempty"`
+	Fasilitas       *map[string]interface{} `json:"fasilitas,omitempty"`
+	Komunikasi      *map[string]interface{} `json:"komunikasi,omitempty"`
+	Akses           *map[string]interface{} `json:"akses,omitempty"`
+	Photos          []PhotoDetailResponse   `json:"photos"`
+	Meta            LocationMeta            `json:"meta"`
+
+	// Feeds are the posko's latest feeds, only with ?include=feeds
+	Feeds []FeedResponse `json:"feeds,omitempty"`
 }
 
 type LocationGeometry struct {
@@ -104,34 +138,46 @@ type LocationGeometry struct {
 	Accuracy    *float64  `json:"accuracy,omitempty"`
 }
 
-type PhotoResponse struct {
-	Type     string `json:"type"`
-	Filename string `json:"filename"`
-	URL      string `json:"url"`
+// PhotoDetailResponse is one photo, in the photo listings and in the detail and feed responses.
+// url and thumbnail_url are only set once the photo is cached; thumbnail_url where a thumbnail
+// endpoint exists.
+type PhotoDetailResponse struct {
+	ID           string `json:"id"`
+	PhotoType    string `json:"photo_type"`
+	Filename     string `json:"filename"`
+	IsCached     bool   `json:"is_cached"`
+	FileSize     *int   `json:"file_size,omitempty"`
+	URL          string `json:"url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	Width        *int   `json:"width,omitempty"`
+	Height       *int   `json:"height,omitempty"`
 }
 
 type LocationMeta struct {
-	SubmittedAt   *time.Time `json:"submitted_at,omitempty"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	SubmitterName string     `json:"submitter,omitempty"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	SubmitterName   string     `json:"submitter,omitempty"`
+	SubmissionCount int        `json:"submission_count"`
 }
 
 // FeedResponse for GET /feeds
 type FeedResponse struct {
-	ID           string              `json:"id"`
-	LocationID   *string             `json:"location_id,omitempty"`
-	LocationName *string             `json:"location_name,omitempty"`
-	FaskesID     *string             `json:"faskes_id,omitempty"`
-	FaskesName   *string             `json:"faskes_name,omitempty"`
-	Content      string              `json:"content"`
-	Category     string              `json:"category"`
-	Type         *string             `json:"type,omitempty"`
-	Username     *string             `json:"username,omitempty"`
-	Organization *string             `json:"organization,omitempty"`
-	SubmittedAt  time.Time           `json:"submitted_at"`
-	Coordinates  []float64           `json:"coordinates,omitempty"`
-	Photos       []FeedPhotoResponse `json:"photos,omitempty"`
-	Region       *FeedRegion         `json:"region,omitempty"`
+	ID           string                `json:"id"`
+	LocationID   *string               `json:"location_id,omitempty"`
+	LocationName *string               `json:"location_name,omitempty"`
+	FaskesID     *string               `json:"faskes_id,omitempty"`
+	FaskesName   *string               `json:"faskes_name,omitempty"`
+	Content      string                `json:"content"`
+	Truncated    bool                  `json:"truncated,omitempty"` // content shortened by ?excerpt=N or FEED_CONTENT_MAX_LENGTH
+	Category     string                `json:"category"`
+	Tags         []string              `json:"tags,omitempty"`
+	Username     *string               `json:"username,omitempty"`
+	Organization *string               `json:"organization,omitempty"`
+	SubmittedAt  time.Time             `json:"submitted_at"`
+	Coordinates  []float64             `json:"coordinates,omitempty"`
+	Photos       []PhotoDetailResponse `json:"photos,omitempty"`
+	Region       *FeedRegion           `json:"region,omitempty"`
 }
 
 // FeedRegion contains regional information from ODK submission
@@ -146,18 +192,11 @@ type FeedRegion struct {
 	IDDesa      string `json:"id_desa,omitempty"`
 }
 
-// FeedPhotoResponse for feed photo data
-type FeedPhotoResponse struct {
-	ID       string `json:"id"`
-	Type     string `json:"type"`
-	Filename string `json:"filename"`
-	URL      string `json:"url"`
-}
-
 // FaskesListResponse for GET /faskes
 type FaskesListResponse struct {
 	Type     string                  `json:"type"`
 	Features []FaskesFeatureResponse `json:"features"`
+	CRS      *GeoJSONCRS             `json:"crs,omitempty"`
 }
 
 type FaskesFeatureResponse struct {
@@ -183,6 +222,8 @@ type FaskesListProperties struct {
 	IDKecamatan     string    `json:"id_kecamatan,omitempty"`
 	IDDesa          string    `json:"id_desa,omitempty"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	PhotoCount PhotoCount `json:"photo_count"`
 }
 
 // FaskesDetailResponse for GET /faskes/:id
@@ -201,7 +242,7 @@ type FaskesDetailResponse struct {
 	SDM             map[string]interface{} `json:"sdm,omitempty"`
 	Perbekalan      map[string]interface{} `json:"perbekalan,omitempty"`
 	Klaster         map[string]interface{} `json:"klaster,omitempty"`
-	Photos          []PhotoResponse        `json:"photos"`
+	Photos          []PhotoDetailResponse  `json:"photos"`
 	Meta            LocationMeta           `json:"meta"`
 }
 
@@ -209,6 +250,7 @@ type FaskesDetailResponse struct {
 type InfrastrukturListResponse struct {
 	Type     string                         `json:"type"`
 	Features []InfrastrukturFeatureResponse `json:"features"`
+	CRS      *GeoJSONCRS                    `json:"crs,omitempty"`
 }
 
 type InfrastrukturFeatureResponse struct {
@@ -234,27 +276,36 @@ type InfrastrukturListProperties struct {
 
 // InfrastrukturDetailResponse for GET /infrastruktur/:id
 type InfrastrukturDetailResponse struct {
-	ID                string            `json:"id"`
-	EntityID          string            `json:"entity_id,omitempty"`
-	ObjectID          string            `json:"object_id,omitempty"`
-	Nama              string            `json:"nama"`
-	Jenis             string            `json:"jenis"`
-	StatusJln         string            `json:"status_jln"`
-	NamaProvinsi      string            `json:"nama_provinsi,omitempty"`
-	NamaKabupaten     string            `json:"nama_kabupaten,omitempty"`
-	Geometry          *LocationGeometry `json:"geometry"`
-	StatusAkses       string            `json:"status_akses,omitempty"`
-	KeteranganBencana string            `json:"keterangan_bencana,omitempty"`
-	Dampak            string            `json:"dampak,omitempty"`
-	StatusPenanganan  string            `json:"status_penanganan,omitempty"`
-	PenangananDetail  string            `json:"penanganan_detail,omitempty"`
-	Bailey            string            `json:"bailey,omitempty"`
-	Progress          int               `json:"progress"`
-	TargetSelesai     string            `json:"target_selesai,omitempty"`
-	BaselineSumber    string            `json:"baseline_sumber,omitempty"`
-	UpdateBy          string            `json:"update_by,omitempty"`
-	Photos            []PhotoResponse   `json:"photos"`
-	Meta              LocationMeta      `json:"meta"`
+	ID                string                `json:"id"`
+	EntityID          string                `json:"entity_id,omitempty"`
+	ObjectID          string                `json:"object_id,omitempty"`
+	Nama              string                `json:"nama"`
+	Jenis             string                `json:"jenis"`
+	StatusJln         string                `json:"status_jln"`
+	NamaProvinsi      string                `json:"nama_provinsi,omitempty"`
+	NamaKabupaten     string                `json:"nama_kabupaten,omitempty"`
+	Geometry          *LocationGeometry     `json:"geometry"`
+	StatusAkses       string                `json:"status_akses,omitempty"`
+	KeteranganBencana string                `json:"keterangan_bencana,omitempty"`
+	Dampak            string                `json:"dampak,omitempty"`
+	StatusPenanganan  string                `json:"status_penanganan,omitempty"`
+	PenangananDetail  string                `json:"penanganan_detail,omitempty"`
+	Bailey            string                `json:"bailey,omitempty"`
+	Progress          int                   `json:"progress"`
+	TargetSelesai     string                `json:"target_selesai,omitempty"`
+	BaselineSumber    string                `json:"baseline_sumber,omitempty"`
+	UpdateBy          string                `json:"update_by,omitempty"`
+	Photos            []PhotoDetailResponse `json:"photos"`
+	Meta              LocationMeta          `json:"meta"`
+}
+
+// InfrastrukturTimelineItem is one progress update in GET /infrastruktur/:id/timeline
+type InfrastrukturTimelineItem struct {
+	ODKSubmissionID  string     `json:"odk_submission_id"`
+	Progress         int        `json:"progress"`
+	StatusPenanganan string     `json:"status_penanganan,omitempty"`
+	UpdateBy         string     `json:"update_by,omitempty"`
+	SubmittedAt      *time.Time `json:"submitted_at,omitempty"`
 }
 
 // InfrastrukturStatsResponse for GET /infrastruktur/stats
@@ -280,6 +331,96 @@ type HealthResponse struct {
 
 type Check struct {
 	Status    string     `json:"status"`
-	LatencyMs int64      `json:"latency_ms,omitempty"`
+	LatencyMs int64      `json:"latency_ms"`
 	LastSync  *time.Time `json:"last_sync,omitempty"`
 }
+
+// SyncFreshnessResponse for GET /sync/freshness
+type SyncFreshnessResponse struct {
+	StaleThresholdSeconds int64           `json:"stale_threshold_seconds"`
+	Forms                 []FormFreshness `json:"forms"`
+}
+
+// FormFreshness describes how recent the last successful sync of a form is
+type FormFreshness struct {
+	Form         string     `json:"form"`
+	FormID       string     `json:"form_id"`
+	Status       string     `json:"status"`
+	LastSyncTime *time.Time `json:"last_sync_time"`
+	AgeSeconds   *int64     `json:"age_seconds"`
+	IsStale      bool       `json:"is_stale"`
+}
+
+// SubmissionSyncResponse for POST /sync/posko/submissions/:submissionId
+type SubmissionSyncResponse struct {
+	SubmissionID string               `json:"submission_id"`
+	LocationID   string               `json:"location_id,omitempty"`
+	Sync         interface{}          `json:"sync"`
+	Photos       *SubmissionPhotoSync `json:"photos,omitempty"`
+}
+
+// SubmissionPhotoSync reports the photo download done as part of a submission re-sync
+type SubmissionPhotoSync struct {
+	Downloaded int    `json:"downloaded"`
+	Error      string `json:"error,omitempty"`
+}
+
+// FormsConfigResponse for GET /config/forms
+type FormsConfigResponse struct {
+	Posko         FormConfig `json:"posko"`
+	Feed          FormConfig `json:"feed"`
+	Faskes        FormConfig `json:"faskes"`
+	Infrastruktur FormConfig `json:"infrastruktur"`
+}
+
+// FormConfig identifies an ODK Central form
+type FormConfig struct {
+	FormID    string `json:"form_id"`
+	ProjectID int    `json:"project_id"`
+}
+
+// LocationStatsResponse for GET /locations/stats
+type LocationStatsResponse struct {
+	TotalPosko        int64             `json:"total_posko"`
+	TotalJiwa         int64             `json:"total_jiwa"`
+	JumlahKK          int64             `json:"jumlah_kk"`
+	KebutuhanAirLiter float64           `json:"kebutuhan_air_liter"`
+	ByStatus          []StatItem        `json:"by_status"`
+	ByProvinsi        []RegionCountItem `json:"by_provinsi"`
+}
+
+// RegionCountItem for GET /locations/by-region
+type RegionCountItem struct {
+	Kode      string `json:"kode"`
+	Nama      string `json:"nama"`
+	Count     int64  `json:"count"`
+	TotalJiwa int64  `json:"total_jiwa"`
+}
+
+// MissingGeometryItem for GET /admin/missing-geometry
+type MissingGeometryItem struct {
+	ID              string     `json:"id"`
+	ODKSubmissionID string     `json:"odk_submission_id,omitempty"`
+	Nama            string     `json:"nama"`
+	SubmitterName   *string    `json:"submitter_name,omitempty"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
+}
+
+// ODKEntityItem for GET /admin/odk/entities
+type ODKEntityItem struct {
+	UUID               string     `json:"uuid"`
+	Label              string     `json:"label"`
+	Version            int        `json:"version,omitempty"`
+	SourceSubmissionID string     `json:"source_submission_id,omitempty"` // from the stored entity mapping
+	CreatedAt          *time.Time `json:"created_at,omitempty"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+}
+
+// ActivityResponse for GET /activity
+type ActivityResponse struct {
+	Type      string    `json:"type"` // posko, faskes, feed, infrastruktur
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Action    string    `json:"action"` // created, updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// ActivityHandler serves the merged change log across posko, faskes, feeds and infrastruktur
+type ActivityHandler struct {
+	activityRepo *repository.ActivityRepository
+}
+
+func NewActivityHandler(activityRepo *repository.ActivityRepository) *ActivityHandler {
+	return &ActivityHandler{activityRepo: activityRepo}
+}
+
+// GetActivity returns recent creates/updates across all record types, newest first
+// @Summary Get recent activity
+// @Description Returns a time-ordered list of recently created/updated records of all types
+// @Tags activity
+// @Produce json
+// @Param since query string false "RFC3339 timestamp or YYYY-MM-DD"
+// @Param limit query int false "Max items (default 50, max 200)"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Router /api/v1/activity [get]
+func (h *ActivityHandler) GetActivity(c *gin.Context) {
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", raw)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "VALIDATION_ERROR",
+					Message: "Invalid since timestamp",
+					Details: map[string]interface{}{"since": "must be RFC3339 or YYYY-MM-DD"},
+				},
+			})
+			return
+		}
+		since = t
+	}
+
+	limit := activityLimit(c.Query("limit"))
+	items, err := h.activityRepo.FindRecent(since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch activity",
+			},
+		})
+		return
+	}
+
+	response := make([]dto.ActivityResponse, len(items))
+	for i, item := range items {
+		response[i] = dto.ActivityResponse{
+			Type:      item.Type,
+			ID:        item.ID.String(),
+			Name:      item.Name,
+			Action:    item.Action,
+			UpdatedAt: item.UpdatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    response,
+		Meta: &dto.MetaInfo{
+			Total:     int64(len(response)),
+			Limit:     limit,
+			Timestamp: time.Now(),
+		},
+	})
+}
+
+// activityLimit parses ?limit=, clamped here rather than only in the repository so
+// meta.limit reports the limit applied
+func activityLimit(raw string) int {
+	if l, err := strconv.Atoi(raw); err == nil && l > 0 {
+		return min(l, repository.MaxActivityLimit)
+	}
+	return 50
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// meta.limit reports activityLimit, so it must be the limit applied, not the one asked for
+func TestActivityLimit(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+	}{
+		{"", 50},
+		{"20", 20},
+		{"200", repository.MaxActivityLimit},
+		{"1000", repository.MaxActivityLimit},
+		{"-5", 50},
+		{"abc", 50},
+	}
+
+	for _, tt := range tests {
+		if got := activityLimit(tt.raw); got != tt.want {
+			t.Errorf("activityLimit(%q) = %d, want %d", tt.raw, got, tt.want)
+		}
+	}
+}
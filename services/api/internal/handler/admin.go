@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// AdminHandler serves data-quality worklists for data stewards
+type AdminHandler struct {
+	locationRepo *repository.LocationRepository
+	faskesRepo   *repository.FaskesRepository
+}
+
+func NewAdminHandler(locationRepo *repository.LocationRepository, faskesRepo *repository.FaskesRepository) *AdminHandler {
+	return &AdminHandler{
+		locationRepo: locationRepo,
+		faskesRepo:   faskesRepo,
+	}
+}
+
+// GetMissingGeometry lists records without valid coordinates, to be fixed in ODK Central
+// @Summary List records with missing geometry
+// @Description Returns posko or faskes whose geometry is NULL, empty or 0,0
+// @Tags admin
+// @Produce json
+// @Param form query string true "posko or faskes"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Router /api/v1/admin/missing-geometry [get]
+func (h *AdminHandler) GetMissingGeometry(c *gin.Context) {
+	var req struct {
+		Form string `form:"form" binding:"required,oneof=posko faskes"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	var (
+		records []repository.MissingGeometryRecord
+		err     error
+	)
+	switch req.Form {
+	case "posko":
+		records, err = h.locationRepo.FindMissingGeometry()
+	case "faskes":
+		records, err = h.faskesRepo.FindMissingGeometry()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch records with missing geometry",
+			},
+		})
+		return
+	}
+
+	items := make([]dto.MissingGeometryItem, len(records))
+	for i, r := range records {
+		items[i] = dto.MissingGeometryItem{
+			ID:            r.ID.String(),
+			Nama:          r.Nama,
+			SubmitterName: r.SubmitterName,
+			SubmittedAt:   r.SubmittedAt,
+		}
+		if r.ODKSubmissionID != nil {
+			items[i].ODKSubmissionID = *r.ODKSubmissionID
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    items,
+		Meta: &dto.MetaInfo{
+			Total:     int64(len(items)),
+			Timestamp: time.Now(),
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// AuditHandler serves the log of admin mutations
+type AuditHandler struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditHandler(auditRepo *repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{auditRepo: auditRepo}
+}
+
+// GetAuditLog returns the most recent authenticated mutating requests, newest first
+// @Summary Get admin audit log
+// @Description Returns who (API key label), what (method and path), when and the effect of recent admin mutations
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Max entries (default 100, max 1000)"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/admin/audit [get]
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	var req struct {
+		Limit int `form:"limit" binding:"omitempty,min=1,max=1000"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	entries, err := h.auditRepo.FindRecent(req.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch audit log",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    entries,
+		Meta: &dto.MetaInfo{
+			Total:     int64(len(entries)),
+			Limit:     req.Limit,
+			Timestamp: time.Now(),
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+)
+
+// ConfigHandler exposes a safe (credential-free) subset of the deployment configuration
+type ConfigHandler struct {
+	forms dto.FormsConfigResponse
+}
+
+func NewConfigHandler(forms dto.FormsConfigResponse) *ConfigHandler {
+	return &ConfigHandler{forms: forms}
+}
+
+// GetForms returns the ODK forms this instance syncs from
+// @Summary Get configured ODK forms
+// @Description Returns the form and project IDs per data type (no credentials)
+// @Tags config
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Router /api/v1/config/forms [get]
+func (h *ConfigHandler) GetForms(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    h.forms,
+	})
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+)
+
+// isCountOnly reports whether a list request asked for ?count_only=true
+func isCountOnly(c *gin.Context) bool {
+	return c.Query("count_only") == "true"
+}
+
+// respondCount writes a count-only list response: {"total": N}
+func respondCount(c *gin.Context, total int64, err error, resource string) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to count " + resource,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    dto.CountResponse{Total: total},
+		Meta: &dto.MetaInfo{
+			Timestamp: time.Now(),
+		},
+	})
+}
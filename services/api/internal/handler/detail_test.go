@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB returns a database handle that builds queries without running them,
+// so photo lookups in the detail builders find nothing
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+func testContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	return c
+}
+
+// copyJSONB returns a shallow copy of m, to compare the model against after the response is built
+func copyJSONB(m model.JSONB) model.JSONB {
+	if m == nil {
+		return nil
+	}
+	out := make(model.JSONB, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func TestCloneJSONB(t *testing.T) {
+	tests := []struct {
+		name string
+		src  model.JSONB
+	}{
+		{"nil", nil},
+		{"empty", model.JSONB{}},
+		{"values", model.JSONB{"a": "x", "b": float64(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := copyJSONB(tt.src)
+			got := cloneJSONB(tt.src)
+			if got == nil {
+				t.Fatal("cloneJSONB returned nil")
+			}
+			if len(got) != len(tt.src) {
+				t.Fatalf("len = %d, want %d", len(got), len(tt.src))
+			}
+
+			got["derived"] = true
+			if !reflect.DeepEqual(tt.src, before) {
+				t.Errorf("source changed to %v, want %v", tt.src, before)
+			}
+		})
+	}
+}
+
+func TestLocationDetailDoesNotMutateModel(t *testing.T) {
+	tests := []struct {
+		name      string
+		identitas model.JSONB
+		alamat    model.JSONB
+	}{
+		{"nil groups", nil, nil},
+		{"empty groups", model.JSONB{}, model.JSONB{}},
+		{"stored nama", model.JSONB{"nama": "stored", "baseline_sumber": "bnpb"}, model.JSONB{"desa": "x"}},
+	}
+
+	h := NewLocationHandler(repository.NewLocationRepository(dryRunDB(t)), nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := &repository.LocationWithCoords{}
+			location.ID = uuid.New()
+			location.Nama = "Posko Uji"
+			location.Identitas = tt.identitas
+			location.Alamat = tt.alamat
+			identitasBefore, alamatBefore := copyJSONB(tt.identitas), copyJSONB(tt.alamat)
+
+			response := h.locationDetail(testContext(), location)
+
+			if response.Identitas == nil || (*response.Identitas)["nama"] != "Posko Uji" {
+				t.Errorf("identitas = %v, want nama Posko Uji", response.Identitas)
+			}
+			if !reflect.DeepEqual(location.Identitas, identitasBefore) {
+				t.Errorf("model identitas changed to %v, want %v", location.Identitas, identitasBefore)
+			}
+			if !reflect.DeepEqual(location.Alamat, alamatBefore) {
+				t.Errorf("model alamat changed to %v, want %v", location.Alamat, alamatBefore)
+			}
+		})
+	}
+}
+
+func TestLocationDetailGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups string
+		want   map[string]string // encoded group, "" when left out
+	}{
+		{"all groups", "", map[string]string{
+			"identitas": `{"nama":"Posko Uji"}`, "alamat": `{"desa":"x"}`, "data_pengungsi": `{}`,
+			"fasilitas": `{}`, "komunikasi": "", "akses": `{"jalan":"rusak"}`,
+		}},
+		{"selected groups", "alamat,data_pengungsi", map[string]string{
+			"identitas": "", "alamat": `{"desa":"x"}`, "data_pengungsi": `{}`,
+			"fasilitas": "", "komunikasi": "", "akses": "",
+		}},
+	}
+
+	h := NewLocationHandler(repository.NewLocationRepository(dryRunDB(t)), nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := &repository.LocationWithCoords{}
+			location.ID = uuid.New()
+			location.Nama = "Posko Uji"
+			location.Alamat = model.JSONB{"desa": "x"}
+			location.Akses = model.JSONB{"jalan": "rusak"}
+
+			response := h.locationDetail(testContext(), location)
+			groups, err := parseDetailGroups(tt.groups)
+			if err != nil {
+				t.Fatalf("parse groups: %v", err)
+			}
+			if groups != nil {
+				omitDetailGroups(&response, groups)
+			}
+
+			data, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("decode %s: %v", data, err)
+			}
+			for group, want := range tt.want {
+				if got := string(fields[group]); got != want {
+					t.Errorf("%s = %q, want %q", group, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFaskesDetailDoesNotMutateModel(t *testing.T) {
+	tests := []struct {
+		name      string
+		identitas model.JSONB
+	}{
+		{"nil identitas", nil},
+		{"empty identitas", model.JSONB{}},
+		{"stored nama", model.JSONB{"nama": "stored"}},
+	}
+
+	h := NewFaskesHandler(repository.NewFaskesRepository(dryRunDB(t)))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faskes := &repository.FaskesWithCoords{}
+			faskes.ID = uuid.New()
+			faskes.Nama = "Puskesmas Uji"
+			faskes.Identitas = tt.identitas
+			before := copyJSONB(tt.identitas)
+
+			response := h.faskesDetail(testContext(), faskes)
+
+			if response.Identitas["nama"] != "Puskesmas Uji" {
+				t.Errorf("identitas.nama = %v, want Puskesmas Uji", response.Identitas["nama"])
+			}
+			if !reflect.DeepEqual(faskes.Identitas, before) {
+				t.Errorf("model identitas changed to %v, want %v", faskes.Identitas, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+	"github.com/leksa/datamapper-senyar/internal/service"
+)
+
+// Strict GeoJSON parsers and the map choke on null, so empty lists must encode as []
+func TestEmptyListsEncodeAsArrays(t *testing.T) {
+	db := dryRunDB(t)
+	locationHandler := NewLocationHandler(repository.NewLocationRepository(db), repository.NewFeedRepository(db))
+	faskesHandler := NewFaskesHandler(repository.NewFaskesRepository(db))
+	infraHandler := NewInfrastrukturHandler(repository.NewInfrastrukturRepository(db))
+	feedHandler := NewFeedHandler(repository.NewFeedRepository(db))
+	photoHandler := NewPhotoHandler(service.NewPhotoService(db, nil, t.TempDir()))
+
+	id := uuid.New().String()
+	tests := []struct {
+		name    string
+		route   string
+		path    string
+		handler gin.HandlerFunc
+		// field is the array in data, "" when data itself is the array
+		field string
+	}{
+		{"locations", "/locations", "/locations", locationHandler.GetLocations, "features"},
+		{"faskes", "/faskes", "/faskes", faskesHandler.GetFaskes, "features"},
+		{"infrastruktur", "/infrastruktur", "/infrastruktur", infraHandler.GetInfrastruktur, "features"},
+		{"feeds", "/feeds", "/feeds", feedHandler.GetFeeds, ""},
+		{"location feeds", "/locations/:id/feeds", "/locations/" + id + "/feeds", feedHandler.GetFeedsByLocation, ""},
+		{"location photos", "/locations/:id/photos", "/locations/" + id + "/photos", photoHandler.GetPhotosByLocation, ""},
+		{"faskes photos", "/faskes/:id/photos", "/faskes/" + id + "/photos", photoHandler.GetPhotosByFaskes, ""},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET(tt.route, tt.handler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+			}
+			var body struct {
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %s: %v", w.Body.String(), err)
+			}
+
+			list := body.Data
+			if tt.field != "" {
+				var data map[string]json.RawMessage
+				if err := json.Unmarshal(body.Data, &data); err != nil {
+					t.Fatalf("decode data %s: %v", body.Data, err)
+				}
+				list = data[tt.field]
+			}
+			if string(list) != "[]" {
+				t.Errorf("list = %s, want []", list)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// FacetsHandler exposes the canonical filter values per domain
+type FacetsHandler struct {
+	feedRepo *repository.FeedRepository
+}
+
+func NewFacetsHandler(feedRepo *repository.FeedRepository) *FacetsHandler {
+	return &FacetsHandler{feedRepo: feedRepo}
+}
+
+// GetFacets returns the canonical status values accepted by the list filters
+// @Summary Get filter facets
+// @Description Returns canonical values for the status filters of posko and faskes and the organizations of the feeds
+// @Tags facets
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/facets [get]
+func (h *FacetsHandler) GetFacets(c *gin.Context) {
+	organizations, err := h.feedRepo.Organizations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch feed organizations",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"posko": map[string]interface{}{
+				"status": model.LocationStatuses,
+			},
+			"faskes": map[string]interface{}{
+				"status_faskes": model.FaskesStatuses,
+			},
+			"feed": map[string]interface{}{
+				"organization": organizations,
+			},
+		},
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,6 +15,9 @@ import (
 
 type FaskesHandler struct {
 	faskesRepo *repository.FaskesRepository
+	staleFlag
+	photoVisibility
+	publicURLs
 }
 
 func NewFaskesHandler(faskesRepo *repository.FaskesRepository) *FaskesHandler {
@@ -33,9 +37,12 @@ func NewFaskesHandler(faskesRepo *repository.FaskesRepository) *FaskesHandler {
 // @Param kondisi_faskes query string false "Filter by kondisi_faskes"
 // @Param search query string false "Search by name"
 // @Param bbox query string false "Bounding box (minLng,minLat,maxLng,maxLat)"
+// @Param sort query string false "Sort order (updated_at_desc, updated_at_asc, nama_asc)"
+// @Param srid query int false "SRID of the returned coordinates (4326, 3857, UTM 32646-32654/32746-32754; default 4326)"
 // @Param page query int false "Page number"
 // @Param limit query int false "Items per page"
 // @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
 // @Router /api/v1/faskes [get]
 func (h *FaskesHandler) GetFaskes(c *gin.Context) {
 	filter := repository.FaskesFilter{
@@ -43,10 +50,30 @@ func (h *FaskesHandler) GetFaskes(c *gin.Context) {
 		StatusFaskes:  c.Query("status_faskes"),
 		KondisiFaskes: c.Query("kondisi_faskes"),
 		Search:        c.Query("search"),
+		Sort:          c.Query("sort"),
 		Page:          1,
 		Limit:         50,
 	}
 
+	if filter.Sort != "" && !repository.IsValidFaskesSort(filter.Sort) {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid sort key",
+				Details: map[string]interface{}{"allowed": repository.FaskesSortKeys()},
+			},
+		})
+		return
+	}
+
+	// srid=3857: coordinates in another projection for GIS partners (default WGS 84)
+	srid, ok := parseSRID(c)
+	if !ok {
+		return
+	}
+	filter.SRID = srid
+
 	// Parse pagination
 	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
 		filter.Page = page
@@ -74,6 +101,12 @@ func (h *FaskesHandler) GetFaskes(c *gin.Context) {
 		}
 	}
 
+	if isCountOnly(c) {
+		total, err := h.faskesRepo.Count(filter)
+		respondCount(c, total, err, "faskes")
+		return
+	}
+
 	faskesList, total, err := h.faskesRepo.FindAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -86,6 +119,16 @@ func (h *FaskesHandler) GetFaskes(c *gin.Context) {
 		return
 	}
 
+	// Photo counts for all listed records in one query (best effort)
+	ids := make([]uuid.UUID, len(faskesList))
+	for i, item := range faskesList {
+		ids[i] = item.ID
+	}
+	photoCounts, err := h.faskesRepo.CountPhotos(ids)
+	if err != nil {
+		log.Printf("Warning: failed to count faskes photos, listing without counts: %v", err)
+	}
+
 	// Convert to GeoJSON
 	features := make([]dto.FaskesFeatureResponse, len(faskesList))
 	for i, f := range faskesList {
@@ -173,6 +216,7 @@ func (h *FaskesHandler) GetFaskes(c *gin.Context) {
 				IDKecamatan:     idKecamatan,
 				IDDesa:          idDesa,
 				UpdatedAt:       f.UpdatedAt,
+				PhotoCount:      dto.PhotoCount(photoCounts[f.ID]),
 			},
 		}
 	}
@@ -182,13 +226,15 @@ func (h *FaskesHandler) GetFaskes(c *gin.Context) {
 		Data: dto.FaskesListResponse{
 			Type:     "FeatureCollection",
 			Features: features,
+			CRS:      geoJSONCRS(filter.SRID),
 		},
-		Meta: &dto.MetaInfo{
-			Total:     total,
-			Page:      filter.Page,
-			Limit:     filter.Limit,
-			Timestamp: time.Now(),
-		},
+		Meta: h.withStale(&dto.MetaInfo{
+			Total:          total.Value,
+			TotalEstimated: total.Estimated,
+			Page:           filter.Page,
+			Limit:          filter.Limit,
+			Timestamp:      time.Now(),
+		}),
 	})
 }
 
@@ -229,15 +275,35 @@ func (h *FaskesHandler) GetFaskesByID(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    h.faskesDetail(c, faskes),
+	})
+}
+
+// faskesDetail builds the detail response of a faskes, with the photos visible to c
+func (h *FaskesHandler) faskesDetail(c *gin.Context, faskes *repository.FaskesWithCoords) dto.FaskesDetailResponse {
 	// Get photos
-	photos, _ := h.faskesRepo.FindPhotos(id)
-	photoResponses := make([]dto.PhotoResponse, len(photos))
-	for i, p := range photos {
-		photoResponses[i] = dto.PhotoResponse{
-			Type:     p.PhotoType,
-			Filename: p.Filename,
-			URL:      "/api/v1/faskes/" + id.String() + "/photos/" + p.Filename,
+	photos, _ := h.faskesRepo.FindPhotos(faskes.ID)
+	photoResponses := make([]dto.PhotoDetailResponse, 0, len(photos))
+	for _, p := range photos {
+		if !h.photoVisible(c, p.PhotoType) {
+			continue
+		}
+		pr := dto.PhotoDetailResponse{
+			ID:        p.ID.String(),
+			PhotoType: p.PhotoType,
+			Filename:  p.Filename,
+			IsCached:  p.IsCached,
+			FileSize:  p.FileSize,
+			CreatedAt: p.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Width:     p.Width,
+			Height:    p.Height,
+		}
+		if p.IsCached {
+			pr.URL = h.absoluteURL("/api/v1/faskes/photos/" + p.ID.String() + "/file")
 		}
+		photoResponses = append(photoResponses, pr)
 	}
 
 	odkSubmissionID := ""
@@ -255,44 +321,18 @@ func (h *FaskesHandler) GetFaskesByID(c *gin.Context) {
 		submitterName = *faskes.SubmitterName
 	}
 
-	// Convert JSONB to map
-	alamat := map[string]interface{}{}
-	if faskes.Alamat != nil {
-		alamat = faskes.Alamat
-	}
-
-	identitas := map[string]interface{}{}
-	if faskes.Identitas != nil {
-		identitas = faskes.Identitas
-	}
+	// Copy JSONB into fresh maps so derived fields never mutate the model
+	alamat := cloneJSONB(faskes.Alamat)
+	identitas := cloneJSONB(faskes.Identitas)
 	identitas["nama"] = faskes.Nama
 
-	isolasi := map[string]interface{}{}
-	if faskes.Isolasi != nil {
-		isolasi = faskes.Isolasi
-	}
-
-	infrastruktur := map[string]interface{}{}
-	if faskes.Infrastruktur != nil {
-		infrastruktur = faskes.Infrastruktur
-	}
-
-	sdm := map[string]interface{}{}
-	if faskes.SDM != nil {
-		sdm = faskes.SDM
-	}
+	isolasi := cloneJSONB(faskes.Isolasi)
+	infrastruktur := cloneJSONB(faskes.Infrastruktur)
+	sdm := cloneJSONB(faskes.SDM)
+	perbekalan := cloneJSONB(faskes.Perbekalan)
+	klaster := cloneJSONB(faskes.Klaster)
 
-	perbekalan := map[string]interface{}{}
-	if faskes.Perbekalan != nil {
-		perbekalan = faskes.Perbekalan
-	}
-
-	klaster := map[string]interface{}{}
-	if faskes.Klaster != nil {
-		klaster = faskes.Klaster
-	}
-
-	response := dto.FaskesDetailResponse{
+	return dto.FaskesDetailResponse{
 		ID:              faskes.ID.String(),
 		ODKSubmissionID: odkSubmissionID,
 		Nama:            faskes.Nama,
@@ -317,9 +357,4 @@ func (h *FaskesHandler) GetFaskesByID(c *gin.Context) {
 			SubmitterName: submitterName,
 		},
 	}
-
-	c.JSON(http.StatusOK, dto.APIResponse{
-		Success: true,
-		Data:    response,
-	})
 }
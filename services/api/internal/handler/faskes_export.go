@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// faskesExportFlushEvery is the number of CSV rows buffered before they are sent
+const faskesExportFlushEvery = 100
+
+// faskesExportColumns are the fixed leading columns of the full faskes CSV
+var faskesExportColumns = []string{
+	"id", "odk_submission_id", "nama", "jenis_faskes", "status_faskes", "kondisi_faskes",
+	"latitude", "longitude", "nama_provinsi", "nama_kota_kab", "nama_kecamatan", "nama_desa",
+	"photo_count", "submitted_at", "updated_at",
+}
+
+// ExportFaskesCSV streams all faskes as CSV with their JSONB groups flattened into columns
+// @Summary Export faskes as CSV
+// @Description Streams every faskes with region, photo count and the selected JSONB groups flattened into "group.key" columns
+// @Tags faskes
+// @Produce text/csv
+// @Param groups query string false "JSONB groups to flatten (identitas, infrastruktur, sdm, perbekalan, klaster; default all)"
+// @Param jenis_faskes query string false "Filter by jenis_faskes"
+// @Param status_faskes query string false "Filter by status_faskes"
+// @Param kondisi_faskes query string false "Filter by kondisi_faskes"
+// @Param search query string false "Search by name"
+// @Success 200 {string} string "CSV"
+// @Failure 400 {object} dto.APIResponse
+// @Router /api/v1/faskes/full.csv [get]
+func (h *FaskesHandler) ExportFaskesCSV(c *gin.Context) {
+	groups := repository.FaskesExportGroups
+	if value := c.Query("groups"); value != "" {
+		groups = nil
+		for _, group := range strings.Split(value, ",") {
+			group = strings.TrimSpace(group)
+			if group == "" {
+				continue
+			}
+			if !repository.IsFaskesExportGroup(group) {
+				respondValidationError(c, fmt.Errorf("unknown group %q (want %s)", group, strings.Join(repository.FaskesExportGroups, ", ")))
+				return
+			}
+			groups = append(groups, group)
+		}
+	}
+
+	filter := repository.FaskesFilter{
+		JenisFaskes:   c.Query("jenis_faskes"),
+		StatusFaskes:  c.Query("status_faskes"),
+		KondisiFaskes: c.Query("kondisi_faskes"),
+		Search:        c.Query("search"),
+	}
+
+	// The columns of each group are the keys present in the exported faskes
+	header := append([]string{}, faskesExportColumns...)
+	groupKeys := make([][]string, len(groups))
+	for i, group := range groups {
+		keys, err := h.faskesRepo.GroupKeys(group, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to read faskes columns",
+				},
+			})
+			return
+		}
+		groupKeys[i] = keys
+		for _, key := range keys {
+			header = append(header, group+"."+key)
+		}
+	}
+
+	// Headers are sent with the first row, so a failing query can still be answered with a JSON error
+	w := csv.NewWriter(c.Writer)
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="faskes_full.csv"`)
+		c.Status(http.StatusOK)
+		return w.Write(header)
+	}
+
+	count := 0
+	record := make([]string, 0, len(header))
+	err := h.faskesRepo.StreamExport(filter, func(row *repository.FaskesExportRow) error {
+		if err := start(); err != nil {
+			return err
+		}
+
+		record = append(record[:0],
+			row.ID.String(),
+			stringValue(row.ODKSubmissionID),
+			row.Nama,
+			row.JenisFaskes,
+			row.StatusFaskes,
+			stringValue(row.KondisiFaskes),
+			strconv.FormatFloat(row.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(row.Longitude, 'f', -1, 64),
+			csvValue(row.Alamat["nama_provinsi"]),
+			csvValue(row.Alamat["nama_kota_kab"]),
+			csvValue(row.Alamat["nama_kecamatan"]),
+			csvValue(row.Alamat["nama_desa"]),
+			strconv.Itoa(row.PhotoCount),
+			timeValue(row.SubmittedAt),
+			row.UpdatedAt.Format(time.RFC3339),
+		)
+		for i, group := range groups {
+			data := faskesGroup(&row.Faskes, group)
+			for _, key := range groupKeys[i] {
+				record = append(record, csvValue(data[key]))
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+
+		count++
+		if count%faskesExportFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	if err == nil {
+		err = start() // no faskes: still send the header row
+	}
+	w.Flush()
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		if !started {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to export faskes",
+				},
+			})
+			return
+		}
+		// Mid-stream: the status is already sent; the client sees a truncated file
+		log.Printf("Faskes CSV export aborted after %d rows: %v", count, err)
+	}
+}
+
+// faskesGroup returns the JSONB group of a faskes by its column name
+func faskesGroup(faskes *model.Faskes, group string) model.JSONB {
+	switch group {
+	case "identitas":
+		return faskes.Identitas
+	case "infrastruktur":
+		return faskes.Infrastruktur
+	case "sdm":
+		return faskes.SDM
+	case "perbekalan":
+		return faskes.Perbekalan
+	case "klaster":
+		return faskes.Klaster
+	}
+	return nil
+}
+
+// csvValue formats a JSONB value as a CSV cell: text as is, numbers without exponent,
+// nested objects and lists as JSON, missing values empty
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timeValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
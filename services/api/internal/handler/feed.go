@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,14 +16,19 @@ import (
 )
 
 type FeedHandler struct {
-	feedRepo *repository.FeedRepository
-	formID   string // ODK form ID for photo URL generation
+	feedRepo             *repository.FeedRepository
+	formID               string // ODK form ID for photo URL generation
+	includePhotosDefault bool   // include photos when ?include is not given
+	staleFlag
+	photoVisibility
+	publicURLs
 }
 
 func NewFeedHandler(feedRepo *repository.FeedRepository) *FeedHandler {
 	return &FeedHandler{
-		feedRepo: feedRepo,
-		formID:   "update_informasi", // default form ID
+		feedRepo:             feedRepo,
+		formID:               "update_informasi", // default form ID
+		includePhotosDefault: true,
 	}
 }
 
@@ -30,11 +37,27 @@ func (h *FeedHandler) SetFormID(formID string) {
 	h.formID = formID
 }
 
+// SetIncludePhotosDefault sets whether feed lists include photos when ?include is not given
+func (h *FeedHandler) SetIncludePhotosDefault(include bool) {
+	h.includePhotosDefault = include
+}
+
+// wantsPhotos reports whether photos should be included in the response.
+// ?include=photos opts in; any other explicit ?include value (e.g. ?include=) opts out.
+func (h *FeedHandler) wantsPhotos(c *gin.Context) bool {
+	include, ok := c.GetQuery("include")
+	if !ok {
+		return h.includePhotosDefault
+	}
+	return includes(include, "photos")
+}
+
 // GetFeeds returns list of information feeds
 func (h *FeedHandler) GetFeeds(c *gin.Context) {
 	filter := repository.FeedFilter{
 		Category:     c.Query("category"),
 		Type:         c.Query("type"),
+		Tags:         parseFeedTags(optionalQuery(c, "tag")),
 		LocationID:   c.Query("location_id"),
 		LocationName: c.Query("location_name"),
 		Search:       c.Query("search"),
@@ -46,6 +69,21 @@ func (h *FeedHandler) GetFeeds(c *gin.Context) {
 		Desa:      c.Query("desa"),
 		Page:      1,
 		Limit:     50,
+
+		Organization: c.Query("organization"),
+		Sort:         c.Query("sort"),
+	}
+
+	if filter.Sort != "" && !repository.IsValidFeedSort(filter.Sort) {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid sort key",
+				Details: map[string]interface{}{"allowed": repository.FeedSortKeys()},
+			},
+		})
+		return
 	}
 
 	// Parse pagination
@@ -56,6 +94,39 @@ func (h *FeedHandler) GetFeeds(c *gin.Context) {
 		filter.Limit = limit
 	}
 
+	// Parse bounding box: bbox=minLng,minLat,maxLng,maxLat
+	if bbox := c.Query("bbox"); bbox != "" {
+		parts := strings.Split(bbox, ",")
+		if len(parts) == 4 {
+			if minLng, err := strconv.ParseFloat(parts[0], 64); err == nil {
+				filter.MinLng = &minLng
+			}
+			if minLat, err := strconv.ParseFloat(parts[1], 64); err == nil {
+				filter.MinLat = &minLat
+			}
+			if maxLng, err := strconv.ParseFloat(parts[2], 64); err == nil {
+				filter.MaxLng = &maxLng
+			}
+			if maxLat, err := strconv.ParseFloat(parts[3], 64); err == nil {
+				filter.MaxLat = &maxLat
+			}
+		}
+	}
+
+	// Radius search: near=lng,lat&radius_km=N
+	if !parseNear(c, &filter) {
+		return
+	}
+
+	if isCountOnly(c) {
+		total, err := h.feedRepo.Count(filter)
+		respondCount(c, total, err, "feeds")
+		return
+	}
+
+	// ?excerpt=N shortens content to a preview of N characters
+	excerpt, _ := strconv.Atoi(c.Query("excerpt"))
+
 	feeds, total, err := h.feedRepo.FindAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -68,90 +139,75 @@ func (h *FeedHandler) GetFeeds(c *gin.Context) {
 		return
 	}
 
-	// Collect feed IDs for batch photo query
-	feedIDs := make([]uuid.UUID, len(feeds))
-	for i, feed := range feeds {
-		feedIDs[i] = feed.ID
+	// Batch fetch photos for all feeds (only when requested)
+	var photosMap map[uuid.UUID][]model.FeedPhoto
+	if h.wantsPhotos(c) {
+		feedIDs := make([]uuid.UUID, len(feeds))
+		for i, feed := range feeds {
+			feedIDs[i] = feed.ID
+		}
+		photosMap, _ = h.feedRepo.GetPhotosForFeeds(feedIDs)
 	}
 
-	// Batch fetch photos for all feeds
-	photosMap, _ := h.feedRepo.GetPhotosForFeeds(feedIDs)
-
 	// Convert to response
 	feedResponses := make([]dto.FeedResponse, len(feeds))
 	for i, feed := range feeds {
-		var locationID *string
-		if feed.LocationID != nil {
-			locIDStr := feed.LocationID.String()
-			locationID = &locIDStr
-		}
-
-		var faskesID *string
-		if feed.FaskesID != nil {
-			faskesIDStr := feed.FaskesID.String()
-			faskesID = &faskesIDStr
-		}
-
-		var coords []float64
-		if feed.Longitude != nil && feed.Latitude != nil {
-			coords = []float64{*feed.Longitude, *feed.Latitude}
-		}
+		resp := newFeedResponse(feed)
 
 		// Get photos for this feed
-		var photoResponses []dto.FeedPhotoResponse
 		if photos, ok := photosMap[feed.ID]; ok {
-			photoResponses = h.convertPhotosToResponse(photos, feed.ODKSubmissionID)
+			resp.Photos = h.convertPhotosToResponse(c, photos, feed.ODKSubmissionID)
 		}
 
 		// Extract region from raw_data
-		var region *dto.FeedRegion
 		if feed.RawData != nil {
-			region = extractRegionFromRawData(feed.RawData)
+			resp.Region = extractRegionFromRawData(feed.RawData)
 		}
 
-		feedResponses[i] = dto.FeedResponse{
-			ID:           feed.ID.String(),
-			LocationID:   locationID,
-			LocationName: feed.LocationName,
-			FaskesID:     faskesID,
-			FaskesName:   feed.FaskesName,
-			Category:     feed.Category,
-			Type:         feed.Type,
-			Content:      feed.Content,
-			Username:     feed.Username,
-			Organization: feed.Organization,
-			SubmittedAt:  getSubmittedAt(feed.SubmittedAt, feed.CreatedAt),
-			Coordinates:  coords,
-			Photos:       photoResponses,
-			Region:       region,
-		}
+		var truncated bool
+		resp.Content, truncated = excerptContent(feed.Content, excerpt)
+		resp.Truncated = resp.Truncated || truncated
+
+		feedResponses[i] = resp
 	}
 
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
 		Data:    feedResponses,
-		Meta: &dto.MetaInfo{
-			Total:     total,
-			Page:      filter.Page,
-			Limit:     filter.Limit,
-			Timestamp: time.Now(),
-		},
+		Meta: h.withStale(&dto.MetaInfo{
+			Total:          total.Value,
+			TotalEstimated: total.Estimated,
+			Page:           filter.Page,
+			Limit:          filter.Limit,
+			Timestamp:      time.Now(),
+		}),
 	})
 }
 
 // convertPhotosToResponse converts feed photos to response format
-func (h *FeedHandler) convertPhotosToResponse(photos []model.FeedPhoto, odkSubmissionID *string) []dto.FeedPhotoResponse {
-	result := make([]dto.FeedPhotoResponse, len(photos))
-	for i, photo := range photos {
-		// Build photo URL - use feed photo endpoint (cached group has no prefix)
-		url := fmt.Sprintf("/api/v1/feeds/photos/%s/file", photo.ID.String())
-
-		result[i] = dto.FeedPhotoResponse{
-			ID:       photo.ID.String(),
-			Type:     photo.PhotoType,
-			Filename: photo.Filename,
-			URL:      url,
+// Photo types hidden from the caller are left out.
+func (h *FeedHandler) convertPhotosToResponse(c *gin.Context, photos []model.FeedPhoto, odkSubmissionID *string) []dto.PhotoDetailResponse {
+	result := make([]dto.PhotoDetailResponse, 0, len(photos))
+	for _, photo := range photos {
+		if !h.photoVisible(c, photo.PhotoType) {
+			continue
+		}
+
+		pr := dto.PhotoDetailResponse{
+			ID:        photo.ID.String(),
+			PhotoType: photo.PhotoType,
+			Filename:  photo.Filename,
+			IsCached:  photo.IsCached,
+			FileSize:  photo.FileSize,
+			CreatedAt: photo.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Width:     photo.Width,
+			Height:    photo.Height,
+		}
+		if photo.IsCached {
+			// Build photo URL - use feed photo endpoint (cached group has no prefix)
+			pr.URL = h.absoluteURL(fmt.Sprintf("/api/v1/feeds/photos/%s/file", photo.ID.String()))
 		}
+		result = append(result, pr)
 	}
 	return result
 }
@@ -185,6 +241,9 @@ func (h *FeedHandler) GetFeedsByLocation(c *gin.Context) {
 		filter.Limit = limit
 	}
 
+	// ?excerpt=N shortens content to a preview of N characters
+	excerpt, _ := strconv.Atoi(c.Query("excerpt"))
+
 	feeds, total, err := h.feedRepo.FindAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -197,68 +256,170 @@ func (h *FeedHandler) GetFeedsByLocation(c *gin.Context) {
 		return
 	}
 
-	// Collect feed IDs for batch photo query
-	locFeedIDs := make([]uuid.UUID, len(feeds))
-	for i, feed := range feeds {
-		locFeedIDs[i] = feed.ID
+	// Batch fetch photos for all feeds (only when requested)
+	var locPhotosMap map[uuid.UUID][]model.FeedPhoto
+	if h.wantsPhotos(c) {
+		locFeedIDs := make([]uuid.UUID, len(feeds))
+		for i, feed := range feeds {
+			locFeedIDs[i] = feed.ID
+		}
+		locPhotosMap, _ = h.feedRepo.GetPhotosForFeeds(locFeedIDs)
 	}
 
-	// Batch fetch photos for all feeds
-	locPhotosMap, _ := h.feedRepo.GetPhotosForFeeds(locFeedIDs)
-
 	// Convert to response
 	feedResponses := make([]dto.FeedResponse, len(feeds))
 	for i, feed := range feeds {
-		var locID *string
-		if feed.LocationID != nil {
-			locIDStr := feed.LocationID.String()
-			locID = &locIDStr
-		}
-
-		var faskesID *string
-		if feed.FaskesID != nil {
-			faskesIDStr := feed.FaskesID.String()
-			faskesID = &faskesIDStr
-		}
-
-		var coords []float64
-		if feed.Longitude != nil && feed.Latitude != nil {
-			coords = []float64{*feed.Longitude, *feed.Latitude}
-		}
+		resp := newFeedResponse(feed)
 
 		// Get photos for this feed
-		var photoResponses []dto.FeedPhotoResponse
 		if photos, ok := locPhotosMap[feed.ID]; ok {
-			photoResponses = h.convertPhotosToResponse(photos, feed.ODKSubmissionID)
+			resp.Photos = h.convertPhotosToResponse(c, photos, feed.ODKSubmissionID)
 		}
 
-		feedResponses[i] = dto.FeedResponse{
-			ID:           feed.ID.String(),
-			LocationID:   locID,
-			LocationName: feed.LocationName,
-			FaskesID:     faskesID,
-			FaskesName:   feed.FaskesName,
-			Category:     feed.Category,
-			Type:         feed.Type,
-			Content:      feed.Content,
-			Username:     feed.Username,
-			Organization: feed.Organization,
-			SubmittedAt:  getSubmittedAt(feed.SubmittedAt, feed.CreatedAt),
-			Coordinates:  coords,
-			Photos:       photoResponses,
-		}
+		var truncated bool
+		resp.Content, truncated = excerptContent(feed.Content, excerpt)
+		resp.Truncated = resp.Truncated || truncated
+
+		feedResponses[i] = resp
 	}
 
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
 		Data:    feedResponses,
-		Meta: &dto.MetaInfo{
-			Total:     total,
-			Page:      filter.Page,
-			Limit:     filter.Limit,
-			Timestamp: time.Now(),
-		},
+		Meta: h.withStale(&dto.MetaInfo{
+			Total:          total.Value,
+			TotalEstimated: total.Estimated,
+			Page:           filter.Page,
+			Limit:          filter.Limit,
+			Timestamp:      time.Now(),
+		}),
+	})
+}
+
+// newFeedResponse converts a feed to its response without photos or region;
+// Truncated reports content cut at sync. Links to a soft-deleted posko or faskes,
+// which the feed query leaves without a name, are dropped.
+func newFeedResponse(feed repository.FeedWithCoords) dto.FeedResponse {
+	var locationID *string
+	if feed.LocationID != nil && feed.LocationName != nil {
+		locIDStr := feed.LocationID.String()
+		locationID = &locIDStr
+	}
+
+	var faskesID *string
+	if feed.FaskesID != nil && feed.FaskesName != nil {
+		faskesIDStr := feed.FaskesID.String()
+		faskesID = &faskesIDStr
+	}
+
+	var coords []float64
+	if feed.Longitude != nil && feed.Latitude != nil {
+		coords = []float64{*feed.Longitude, *feed.Latitude}
+	}
+
+	return dto.FeedResponse{
+		ID:           feed.ID.String(),
+		LocationID:   locationID,
+		LocationName: feed.LocationName,
+		FaskesID:     faskesID,
+		FaskesName:   feed.FaskesName,
+		Category:     feed.Category,
+		Tags:         parseFeedTags(feed.Type),
+		Content:      feed.Content,
+		Truncated:    feed.ContentTruncated,
+		Username:     feed.Username,
+		Organization: feed.Organization,
+		SubmittedAt:  getSubmittedAt(feed.SubmittedAt, feed.CreatedAt),
+		Coordinates:  coords,
+	}
+}
+
+// excerptContent shortens content to at most n characters, cutting at the last word
+// boundary and appending an ellipsis. n <= 0 returns the content unchanged.
+func excerptContent(content string, n int) (string, bool) {
+	runes := []rune(content)
+	if n <= 0 || len(runes) <= n {
+		return content, false
+	}
+
+	cut := runes[:n]
+	// Back up to the last whitespace so words aren't split (unless that would drop everything)
+	for i := len(cut) - 1; i > 0; i-- {
+		if unicode.IsSpace(cut[i]) {
+			cut = cut[:i]
+			break
+		}
+	}
+
+	excerpt := strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	return excerpt + "…", true
+}
+
+// maxFeedRadiusKm bounds ?radius_km= for the feed radius search
+const maxFeedRadiusKm = 1000
+
+// parseNear reads ?near=lng,lat and ?radius_km= into filter. Both are required together;
+// invalid values are answered with 400 and false.
+func parseNear(c *gin.Context, filter *repository.FeedFilter) bool {
+	near, radius := c.Query("near"), c.Query("radius_km")
+	if near == "" && radius == "" {
+		return true
+	}
+
+	invalid := func(message string) bool {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: message,
+			},
+		})
+		return false
+	}
+
+	parts := strings.Split(near, ",")
+	if len(parts) != 2 {
+		return invalid("near must be lng,lat")
+	}
+	lng, errLng := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errLng != nil || errLat != nil || lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+		return invalid("near must be lng,lat in degrees")
+	}
+	radiusKm, err := strconv.ParseFloat(radius, 64)
+	if err != nil || radiusKm <= 0 || radiusKm > maxFeedRadiusKm {
+		return invalid(fmt.Sprintf("radius_km is required with near and must be between 0 and %d", maxFeedRadiusKm))
+	}
+
+	filter.NearLng, filter.NearLat, filter.RadiusKm = &lng, &lat, radiusKm
+	return true
+}
+
+// optionalQuery returns a pointer to the query value, or nil when it is absent or empty
+func optionalQuery(c *gin.Context, key string) *string {
+	if v := c.Query(key); v != "" {
+		return &v
+	}
+	return nil
+}
+
+// parseFeedTags splits the stored feed type (comma/space separated ODK select_multiple) into tags
+func parseFeedTags(raw *string) []string {
+	if raw == nil {
+		return nil
+	}
+	fields := strings.FieldsFunc(*raw, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
 	})
+	var tags []string
+	for _, f := range fields {
+		if t := strings.TrimSpace(f); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
 }
 
 func getSubmittedAt(submittedAt *time.Time, createdAt time.Time) time.Time {
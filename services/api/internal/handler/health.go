@@ -5,16 +5,29 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
 	"gorm.io/gorm"
 )
 
 type HealthHandler struct {
-	db *gorm.DB
+	db                 *gorm.DB
+	dbLatencyThreshold time.Duration // DB round-trips slower than this mark readiness degraded
 }
 
+// defaultDBLatencyThreshold is used when no threshold is configured
+const defaultDBLatencyThreshold = 500 * time.Millisecond
+
 func NewHealthHandler(db *gorm.DB) *HealthHandler {
 	return &HealthHandler{
-		db: db,
+		db:                 db,
+		dbLatencyThreshold: defaultDBLatencyThreshold,
+	}
+}
+
+// SetDBLatencyThreshold sets the DB latency above which /ready reports degraded
+func (h *HealthHandler) SetDBLatencyThreshold(threshold time.Duration) {
+	if threshold > 0 {
+		h.dbLatencyThreshold = threshold
 	}
 }
 
@@ -73,7 +86,31 @@ func (h *HealthHandler) Ready(c *gin.Context) {
 		return
 	}
 
+	// Time a lightweight round-trip so latency_ms reflects real query latency
+	start := time.Now()
+	if err := h.db.Exec("SELECT 1").Error; err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not ready",
+			"error":  "database query failed",
+		})
+		return
+	}
+	latency := time.Since(start)
+
+	dbCheck := dto.Check{
+		Status:    "healthy",
+		LatencyMs: latency.Milliseconds(),
+	}
+	status := "ready"
+	if latency > h.dbLatencyThreshold {
+		dbCheck.Status = "degraded"
+		status = "degraded"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ready",
+		"status": status,
+		"checks": map[string]dto.Check{
+			"database": dbCheck,
+		},
 	})
 }
@@ -14,6 +14,9 @@ import (
 
 type InfrastrukturHandler struct {
 	infraRepo *repository.InfrastrukturRepository
+	staleFlag
+	photoVisibility
+	publicURLs
 }
 
 func NewInfrastrukturHandler(infraRepo *repository.InfrastrukturRepository) *InfrastrukturHandler {
@@ -35,6 +38,7 @@ func NewInfrastrukturHandler(infraRepo *repository.InfrastrukturRepository) *Inf
 // @Param kabupaten query string false "Filter by kabupaten name"
 // @Param search query string false "Search by name"
 // @Param bbox query string false "Bounding box (minLng,minLat,maxLng,maxLat)"
+// @Param srid query int false "SRID of the returned coordinates (4326, 3857, UTM 32646-32654/32746-32754; default 4326)"
 // @Param page query int false "Page number"
 // @Param limit query int false "Items per page"
 // @Success 200 {object} dto.APIResponse
@@ -51,6 +55,13 @@ func (h *InfrastrukturHandler) GetInfrastruktur(c *gin.Context) {
 		Limit:            50,
 	}
 
+	// srid=3857: coordinates in another projection for GIS partners (default WGS 84)
+	srid, ok := parseSRID(c)
+	if !ok {
+		return
+	}
+	filter.SRID = srid
+
 	// Parse pagination
 	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
 		filter.Page = page
@@ -78,6 +89,12 @@ func (h *InfrastrukturHandler) GetInfrastruktur(c *gin.Context) {
 		}
 	}
 
+	if isCountOnly(c) {
+		total, err := h.infraRepo.Count(filter)
+		respondCount(c, total, err, "infrastruktur")
+		return
+	}
+
 	infraList, total, err := h.infraRepo.FindAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -121,13 +138,15 @@ func (h *InfrastrukturHandler) GetInfrastruktur(c *gin.Context) {
 		Data: dto.InfrastrukturListResponse{
 			Type:     "FeatureCollection",
 			Features: features,
+			CRS:      geoJSONCRS(filter.SRID),
 		},
-		Meta: &dto.MetaInfo{
-			Total:     total,
-			Page:      filter.Page,
-			Limit:     filter.Limit,
-			Timestamp: time.Now(),
-		},
+		Meta: h.withStale(&dto.MetaInfo{
+			Total:          total.Value,
+			TotalEstimated: total.Estimated,
+			Page:           filter.Page,
+			Limit:          filter.Limit,
+			Timestamp:      time.Now(),
+		}),
 	})
 }
 
@@ -170,13 +189,25 @@ func (h *InfrastrukturHandler) GetInfrastrukturByID(c *gin.Context) {
 
 	// Get photos
 	photos, _ := h.infraRepo.FindPhotos(id)
-	photoResponses := make([]dto.PhotoResponse, len(photos))
-	for i, p := range photos {
-		photoResponses[i] = dto.PhotoResponse{
-			Type:     p.PhotoType,
-			Filename: p.Filename,
-			URL:      "/api/v1/infrastruktur/photos/" + p.ID.String() + "/file",
+	photoResponses := make([]dto.PhotoDetailResponse, 0, len(photos))
+	for _, p := range photos {
+		if !h.photoVisible(c, p.PhotoType) {
+			continue
+		}
+		pr := dto.PhotoDetailResponse{
+			ID:        p.ID.String(),
+			PhotoType: p.PhotoType,
+			Filename:  p.Filename,
+			IsCached:  p.IsCached,
+			FileSize:  p.FileSize,
+			CreatedAt: p.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Width:     p.Width,
+			Height:    p.Height,
 		}
+		if p.IsCached {
+			pr.URL = h.absoluteURL("/api/v1/infrastruktur/photos/" + p.ID.String() + "/file")
+		}
+		photoResponses = append(photoResponses, pr)
 	}
 
 	submitterName := ""
@@ -221,6 +252,68 @@ func (h *InfrastrukturHandler) GetInfrastrukturByID(c *gin.Context) {
 	})
 }
 
+// GetInfrastrukturTimeline returns the progress updates of an infrastruktur over time
+// @Summary Get infrastruktur progress timeline
+// @Description Returns every recorded progress update (progress, status_penanganan, update_by), oldest first
+// @Tags infrastruktur
+// @Produce json
+// @Param id path string true "Infrastruktur ID"
+// @Success 200 {object} dto.APIResponse
+// @Failure 404 {object} dto.APIResponse
+// @Router /api/v1/infrastruktur/{id}/timeline [get]
+func (h *InfrastrukturHandler) GetInfrastrukturTimeline(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid infrastruktur ID format",
+			},
+		})
+		return
+	}
+
+	if _, err := h.infraRepo.FindByID(id); err != nil {
+		c.JSON(http.StatusNotFound, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "NOT_FOUND",
+				Message: "Infrastruktur not found",
+			},
+		})
+		return
+	}
+
+	history, err := h.infraRepo.FindHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch infrastruktur timeline",
+			},
+		})
+		return
+	}
+
+	timeline := make([]dto.InfrastrukturTimelineItem, len(history))
+	for i, entry := range history {
+		timeline[i] = dto.InfrastrukturTimelineItem{
+			ODKSubmissionID:  entry.ODKSubmissionID,
+			Progress:         entry.Progress,
+			StatusPenanganan: entry.StatusPenanganan,
+			UpdateBy:         entry.UpdateBy,
+			SubmittedAt:      entry.SubmittedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    timeline,
+	})
+}
+
 // GetInfrastrukturStats returns statistics about infrastructure
 // @Summary Get infrastruktur statistics
 // @Description Returns statistics about infrastructure (by jenis, status_akses, etc.)
@@ -245,8 +338,8 @@ func (h *InfrastrukturHandler) GetInfrastrukturStats(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
 		Data:    stats,
-		Meta: &dto.MetaInfo{
+		Meta: h.withStale(&dto.MetaInfo{
 			Timestamp: time.Now(),
-		},
+		}),
 	})
 }
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,12 +12,21 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/middleware"
+	"github.com/leksa/datamapper-senyar/internal/model"
 	"github.com/leksa/datamapper-senyar/internal/repository"
+	"gorm.io/gorm"
 )
 
 type LocationHandler struct {
 	locationRepo *repository.LocationRepository
 	feedRepo     *repository.FeedRepository
+	staleFlag
+	photoVisibility
+	publicURLs
+
+	// cleared after a manual status change so lists and details show it right away
+	responseCache *middleware.Cache
 }
 
 func NewLocationHandler(locationRepo *repository.LocationRepository, feedRepo *repository.FeedRepository) *LocationHandler {
@@ -24,16 +36,66 @@ func NewLocationHandler(locationRepo *repository.LocationRepository, feedRepo *r
 	}
 }
 
+// SetResponseCache sets the response cache cleared after a manual status change
+func (h *LocationHandler) SetResponseCache(cache *middleware.Cache) {
+	h.responseCache = cache
+}
+
 // GetLocations returns GeoJSON FeatureCollection of locations
 func (h *LocationHandler) GetLocations(c *gin.Context) {
 	filter := repository.LocationFilter{
 		Type:   c.Query("type"),
 		Status: c.Query("status"),
 		Search: c.Query("search"),
+		Sort:   c.Query("sort"),
 		Page:   1,
 		Limit:  50,
 	}
 
+	if filter.Sort != "" && !repository.IsValidLocationSort(filter.Sort) {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid sort key",
+				Details: map[string]interface{}{"allowed": repository.LocationSortKeys()},
+			},
+		})
+		return
+	}
+
+	// srid=3857: coordinates in another projection for GIS partners (default WGS 84)
+	srid, ok := parseSRID(c)
+	if !ok {
+		return
+	}
+	filter.SRID = srid
+
+	// has_facility=dapur_umum,posko_logistik: posko offering all listed facilities
+	if hasFacility := c.Query("has_facility"); hasFacility != "" {
+		for _, facility := range strings.Split(hasFacility, ",") {
+			facility = strings.TrimSpace(facility)
+			if facility == "" {
+				continue
+			}
+			if !repository.IsValidFacilityKey(facility) {
+				c.JSON(http.StatusBadRequest, dto.APIResponse{
+					Success: false,
+					Error: &dto.ErrorInfo{
+						Code:    "VALIDATION_ERROR",
+						Message: "Invalid facility key: " + facility,
+					},
+				})
+				return
+			}
+			filter.HasFacility = append(filter.HasFacility, facility)
+		}
+	}
+
+	if minJiwa, err := strconv.Atoi(c.Query("min_jiwa")); err == nil && minJiwa > 0 {
+		filter.MinJiwa = &minJiwa
+	}
+
 	// Parse pagination
 	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
 		filter.Page = page
@@ -61,6 +123,12 @@ func (h *LocationHandler) GetLocations(c *gin.Context) {
 		}
 	}
 
+	if isCountOnly(c) {
+		total, err := h.locationRepo.Count(filter)
+		respondCount(c, total, err, "locations")
+		return
+	}
+
 	locations, total, err := h.locationRepo.FindAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -73,6 +141,16 @@ func (h *LocationHandler) GetLocations(c *gin.Context) {
 		return
 	}
 
+	// Photo counts for all listed records in one query (best effort)
+	ids := make([]uuid.UUID, len(locations))
+	for i, item := range locations {
+		ids[i] = item.ID
+	}
+	photoCounts, err := h.locationRepo.CountPhotos(ids)
+	if err != nil {
+		log.Printf("Warning: failed to count location photos, listing without counts: %v", err)
+	}
+
 	// Convert to GeoJSON
 	features := make([]dto.LocationFeatureResponse, len(locations))
 	for i, loc := range locations {
@@ -132,66 +210,6 @@ func (h *LocationHandler) GetLocations(c *gin.Context) {
 			alamatSingkat = strings.Join(parts, ", ")
 		}
 
-		// Get jumlah_kk and total_jiwa from data_pengungsi
-		jumlahKK := 0
-		totalJiwa := 0
-		jumlahPerempuan := 0
-		jumlahLaki := 0
-		jumlahBalita := 0
-		if loc.DataPengungsi != nil {
-			if v, ok := loc.DataPengungsi["jumlah_kk"].(float64); ok {
-				jumlahKK = int(v)
-			}
-			if v, ok := loc.DataPengungsi["total_jiwa"].(float64); ok {
-				totalJiwa = int(v)
-			}
-			// Sum all female categories: dewasa_perempuan, remaja_perempuan, anak_perempuan, balita_perempuan, bayi_perempuan
-			if v, ok := loc.DataPengungsi["dewasa_perempuan"].(float64); ok {
-				jumlahPerempuan += int(v)
-			}
-			if v, ok := loc.DataPengungsi["remaja_perempuan"].(float64); ok {
-				jumlahPerempuan += int(v)
-			}
-			if v, ok := loc.DataPengungsi["anak_perempuan"].(float64); ok {
-				jumlahPerempuan += int(v)
-			}
-			if v, ok := loc.DataPengungsi["balita_perempuan"].(float64); ok {
-				jumlahPerempuan += int(v)
-			}
-			if v, ok := loc.DataPengungsi["bayi_perempuan"].(float64); ok {
-				jumlahPerempuan += int(v)
-			}
-			// Sum all male categories: dewasa_laki, remaja_laki, anak_laki, balita_laki, bayi_laki
-			if v, ok := loc.DataPengungsi["dewasa_laki"].(float64); ok {
-				jumlahLaki += int(v)
-			}
-			if v, ok := loc.DataPengungsi["remaja_laki"].(float64); ok {
-				jumlahLaki += int(v)
-			}
-			if v, ok := loc.DataPengungsi["anak_laki"].(float64); ok {
-				jumlahLaki += int(v)
-			}
-			if v, ok := loc.DataPengungsi["balita_laki"].(float64); ok {
-				jumlahLaki += int(v)
-			}
-			if v, ok := loc.DataPengungsi["bayi_laki"].(float64); ok {
-				jumlahLaki += int(v)
-			}
-			// Sum balita: balita_perempuan + balita_laki + bayi_perempuan + bayi_laki
-			if v, ok := loc.DataPengungsi["balita_perempuan"].(float64); ok {
-				jumlahBalita += int(v)
-			}
-			if v, ok := loc.DataPengungsi["balita_laki"].(float64); ok {
-				jumlahBalita += int(v)
-			}
-			if v, ok := loc.DataPengungsi["bayi_perempuan"].(float64); ok {
-				jumlahBalita += int(v)
-			}
-			if v, ok := loc.DataPengungsi["bayi_laki"].(float64); ok {
-				jumlahBalita += int(v)
-			}
-		}
-
 		// Get kebutuhan_air from fasilitas
 		kebutuhanAir := ""
 		kebutuhanAirLiter := 0
@@ -199,7 +217,7 @@ func (h *LocationHandler) GetLocations(c *gin.Context) {
 			if v, ok := loc.Fasilitas["ketersediaan_air"].(string); ok {
 				kebutuhanAir = v
 			}
-			if v, ok := loc.Fasilitas["kebutuhan_air"].(float64); ok {
+			if v, ok := loc.Fasilitas.Number("kebutuhan_air"); ok {
 				kebutuhanAirLiter = int(v)
 			}
 		}
@@ -238,15 +256,16 @@ func (h *LocationHandler) GetLocations(c *gin.Context) {
 				IDKotaKab:       idKotaKab,
 				IDKecamatan:     idKecamatan,
 				IDDesa:          idDesa,
-				JumlahKK:        jumlahKK,
-				TotalJiwa:       totalJiwa,
-				JumlahPerempuan: jumlahPerempuan,
-				JumlahLaki:      jumlahLaki,
-				JumlahBalita:      jumlahBalita,
+				JumlahKK:        loc.JumlahKK,
+				TotalJiwa:       loc.TotalJiwa,
+				JumlahPerempuan: loc.JumlahPerempuan,
+				JumlahLaki:      loc.JumlahLaki,
+				JumlahBalita:      loc.JumlahBalita,
 				KebutuhanAir:      kebutuhanAir,
 				KebutuhanAirLiter: kebutuhanAirLiter,
 				BaselineSumber:    baselineSumber,
 				UpdatedAt:         loc.UpdatedAt,
+				PhotoCount:        dto.PhotoCount(photoCounts[loc.ID]),
 			},
 		}
 	}
@@ -256,17 +275,120 @@ func (h *LocationHandler) GetLocations(c *gin.Context) {
 		Data: dto.LocationListResponse{
 			Type:     "FeatureCollection",
 			Features: features,
+			CRS:      geoJSONCRS(filter.SRID),
 		},
-		Meta: &dto.MetaInfo{
-			Total:     total,
-			Page:      filter.Page,
-			Limit:     filter.Limit,
+		Meta: h.withStale(&dto.MetaInfo{
+			Total:          total.Value,
+			TotalEstimated: total.Estimated,
+			Page:           filter.Page,
+			Limit:          filter.Limit,
+			Timestamp:      time.Now(),
+		}),
+	})
+}
+
+// GetLocationsByRegion returns posko counts and total_jiwa per region, for choropleth maps.
+// ?level=provinsi|kota_kab|kecamatan (default kota_kab); ?type and ?status filter like /locations.
+func (h *LocationHandler) GetLocationsByRegion(c *gin.Context) {
+	var req struct {
+		Level  string `form:"level" binding:"omitempty,oneof=provinsi kota_kab kecamatan"`
+		Type   string `form:"type"`
+		Status string `form:"status"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if req.Level == "" {
+		req.Level = "kota_kab"
+	}
+
+	counts, err := h.locationRepo.CountByRegion(req.Level, repository.LocationFilter{
+		Type:   req.Type,
+		Status: req.Status,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to count locations by region",
+			},
+		})
+		return
+	}
+
+	items := make([]dto.RegionCountItem, len(counts))
+	for i, rc := range counts {
+		items[i] = dto.RegionCountItem{
+			Kode:      rc.Kode,
+			Nama:      rc.Nama,
+			Count:     rc.Count,
+			TotalJiwa: rc.TotalJiwa,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    items,
+		Meta: h.withStale(&dto.MetaInfo{
+			Total:     int64(len(items)),
 			Timestamp: time.Now(),
-		},
+		}),
+	})
+}
+
+// GetLocationStats returns aggregate posko demographics: totals, counts per status and per province.
+// ?provinsi= (code or name) scopes the aggregation to one province.
+func (h *LocationHandler) GetLocationStats(c *gin.Context) {
+	stats, err := h.locationRepo.GetStats(repository.LocationFilter{
+		Provinsi: strings.TrimSpace(c.Query("provinsi")),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch statistics",
+			},
+		})
+		return
+	}
+
+	response := dto.LocationStatsResponse{
+		TotalPosko:        stats.TotalPosko,
+		TotalJiwa:         stats.TotalJiwa,
+		JumlahKK:          stats.JumlahKK,
+		KebutuhanAirLiter: stats.KebutuhanAirLiter,
+		ByStatus:          make([]dto.StatItem, len(stats.ByStatus)),
+		ByProvinsi:        make([]dto.RegionCountItem, len(stats.ByProvinsi)),
+	}
+	for i, sc := range stats.ByStatus {
+		response.ByStatus[i] = dto.StatItem{Name: sc.Status, Count: sc.Count}
+	}
+	for i, rc := range stats.ByProvinsi {
+		response.ByProvinsi[i] = dto.RegionCountItem{
+			Kode:      rc.Kode,
+			Nama:      rc.Nama,
+			Count:     rc.Count,
+			TotalJiwa: rc.TotalJiwa,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    response,
+		Meta: h.withStale(&dto.MetaInfo{
+			Timestamp: time.Now(),
+		}),
 	})
 }
 
-// GetLocationByID returns detailed location info
+// defaultDetailFeeds is how many feeds ?include=feeds attaches without ?feeds_limit
+const defaultDetailFeeds = 5
+
+// GetLocationByID returns detailed location info.
+// ?include=feeds attaches the posko's latest feeds (?feeds_limit=1-50, default 5).
 func (h *LocationHandler) GetLocationByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -281,6 +403,21 @@ func (h *LocationHandler) GetLocationByID(c *gin.Context) {
 		return
 	}
 
+	var query struct {
+		Include    string `form:"include"`
+		FeedsLimit int    `form:"feeds_limit" binding:"omitempty,min=1,max=50"`
+		Groups     string `form:"groups"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	groups, err := parseDetailGroups(query.Groups)
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
 	location, err := h.locationRepo.FindByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, dto.APIResponse{
@@ -293,24 +430,145 @@ func (h *LocationHandler) GetLocationByID(c *gin.Context) {
 		return
 	}
 
+	response := h.locationDetail(c, location)
+
+	if groups != nil {
+		omitDetailGroups(&response, groups)
+	}
+
+	// Opt-in: the latest feeds linked to this posko, saving a second request
+	if includes(query.Include, "feeds") {
+		limit := query.FeedsLimit
+		if limit == 0 {
+			limit = defaultDetailFeeds
+		}
+		feeds, err := h.feedRepo.FindLatestByLocation(id, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to fetch feeds",
+				},
+			})
+			return
+		}
+		response.Feeds = make([]dto.FeedResponse, len(feeds))
+		for i, feed := range feeds {
+			response.Feeds[i] = newFeedResponse(feed)
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// UpdateLocationStatus handles PATCH /api/v1/locations/:id
+// Overrides the posko status by hand; the override survives syncs until the ODK status changes
+func (h *LocationHandler) UpdateLocationStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: "Invalid location ID format",
+			},
+		})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	status := model.NormalizeStatus(req.Status)
+	if !model.IsLocationStatus(status) {
+		respondValidationError(c, fmt.Errorf("unknown status %q (want operasional, non_aktif, evakuasi or persiapan_huntara)", req.Status))
+		return
+	}
+
+	if err := h.locationRepo.SetManualStatus(id, status); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "NOT_FOUND",
+					Message: "Location not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update location status",
+			},
+		})
+		return
+	}
+
+	if h.responseCache != nil {
+		h.responseCache.Clear()
+	}
+
+	location, err := h.locationRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to fetch updated location",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    h.locationDetail(c, location),
+	})
+}
+
+// locationDetail builds the detail response of a location with its visible photos
+func (h *LocationHandler) locationDetail(c *gin.Context, location *repository.LocationWithCoords) dto.LocationDetailResponse {
 	// Get photos
-	photos, _ := h.locationRepo.FindPhotos(id)
-	photoResponses := make([]dto.PhotoResponse, len(photos))
-	for i, p := range photos {
-		photoResponses[i] = dto.PhotoResponse{
-			Type:     p.PhotoType,
-			Filename: p.Filename,
-			URL:      "/api/v1/photos/" + p.ID.String() + "/file",
+	photos, _ := h.locationRepo.FindPhotos(location.ID)
+	photoResponses := make([]dto.PhotoDetailResponse, 0, len(photos))
+	for _, p := range photos {
+		if !h.photoVisible(c, p.PhotoType) {
+			continue
+		}
+		pr := dto.PhotoDetailResponse{
+			ID:        p.ID.String(),
+			PhotoType: p.PhotoType,
+			Filename:  p.Filename,
+			IsCached:  p.IsCached,
+			FileSize:  p.FileSize,
+			CreatedAt: p.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Width:     p.Width,
+			Height:    p.Height,
 		}
+		if p.IsCached {
+			pr.URL = h.absoluteURL("/api/v1/photos/" + p.ID.String() + "/file")
+			pr.ThumbnailURL = h.absoluteURL("/api/v1/photos/" + p.ID.String() + "/thumb")
+		}
+		photoResponses = append(photoResponses, pr)
 	}
 
 	// Build geometry with metadata
 	var altitude, accuracy *float64
 	if location.GeoMeta != nil {
-		if v, ok := location.GeoMeta["altitude"].(float64); ok {
+		if v, ok := location.GeoMeta.Number("altitude"); ok {
 			altitude = &v
 		}
-		if v, ok := location.GeoMeta["accuracy"].(float64); ok {
+		if v, ok := location.GeoMeta.Number("accuracy"); ok {
 			accuracy = &v
 		}
 	}
@@ -325,37 +583,24 @@ func (h *LocationHandler) GetLocationByID(c *gin.Context) {
 		submitterName = *location.SubmitterName
 	}
 
-	// Convert JSONB to map
-	identitas := map[string]interface{}{}
-	if location.Identitas != nil {
-		identitas = location.Identitas
-	}
+	// Copy JSONB into fresh maps so derived fields never mutate the model
+	identitas := cloneJSONB(location.Identitas)
 	// Add nama to identitas
 	identitas["nama"] = location.Nama
 
-	alamat := map[string]interface{}{}
-	if location.Alamat != nil {
-		alamat = location.Alamat
-	}
-
-	dataPengungsi := map[string]interface{}{}
-	if location.DataPengungsi != nil {
-		dataPengungsi = location.DataPengungsi
-	}
-
-	fasilitas := map[string]interface{}{}
-	if location.Fasilitas != nil {
-		fasilitas = location.Fasilitas
-	}
+	alamat := cloneJSONB(location.Alamat)
+	dataPengungsi := cloneJSONB(location.DataPengungsi)
+	fasilitas := cloneJSONB(location.Fasilitas)
 
-	komunikasi := map[string]interface{}{}
-	if location.Komunikasi != nil {
-		komunikasi = location.Komunikasi
+	// Komunikasi and akses are left out when the posko has no data for them
+	var komunikasi, akses *map[string]interface{}
+	if len(location.Komunikasi) > 0 {
+		group := cloneJSONB(location.Komunikasi)
+		komunikasi = &group
 	}
-
-	akses := map[string]interface{}{}
-	if location.Akses != nil {
-		akses = location.Akses
+	if len(location.Akses) > 0 {
+		group := cloneJSONB(location.Akses)
+		akses = &group
 	}
 
 	// Get baseline_sumber - prefer dedicated column, fallback to identitas JSONB
@@ -366,34 +611,103 @@ func (h *LocationHandler) GetLocationByID(c *gin.Context) {
 		}
 	}
 
-	response := dto.LocationDetailResponse{
+	return dto.LocationDetailResponse{
 		ID:              location.ID.String(),
 		ODKSubmissionID: odkSubmissionID,
 		Type:            location.Type,
 		Status:          location.Status,
 		BaselineSumber:  baselineSumber,
+		Source:          location.Source,
 		Geometry: &dto.LocationGeometry{
 			Type:        "Point",
 			Coordinates: []float64{location.Longitude, location.Latitude},
 			Altitude:    altitude,
 			Accuracy:    accuracy,
 		},
-		Identitas:     identitas,
-		Alamat:        alamat,
-		DataPengungsi: dataPengungsi,
-		Fasilitas:     fasilitas,
+		Identitas:     &identitas,
+		Alamat:        &alamat,
+		DataPengungsi: &dataPengungsi,
+		Fasilitas:     &fasilitas,
 		Komunikasi:    komunikasi,
 		Akses:         akses,
 		Photos:        photoResponses,
 		Meta: dto.LocationMeta{
-			SubmittedAt:   location.SubmittedAt,
-			UpdatedAt:     location.UpdatedAt,
-			SubmitterName: submitterName,
+			SubmittedAt:     location.SubmittedAt,
+			UpdatedAt:       location.UpdatedAt,
+			SubmitterName:   submitterName,
+			SubmissionCount: location.SubmissionCount,
 		},
 	}
+}
 
-	c.JSON(http.StatusOK, dto.APIResponse{
-		Success: true,
-		Data:    response,
-	})
+// detailGroups are the JSONB groups of the location detail that ?groups= can select
+var detailGroups = []string{"identitas", "alamat", "data_pengungsi", "fasilitas", "komunikasi", "akses"}
+
+// parseDetailGroups parses a comma separated ?groups= value; nil means all groups
+func parseDetailGroups(value string) (map[string]bool, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	groups := make(map[string]bool)
+	for _, g := range strings.Split(value, ",") {
+		g = strings.TrimSpace(g)
+		if g == "" {
+			continue
+		}
+		known := false
+		for _, name := range detailGroups {
+			if g == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown group %q (want %s)", g, strings.Join(detailGroups, ", "))
+		}
+		groups[g] = true
+	}
+	return groups, nil
+}
+
+// omitDetailGroups drops the JSONB groups not listed in groups from a location detail
+func omitDetailGroups(response *dto.LocationDetailResponse, groups map[string]bool) {
+	if !groups["identitas"] {
+		response.Identitas = nil
+	}
+	if !groups["alamat"] {
+		response.Alamat = nil
+	}
+	if !groups["data_pengungsi"] {
+		response.DataPengungsi = nil
+	}
+	if !groups["fasilitas"] {
+		response.Fasilitas = nil
+	}
+	if !groups["komunikasi"] {
+		response.Komunikasi = nil
+	}
+	if !groups["akses"] {
+		response.Akses = nil
+	}
+}
+
+// includes reports whether a comma separated ?include= value lists part
+func includes(include, part string) bool {
+	for _, p := range strings.Split(include, ",") {
+		if strings.TrimSpace(p) == part {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneJSONB returns a shallow copy of a JSONB column as a non-nil map, so response
+// building can add derived fields without mutating the model (or anything sharing it)
+func cloneJSONB(src model.JSONB) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
 }
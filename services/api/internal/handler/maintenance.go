@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/middleware"
+)
+
+// MaintenanceHandler reads and toggles the read-only maintenance mode
+type MaintenanceHandler struct {
+	maintenance *middleware.Maintenance
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(maintenance *middleware.Maintenance) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenance: maintenance}
+}
+
+// GetStatus returns whether maintenance mode is on
+// @Summary Get maintenance mode
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Router /api/v1/admin/maintenance [get]
+func (h *MaintenanceHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    h.maintenance.Status(),
+	})
+}
+
+// SetMode turns maintenance mode on or off. While on, sync and write endpoints return
+// 503 with the given message, reads keep working and the scheduler skips its cycles.
+// @Summary Toggle maintenance mode
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Router /api/v1/admin/maintenance [post]
+func (h *MaintenanceHandler) SetMode(c *gin.Context) {
+	var req struct {
+		Enabled *bool  `json:"enabled" binding:"required"`
+		Message string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New(`body must be {"enabled": true|false}`)
+		}
+		respondValidationError(c, err)
+		return
+	}
+
+	h.maintenance.Set(*req.Enabled, req.Message)
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    h.maintenance.Status(),
+	})
+}
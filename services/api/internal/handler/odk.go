@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/odk"
+	"github.com/leksa/datamapper-senyar/internal/service"
+)
+
+// ODKHandler checks the ODK Central connection this instance is configured with
+// and exposes raw submissions of the configured forms
+type ODKHandler struct {
+	config   odk.ODKConfig
+	forms    map[string]string // data type -> xmlFormId
+	datasets []string
+	clients  map[string]*odk.Client // data type -> client for that form
+	client   *odk.Client            // project-level requests (entity lists)
+
+	syncService *service.SyncService // optional, source submissions of posko entities
+}
+
+func NewODKHandler(config odk.ODKConfig, forms map[string]string, datasets []string) *ODKHandler {
+	clients := make(map[string]*odk.Client, len(forms))
+	for name, formID := range forms {
+		formConfig := config
+		formConfig.FormID = formID
+		clients[name] = odk.NewClient(&formConfig)
+	}
+
+	return &ODKHandler{
+		config:   config,
+		forms:    forms,
+		datasets: datasets,
+		clients:  clients,
+		client:   odk.NewClient(&config),
+	}
+}
+
+// SetSyncService enables source submission IDs in the posko entity list, taken from
+// the entity mapping stored by the sync
+func (h *ODKHandler) SetSyncService(syncService *service.SyncService) {
+	h.syncService = syncService
+}
+
+// TestConnection validates ODK credentials, project, forms and entity datasets
+// @Summary Test ODK Central connection
+// @Description Authenticates with the given (or configured) credentials and checks that the project, configured forms and entity datasets exist. Omitted body fields use the current configuration. base_url must use the configured scheme and host.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body object false "base_url, email, password, project_id to try instead of the configured ones"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Failure 502 {object} dto.APIResponse
+// @Router /api/v1/admin/odk/test [post]
+func (h *ODKHandler) TestConnection(c *gin.Context) {
+	var req struct {
+		BaseURL   string `json:"base_url" binding:"omitempty,url"`
+		Email     string `json:"email"`
+		Password  string `json:"password"`
+		Token     string `json:"token"`
+		ProjectID int    `json:"project_id" binding:"omitempty,min=1"`
+	}
+	// The body is optional: an empty one tests the configured connection
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondValidationError(c, err)
+		return
+	}
+
+	config := h.config
+	config.TokenCache = nil // always log in: the check is about the credentials
+	if req.BaseURL != "" {
+		// The configured credentials are sent to base_url, so it may only point at the
+		// configured ODK Central server
+		if !sameServer(req.BaseURL, h.config.BaseURL) {
+			c.JSON(http.StatusBadRequest, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "BASE_URL_NOT_ALLOWED",
+					Message: "base_url must point at the configured ODK Central server",
+				},
+			})
+			return
+		}
+		config.BaseURL = req.BaseURL
+	}
+	if req.Email != "" {
+		config.Email = req.Email
+	}
+	if req.Password != "" {
+		config.Password = req.Password
+	}
+	// Trial credentials replace the configured ones of the other mode
+	if req.Token != "" {
+		config.Token = req.Token
+		config.Email, config.Password = "", ""
+	} else if req.Email != "" || req.Password != "" {
+		config.Token = ""
+	}
+	if req.ProjectID > 0 {
+		config.ProjectID = req.ProjectID
+	}
+
+	result := odk.CheckConnection(c.Request.Context(), config, h.forms, h.datasets)
+	if !result.OK {
+		c.JSON(http.StatusBadGateway, dto.APIResponse{
+			Success: false,
+			Data:    result,
+			Error: &dto.ErrorInfo{
+				Code:    "ODK_CHECK_FAILED",
+				Message: result.Errors[0],
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// StreamRawSubmissions streams the approved submissions of a form as NDJSON
+// @Summary Stream raw ODK submissions
+// @Description Streams each approved submission of the form as one JSON object per line, straight from ODK Central (nothing is persisted)
+// @Tags admin
+// @Produce application/x-ndjson
+// @Param form query string true "posko, feed, faskes or infrastruktur"
+// @Success 200 {string} string "NDJSON"
+// @Failure 400 {object} dto.APIResponse
+// @Failure 502 {object} dto.APIResponse
+// @Router /api/v1/admin/submissions/raw [get]
+func (h *ODKHandler) StreamRawSubmissions(c *gin.Context) {
+	var req struct {
+		Form string `form:"form" binding:"required,oneof=posko feed faskes infrastruktur"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	client, ok := h.clients[req.Form]
+	if !ok {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "SERVICE_NOT_CONFIGURED",
+				Message: "No ODK form configured for " + req.Form,
+			},
+		})
+		return
+	}
+
+	// Headers are sent with the first submission, so a failing first request can still
+	// be answered with a JSON error
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+	}
+
+	var line bytes.Buffer
+	count, err := client.StreamApprovedSubmissions(c.Request.Context(), odk.DefaultStreamPageSize, func(raw json.RawMessage) error {
+		start()
+		line.Reset()
+		if err := json.Compact(&line, raw); err != nil {
+			return err
+		}
+		line.WriteByte('\n')
+		if _, err := c.Writer.Write(line.Bytes()); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			c.JSON(http.StatusBadGateway, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "ODK_FETCH_FAILED",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		// Mid-stream: the status is already sent; the client sees a truncated stream
+		log.Printf("Raw %s submission stream aborted after %d submissions: %v", req.Form, count, err)
+		return
+	}
+
+	start()
+}
+
+// ListEntities lists the entities of a configured dataset straight from ODK Central
+// @Summary List ODK entities
+// @Description Lists the entities (uuid, label, version) of an entity dataset from ODK Central, for diagnosing entity mapping issues without ODK admin access. Posko entities include their source submission from the stored entity mapping.
+// @Tags admin
+// @Produce json
+// @Param dataset query string false "Entity dataset (default: the posko dataset)"
+// @Success 200 {object} dto.APIResponse{data=[]dto.ODKEntityItem}
+// @Failure 400 {object} dto.APIResponse
+// @Failure 404 {object} dto.APIResponse
+// @Failure 502 {object} dto.APIResponse
+// @Router /api/v1/admin/odk/entities [get]
+func (h *ODKHandler) ListEntities(c *gin.Context) {
+	dataset := c.Query("dataset")
+	if dataset == "" && len(h.datasets) > 0 {
+		dataset = h.datasets[0]
+	}
+	known := false
+	for _, d := range h.datasets {
+		known = known || d == dataset
+	}
+	if !known {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INVALID_DATASET",
+				Message: "Unknown entity dataset",
+				Details: h.datasets,
+			},
+		})
+		return
+	}
+
+	entities, err := h.client.GetEntities(dataset)
+	if err != nil {
+		var statusErr *odk.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "DATASET_NOT_FOUND",
+					Message: "Entity dataset " + dataset + " does not exist in ODK Central",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusBadGateway, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "ODK_FETCH_FAILED",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	var sources map[string]string
+	if h.syncService != nil && dataset == h.syncService.EntityDataset() {
+		if sources, err = h.syncService.StoredEntitySources(); err != nil {
+			log.Printf("Warning: could not read stored entity mapping: %v", err)
+		}
+	}
+
+	items := make([]dto.ODKEntityItem, 0, len(entities))
+	for _, entity := range entities {
+		item := dto.ODKEntityItem{
+			CreatedAt: parseODKTime(entity["createdAt"]),
+			UpdatedAt: parseODKTime(entity["updatedAt"]),
+		}
+		item.UUID, _ = entity["uuid"].(string)
+		if version, ok := entity["currentVersion"].(map[string]interface{}); ok {
+			item.Label, _ = version["label"].(string)
+			if v, ok := version["version"].(float64); ok {
+				item.Version = int(v)
+			}
+		}
+		item.SourceSubmissionID = sources[item.UUID]
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    items,
+		Meta: &dto.MetaInfo{
+			Total:     int64(len(items)),
+			Timestamp: time.Now(),
+		},
+	})
+}
+
+// sameServer reports whether both URLs have the same scheme and host (and port)
+func sameServer(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Host != "" && strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// parseODKTime parses an ODK Central timestamp; nil when absent or invalid
+func parseODKTime(value interface{}) *time.Time {
+	str, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -1,19 +1,26 @@
 package handler
 
 import (
+	"errors"
 	"io"
+	"log"
 	"net/http"
-	"path/filepath"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/dto"
 	"github.com/leksa/datamapper-senyar/internal/service"
 )
 
 // PhotoHandler handles photo-related HTTP requests
 type PhotoHandler struct {
 	photoService *service.PhotoService
+	photoQueue   *service.PhotoQueue // optional background download queue
+	photoVisibility
+	publicURLs
 }
 
 // NewPhotoHandler creates a new photo handler
@@ -23,6 +30,32 @@ func NewPhotoHandler(photoService *service.PhotoService) *PhotoHandler {
 	}
 }
 
+// SetPhotoQueue sets the background download queue reported by GetQueueStatus
+func (h *PhotoHandler) SetPhotoQueue(queue *service.PhotoQueue) {
+	h.photoQueue = queue
+}
+
+// GetQueueStatus returns the background photo download queue status
+func (h *PhotoHandler) GetQueueStatus(c *gin.Context) {
+	if h.photoQueue == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"enabled": false,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"enabled": true,
+			"queue":   h.photoQueue.Status(),
+		},
+	})
+}
+
 // GetPhotosByLocation returns all photos for a location
 func (h *PhotoHandler) GetPhotosByLocation(c *gin.Context) {
 	locationIDStr := c.Param("id")
@@ -44,33 +77,25 @@ func (h *PhotoHandler) GetPhotosByLocation(c *gin.Context) {
 		return
 	}
 
-	// Build photo URLs
-	type PhotoResponse struct {
-		ID          string  `json:"id"`
-		PhotoType   string  `json:"photo_type"`
-		Filename    string  `json:"filename"`
-		IsCached    bool    `json:"is_cached"`
-		FileSize    *int    `json:"file_size,omitempty"`
-		URL         string  `json:"url,omitempty"`
-		StoragePath string  `json:"storage_path,omitempty"`
-		CreatedAt   string  `json:"created_at"`
-	}
-
-	var response []PhotoResponse
+	// Non-nil so an empty list encodes as [] rather than null
+	response := make([]dto.PhotoDetailResponse, 0, len(photos))
 	for _, photo := range photos {
-		pr := PhotoResponse{
+		if !h.photoVisible(c, photo.PhotoType) {
+			continue
+		}
+		pr := dto.PhotoDetailResponse{
 			ID:        photo.ID.String(),
 			PhotoType: photo.PhotoType,
 			Filename:  photo.Filename,
 			IsCached:  photo.IsCached,
 			FileSize:  photo.FileSize,
 			CreatedAt: photo.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		}
-		if photo.StoragePath != nil {
-			pr.StoragePath = *photo.StoragePath
+			Width:     photo.Width,
+			Height:    photo.Height,
 		}
 		if photo.IsCached {
-			pr.URL = "/api/v1/photos/" + photo.ID.String() + "/file"
+			pr.URL = h.absoluteURL("/api/v1/photos/" + photo.ID.String() + "/file")
+			pr.ThumbnailURL = h.absoluteURL("/api/v1/photos/" + photo.ID.String() + "/thumb")
 		}
 		response = append(response, pr)
 	}
@@ -81,6 +106,60 @@ func (h *PhotoHandler) GetPhotosByLocation(c *gin.Context) {
 	})
 }
 
+// GetPhotoURLsByLocation returns directly fetchable URLs for all photos of a location,
+// so a gallery can load without a redirect per photo
+func (h *PhotoHandler) GetPhotoURLsByLocation(c *gin.Context) {
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid location ID",
+		})
+		return
+	}
+
+	urls, err := h.photoService.GetPhotoURLsByLocation(locationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.visiblePhotoURLs(c, urls),
+	})
+}
+
+// GetPhotoURLsByFaskes returns directly fetchable URLs for all photos of a faskes.
+// Photos under a private prefix get pre-signed URLs.
+func (h *PhotoHandler) GetPhotoURLsByFaskes(c *gin.Context) {
+	faskesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid faskes ID",
+		})
+		return
+	}
+
+	urls, err := h.photoService.GetPhotoURLsByFaskes(faskesID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.visiblePhotoURLs(c, urls),
+	})
+}
+
 // GetPhoto returns a single photo's metadata
 func (h *PhotoHandler) GetPhoto(c *gin.Context) {
 	photoIDStr := c.Param("id")
@@ -141,13 +220,12 @@ func (h *PhotoHandler) GetPhotoFile(c *gin.Context) {
 	}
 
 	// If S3 URL, redirect to it directly (more efficient)
-	if strings.HasPrefix(storagePath, "http") {
-		c.Redirect(http.StatusFound, storagePath)
+	if h.redirectToS3(c, storagePath) {
 		return
 	}
 
 	// Local file - stream it
-	reader, filename, err := h.photoService.GetPhotoReader(photoID)
+	reader, filename, contentType, err := h.photoService.GetPhotoReader(photoID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -157,22 +235,99 @@ func (h *PhotoHandler) GetPhotoFile(c *gin.Context) {
 	}
 	defer reader.Close()
 
-	// Determine content type based on extension
-	ext := filepath.Ext(filename)
-	contentType := "application/octet-stream"
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".png":
-		contentType = "image/png"
-	case ".gif":
-		contentType = "image/gif"
-	case ".webp":
-		contentType = "image/webp"
+	servePhotoFile(c, reader, filename, contentType)
+}
+
+// redirectToS3 redirects to a photo stored in S3, through a pre-signed URL when its
+// prefix is private. Returns false for local files, or when signing fails so the
+// caller streams the file instead.
+func (h *PhotoHandler) redirectToS3(c *gin.Context, storagePath string) bool {
+	if !strings.HasPrefix(storagePath, "http") {
+		return false
+	}
+	redirectURL, err := h.photoService.S3RedirectURL(c.Request.Context(), storagePath)
+	if err != nil {
+		log.Printf("Warning: %v, streaming photo instead", err)
+		return false
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+	return true
+}
+
+// GetPhotoThumbnail serves a small preview of a photo for map markers, falling back to
+// the full photo when it has no thumbnail
+func (h *PhotoHandler) GetPhotoThumbnail(c *gin.Context) {
+	photoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid photo ID",
+		})
+		return
+	}
+
+	reader, filename, contentType, err := h.photoService.GetPhotoThumbnailReader(photoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	servePhotoFile(c, reader, filename, contentType)
+}
+
+// GetPhotoOriginal serves a photo even if it hasn't been downloaded yet: cached photos are
+// served like GetPhotoFile, uncached ones are proxied (streamed) from ODK Central.
+// With ?cache=true an uncached photo is also queued for background download (requires the photo queue).
+// Photo types hidden from the caller answer 404, as if the photo did not exist.
+func (h *PhotoHandler) GetPhotoOriginal(c *gin.Context) {
+	photoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid photo ID",
+		})
+		return
+	}
+
+	photo, err := h.photoService.GetPhotoByID(photoID)
+	if err != nil || !h.photoVisible(c, photo.PhotoType) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   service.ErrPhotoNotFound.Error(),
+		})
+		return
+	}
+
+	if _, err := h.photoService.GetPhotoPath(photoID); err == nil {
+		h.GetPhotoFile(c)
+		return
+	}
+
+	reader, filename, contentType, err := h.photoService.OpenPhotoOriginal(photoID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, service.ErrPhotoNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	if c.Query("cache") == "true" && h.photoQueue != nil {
+		h.photoQueue.Enqueue(service.PhotoKindLocation, photoID)
 	}
 
 	c.Header("Content-Type", contentType)
 	c.Header("Content-Disposition", "inline; filename="+filename)
+	c.Header("Cache-Control", "no-store")
 
 	c.Stream(func(w io.Writer) bool {
 		io.Copy(w, reader)
@@ -197,6 +352,25 @@ func (h *PhotoHandler) SyncPhotos(c *gin.Context) {
 	})
 }
 
+// SyncAllPhotoTypes downloads pending posko, feed, faskes and infrastruktur photos in one call.
+// ?workers=1-4 sets how many photo types sync concurrently (default 1).
+func (h *PhotoHandler) SyncAllPhotoTypes(c *gin.Context) {
+	var req struct {
+		Workers int `form:"workers" binding:"omitempty,min=1,max=4"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	result := h.photoService.SyncAllPhotoTypes(req.Workers)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // CleanupOrphaned removes orphaned photo files
 func (h *PhotoHandler) CleanupOrphaned(c *gin.Context) {
 	cleaned, err := h.photoService.CleanupOrphanedFiles()
@@ -238,14 +412,13 @@ func (h *PhotoHandler) GetFeedPhotoFile(c *gin.Context) {
 		return
 	}
 
-	// If S3 URL, redirect to it directly
-	if strings.HasPrefix(storagePath, "http") {
-		c.Redirect(http.StatusFound, storagePath)
+	// If S3 URL, redirect to it directly (more efficient)
+	if h.redirectToS3(c, storagePath) {
 		return
 	}
 
 	// Local file - stream it
-	reader, filename, err := h.photoService.GetFeedPhotoReader(photoID)
+	reader, filename, contentType, err := h.photoService.GetFeedPhotoReader(photoID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -255,35 +428,12 @@ func (h *PhotoHandler) GetFeedPhotoFile(c *gin.Context) {
 	}
 	defer reader.Close()
 
-	// Determine content type based on extension
-	ext := filepath.Ext(filename)
-	contentType := "application/octet-stream"
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".png":
-		contentType = "image/png"
-	case ".gif":
-		contentType = "image/gif"
-	case ".webp":
-		contentType = "image/webp"
-	}
-
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Disposition", "inline; filename="+filename)
-
-	c.Stream(func(w io.Writer) bool {
-		io.Copy(w, reader)
-		return false
-	})
+	servePhotoFile(c, reader, filename, contentType)
 }
 
 // SyncFeedPhotos triggers feed photo synchronization
 func (h *PhotoHandler) SyncFeedPhotos(c *gin.Context) {
-	formID := c.Query("form_id")
-	if formID == "" {
-		formID = "form_feed_v1" // default feed form ID
-	}
+	formID := deprecatedFormID(c)
 
 	result, err := h.photoService.SyncFeedPhotos(formID)
 	if err != nil {
@@ -326,14 +476,13 @@ func (h *PhotoHandler) GetFaskesPhotoFile(c *gin.Context) {
 		return
 	}
 
-	// If S3 URL, redirect to it directly
-	if strings.HasPrefix(storagePath, "http") {
-		c.Redirect(http.StatusFound, storagePath)
+	// If S3 URL, redirect to it directly (more efficient)
+	if h.redirectToS3(c, storagePath) {
 		return
 	}
 
 	// Local file - stream it
-	reader, filename, err := h.photoService.GetFaskesPhotoReader(photoID)
+	reader, filename, contentType, err := h.photoService.GetFaskesPhotoReader(photoID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -343,27 +492,7 @@ func (h *PhotoHandler) GetFaskesPhotoFile(c *gin.Context) {
 	}
 	defer reader.Close()
 
-	// Determine content type based on extension
-	ext := filepath.Ext(filename)
-	contentType := "application/octet-stream"
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".png":
-		contentType = "image/png"
-	case ".gif":
-		contentType = "image/gif"
-	case ".webp":
-		contentType = "image/webp"
-	}
-
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Disposition", "inline; filename="+filename)
-
-	c.Stream(func(w io.Writer) bool {
-		io.Copy(w, reader)
-		return false
-	})
+	servePhotoFile(c, reader, filename, contentType)
 }
 
 // GetPhotosByFaskes returns all photos for a faskes
@@ -387,29 +516,24 @@ func (h *PhotoHandler) GetPhotosByFaskes(c *gin.Context) {
 		return
 	}
 
-	// Build photo URLs
-	type PhotoResponse struct {
-		ID        string `json:"id"`
-		PhotoType string `json:"photo_type"`
-		Filename  string `json:"filename"`
-		IsCached  bool   `json:"is_cached"`
-		FileSize  *int   `json:"file_size,omitempty"`
-		URL       string `json:"url,omitempty"`
-		CreatedAt string `json:"created_at"`
-	}
-
-	var response []PhotoResponse
+	// Non-nil so an empty list encodes as [] rather than null
+	response := make([]dto.PhotoDetailResponse, 0, len(photos))
 	for _, photo := range photos {
-		pr := PhotoResponse{
+		if !h.photoVisible(c, photo.PhotoType) {
+			continue
+		}
+		pr := dto.PhotoDetailResponse{
 			ID:        photo.ID.String(),
 			PhotoType: photo.PhotoType,
 			Filename:  photo.Filename,
 			IsCached:  photo.IsCached,
 			FileSize:  photo.FileSize,
 			CreatedAt: photo.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Width:     photo.Width,
+			Height:    photo.Height,
 		}
 		if photo.IsCached {
-			pr.URL = "/api/v1/faskes/photos/" + photo.ID.String() + "/file"
+			pr.URL = h.absoluteURL("/api/v1/faskes/photos/" + photo.ID.String() + "/file")
 		}
 		response = append(response, pr)
 	}
@@ -422,10 +546,7 @@ func (h *PhotoHandler) GetPhotosByFaskes(c *gin.Context) {
 
 // SyncFaskesPhotos triggers faskes photo synchronization
 func (h *PhotoHandler) SyncFaskesPhotos(c *gin.Context) {
-	formID := c.Query("form_id")
-	if formID == "" {
-		formID = "form_faskes_v1" // default faskes form ID
-	}
+	formID := deprecatedFormID(c)
 
 	result, err := h.photoService.SyncFaskesPhotos(formID)
 	if err != nil {
@@ -442,13 +563,148 @@ func (h *PhotoHandler) SyncFaskesPhotos(c *gin.Context) {
 	})
 }
 
+// ========================================
+// INFRASTRUKTUR PHOTOS ENDPOINTS
+// ========================================
+
+// GetInfraPhotoFile serves the actual infrastruktur photo file
+func (h *PhotoHandler) GetInfraPhotoFile(c *gin.Context) {
+	photoIDStr := c.Param("id")
+	photoID, err := uuid.Parse(photoIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid photo ID",
+		})
+		return
+	}
+
+	// Get storage path
+	storagePath, err := h.photoService.GetInfraPhotoPath(photoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// If S3 URL, redirect to it directly (more efficient)
+	if h.redirectToS3(c, storagePath) {
+		return
+	}
+
+	// Local file - stream it
+	reader, filename, contentType, err := h.photoService.GetInfraPhotoReader(photoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	servePhotoFile(c, reader, filename, contentType)
+}
+
+// SyncInfraPhotos triggers infrastruktur photo synchronization
+func (h *PhotoHandler) SyncInfraPhotos(c *gin.Context) {
+	formID := deprecatedFormID(c)
+
+	result, err := h.photoService.SyncInfraPhotos(formID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // ========================================
 // S3 MIGRATION ENDPOINT
 // ========================================
 
 // MigrateToS3 migrates all locally cached photos to S3
+// Use ?delete_local=true to remove each local file once its S3 upload is confirmed
 func (h *PhotoHandler) MigrateToS3(c *gin.Context) {
-	result, err := h.photoService.MigrateToS3()
+	deleteLocal := c.Query("delete_local") == "true"
+
+	result, err := h.photoService.MigrateToS3(deleteLocal)
+	if errors.Is(err, service.ErrMigrationRunning) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetMigrationStatus returns the progress of the latest S3 migration: status (running,
+// completed, interrupted), total, migrated, errors and the last processed photo
+func (h *PhotoHandler) GetMigrationStatus(c *gin.Context) {
+	job, err := h.photoService.LatestMigrationJob()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "no S3 migration has run yet",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// CleanupLocalMigrated removes local photo files already migrated to S3, after checking
+// that each S3 object exists, and reports the bytes freed
+func (h *PhotoHandler) CleanupLocalMigrated(c *gin.Context) {
+	result, err := h.photoService.CleanupLocalMigrated()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// BackfillDimensions records width and height of photos cached before dimensions were
+// captured at download time
+func (h *PhotoHandler) BackfillDimensions(c *gin.Context) {
+	result, err := h.photoService.BackfillDimensions()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -488,3 +744,35 @@ func (h *PhotoHandler) ResetCache(c *gin.Context) {
 		"message": message,
 	})
 }
+
+// deprecatedFormID returns the deprecated ?form_id override for photo syncs. Without it
+// the photo service uses the form configured for the photo type (ODK_*_FORM_ID).
+func deprecatedFormID(c *gin.Context) string {
+	formID := c.Query("form_id")
+	if formID != "" {
+		log.Printf("Deprecated: form_id=%s on %s overrides the configured form", formID, c.FullPath())
+		c.Header("Deprecation", "true")
+	}
+	return formID
+}
+
+// servePhotoFile writes a photo to the response. Local files (seekable) go through
+// http.ServeContent so Range requests get 206 Partial Content; other readers are streamed.
+func servePhotoFile(c *gin.Context, reader io.Reader, filename, contentType string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "inline; filename="+filename)
+
+	if file, ok := reader.(*os.File); ok {
+		modTime := time.Time{}
+		if info, err := file.Stat(); err == nil {
+			modTime = info.ModTime()
+		}
+		http.ServeContent(c.Writer, c.Request, filename, modTime, file)
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		io.Copy(w, reader)
+		return false
+	})
+}
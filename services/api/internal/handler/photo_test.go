@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/middleware"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/service"
+	"gorm.io/gorm"
+)
+
+// The original photo route proxies ODK Central with the server's credentials, so it must not
+// be reachable anonymously, and hidden photo types must not leak through it
+func TestPhotoOriginalAccess(t *testing.T) {
+	db := dryRunDB(t)
+	// Every location photo lookup finds a photo of the hidden "qa" type
+	db.Callback().Query().After("gorm:query").Register("test:hidden_photo", func(tx *gorm.DB) {
+		if photo, ok := tx.Statement.Dest.(*model.LocationPhoto); ok {
+			photo.ID = uuid.New()
+			photo.PhotoType = "qa"
+		}
+	})
+	photoHandler := NewPhotoHandler(service.NewPhotoService(db, nil, t.TempDir()))
+	photoHandler.SetHiddenPhotoTypes([]string{"qa"})
+
+	keys := []middleware.APIKey{{Label: "cron", Key: "s3cret", Scopes: []string{middleware.ScopeSync}}}
+	path := "/photos/" + uuid.New().String() + "/original"
+	tests := []struct {
+		name   string
+		keys   []middleware.APIKey
+		apiKey string
+		want   int
+	}{
+		{"anonymous", keys, "", http.StatusUnauthorized},
+		{"hidden type without API keys configured", nil, "", http.StatusNotFound},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			protected := r.Group("", middleware.APIKeyAuth(tt.keys), middleware.RequireScope(middleware.ScopeSync))
+			protected.GET("/photos/:id/original", photoHandler.GetPhotoOriginal)
+
+			req := httptest.NewRequest(http.MethodGet, path+"?cache=true", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
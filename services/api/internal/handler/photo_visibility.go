@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/middleware"
+	"github.com/leksa/datamapper-senyar/internal/service"
+)
+
+// photoVisibility is embedded by handlers that list photos, to leave non-public photo
+// types out of responses to requests without a valid API key
+type photoVisibility struct {
+	hiddenPhotoTypes map[string]bool
+}
+
+// SetHiddenPhotoTypes marks photo types (e.g. internal QA shots) as non-public
+func (v *photoVisibility) SetHiddenPhotoTypes(types []string) {
+	v.hiddenPhotoTypes = make(map[string]bool, len(types))
+	for _, t := range types {
+		v.hiddenPhotoTypes[t] = true
+	}
+}
+
+// photoVisible reports whether a photo of photoType may be listed in the response to c
+func (v *photoVisibility) photoVisible(c *gin.Context, photoType string) bool {
+	return !v.hiddenPhotoTypes[photoType] || middleware.IsAuthenticated(c)
+}
+
+// visiblePhotoURLs drops the URLs of photo types hidden from c
+func (v *photoVisibility) visiblePhotoURLs(c *gin.Context, urls []service.PhotoURL) []service.PhotoURL {
+	visible := make([]service.PhotoURL, 0, len(urls))
+	for _, u := range urls {
+		if v.photoVisible(c, u.Type) {
+			visible = append(visible, u)
+		}
+	}
+	return visible
+}
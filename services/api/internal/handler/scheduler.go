@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/leksa/datamapper-senyar/internal/dto"
 	"github.com/leksa/datamapper-senyar/internal/scheduler"
@@ -97,6 +98,37 @@ func (h *SchedulerHandler) ClearManualMode(c *gin.Context) {
 	})
 }
 
+// Pause suspends scheduled syncs for a number of minutes, e.g. during ODK maintenance
+// @Summary Pause scheduler
+// @Description Suspends scheduled syncs until now + minutes; the scheduler resumes automatically
+// @Tags scheduler
+// @Accept json
+// @Produce json
+// @Param minutes query int true "Pause duration in minutes"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Router /api/v1/scheduler/pause [post]
+func (h *SchedulerHandler) Pause(c *gin.Context) {
+	var req struct {
+		Minutes int `form:"minutes" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	until := time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+	h.scheduler.PauseUntil(until)
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"paused_until": until,
+			"message":      "Scheduler paused",
+		},
+	})
+}
+
 // TriggerSync manually triggers a sync cycle
 // @Summary Trigger sync
 // @Description Manually triggers an immediate sync cycle
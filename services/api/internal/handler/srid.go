@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// parseSRID reads the output projection of a GeoJSON list from ?srid= (default
+// repository.DefaultSRID). Unsupported values are answered with 400 and ok=false.
+func parseSRID(c *gin.Context) (srid int, ok bool) {
+	value := c.Query("srid")
+	if value == "" {
+		return repository.DefaultSRID, true
+	}
+	srid, err := strconv.Atoi(value)
+	if err != nil || !repository.IsSupportedSRID(srid) {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "VALIDATION_ERROR",
+				Message: fmt.Sprintf("Unsupported srid %q (want 4326, 3857 or a UTM zone 32646-32654, 32746-32754)", value),
+			},
+		})
+		return 0, false
+	}
+	return srid, true
+}
+
+// geoJSONCRS names the projection of a FeatureCollection returned in srid; nil for
+// WGS 84, the GeoJSON default
+func geoJSONCRS(srid int) *dto.GeoJSONCRS {
+	if srid == repository.DefaultSRID {
+		return nil
+	}
+	return &dto.GeoJSONCRS{
+		Type:       "name",
+		Properties: map[string]string{"name": fmt.Sprintf("urn:ogc:def:crs:EPSG::%d", srid)},
+	}
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/service"
+)
+
+// staleFlag marks a handler's list responses as stale while its form's sync is failing.
+// Embedded in the list handlers; without a checker responses are never flagged.
+type staleFlag struct {
+	staleChecker *service.StaleChecker
+	staleFormID  string
+}
+
+// SetStaleChecker enables the meta.stale flag for the given ODK form
+func (f *staleFlag) SetStaleChecker(checker *service.StaleChecker, formID string) {
+	f.staleChecker = checker
+	f.staleFormID = formID
+}
+
+// withStale sets meta.stale and meta.stale_since when the form's data is stale
+func (f *staleFlag) withStale(meta *dto.MetaInfo) *dto.MetaInfo {
+	if stale, since := f.staleChecker.Check(f.staleFormID); stale {
+		meta.Stale = true
+		meta.StaleSince = since
+	}
+	return meta
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/leksa/datamapper-senyar/internal/dto"
+	"github.com/leksa/datamapper-senyar/internal/odk"
 	"github.com/leksa/datamapper-senyar/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -15,14 +19,23 @@ type SyncHandler struct {
 	feedSyncService         *service.FeedSyncService
 	faskesSyncService       *service.FaskesSyncService
 	infrastrukturSyncService *service.InfrastrukturSyncService
+	photoService             *service.PhotoService // optional, for ?photos=true on submission re-sync
+	staleThreshold           time.Duration
+
+	// purgeRetention is the default age of soft-deleted records removed by PurgeDeleted
+	purgeRetention time.Duration
 }
 
+// defaultStaleThreshold is used when no threshold is configured
+const defaultStaleThreshold = 30 * time.Minute
+
 // NewSyncHandler creates a new sync handler
 func NewSyncHandler(syncService *service.SyncService, feedSyncService *service.FeedSyncService, faskesSyncService *service.FaskesSyncService) *SyncHandler {
 	return &SyncHandler{
 		syncService:       syncService,
 		feedSyncService:   feedSyncService,
 		faskesSyncService: faskesSyncService,
+		staleThreshold:    defaultStaleThreshold,
 	}
 }
 
@@ -33,9 +46,28 @@ func NewSyncHandlerWithInfrastruktur(syncService *service.SyncService, feedSyncS
 		feedSyncService:          feedSyncService,
 		faskesSyncService:        faskesSyncService,
 		infrastrukturSyncService: infrastrukturSyncService,
+		staleThreshold:           defaultStaleThreshold,
 	}
 }
 
+// SetStaleThreshold sets the age after which synced data is reported as stale
+func (h *SyncHandler) SetStaleThreshold(threshold time.Duration) {
+	if threshold > 0 {
+		h.staleThreshold = threshold
+	}
+}
+
+// SetPurgeRetention sets how long soft-deleted records are kept when PurgeDeleted is
+// called without ?days=
+func (h *SyncHandler) SetPurgeRetention(retention time.Duration) {
+	h.purgeRetention = retention
+}
+
+// SetPhotoService enables downloading photos as part of a single-submission re-sync
+func (h *SyncHandler) SetPhotoService(photoService *service.PhotoService) {
+	h.photoService = photoService
+}
+
 // SyncAll triggers a full sync of all submissions
 // @Summary Sync all ODK submissions
 // @Description Fetches all approved submissions from ODK Central and syncs to PostgreSQL
@@ -64,9 +96,34 @@ func (h *SyncHandler) SyncAll(c *gin.Context) {
 	})
 }
 
+// SyncAllForms syncs every form: posko and faskes concurrently, then feeds, then infrastruktur
+// @Summary Sync all forms
+// @Description Runs posko and faskes in parallel, then feeds and infrastruktur; returns per-form results once all settle
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Router /api/v1/sync/all [post]
+func (h *SyncHandler) SyncAllForms(c *gin.Context) {
+	result := service.SyncAllForms(h.syncService, h.faskesSyncService, h.feedSyncService, h.infrastrukturSyncService)
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// poskoSyncStatus is the posko sync state plus whether syncs run in submission mode
+// (entity dataset missing in ODK Central)
+type poskoSyncStatus struct {
+	*odk.SyncState
+	SubmissionMode bool   `json:"submission_mode"`
+	EntityDataset  string `json:"entity_dataset"`
+}
+
 // GetSyncStatus returns the current sync status
 // @Summary Get sync status
-// @Description Returns the current synchronization status for posko form
+// @Description Returns the current synchronization status for posko form, flagging submission mode when the entity dataset is missing
 // @Tags sync
 // @Accept json
 // @Produce json
@@ -87,7 +144,11 @@ func (h *SyncHandler) GetSyncStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
-		Data:    state,
+		Data: poskoSyncStatus{
+			SyncState:      state,
+			SubmissionMode: h.syncService.SubmissionMode(),
+			EntityDataset:  h.syncService.EntityDataset(),
+		},
 	})
 }
 
@@ -233,6 +294,73 @@ func (h *SyncHandler) HardSyncPosko(c *gin.Context) {
 	})
 }
 
+// SyncPoskoSubmission re-syncs a single posko submission, optionally downloading its photos
+// @Summary Re-sync one posko submission
+// @Description Fetches one submission from ODK Central and updates its entity's record. With photos=true the record's uncached photos are downloaded before responding
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param submissionId path string true "ODK submission instance ID"
+// @Param photos query bool false "Also download the record's photos"
+// @Success 200 {object} dto.APIResponse
+// @Failure 404 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/sync/posko/submissions/{submissionId} [post]
+func (h *SyncHandler) SyncPoskoSubmission(c *gin.Context) {
+	submissionID := c.Param("submissionId")
+
+	result, location, err := h.syncService.SyncSubmission(submissionID)
+	if err != nil {
+		if errors.Is(err, odk.ErrSubmissionNotFound) {
+			c.JSON(http.StatusNotFound, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "SYNC_FAILED",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	response := dto.SubmissionSyncResponse{
+		SubmissionID: submissionID,
+		Sync:         result,
+	}
+
+	if location != nil {
+		response.LocationID = location.ID.String()
+
+		if c.Query("photos") == "true" && h.photoService != nil {
+			// Photos are fetched from the record's current (latest) submission
+			photoSubmissionID := submissionID
+			if location.ODKSubmissionID != nil {
+				photoSubmissionID = *location.ODKSubmissionID
+			}
+			photos := &dto.SubmissionPhotoSync{}
+			downloaded, err := h.photoService.SyncPhotos(location.ID, photoSubmissionID)
+			photos.Downloaded = downloaded
+			if err != nil {
+				photos.Error = err.Error()
+			}
+			response.Photos = photos
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
 // HardSyncFeeds triggers a hard sync of feeds - syncs and deletes removed submissions
 // @Summary Hard sync feed data
 // @Description Syncs feed data and deletes records that no longer exist in ODK Central
@@ -408,3 +536,439 @@ func (h *SyncHandler) HardSyncInfrastruktur(c *gin.Context) {
 		Data:    result,
 	})
 }
+
+// GetSyncDelta compares the two most recent sync runs of a form
+// @Summary Get sync delta
+// @Description Returns created/updated/changed/deleted counts and record names of the latest sync run, with the previous run for comparison
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param form path string true "Form" Enums(posko, feed, faskes, infrastruktur)
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Failure 404 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/sync/{form}/delta [get]
+func (h *SyncHandler) GetSyncDelta(c *gin.Context) {
+	form := c.Param("form")
+
+	var (
+		delta *service.SyncDelta
+		err   error
+	)
+	switch form {
+	case "posko":
+		delta, err = h.syncService.Delta()
+	case "feed":
+		delta, err = h.feedSyncService.Delta()
+	case "faskes":
+		delta, err = h.faskesSyncService.Delta()
+	case "infrastruktur":
+		if h.infrastrukturSyncService == nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "SERVICE_NOT_CONFIGURED",
+					Message: "Infrastruktur sync service not configured",
+				},
+			})
+			return
+		}
+		delta, err = h.infrastrukturSyncService.Delta()
+	default:
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "INVALID_FORM",
+				Message: "Form must be one of: posko, feed, faskes, infrastruktur",
+			},
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNoSyncRuns) {
+		c.JSON(http.StatusNotFound, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "NOT_FOUND",
+				Message: "No sync runs recorded for " + form + " yet",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "SYNC_DELTA_FETCH_FAILED",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    delta,
+	})
+}
+
+// GetFreshness returns per-form data freshness based on the last successful sync
+// @Summary Get data freshness
+// @Description Returns last sync time, age and stale flag for each synced form
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/sync/freshness [get]
+func (h *SyncHandler) GetFreshness(c *gin.Context) {
+	type formState struct {
+		name  string
+		fetch func() (*odk.SyncState, error)
+	}
+
+	forms := []formState{
+		{"posko", h.syncService.GetSyncState},
+		{"feed", h.feedSyncService.GetSyncState},
+		{"faskes", h.faskesSyncService.GetSyncState},
+	}
+	if h.infrastrukturSyncService != nil {
+		forms = append(forms, formState{"infrastruktur", h.infrastrukturSyncService.GetSyncState})
+	}
+
+	now := time.Now()
+	response := dto.SyncFreshnessResponse{
+		StaleThresholdSeconds: int64(h.staleThreshold.Seconds()),
+		Forms:                 make([]dto.FormFreshness, 0, len(forms)),
+	}
+
+	for _, f := range forms {
+		state, err := f.fetch()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "STATUS_FETCH_FAILED",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		freshness := dto.FormFreshness{
+			Form:         f.name,
+			FormID:       state.FormID,
+			Status:       state.Status,
+			LastSyncTime: state.LastSyncTime,
+			IsStale:      true, // never synced counts as stale
+		}
+		if state.LastSyncTime != nil {
+			age := now.Sub(*state.LastSyncTime)
+			ageSeconds := int64(age.Seconds())
+			freshness.AgeSeconds = &ageSeconds
+			freshness.IsStale = age > h.staleThreshold
+		}
+		response.Forms = append(response.Forms, freshness)
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    response,
+		Meta: &dto.MetaInfo{
+			Timestamp: now,
+		},
+	})
+}
+
+// Remap re-runs the current mappers over stored raw_data for one form, without fetching from ODK
+// @Summary Remap stored submissions
+// @Description Re-applies the current mapper to every record's raw_data and updates derived fields
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param form query string true "Form to remap (posko, feed, faskes, infrastruktur)"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/admin/remap [post]
+func (h *SyncHandler) Remap(c *gin.Context) {
+	var req struct {
+		Form string `form:"form" binding:"required,oneof=posko feed faskes infrastruktur"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch req.Form {
+	case "posko":
+		result, err = h.syncService.Remap()
+	case "feed":
+		result, err = h.feedSyncService.Remap()
+	case "faskes":
+		result, err = h.faskesSyncService.Remap()
+	case "infrastruktur":
+		if h.infrastrukturSyncService == nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "SERVICE_NOT_CONFIGURED",
+					Message: "Infrastruktur sync service not configured",
+				},
+			})
+			return
+		}
+		result, err = h.infrastrukturSyncService.Remap()
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "REMAP_FAILED",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// RefreshEntityMapping godoc
+// @Summary Refresh posko entity mapping
+// @Description Refetch the entity-to-submission mapping from ODK Central, replacing the stored copy
+// @Tags sync
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Failure 502 {object} dto.APIResponse
+// @Router /api/v1/admin/entity-mapping/refresh [post]
+func (h *SyncHandler) RefreshEntityMapping(c *gin.Context) {
+	start := time.Now()
+	entities, err := h.syncService.RefreshEntityMapping()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "ENTITY_MAPPING_FAILED",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"entities": entities,
+			"duration": time.Since(start).String(),
+		},
+	})
+}
+
+// EnrichWilayah re-runs the wilayah lookups over stored posko and faskes alamat
+// @Summary Re-enrich wilayah names
+// @Description Fills region names (posko) and region IDs (faskes) from the wilayah reference tables, without fetching from ODK
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/admin/enrich-wilayah [post]
+func (h *SyncHandler) EnrichWilayah(c *gin.Context) {
+	posko, err := h.syncService.EnrichWilayah()
+	if err != nil {
+		respondEnrichWilayahError(c, err)
+		return
+	}
+
+	faskes, err := h.faskesSyncService.EnrichWilayah()
+	if err != nil {
+		respondEnrichWilayahError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"posko":    posko,
+			"faskes":   faskes,
+			"enriched": posko.Enriched + faskes.Enriched,
+		},
+	})
+}
+
+func respondEnrichWilayahError(c *gin.Context, err error) {
+	c.JSON(http.StatusInternalServerError, dto.APIResponse{
+		Success: false,
+		Error: &dto.ErrorInfo{
+			Code:    "ENRICH_FAILED",
+			Message: err.Error(),
+		},
+	})
+}
+
+// Rebuild purges a form's data and rebuilds it from ODK Central
+// @Summary Purge and rebuild a form's data
+// @Description Soft-deletes all records of the form and removes their photo rows, then runs a full sync.
+// @Description Requires confirm=true. Returns record/photo counts before and after.
+// @Tags admin
+// @Produce json
+// @Param form query string true "Form" Enums(posko, faskes, infrastruktur)
+// @Param confirm query bool true "Must be true"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Failure 500 {object} dto.APIResponse
+// @Router /api/v1/admin/rebuild [post]
+func (h *SyncHandler) Rebuild(c *gin.Context) {
+	var req struct {
+		Form    string `form:"form" binding:"required,oneof=posko faskes infrastruktur"`
+		Confirm bool   `form:"confirm"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if !req.Confirm {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "CONFIRMATION_REQUIRED",
+				Message: "Rebuild deletes all " + req.Form + " data before re-syncing; pass confirm=true",
+			},
+		})
+		return
+	}
+
+	log.Printf("REBUILD %s requested by %s", req.Form, c.ClientIP())
+
+	var (
+		result *service.RebuildResult
+		err    error
+	)
+	switch req.Form {
+	case "posko":
+		result, err = h.syncService.Rebuild()
+	case "faskes":
+		result, err = h.faskesSyncService.Rebuild()
+	case "infrastruktur":
+		if h.infrastrukturSyncService == nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "SERVICE_NOT_CONFIGURED",
+					Message: "Infrastruktur sync service not configured",
+				},
+			})
+			return
+		}
+		result, err = h.infrastrukturSyncService.Rebuild()
+	}
+	if err != nil {
+		resp := dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "REBUILD_FAILED",
+				Message: err.Error(),
+			},
+		}
+		// A failed sync after the purge still reports what was deleted
+		if result != nil {
+			resp.Data = result
+		}
+		c.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// PurgeDeleted permanently removes records soft-deleted by hard syncs and rebuilds more
+// than ?days= days ago (default PURGE_DELETED_AFTER_DAYS), for one form or all of them
+// @Summary Purge soft-deleted records
+// @Tags admin
+// @Produce json
+// @Param form query string false "posko, feed, faskes or infrastruktur (default all)"
+// @Param days query int false "minimum age in days of the deletions to purge"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.APIResponse
+// @Router /api/v1/admin/purge-deleted [post]
+func (h *SyncHandler) PurgeDeleted(c *gin.Context) {
+	var req struct {
+		Form string `form:"form" binding:"omitempty,oneof=posko feed faskes infrastruktur"`
+		Days *int   `form:"days" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	retention := h.purgeRetention
+	if retention <= 0 {
+		retention = service.DefaultPurgeRetention
+	}
+	if req.Days != nil {
+		retention = time.Duration(*req.Days) * 24 * time.Hour
+	}
+
+	purges := map[string]func(time.Duration) (*service.PurgeResult, error){
+		"posko":  h.syncService.PurgeDeleted,
+		"feed":   h.feedSyncService.PurgeDeleted,
+		"faskes": h.faskesSyncService.PurgeDeleted,
+	}
+	if h.infrastrukturSyncService != nil {
+		purges["infrastruktur"] = h.infrastrukturSyncService.PurgeDeleted
+	}
+
+	forms := []string{"posko", "feed", "faskes", "infrastruktur"}
+	if req.Form != "" {
+		forms = []string{req.Form}
+	}
+
+	results := make([]*service.PurgeResult, 0, len(forms))
+	for _, form := range forms {
+		purge, ok := purges[form]
+		if !ok {
+			if req.Form == "" {
+				continue
+			}
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "SERVICE_NOT_CONFIGURED",
+					Message: "Infrastruktur sync service not configured",
+				},
+			})
+			return
+		}
+		result, err := purge(retention)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{
+				Success: false,
+				Error: &dto.ErrorInfo{
+					Code:    "PURGE_FAILED",
+					Message: err.Error(),
+				},
+				// Forms purged before the failure stay purged
+				Data: results,
+			})
+			return
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{
+		Success: true,
+		Data:    results,
+	})
+}
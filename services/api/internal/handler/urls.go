@@ -0,0 +1,19 @@
+package handler
+
+import "strings"
+
+// publicURLs is embedded by handlers that generate photo/feed URLs, so they can be
+// made absolute. Without a base URL they stay relative.
+type publicURLs struct {
+	publicBaseURL string // e.g. https://api.example.org
+}
+
+// SetPublicBaseURL makes generated URLs absolute, for clients on another origin or behind a path prefix
+func (u *publicURLs) SetPublicBaseURL(base string) {
+	u.publicBaseURL = strings.TrimRight(base, "/")
+}
+
+// absoluteURL prefixes an API path with the configured public base URL
+func (u *publicURLs) absoluteURL(path string) string {
+	return u.publicBaseURL + path
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+)
+
+func init() {
+	// Report fields by their json/form name (what the client sent), not the Go field name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name, _, _ := strings.Cut(fld.Tag.Get(tag), ",")
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return fld.Name
+		})
+	}
+}
+
+// respondValidationError writes a 400 VALIDATION_ERROR. Binding/validation failures are
+// reported per field in Details, e.g. {"minutes": "must be at least 1"}
+func respondValidationError(c *gin.Context, err error) {
+	info := &dto.ErrorInfo{
+		Code:    "VALIDATION_ERROR",
+		Message: "Invalid request",
+	}
+
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		details := make(map[string]interface{}, len(verrs))
+		for _, fe := range verrs {
+			details[fe.Field()] = validationMessage(fe)
+		}
+		info.Details = details
+	} else {
+		info.Message = err.Error()
+	}
+
+	c.JSON(http.StatusBadRequest, dto.APIResponse{
+		Success: false,
+		Error:   info,
+	})
+}
+
+// validationMessage turns a validator field error into a client-facing message
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "latitude", "longitude":
+		return "out of range"
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
@@ -1,28 +1,105 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// APIKeyAuth creates a middleware that validates API key from header or query param
-func APIKeyAuth(validKey string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Skip if no key is configured (empty string means disabled)
-		if validKey == "" {
-			c.Next()
-			return
+// API key scopes
+const (
+	ScopeSync  = "sync"  // trigger syncs (incl. hard sync) and photo downloads
+	ScopeAdmin = "admin" // admin tools, scheduler control, audit log
+	ScopeWrite = "write" // change stored data outside of a sync (photo storage, cache resets)
+	ScopeAll   = "*"
+)
+
+// Context keys set by APIKeyAuth
+const (
+	APIKeyIDContextKey     = "api_key_id" // label of the key that authenticated the request
+	APIKeyScopesContextKey = "api_key_scopes"
+)
+
+// APIKey is a named key and the scopes it may use
+type APIKey struct {
+	Label  string
+	Key    string
+	Scopes []string
+}
+
+// HasScope reports whether the key may call routes guarded by scope
+func (k APIKey) HasScope(scope string) bool {
+	return hasScope(k.Scopes, scope)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAll {
+			return true
 		}
+	}
+	return false
+}
 
-		// Check header first: X-API-Key
-		apiKey := c.GetHeader("X-API-Key")
+// ParseAPIKeys parses "label:key:scopes" entries, scopes separated by "|"
+// (e.g. "cron:s3cret:sync" or "ops:t0ken:sync|admin|write", "*" for all scopes)
+func ParseAPIKeys(entries []string) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(entries))
+	labels := make(map[string]bool)
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid API key entry %q: expected label:key:scopes", redactKeyEntry(entry))
+		}
+		label, key := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if label == "" || key == "" {
+			return nil, fmt.Errorf("invalid API key entry %q: label and key are required", redactKeyEntry(entry))
+		}
+		if labels[label] {
+			return nil, fmt.Errorf("duplicate API key label %q", label)
+		}
+		labels[label] = true
 
-		// Fallback to query param: ?api_key=xxx
-		if apiKey == "" {
-			apiKey = c.Query("api_key")
+		apiKey := APIKey{Label: label, Key: key}
+		for _, scope := range strings.Split(parts[2], "|") {
+			switch scope = strings.TrimSpace(scope); scope {
+			case ScopeSync, ScopeAdmin, ScopeWrite, ScopeAll:
+				apiKey.Scopes = append(apiKey.Scopes, scope)
+			case "":
+			default:
+				return nil, fmt.Errorf("API key %q: unknown scope %q (want %s, %s, %s or %s)", label, scope, ScopeSync, ScopeAdmin, ScopeWrite, ScopeAll)
+			}
+		}
+		if len(apiKey.Scopes) == 0 {
+			return nil, fmt.Errorf("API key %q has no scopes", label)
+		}
+		keys = append(keys, apiKey)
+	}
+	return keys, nil
+}
+
+// redactKeyEntry hides the key part of a config entry for error messages
+func redactKeyEntry(entry string) string {
+	if label, _, ok := strings.Cut(entry, ":"); ok {
+		return label + ":***"
+	}
+	return "***"
+}
+
+// APIKeyAuth creates a middleware that validates API key from header or query param,
+// and attaches the key's label and scopes to the context for RequireScope and the audit log
+func APIKeyAuth(keys []APIKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Skip if no key is configured (empty means disabled)
+		if len(keys) == 0 {
+			c.Next()
+			return
 		}
 
+		apiKey := requestAPIKey(c)
 		if apiKey == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"success": false,
@@ -31,7 +108,8 @@ func APIKeyAuth(validKey string) gin.HandlerFunc {
 			return
 		}
 
-		if apiKey != validKey {
+		matched := matchAPIKey(keys, apiKey)
+		if matched == nil {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"success": false,
 				"error":   "Invalid API key",
@@ -39,6 +117,68 @@ func APIKeyAuth(validKey string) gin.HandlerFunc {
 			return
 		}
 
+		c.Set(APIKeyIDContextKey, matched.Label)
+		c.Set(APIKeyScopesContextKey, matched.Scopes)
+		c.Next()
+	}
+}
+
+// OptionalAPIKey attaches the label and scopes of a valid API key to the context like
+// APIKeyAuth, but lets requests without (or with an invalid) key through, so public
+// routes can show more to authenticated callers
+func OptionalAPIKey(keys []APIKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if matched := matchAPIKey(keys, requestAPIKey(c)); matched != nil {
+			c.Set(APIKeyIDContextKey, matched.Label)
+			c.Set(APIKeyScopesContextKey, matched.Scopes)
+		}
+		c.Next()
+	}
+}
+
+// IsAuthenticated reports whether a valid API key was attached by APIKeyAuth or OptionalAPIKey
+func IsAuthenticated(c *gin.Context) bool {
+	_, ok := c.Get(APIKeyScopesContextKey)
+	return ok
+}
+
+// requestAPIKey reads the key from the X-API-Key header, falling back to ?api_key=
+func requestAPIKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	return c.Query("api_key")
+}
+
+// matchAPIKey returns the configured key equal to apiKey, or nil
+func matchAPIKey(keys []APIKey, apiKey string) *APIKey {
+	if apiKey == "" {
+		return nil
+	}
+	for i := range keys {
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(keys[i].Key)) == 1 {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// RequireScope rejects requests whose API key lacks scope. Use after APIKeyAuth;
+// when API keys are disabled (no key on the context) every request passes.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get(APIKeyScopesContextKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		if scopes, ok := value.([]string); !ok || !hasScope(scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("API key %q lacks the %q scope", c.GetString(APIKeyIDContextKey), scope),
+			})
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+)
+
+// auditSummaryKeys are non-numeric response data fields kept in the summary (affected IDs)
+var auditSummaryKeys = map[string]bool{
+	"id":            true,
+	"form":          true,
+	"form_id":       true,
+	"submission_id": true,
+	"mode":          true,
+}
+
+// AuditLog records every mutating request (anything but GET/HEAD/OPTIONS) that passed
+// the API key check: the label the key was configured with, method and path, status and
+// a summary of the effect taken from the path params and the response data. Keys and
+// request bodies are never stored (they may carry credentials). Use after APIKeyAuth.
+func AuditLog(repo *repository.AuditRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		writer := &responseWriter{
+			ResponseWriter: c.Writer,
+			body:           bytes.NewBuffer(nil),
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		entry := &model.AuditLog{
+			CreatedAt:  start,
+			KeyID:      c.GetString(APIKeyIDContextKey),
+			ClientIP:   c.ClientIP(),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Query:      auditQuery(c.Request.URL.Query()),
+			Status:     writer.Status(),
+			DurationMs: time.Since(start).Milliseconds(),
+			Summary:    auditSummary(c.Params, writer.body.Bytes()),
+		}
+		if entry.KeyID == "" {
+			entry.KeyID = "none" // API key not configured
+		}
+
+		// Written in the background so the audit insert never delays or fails the request
+		go func() {
+			if err := repo.Create(entry); err != nil {
+				log.Printf("Warning: failed to write audit log for %s %s: %v", entry.Method, entry.Path, err)
+			}
+		}()
+	}
+}
+
+// auditQuery encodes the query string without the api_key param
+func auditQuery(query url.Values) string {
+	query.Del("api_key")
+	return query.Encode()
+}
+
+// auditSummary collects path params, the error code of a failed request, and the
+// numeric fields and IDs of the response data (e.g. created/updated/deleted counts)
+func auditSummary(params gin.Params, body []byte) model.JSONB {
+	summary := model.JSONB{}
+	for _, p := range params {
+		summary[p.Key] = p.Value
+	}
+
+	var resp struct {
+		Data  json.RawMessage `json:"data"`
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) == nil {
+		var data map[string]interface{}
+		if json.Unmarshal(resp.Data, &data) == nil {
+			for k, v := range data {
+				switch v.(type) {
+				case float64:
+					summary[k] = v
+				case string:
+					if auditSummaryKeys[k] {
+						summary[k] = v
+					}
+				}
+			}
+		}
+
+		// dto.ErrorInfo carries a code; photo handlers return a plain message
+		var errInfo struct {
+			Code string `json:"code"`
+		}
+		var errMsg string
+		if json.Unmarshal(resp.Error, &errInfo) == nil && errInfo.Code != "" {
+			summary["error"] = errInfo.Code
+		} else if json.Unmarshal(resp.Error, &errMsg) == nil && errMsg != "" {
+			summary["error"] = errMsg
+		}
+	}
+
+	if len(summary) == 0 {
+		return nil
+	}
+	return summary
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/repository"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// The audit log names the key by its configured label, so the key itself never reaches the table
+func TestAuditLogRecordsKeyLabel(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	entries := make(chan *model.AuditLog, 1)
+	db.Callback().Create().After("gorm:create").Register("test:audit_entries", func(tx *gorm.DB) {
+		if entry, ok := tx.Statement.Dest.(*model.AuditLog); ok {
+			entries <- entry
+		}
+	})
+
+	keys := []APIKey{{Label: "ops", Key: "s3cret", Scopes: []string{ScopeAdmin}}}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(APIKeyAuth(keys), AuditLog(repository.NewAuditRepository(db)))
+	r.POST("/admin/remap", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/remap?api_key=s3cret", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case entry := <-entries:
+		if entry.KeyID != "ops" {
+			t.Errorf("key_id = %q, want the key label ops", entry.KeyID)
+		}
+		if entry.Query != "" {
+			t.Errorf("query = %q, want the api_key param dropped", entry.Query)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no audit entry written")
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+)
+
+// DefaultMaxBodyBytes is the default request body limit for write endpoints (1MB)
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// BodyLimit caps the request body size of write requests (POST, PUT, PATCH, DELETE).
+// Bodies over maxBytes are rejected with 413 before reaching the handler.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			abortBodyTooLarge(c, maxBytes)
+			return
+		}
+
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			// Read through MaxBytesReader so bodies without (or with a lying) Content-Length
+			// are also caught here, then hand the buffered body to the handler
+			body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					abortBodyTooLarge(c, maxBytes)
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, dto.APIResponse{
+					Success: false,
+					Error: &dto.ErrorInfo{
+						Code:    "INVALID_BODY",
+						Message: "Failed to read request body",
+					},
+				})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		c.Next()
+	}
+}
+
+func abortBodyTooLarge(c *gin.Context, maxBytes int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, dto.APIResponse{
+		Success: false,
+		Error: &dto.ErrorInfo{
+			Code:    "PAYLOAD_TOO_LARGE",
+			Message: "Request body too large",
+			Details: map[string]interface{}{
+				"max_bytes": maxBytes,
+			},
+		},
+	})
+}
@@ -157,6 +157,13 @@ func (cache *Cache) Middleware() gin.HandlerFunc {
 			return
 		}
 
+		// Authenticated callers may see more (e.g. non-public photo types); never share their
+		// responses. Only a valid key counts, so an arbitrary one can't bypass the cache.
+		if IsAuthenticated(c) {
+			c.Next()
+			return
+		}
+
 		// Skip caching for certain paths
 		path := c.Request.URL.Path
 		skipPaths := []string{"/health", "/ready", "/api/v1/events", "/api/v1/sync", "/api/v1/scheduler"}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// DefaultWarmPaths are the list queries the map loads on first paint
+var DefaultWarmPaths = []string{"/api/v1/locations", "/api/v1/faskes"}
+
+// CacheWarmer clears the response cache after a sync and, when enabled, immediately
+// re-issues common GET queries through the router so the first visitors get a HIT.
+// Triggers arriving while a warm-up runs are coalesced into one follow-up run.
+type CacheWarmer struct {
+	cache   *Cache
+	handler http.Handler
+	paths   []string
+	warm    bool
+
+	trigger chan struct{}
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewCacheWarmer creates a warmer; handler is the router serving the cached routes.
+// With warm false it only invalidates the cache. Call Start to run the worker.
+func NewCacheWarmer(cache *Cache, handler http.Handler, paths []string, warm bool) *CacheWarmer {
+	if len(paths) == 0 {
+		paths = DefaultWarmPaths
+	}
+	return &CacheWarmer{
+		cache:   cache,
+		handler: handler,
+		paths:   paths,
+		warm:    warm,
+		trigger: make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start launches the background worker
+func (w *CacheWarmer) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-w.trigger:
+				w.run()
+			}
+		}
+	}()
+}
+
+// Stop waits for a running warm-up to finish and stops the worker
+func (w *CacheWarmer) Stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+// Trigger schedules an invalidation (and warm-up) without blocking
+func (w *CacheWarmer) Trigger() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+		// A run is already pending, it will pick up this change too
+	}
+}
+
+func (w *CacheWarmer) run() {
+	w.cache.Clear()
+	if !w.warm || w.handler == nil {
+		return
+	}
+
+	start := time.Now()
+	warmed := 0
+	for _, path := range w.paths {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			log.Printf("Cache warm: invalid path %q: %v", path, err)
+			continue
+		}
+		req.RemoteAddr = "127.0.0.1:0"
+		rec := httptest.NewRecorder()
+		w.handler.ServeHTTP(rec, req)
+		if rec.Code < 200 || rec.Code >= 300 {
+			log.Printf("Cache warm: %s returned %d", path, rec.Code)
+			continue
+		}
+		warmed++
+	}
+	log.Printf("Cache warm: %d/%d paths in %s", warmed, len(w.paths), time.Since(start))
+}
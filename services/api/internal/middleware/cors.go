@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS wildcard policies: what to do when CORS_ORIGINS allows any origin ("*") while
+// credentials are allowed, a combination browsers reject
+const (
+	CORSWildcardReject = "reject" // refuse to start
+	CORSWildcardWarn   = "warn"   // log a warning and allow any origin without credentials
+)
+
+// CORS returns the CORS middleware for the allowed origins: exact origins
+// ("https://dayawarga.com"), subdomain patterns ("https://*.dayawarga.com", matching
+// any subdomain but not the domain itself) or "*" for any origin.
+func CORS(origins []string, allowCredentials bool, wildcardPolicy string) (gin.HandlerFunc, error) {
+	switch wildcardPolicy {
+	case "":
+		wildcardPolicy = CORSWildcardReject
+	case CORSWildcardReject, CORSWildcardWarn:
+	default:
+		return nil, fmt.Errorf("unknown CORS wildcard policy %q (want %s or %s)", wildcardPolicy, CORSWildcardReject, CORSWildcardWarn)
+	}
+
+	config := cors.Config{
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length", "X-Cache", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
+		AllowCredentials: allowCredentials,
+		MaxAge:           12 * time.Hour,
+	}
+
+	exact := make(map[string]bool)
+	var subdomains []subdomainOrigin
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch {
+		case origin == "*":
+			config.AllowAllOrigins = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(origin, "://*.")
+			if domain == "" || strings.Contains(domain, "*") {
+				return nil, fmt.Errorf("invalid CORS origin pattern %q (want scheme://*.domain)", origin)
+			}
+			subdomains = append(subdomains, subdomainOrigin{prefix: scheme + "://", suffix: "." + domain})
+		case strings.Contains(origin, "*"):
+			return nil, fmt.Errorf("invalid CORS origin %q: only a leading subdomain wildcard (scheme://*.domain) or * is supported", origin)
+		case !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://"):
+			return nil, fmt.Errorf("invalid CORS origin %q (want http:// or https://)", origin)
+		default:
+			exact[origin] = true
+		}
+	}
+
+	if config.AllowAllOrigins {
+		if allowCredentials {
+			if wildcardPolicy == CORSWildcardReject {
+				return nil, fmt.Errorf("CORS origin * cannot be combined with credentials; list the origins explicitly, " +
+					"use a subdomain pattern (https://*.example.com), set CORS_ALLOW_CREDENTIALS=false or CORS_WILDCARD_POLICY=warn")
+			}
+			log.Printf("WARNING: CORS origin * is combined with credentials, which browsers reject; allowing any origin WITHOUT credentials")
+			config.AllowCredentials = false
+		}
+		return cors.New(config), nil
+	}
+
+	if len(exact) == 0 && len(subdomains) == 0 {
+		return nil, fmt.Errorf("no CORS origins configured")
+	}
+
+	// Matched per request instead of a literal *, so credentials keep working
+	config.AllowOriginFunc = func(origin string) bool {
+		if exact[origin] {
+			return true
+		}
+		for _, p := range subdomains {
+			if p.matches(origin) {
+				return true
+			}
+		}
+		return false
+	}
+	return cors.New(config), nil
+}
+
+// subdomainOrigin is a "scheme://*.domain" origin pattern
+type subdomainOrigin struct {
+	prefix string // "https://"
+	suffix string // ".dayawarga.com"
+}
+
+func (p subdomainOrigin) matches(origin string) bool {
+	host, ok := strings.CutPrefix(origin, p.prefix)
+	return ok && len(host) > len(p.suffix) && strings.HasSuffix(host, p.suffix) && !strings.Contains(host, "/")
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leksa/datamapper-senyar/internal/dto"
+)
+
+// DefaultMaintenanceMessage is returned by blocked requests when no message was given
+const DefaultMaintenanceMessage = "The API is in maintenance mode: syncs and changes are disabled, data stays readable"
+
+// Maintenance is the read-only maintenance mode switch. While it is enabled, BlockWrites
+// answers requests that sync or change data with 503; reads are served as usual.
+// Safe for concurrent use.
+type Maintenance struct {
+	mu      sync.RWMutex
+	enabled bool
+	message string
+	since   time.Time
+}
+
+// MaintenanceStatus is the current state of the maintenance mode
+type MaintenanceStatus struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// NewMaintenance creates the maintenance switch, initially enabled or not
+func NewMaintenance(enabled bool) *Maintenance {
+	m := &Maintenance{}
+	m.Set(enabled, "")
+	return m
+}
+
+// Set enables or disables maintenance mode. message replaces DefaultMaintenanceMessage
+// in the 503 responses when not empty.
+func (m *Maintenance) Set(enabled bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if enabled && !m.enabled {
+		m.since = time.Now()
+	}
+	m.enabled = enabled
+	m.message = ""
+	if enabled {
+		m.message = DefaultMaintenanceMessage
+		if message != "" {
+			m.message = message
+		}
+	}
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *Maintenance) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.enabled
+}
+
+// Status returns the current state
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := MaintenanceStatus{Enabled: m.enabled, Message: m.message}
+	if m.enabled {
+		since := m.since
+		status.Since = &since
+	}
+	return status
+}
+
+// BlockWrites rejects every request except GET, HEAD and OPTIONS with 503 while
+// maintenance mode is on
+func (m *Maintenance) BlockWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		status := m.Status()
+		if !status.Enabled {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, dto.APIResponse{
+			Success: false,
+			Error: &dto.ErrorInfo{
+				Code:    "MAINTENANCE_MODE",
+				Message: status.Message,
+			},
+		})
+	}
+}
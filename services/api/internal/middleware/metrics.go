@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the request duration
+// histogram (the Prometheus client defaults)
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts requests and records their latency per route, method and status, and
+// serves them in the Prometheus text format. The route is the matched template
+// (/api/v1/locations/:id), never the raw path, so IDs don't multiply the series.
+// It also counts how often posko syncs resolve entity IDs through the submission ID fallback.
+type Metrics struct {
+	mu      sync.Mutex
+	buckets []float64
+	series  map[requestKey]*requestSeries
+
+	entitySubmissions uint64 // posko submissions whose entity ID was resolved
+	entityFallbacks   uint64 // of those, resolved through the submission ID fallback
+}
+
+type requestKey struct {
+	route  string
+	method string
+	status string
+}
+
+type requestSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // observations per bucket, made cumulative when rendered
+}
+
+// NewMetrics creates an empty request metrics registry with DefaultLatencyBuckets
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets: DefaultLatencyBuckets,
+		series:  make(map[requestKey]*requestSeries),
+	}
+}
+
+// Middleware records every request once it has been handled. Requests matching no route
+// are recorded as route "unmatched". A panicking handler is recorded as the 500 the
+// recovery middleware answers with (unless it already wrote its status), then re-panics.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		defer func() {
+			if err := recover(); err != nil {
+				status := http.StatusInternalServerError
+				if c.Writer.Written() {
+					status = c.Writer.Status()
+				}
+				m.observeRequest(c, status, start)
+				panic(err)
+			}
+		}()
+
+		c.Next()
+		m.observeRequest(c, c.Writer.Status(), start)
+	}
+}
+
+func (m *Metrics) observeRequest(c *gin.Context, status int, start time.Time) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	m.observe(requestKey{
+		route:  route,
+		method: metricsMethod(c.Request.Method),
+		status: strconv.Itoa(status),
+	}, time.Since(start).Seconds())
+}
+
+// CountEntityResolutions adds a posko sync's submissions and how many of them resolved
+// their entity ID through the submission ID fallback
+func (m *Metrics) CountEntityResolutions(fallbacks, total int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entityFallbacks += uint64(fallbacks)
+	m.entitySubmissions += uint64(total)
+}
+
+// metricsMethod keeps client-chosen methods from adding series: non-standard ones are "OTHER"
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
+func (m *Metrics) observe(key requestKey, seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.series[key]
+	if !ok {
+		s = &requestSeries{buckets: make([]uint64, len(m.buckets))}
+		m.series[key] = s
+	}
+	s.count++
+	s.sum += seconds
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			s.buckets[i]++
+			break
+		}
+	}
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (m *Metrics) Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", m.render())
+}
+
+func (m *Metrics) render() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("# HELP http_requests_total Total HTTP requests by route, method and status.\n")
+	buf.WriteString("# TYPE http_requests_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "http_requests_total{%s} %d\n", key.labels(), m.series[key].count)
+	}
+
+	buf.WriteString("# HELP http_request_duration_seconds HTTP request latency by route, method and status.\n")
+	buf.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		s := m.series[key]
+		labels := key.labels()
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(&buf, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(upper, 'f', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&buf, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.count)
+		fmt.Fprintf(&buf, "http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(s.sum, 'f', -1, 64))
+		fmt.Fprintf(&buf, "http_request_duration_seconds_count{%s} %d\n", labels, s.count)
+	}
+
+	buf.WriteString("# HELP sync_entity_submissions_total Posko submissions whose entity ID was resolved by a sync.\n")
+	buf.WriteString("# TYPE sync_entity_submissions_total counter\n")
+	fmt.Fprintf(&buf, "sync_entity_submissions_total %d\n", m.entitySubmissions)
+	buf.WriteString("# HELP sync_entity_fallbacks_total Posko submissions whose entity ID fell back to the submission ID.\n")
+	buf.WriteString("# TYPE sync_entity_fallbacks_total counter\n")
+	fmt.Fprintf(&buf, "sync_entity_fallbacks_total %d\n", m.entityFallbacks)
+	return buf.Bytes()
+}
+
+// labelEscaper escapes label values as the Prometheus text format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (k requestKey) labels() string {
+	return fmt.Sprintf(`route="%s",method="%s",status="%s"`,
+		labelEscaper.Replace(k.route), k.method, k.status)
+}
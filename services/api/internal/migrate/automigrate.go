@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"github.com/leksa/datamapper-senyar/internal/odk"
+	"gorm.io/gorm"
+)
+
+// AutoMigrateModels runs GORM AutoMigrate for the model structs.
+// DEV ONLY: production schema is managed by the SQL migrations, which also own the
+// PostGIS geom columns. The model structs don't declare geom (coordinates are gorm:"-"),
+// so AutoMigrate never creates, alters or drops it; on a fresh database run -migrate first.
+func AutoMigrateModels(db *gorm.DB) error {
+	models := []interface{}{
+		&model.Location{},
+		&model.LocationPhoto{},
+		&model.Feed{},
+		&model.FeedPhoto{},
+		&model.Faskes{},
+		&model.FaskesPhoto{},
+		&model.Infrastruktur{},
+		&model.InfrastrukturPhoto{},
+		&model.InfrastrukturHistory{},
+		&model.SyncRun{},
+		&model.AuditLog{},
+		&model.MigrationJob{},
+		&odk.SyncState{},
+		&odk.EntityMapping{},
+	}
+
+	for _, m := range models {
+		if err := db.AutoMigrate(m); err != nil {
+			return fmt.Errorf("auto-migrate %T: %w", m, err)
+		}
+	}
+
+	log.Printf("AutoMigrate completed for %d models", len(models))
+	return nil
+}
@@ -0,0 +1,217 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a single versioned SQL file
+type Migration struct {
+	Version int64
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// SchemaMigration tracks applied migration versions
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Runner applies embedded SQL migrations in version order
+type Runner struct {
+	db   *gorm.DB
+	fsys fs.FS
+}
+
+func NewRunner(db *gorm.DB, fsys fs.FS) *Runner {
+	return &Runner{db: db, fsys: fsys}
+}
+
+// Load reads all *.sql files from fsys, sorted by version.
+// File names must start with a numeric version, e.g. 000009_add_checksum.sql
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		versionStr, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: version must be numeric", file)
+		}
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, prev, file)
+		}
+		seen[version] = file
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			SQL:     string(content),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// ensureTable creates the schema_migrations table if needed
+func (r *Runner) ensureTable() error {
+	return r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`).Error
+}
+
+// applied returns applied migrations keyed by version
+func (r *Runner) applied() (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := r.db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	result := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Up applies all pending migrations in order, each in its own transaction.
+// It stops at the first failure and returns the migrations applied so far.
+func (r *Runner) Up() ([]Migration, error) {
+	migrations, err := Load(r.fsys)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ensureTable(); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	done, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %06d_%s", m.Version, m.Name)
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.SQL).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %06d_%s failed: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+// Baseline records the migrations up to and including version as applied without
+// running them, for a database whose schema was set up by hand before the runner
+// existed. It does nothing once any migration is recorded, or on an empty database
+// (no locations table), where Up must run every migration.
+func (r *Runner) Baseline(version int64) ([]Migration, error) {
+	migrations, err := Load(r.fsys)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ensureTable(); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	done, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+	if len(done) > 0 || !r.db.Migrator().HasTable("locations") {
+		return nil, nil
+	}
+
+	var recorded []Migration
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range migrations {
+			if m.Version > version {
+				break
+			}
+			if err := tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error; err != nil {
+				return err
+			}
+			recorded = append(recorded, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record baseline: %w", err)
+	}
+	return recorded, nil
+}
+
+// Status lists all known migrations with their applied state
+func (r *Runner) Status() ([]MigrationStatus, error) {
+	migrations, err := Load(r.fsys)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ensureTable(); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	done, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		result[i] = MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := done[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			result[i].Applied = true
+			result[i].AppliedAt = &appliedAt
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog is one authenticated mutating request against the protected API
+type AuditLog struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at;index:idx_audit_log_created"`
+	KeyID      string    `json:"key_id" gorm:"column:key_id"` // label of the API key (API_KEYS label:key:scopes), never the key itself
+	ClientIP   string    `json:"client_ip" gorm:"column:client_ip"`
+	Method     string    `json:"method" gorm:"column:method;not null"`
+	Path       string    `json:"path" gorm:"column:path;not null"`
+	Query      string    `json:"query,omitempty" gorm:"column:query"`
+	Status     int       `json:"status" gorm:"column:status"`
+	DurationMs int64     `json:"duration_ms" gorm:"column:duration_ms"`
+	// Summary holds the effect: path params plus the counts/IDs reported in the response data
+	Summary JSONB `json:"summary,omitempty" gorm:"column:summary;type:jsonb"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
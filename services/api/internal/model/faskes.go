@@ -19,6 +19,10 @@ type Faskes struct {
 	Latitude  *float64 `json:"latitude,omitempty" gorm:"-"`
 	Longitude *float64 `json:"longitude,omitempty" gorm:"-"`
 
+	// InvalidCoordinates is set by the mapper when the submission's coordinates were out of
+	// range and dropped (see validateCoords); not stored
+	InvalidCoordinates bool `json:"-" gorm:"-"`
+
 	// JSONB fields
 	Alamat        JSONB `json:"alamat,omitempty" gorm:"type:jsonb"`
 	Identitas     JSONB `json:"identitas,omitempty" gorm:"type:jsonb"`
@@ -52,6 +56,22 @@ type FaskesPhoto struct {
 	IsCached    bool      `json:"is_cached" gorm:"default:false"`
 	FileSize    *int      `json:"file_size,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// ThumbnailPath is the stored preview (max 400px long edge), nil for non-images
+	ThumbnailPath *string `json:"thumbnail_path,omitempty" gorm:"column:thumbnail_path"`
+
+	// Width and Height are the image size in pixels read from its header, nil for
+	// non-images and photos cached before dimensions were recorded
+	Width  *int `json:"width,omitempty" gorm:"column:width"`
+	Height *int `json:"height,omitempty" gorm:"column:height"`
+
+	// ContentType is the MIME type sniffed from the data at download, nil for photos
+	// cached before it was recorded (served by their extension)
+	ContentType *string `json:"content_type,omitempty" gorm:"column:content_type"`
+
+	// RejectedContentType is the type the attachment was rejected with by the content type
+	// allowlist; photo syncs skip it while the type stays disallowed
+	RejectedContentType *string `json:"rejected_content_type,omitempty" gorm:"column:rejected_content_type"`
 }
 
 func (FaskesPhoto) TableName() string {
@@ -25,9 +25,13 @@ type Feed struct {
 
 	RawData JSONB `json:"raw_data,omitempty" gorm:"type:jsonb;column:raw_data"`
 
+	// ContentTruncated is set when Content was cut to FEED_CONTENT_MAX_LENGTH at sync
+	ContentTruncated bool `json:"content_truncated,omitempty" gorm:"column:content_truncated"`
+
 	SubmittedAt *time.Time `json:"submitted_at,omitempty" gorm:"column:submitted_at"`
 	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"column:deleted_at"`
 
 	// Joined fields
 	LocationName *string `json:"location_name,omitempty" gorm:"-"`
@@ -51,6 +55,22 @@ type FeedPhoto struct {
 	IsCached    bool      `json:"is_cached" gorm:"default:false"`
 	FileSize    *int      `json:"file_size,omitempty"`
 	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+
+	// ThumbnailPath is the stored preview (max 400px long edge), nil for non-images
+	ThumbnailPath *string `json:"thumbnail_path,omitempty" gorm:"column:thumbnail_path"`
+
+	// Width and Height are the image size in pixels read from its header, nil for
+	// non-images and photos cached before dimensions were recorded
+	Width  *int `json:"width,omitempty" gorm:"column:width"`
+	Height *int `json:"height,omitempty" gorm:"column:height"`
+
+	// ContentType is the MIME type sniffed from the data at download, nil for photos
+	// cached before it was recorded (served by their extension)
+	ContentType *string `json:"content_type,omitempty" gorm:"column:content_type"`
+
+	// RejectedContentType is the type the attachment was rejected with by the content type
+	// allowlist; photo syncs skip it while the type stays disallowed
+	RejectedContentType *string `json:"rejected_content_type,omitempty" gorm:"column:rejected_content_type"`
 }
 
 func (FeedPhoto) TableName() string {
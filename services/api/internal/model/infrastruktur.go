@@ -66,8 +66,37 @@ type InfrastrukturPhoto struct {
 	IsCached        bool      `json:"is_cached" gorm:"default:false"`
 	FileSize        *int      `json:"file_size,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	// Width and Height are the image size in pixels read from its header, nil for
+	// non-images and photos cached before dimensions were recorded
+	Width  *int `json:"width,omitempty" gorm:"column:width"`
+	Height *int `json:"height,omitempty" gorm:"column:height"`
+
+	// ContentType is the MIME type sniffed from the data at download, nil for photos
+	// cached before it was recorded (served by their extension)
+	ContentType *string `json:"content_type,omitempty" gorm:"column:content_type"`
+
+	// RejectedContentType is the type the attachment was rejected with by the content type
+	// allowlist; photo syncs skip it while the type stays disallowed
+	RejectedContentType *string `json:"rejected_content_type,omitempty" gorm:"column:rejected_content_type"`
 }
 
 func (InfrastrukturPhoto) TableName() string {
 	return "infrastruktur_photos"
 }
+
+// InfrastrukturHistory is one recorded progress update (one approved ODK submission) of an infrastruktur
+type InfrastrukturHistory struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	InfrastrukturID  uuid.UUID  `json:"infrastruktur_id" gorm:"type:uuid;not null;uniqueIndex:uq_infrastruktur_history_submission"`
+	ODKSubmissionID  string     `json:"odk_submission_id" gorm:"column:odk_submission_id;not null;uniqueIndex:uq_infrastruktur_history_submission"`
+	Progress         int        `json:"progress" gorm:"column:progress"`
+	StatusPenanganan string     `json:"status_penanganan" gorm:"column:status_penanganan"`
+	UpdateBy         string     `json:"update_by" gorm:"column:update_by"`
+	SubmittedAt      *time.Time `json:"submitted_at,omitempty" gorm:"column:submitted_at"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+func (InfrastrukturHistory) TableName() string {
+	return "infrastruktur_history"
+}
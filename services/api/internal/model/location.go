@@ -4,6 +4,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,19 +30,44 @@ func (j *JSONB) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, j)
 }
 
+// Number returns the numeric value at key. Besides JSON numbers it accepts
+// string-encoded numbers (e.g. "12" after a manual edit or import), so those
+// are not silently read as zero. ok is false if the key is missing or not numeric.
+func (j JSONB) Number(key string) (float64, bool) {
+	switch v := j[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
 // Location represents a posko/shelter location
 type Location struct {
 	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	ODKSubmissionID *string    `json:"odk_submission_id,omitempty" gorm:"column:odk_submission_id"`
 	Nama            string     `json:"nama" gorm:"not null"`
 	Type            string     `json:"type" gorm:"default:'posko'"`
-	Status          string     `json:"status" gorm:"default:'operational'"`
+	Status          string     `json:"status" gorm:"default:'operasional'"`
 
 	// Geometry stored as WKT for simplicity, will be converted to GeoJSON in response
 	Latitude  *float64 `json:"latitude,omitempty" gorm:"-"`
 	Longitude *float64 `json:"longitude,omitempty" gorm:"-"`
 	GeoMeta   JSONB    `json:"geo_meta,omitempty" gorm:"type:jsonb"`
 
+	// InvalidCoordinates is set by the mapper when the submission's coordinates were out of
+	// range and dropped (see validateCoords); not stored
+	InvalidCoordinates bool `json:"-" gorm:"-"`
+
 	// JSONB fields
 	Identitas     JSONB `json:"identitas,omitempty" gorm:"type:jsonb"`
 	Alamat        JSONB `json:"alamat,omitempty" gorm:"type:jsonb"`
@@ -52,6 +79,17 @@ type Location struct {
 
 	// Source info
 	BaselineSumber string `json:"baseline_sumber" gorm:"column:baseline_sumber"`
+	Source         string `json:"source" gorm:"column:source;default:'manual'"` // provenance: odk_live, odk_dump or manual
+
+	// Demographic totals precomputed from DataPengungsi at sync time (JSONB stays the source of truth)
+	JumlahKK        int `json:"jumlah_kk" gorm:"column:jumlah_kk;default:0"`
+	TotalJiwa       int `json:"total_jiwa" gorm:"column:total_jiwa;default:0"`
+	JumlahPerempuan int `json:"jumlah_perempuan" gorm:"column:jumlah_perempuan;default:0"`
+	JumlahLaki      int `json:"jumlah_laki" gorm:"column:jumlah_laki;default:0"`
+	JumlahBalita    int `json:"jumlah_balita" gorm:"column:jumlah_balita;default:0"`
+
+	// SubmissionCount is the number of approved ODK submissions for this entity
+	SubmissionCount int `json:"submission_count" gorm:"column:submission_count;default:1"`
 
 	// Metadata
 	SubmitterName *string    `json:"submitter_name,omitempty" gorm:"column:submitter_name"`
@@ -62,6 +100,13 @@ type Location struct {
 	DeletedAt     *time.Time `json:"deleted_at,omitempty" gorm:"column:deleted_at"`
 }
 
+// Location record provenance (locations.source)
+const (
+	SourceODKLive = "odk_live" // live form submission, with final_* calculated fields
+	SourceODKDump = "odk_dump" // data dump, nested grp_* fields only
+	SourceManual  = "manual"   // not created from an ODK submission
+)
+
 func (Location) TableName() string {
 	return "locations"
 }
@@ -76,6 +121,27 @@ type LocationPhoto struct {
 	IsCached    bool      `json:"is_cached" gorm:"default:false"`
 	FileSize    *int      `json:"file_size,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// Source submission of the attachment and SHA-256 of the cached file, used to detect
+	// an edited submission reusing a filename for a different image
+	ODKSubmissionID *string `json:"odk_submission_id,omitempty" gorm:"column:odk_submission_id"`
+	Checksum        *string `json:"checksum,omitempty" gorm:"column:checksum"`
+
+	// ThumbnailPath is the stored preview (max 400px long edge), nil for non-images
+	ThumbnailPath *string `json:"thumbnail_path,omitempty" gorm:"column:thumbnail_path"`
+
+	// Width and Height are the image size in pixels read from its header, nil for
+	// non-images and photos cached before dimensions were recorded
+	Width  *int `json:"width,omitempty" gorm:"column:width"`
+	Height *int `json:"height,omitempty" gorm:"column:height"`
+
+	// ContentType is the MIME type sniffed from the data at download, nil for photos
+	// cached before it was recorded (served by their extension)
+	ContentType *string `json:"content_type,omitempty" gorm:"column:content_type"`
+
+	// RejectedContentType is the type the attachment was rejected with by the content type
+	// allowlist; photo syncs skip it while the type stays disallowed
+	RejectedContentType *string `json:"rejected_content_type,omitempty" gorm:"column:rejected_content_type"`
 }
 
 func (LocationPhoto) TableName() string {
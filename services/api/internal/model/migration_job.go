@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Migration job statuses
+const (
+	MigrationJobRunning     = "running"
+	MigrationJobCompleted   = "completed"
+	MigrationJobInterrupted = "interrupted" // the process stopped before the job finished
+)
+
+// MigrationJob is the progress of one local-to-S3 photo migration, updated per photo
+type MigrationJob struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Status          string     `json:"status" gorm:"column:status;not null"`
+	Total           int        `json:"total" gorm:"column:total"`
+	Migrated        int        `json:"migrated" gorm:"column:migrated"`
+	Errors          int        `json:"errors" gorm:"column:errors"`
+	LastTable       *string    `json:"last_table,omitempty" gorm:"column:last_table"`
+	LastProcessedID *uuid.UUID `json:"last_processed_id,omitempty" gorm:"column:last_processed_id;type:uuid"`
+	StartedAt       time.Time  `json:"started_at" gorm:"column:started_at"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"column:updated_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty" gorm:"column:finished_at"`
+}
+
+func (MigrationJob) TableName() string {
+	return "migration_jobs"
+}
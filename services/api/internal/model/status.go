@@ -0,0 +1,77 @@
+package model
+
+import "strings"
+
+// Canonical posko (location) status values
+const (
+	LocationStatusOperasional      = "operasional"
+	LocationStatusNonAktif         = "non_aktif"
+	LocationStatusEvakuasi         = "evakuasi"
+	LocationStatusPersiapanHuntara = "persiapan_huntara"
+)
+
+// Canonical faskes status values
+const (
+	FaskesStatusOperasional = "operasional"
+	FaskesStatusNonAktif    = "non_aktif"
+)
+
+// LocationStatuses lists the canonical posko statuses
+var LocationStatuses = []string{
+	LocationStatusOperasional,
+	LocationStatusNonAktif,
+	LocationStatusEvakuasi,
+	LocationStatusPersiapanHuntara,
+}
+
+// raw_data keys of a posko status set by hand (PATCH /locations/:id). Syncs keep the
+// override until the status mapped from ODK differs from the one it replaced.
+const (
+	RawDataODKStatus             = "_odk_status"                 // status mapped from the synced submission
+	RawDataManualOverride        = "_manual_override"            // true while the status is overridden
+	RawDataManualOverrideODKBase = "_manual_override_odk_status" // ODK status when the override was set
+)
+
+// IsLocationStatus reports whether status is a canonical posko status
+func IsLocationStatus(status string) bool {
+	for _, s := range LocationStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// FaskesStatuses lists the canonical faskes statuses
+var FaskesStatuses = []string{
+	FaskesStatusOperasional,
+	FaskesStatusNonAktif,
+}
+
+// statusAliases maps known spelling variants (already lowercased, underscored) to canonical values
+var statusAliases = map[string]string{
+	"operational": "operasional",
+	"aktif":       "operasional",
+	"active":      "operasional",
+	"nonaktif":    "non_aktif",
+	"non_active":  "non_aktif",
+	"inactive":    "non_aktif",
+	"tidak_aktif": "non_aktif",
+	"tutup":       "non_aktif",
+	"closed":      "non_aktif",
+	"evacuation":  "evakuasi",
+	"huntara":     "persiapan_huntara",
+	"persiapan":   "persiapan_huntara",
+	"pre_huntara": "persiapan_huntara",
+}
+
+// NormalizeStatus lowercases a status, turns spaces/hyphens into underscores and maps
+// known variants to their canonical spelling. Unknown values are returned normalized.
+func NormalizeStatus(status string) string {
+	s := strings.ToLower(strings.TrimSpace(status))
+	s = strings.NewReplacer(" ", "_", "-", "_").Replace(s)
+	if canonical, ok := statusAliases[s]; ok {
+		return canonical
+	}
+	return s
+}
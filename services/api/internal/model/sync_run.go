@@ -0,0 +1,61 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sync run modes
+const (
+	SyncRunModeFull        = "full"
+	SyncRunModeIncremental = "incremental"
+	SyncRunModeHard        = "hard"
+)
+
+// SyncRunChanges lists the names of the records a sync run touched.
+// Changed are existing records that received a newer submission.
+type SyncRunChanges struct {
+	Created []string `json:"created,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+func (c SyncRunChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *SyncRunChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = SyncRunChanges{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// SyncRun is one completed sync of a form, kept so consecutive runs can be compared
+type SyncRun struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	FormID       string         `json:"form_id" gorm:"column:form_id;not null;index:idx_sync_runs_form_finished"`
+	Mode         string         `json:"mode" gorm:"column:mode;not null"`
+	StartedAt    time.Time      `json:"started_at" gorm:"column:started_at"`
+	FinishedAt   time.Time      `json:"finished_at" gorm:"column:finished_at;index:idx_sync_runs_form_finished"`
+	TotalFetched int            `json:"total_fetched" gorm:"column:total_fetched"`
+	Created      int            `json:"created" gorm:"column:created"`
+	Updated      int            `json:"updated" gorm:"column:updated"`
+	Changed      int            `json:"changed" gorm:"column:changed"`
+	Deleted      int            `json:"deleted" gorm:"column:deleted"`
+	Errors       int            `json:"errors" gorm:"column:errors"`
+	Changes      SyncRunChanges `json:"changes" gorm:"column:changes;type:jsonb"`
+}
+
+func (SyncRun) TableName() string {
+	return "sync_runs"
+}
@@ -0,0 +1,176 @@
+package odk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// StatusError is a non-2xx response from ODK Central
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// ResourceCheck reports whether a configured form or entity dataset exists in the project
+type ResourceCheck struct {
+	Name  string `json:"name"`
+	ID    string `json:"id"`
+	Found bool   `json:"found"`
+}
+
+// ConnectionCheck is the result of CheckConnection. Errors holds one readable message per failure.
+type ConnectionCheck struct {
+	BaseURL       string          `json:"base_url"`
+	ProjectID     int             `json:"project_id"`
+	AuthMode      string          `json:"auth_mode"`
+	OK            bool            `json:"ok"`
+	Authenticated bool            `json:"authenticated"`
+	ProjectFound  bool            `json:"project_found"`
+	ProjectName   string          `json:"project_name,omitempty"`
+	Forms         []ResourceCheck `json:"forms"`
+	Datasets      []ResourceCheck `json:"datasets"`
+	Errors        []string        `json:"errors,omitempty"`
+}
+
+// CheckConnection authenticates against ODK Central with config and verifies that the project,
+// the given forms (name -> xmlFormId) and entity datasets exist. It uses its own client, so
+// trial credentials never replace the session of a running client. The checks stop when ctx ends.
+func CheckConnection(ctx context.Context, config ODKConfig, forms map[string]string, datasets []string) *ConnectionCheck {
+	c := NewClient(&config)
+	result := &ConnectionCheck{
+		BaseURL:   config.BaseURL,
+		ProjectID: config.ProjectID,
+		AuthMode:  AuthModePassword,
+		Forms:     []ResourceCheck{},
+		Datasets:  []ResourceCheck{},
+	}
+
+	if config.Token != "" {
+		result.AuthMode = AuthModeToken
+	}
+
+	if err := c.authenticate(ctx); err != nil {
+		var statusErr *StatusError
+		switch {
+		case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+			result.addError("authentication failed: invalid ODK email or password")
+		case errors.As(err, &statusErr):
+			result.addError("authentication failed: ODK Central at %s returned status %d", config.BaseURL, statusErr.StatusCode)
+		default:
+			result.addError("cannot reach ODK Central at %s: %v", config.BaseURL, err)
+		}
+		return result
+	}
+	result.Authenticated = true
+
+	var project struct {
+		Name string `json:"name"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/v1/projects/%d", config.BaseURL, config.ProjectID), &project); err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized && result.AuthMode == AuthModeToken {
+			// A token is only verified by its first request
+			result.Authenticated = false
+			result.addError("authentication failed: invalid or revoked ODK token")
+		} else if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusForbidden) {
+			result.addError("project %d not found or not accessible with these credentials", config.ProjectID)
+		} else {
+			result.addError("failed to read project %d: %v", config.ProjectID, err)
+		}
+		return result
+	}
+	result.ProjectFound = true
+	result.ProjectName = project.Name
+
+	var projectForms []struct {
+		XMLFormID string `json:"xmlFormId"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/v1/projects/%d/forms", config.BaseURL, config.ProjectID), &projectForms); err != nil {
+		result.addError("failed to list forms of project %d: %v", config.ProjectID, err)
+	} else {
+		existing := make(map[string]bool, len(projectForms))
+		for _, f := range projectForms {
+			existing[f.XMLFormID] = true
+		}
+		for _, name := range sortedKeys(forms) {
+			formID := forms[name]
+			found := existing[formID]
+			result.Forms = append(result.Forms, ResourceCheck{Name: name, ID: formID, Found: found})
+			if !found {
+				result.addError("%s form %q not found in project %d", name, formID, config.ProjectID)
+			}
+		}
+	}
+
+	projectDatasets, err := c.GetDatasetsContext(ctx)
+	if err != nil {
+		result.addError("failed to list entity datasets of project %d: %v", config.ProjectID, err)
+	} else {
+		existing := make(map[string]bool, len(projectDatasets))
+		for _, d := range projectDatasets {
+			if name, ok := d["name"].(string); ok {
+				existing[name] = true
+			}
+		}
+		for _, dataset := range datasets {
+			found := existing[dataset]
+			result.Datasets = append(result.Datasets, ResourceCheck{Name: dataset, ID: dataset, Found: found})
+			if !found {
+				result.addError("entity dataset %q not found in project %d", dataset, config.ProjectID)
+			}
+		}
+	}
+
+	result.OK = len(result.Errors) == 0
+	return result
+}
+
+func (r *ConnectionCheck) addError(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// getJSON performs an authenticated GET and decodes the JSON body into out.
+// Non-200 responses are returned as *StatusError.
+func (c *Client) getJSON(ctx context.Context, reqURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
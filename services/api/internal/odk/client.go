@@ -1,12 +1,17 @@
 package odk
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,46 +21,112 @@ type Client struct {
 	httpClient *http.Client
 	token      string
 	tokenExp   time.Time
+	authMu     sync.Mutex // guards token and tokenExp during (re)authentication
+
+	mappingOpts EntityMappingOptions
 }
 
 // NewClient creates a new ODK Central client
 func NewClient(config *ODKConfig) *Client {
 	return &Client{
 		config: config,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		// No client-wide timeout: each request gets the deadline of its operation (see send)
+		httpClient: &http.Client{},
 	}
 }
 
+// ODK authentication modes
+const (
+	AuthModePassword = "password" // session from email/password, renewed when it expires
+	AuthModeToken    = "token"    // pre-issued (App User) token used as is, never refreshed
+)
+
+// ErrNoCredentials is returned by AuthMode when neither a token nor email/password is set
+var ErrNoCredentials = errors.New("no ODK credentials configured (token or email/password)")
+
+// AuthMode returns the configured authentication mode. Configuring both a token and
+// email/password is rejected; configuring neither returns ErrNoCredentials.
+func (cfg *ODKConfig) AuthMode() (string, error) {
+	hasPassword := cfg.Email != "" || cfg.Password != ""
+	switch {
+	case cfg.Token != "" && hasPassword:
+		return "", fmt.Errorf("both ODK token and email/password are configured; set only one")
+	case cfg.Token != "":
+		return AuthModeToken, nil
+	case cfg.Email != "" && cfg.Password != "":
+		return AuthModePassword, nil
+	case hasPassword:
+		return "", fmt.Errorf("ODK email and password must both be set")
+	}
+	return "", ErrNoCredentials
+}
+
 // authenticate gets a session token from ODK Central
-func (c *Client) authenticate() error {
+func (c *Client) authenticate(ctx context.Context) error {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	// A pre-issued token is used directly: no session call, no refresh
+	if c.config.Token != "" {
+		c.token = c.config.Token
+		return nil
+	}
+
 	// Check if token is still valid
 	if c.token != "" && time.Now().Before(c.tokenExp) {
 		return nil
 	}
 
+	var token string
+	var exp time.Time
+	var err error
+	if c.config.TokenCache != nil {
+		token, exp, err = c.config.TokenCache.get(tokenCacheKey(c.config), func() (string, time.Time, error) {
+			return c.createSession(ctx)
+		})
+	} else {
+		token, exp, err = c.createSession(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.token = token
+	c.tokenExp = exp
+
+	return nil
+}
+
+// currentToken returns the session token, which authenticate and renewSession may
+// replace concurrently
+func (c *Client) currentToken() string {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.token
+}
+
+// createSession logs in with email/password and returns the session token and its expiry
+func (c *Client) createSession(ctx context.Context) (string, time.Time, error) {
 	authURL := fmt.Sprintf("%s/v1/sessions", c.config.BaseURL)
 
 	payload := fmt.Sprintf(`{"email":"%s","password":"%s"}`, c.config.Email, c.config.Password)
 
-	req, err := http.NewRequest("POST", authURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", authURL, strings.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("failed to create auth request: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to create auth request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Body = io.NopCloser(strings.NewReader(payload))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
-		return fmt.Errorf("failed to authenticate: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to authenticate: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("authentication failed with status %d: %s", resp.StatusCode, string(body))
+		return "", time.Time{}, fmt.Errorf("authentication failed with %w", &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
 	}
 
 	var authResp struct {
@@ -64,18 +135,20 @@ func (c *Client) authenticate() error {
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		return fmt.Errorf("failed to decode auth response: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to decode auth response: %w", err)
 	}
 
-	c.token = authResp.Token
-	c.tokenExp = authResp.ExpiresAt
-
-	return nil
+	return authResp.Token, authResp.ExpiresAt, nil
 }
 
 // GetSubmissions fetches submissions from ODK Central OData API
 func (c *Client) GetSubmissions(filter string, skip, top int) (*ODataResponse, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetSubmissionsContext(context.Background(), filter, skip, top)
+}
+
+// GetSubmissionsContext is GetSubmissions bounded by ctx
+func (c *Client) GetSubmissionsContext(ctx context.Context, filter string, skip, top int) (*ODataResponse, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
@@ -99,15 +172,15 @@ func (c *Client) GetSubmissions(filter string, skip, top int) (*ODataResponse, e
 		odataURL += "?" + params.Encode()
 	}
 
-	req, err := http.NewRequest("GET", odataURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", odataURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch submissions: %w", err)
 	}
@@ -128,7 +201,12 @@ func (c *Client) GetSubmissions(filter string, skip, top int) (*ODataResponse, e
 
 // GetSubmissionsRaw fetches raw submission data as map for flexible parsing
 func (c *Client) GetSubmissionsRaw(filter string, skip, top int) ([]map[string]interface{}, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetSubmissionsRawContext(context.Background(), filter, skip, top)
+}
+
+// GetSubmissionsRawContext is GetSubmissionsRaw bounded by ctx
+func (c *Client) GetSubmissionsRawContext(ctx context.Context, filter string, skip, top int) ([]map[string]interface{}, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
@@ -150,15 +228,15 @@ func (c *Client) GetSubmissionsRaw(filter string, skip, top int) ([]map[string]i
 		odataURL += "?" + params.Encode()
 	}
 
-	req, err := http.NewRequest("GET", odataURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", odataURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch submissions: %w", err)
 	}
@@ -182,24 +260,39 @@ func (c *Client) GetSubmissionsRaw(filter string, skip, top int) ([]map[string]i
 
 // GetSubmissionsSince fetches submissions updated after a specific time
 func (c *Client) GetSubmissionsSince(since time.Time) ([]map[string]interface{}, error) {
+	return c.GetSubmissionsSinceContext(context.Background(), since)
+}
+
+// GetSubmissionsSinceContext is GetSubmissionsSince bounded by ctx
+func (c *Client) GetSubmissionsSinceContext(ctx context.Context, since time.Time) ([]map[string]interface{}, error) {
 	filter := fmt.Sprintf("__system/updatedAt gt %s", since.UTC().Format(time.RFC3339))
-	return c.GetSubmissionsRaw(filter, 0, 0)
+	return c.GetSubmissionsRawContext(ctx, filter, 0, 0)
 }
 
 // GetApprovedSubmissions fetches only approved submissions
 func (c *Client) GetApprovedSubmissions() ([]map[string]interface{}, error) {
+	return c.GetApprovedSubmissionsContext(context.Background())
+}
+
+// GetApprovedSubmissionsContext is GetApprovedSubmissions bounded by ctx
+func (c *Client) GetApprovedSubmissionsContext(ctx context.Context) ([]map[string]interface{}, error) {
 	filter := "__system/reviewState eq 'approved'"
-	return c.GetSubmissionsRaw(filter, 0, 0)
+	return c.GetSubmissionsRawContext(ctx, filter, 0, 0)
 }
 
 // GetAllSubmissions fetches all submissions with pagination
 func (c *Client) GetAllSubmissions() ([]map[string]interface{}, error) {
+	return c.GetAllSubmissionsContext(context.Background())
+}
+
+// GetAllSubmissionsContext is GetAllSubmissions bounded by ctx
+func (c *Client) GetAllSubmissionsContext(ctx context.Context) ([]map[string]interface{}, error) {
 	var allSubmissions []map[string]interface{}
 	skip := 0
 	pageSize := 100
 
 	for {
-		submissions, err := c.GetSubmissionsRaw("", skip, pageSize)
+		submissions, err := c.GetSubmissionsRawContext(ctx, "", skip, pageSize)
 		if err != nil {
 			return nil, err
 		}
@@ -220,23 +313,82 @@ func (c *Client) GetAllSubmissions() ([]map[string]interface{}, error) {
 	return allSubmissions, nil
 }
 
+// ErrSubmissionNotFound is returned when a submission ID does not exist in the form
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+// GetSubmission fetches a single submission by instance ID (OData entity lookup)
+func (c *Client) GetSubmission(submissionID string) (map[string]interface{}, error) {
+	return c.GetSubmissionContext(context.Background(), submissionID)
+}
+
+// GetSubmissionContext is GetSubmission bounded by ctx
+func (c *Client) GetSubmissionContext(ctx context.Context, submissionID string) (map[string]interface{}, error) {
+	if err := c.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	// OData key literal: single quotes inside the key are escaped by doubling
+	key := strings.ReplaceAll(submissionID, "'", "''")
+	odataURL := fmt.Sprintf("%s/v1/projects/%d/forms/%s.svc/Submissions('%s')",
+		c.config.BaseURL, c.config.ProjectID, c.config.FormID, url.PathEscape(key))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", odataURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch submission: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrSubmissionNotFound, submissionID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var rawResp struct {
+		Value []map[string]interface{} `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rawResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(rawResp.Value) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSubmissionNotFound, submissionID)
+	}
+
+	return rawResp.Value[0], nil
+}
+
 // GetAttachment downloads an attachment from a submission
 func (c *Client) GetAttachment(submissionID, filename string) ([]byte, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetAttachmentContext(context.Background(), submissionID, filename)
+}
+
+// GetAttachmentContext is GetAttachment bounded by ctx
+func (c *Client) GetAttachmentContext(ctx context.Context, submissionID, filename string) ([]byte, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
 	attachmentURL := fmt.Sprintf("%s/v1/projects/%d/forms/%s/submissions/%s/attachments/%s",
 		c.config.BaseURL, c.config.ProjectID, c.config.FormID, submissionID, filename)
 
-	req, err := http.NewRequest("GET", attachmentURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", attachmentURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch attachment: %w", err)
 	}
@@ -251,21 +403,26 @@ func (c *Client) GetAttachment(submissionID, filename string) ([]byte, error) {
 
 // GetAttachmentForForm downloads an attachment from a submission for a specific form
 func (c *Client) GetAttachmentForForm(formID, submissionID, filename string) ([]byte, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetAttachmentForFormContext(context.Background(), formID, submissionID, filename)
+}
+
+// GetAttachmentForFormContext is GetAttachmentForForm bounded by ctx
+func (c *Client) GetAttachmentForFormContext(ctx context.Context, formID, submissionID, filename string) ([]byte, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
 	attachmentURL := fmt.Sprintf("%s/v1/projects/%d/forms/%s/submissions/%s/attachments/%s",
 		c.config.BaseURL, c.config.ProjectID, formID, submissionID, filename)
 
-	req, err := http.NewRequest("GET", attachmentURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", attachmentURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch attachment: %w", err)
 	}
@@ -278,24 +435,68 @@ func (c *Client) GetAttachmentForForm(formID, submissionID, filename string) ([]
 	return io.ReadAll(resp.Body)
 }
 
+// OpenAttachment streams an attachment from a submission of the given form (empty formID
+// uses the client's form). The caller must close the returned body. The content type is
+// taken from ODK Central's response headers.
+func (c *Client) OpenAttachment(formID, submissionID, filename string) (io.ReadCloser, string, error) {
+	return c.OpenAttachmentContext(context.Background(), formID, submissionID, filename)
+}
+
+// OpenAttachmentContext is OpenAttachment bounded by ctx
+func (c *Client) OpenAttachmentContext(ctx context.Context, formID, submissionID, filename string) (io.ReadCloser, string, error) {
+	if err := c.authenticate(ctx); err != nil {
+		return nil, "", err
+	}
+
+	if formID == "" {
+		formID = c.config.FormID
+	}
+	attachmentURL := fmt.Sprintf("%s/v1/projects/%d/forms/%s/submissions/%s/attachments/%s",
+		c.config.BaseURL, c.config.ProjectID, formID, submissionID, filename)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", attachmentURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch attachment: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("attachment request failed with status %d", resp.StatusCode)
+	}
+
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
 // GetDatasets lists all datasets (entity lists) in the project
 func (c *Client) GetDatasets() ([]map[string]interface{}, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetDatasetsContext(context.Background())
+}
+
+// GetDatasetsContext is GetDatasets bounded by ctx
+func (c *Client) GetDatasetsContext(ctx context.Context) ([]map[string]interface{}, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
 	datasetsURL := fmt.Sprintf("%s/v1/projects/%d/datasets",
 		c.config.BaseURL, c.config.ProjectID)
 
-	req, err := http.NewRequest("GET", datasetsURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", datasetsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch datasets: %w", err)
 	}
@@ -316,22 +517,27 @@ func (c *Client) GetDatasets() ([]map[string]interface{}, error) {
 
 // GetEntities lists all entities in a dataset
 func (c *Client) GetEntities(datasetName string) ([]map[string]interface{}, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetEntitiesContext(context.Background(), datasetName)
+}
+
+// GetEntitiesContext is GetEntities bounded by ctx
+func (c *Client) GetEntitiesContext(ctx context.Context, datasetName string) ([]map[string]interface{}, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
 	entitiesURL := fmt.Sprintf("%s/v1/projects/%d/datasets/%s/entities",
 		c.config.BaseURL, c.config.ProjectID, datasetName)
 
-	req, err := http.NewRequest("GET", entitiesURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", entitiesURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch entities: %w", err)
 	}
@@ -339,7 +545,7 @@ func (c *Client) GetEntities(datasetName string) ([]map[string]interface{}, erro
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("request failed with %w", &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
 	}
 
 	var entities []map[string]interface{}
@@ -352,7 +558,12 @@ func (c *Client) GetEntities(datasetName string) ([]map[string]interface{}, erro
 
 // CreateEntity creates a single entity in a dataset
 func (c *Client) CreateEntity(datasetName string, entity EntityCreateRequest) (*map[string]interface{}, error) {
-	if err := c.authenticate(); err != nil {
+	return c.CreateEntityContext(context.Background(), datasetName, entity)
+}
+
+// CreateEntityContext is CreateEntity bounded by ctx
+func (c *Client) CreateEntityContext(ctx context.Context, datasetName string, entity EntityCreateRequest) (*map[string]interface{}, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
@@ -364,16 +575,16 @@ func (c *Client) CreateEntity(datasetName string, entity EntityCreateRequest) (*
 		return nil, fmt.Errorf("failed to marshal entity: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", entitiesURL, strings.NewReader(string(payload)))
+	req, err := http.NewRequestWithContext(ctx, "POST", entitiesURL, strings.NewReader(string(payload)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create entity: %w", err)
 	}
@@ -381,7 +592,7 @@ func (c *Client) CreateEntity(datasetName string, entity EntityCreateRequest) (*
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("request failed with %w", &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
 	}
 
 	var result map[string]interface{}
@@ -394,7 +605,12 @@ func (c *Client) CreateEntity(datasetName string, entity EntityCreateRequest) (*
 
 // CreateEntitiesBulk creates multiple entities in a dataset
 func (c *Client) CreateEntitiesBulk(datasetName string, entities []EntityCreateRequest, sourceName string) ([]map[string]interface{}, error) {
-	if err := c.authenticate(); err != nil {
+	return c.CreateEntitiesBulkContext(context.Background(), datasetName, entities, sourceName)
+}
+
+// CreateEntitiesBulkContext is CreateEntitiesBulk bounded by ctx
+func (c *Client) CreateEntitiesBulkContext(ctx context.Context, datasetName string, entities []EntityCreateRequest, sourceName string) ([]map[string]interface{}, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
@@ -414,16 +630,16 @@ func (c *Client) CreateEntitiesBulk(datasetName string, entities []EntityCreateR
 		return nil, fmt.Errorf("failed to marshal entities: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", entitiesURL, strings.NewReader(string(payload)))
+	req, err := http.NewRequestWithContext(ctx, "POST", entitiesURL, strings.NewReader(string(payload)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create entities: %w", err)
 	}
@@ -431,13 +647,18 @@ func (c *Client) CreateEntitiesBulk(datasetName string, entities []EntityCreateR
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("request failed with %w", &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
 	}
 
-	var results []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+	// Depending on the Central version the response is the created entities or just {"success": true}
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	var results []map[string]interface{}
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return []map[string]interface{}{}, nil
+	}
 
 	return results, nil
 }
@@ -445,67 +666,148 @@ func (c *Client) CreateEntitiesBulk(datasetName string, entities []EntityCreateR
 // GetEntitySubmissionMapping builds a mapping from entity UUID to submission instance ID
 // by fetching entity versions which contain the source submission info
 func (c *Client) GetEntitySubmissionMapping(datasetName string) (map[string]string, error) {
-	if err := c.authenticate(); err != nil {
+	return c.GetEntitySubmissionMappingContext(context.Background(), datasetName)
+}
+
+// GetEntitySubmissionMappingContext is GetEntitySubmissionMapping bounded by ctx. Entity
+// versions are fetched by a pool of SetEntityMappingOptions workers (one request per entity)
+// with progress logged every ProgressEvery entities. If ctx ends first, the error reports
+// how far the mapping got and no partial mapping is returned.
+func (c *Client) GetEntitySubmissionMappingContext(ctx context.Context, datasetName string) (map[string]string, error) {
+	if err := c.authenticate(ctx); err != nil {
 		return nil, err
 	}
 
 	// First, get all entities
-	entities, err := c.GetEntities(datasetName)
+	entities, err := c.GetEntitiesContext(ctx, datasetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get entities: %w", err)
 	}
 
-	mapping := make(map[string]string)
+	opts := c.entityMappingOptions()
+	total := len(entities)
+	start := time.Now()
+	log.Printf("Entity mapping %s: fetching versions for %d entities (%d workers)", datasetName, total, opts.Concurrency)
+
+	var (
+		mu      sync.Mutex
+		mapping = make(map[string]string)
+		done    atomic.Int64
+		wg      sync.WaitGroup
+	)
+
+	uuids := make(chan string)
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entityUUID := range uuids {
+				if instanceID, ok := c.getEntitySourceSubmission(ctx, datasetName, entityUUID); ok {
+					mu.Lock()
+					mapping[entityUUID] = instanceID
+					mu.Unlock()
+				}
+				if n := done.Add(1); n%int64(opts.ProgressEvery) == 0 {
+					log.Printf("Entity mapping %s: %d/%d entities (%s)", datasetName, n, total, time.Since(start).Round(time.Second))
+				}
+			}
+		}()
+	}
 
 	// For each entity, get its first version to find the source submission
+enqueue:
 	for _, entity := range entities {
 		entityUUID, ok := entity["uuid"].(string)
 		if !ok || entityUUID == "" {
+			done.Add(1)
 			continue
 		}
+		select {
+		case uuids <- entityUUID:
+		case <-ctx.Done():
+			break enqueue
+		}
+	}
+	close(uuids)
+	wg.Wait()
 
-		// Get entity versions
-		versionsURL := fmt.Sprintf("%s/v1/projects/%d/datasets/%s/entities/%s/versions",
-			c.config.BaseURL, c.config.ProjectID, datasetName, entityUUID)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("entity mapping %s stopped after %d/%d entities: %w", datasetName, done.Load(), total, err)
+	}
 
-		req, err := http.NewRequest("GET", versionsURL, nil)
-		if err != nil {
-			continue
-		}
+	log.Printf("Entity mapping %s: mapped %d/%d entities in %s", datasetName, len(mapping), total, time.Since(start).Round(time.Millisecond))
+	return mapping, nil
+}
 
-		req.Header.Set("Authorization", "Bearer "+c.token)
-		req.Header.Set("Accept", "application/json")
+// getEntitySourceSubmission returns the instance ID of the submission that created an
+// entity (the source of its first version)
+func (c *Client) getEntitySourceSubmission(ctx context.Context, datasetName, entityUUID string) (string, bool) {
+	// Get entity versions
+	versionsURL := fmt.Sprintf("%s/v1/projects/%d/datasets/%s/entities/%s/versions",
+		c.config.BaseURL, c.config.ProjectID, datasetName, entityUUID)
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			continue
-		}
+	req, err := http.NewRequestWithContext(ctx, "GET", versionsURL, nil)
+	if err != nil {
+		return "", false
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			continue
-		}
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
+	req.Header.Set("Accept", "application/json")
 
-		var versions []map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-			resp.Body.Close()
-			continue
-		}
-		resp.Body.Close()
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
 
-		// Get submission ID from first version's source
-		if len(versions) > 0 {
-			if source, ok := versions[0]["source"].(map[string]interface{}); ok {
-				if submission, ok := source["submission"].(map[string]interface{}); ok {
-					if instanceID, ok := submission["instanceId"].(string); ok {
-						mapping[entityUUID] = instanceID
-					}
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	var versions []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return "", false
+	}
+
+	// Get submission ID from first version's source
+	if len(versions) > 0 {
+		if source, ok := versions[0]["source"].(map[string]interface{}); ok {
+			if submission, ok := source["submission"].(map[string]interface{}); ok {
+				if instanceID, ok := submission["instanceId"].(string); ok {
+					return instanceID, true
 				}
 			}
 		}
 	}
+	return "", false
+}
 
-	return mapping, nil
+// EntityMappingOptions tunes GetEntitySubmissionMapping
+type EntityMappingOptions struct {
+	Concurrency   int // parallel version requests
+	ProgressEvery int // log progress every N entities
+}
+
+// DefaultEntityMappingOptions are used for unset (zero) options
+var DefaultEntityMappingOptions = EntityMappingOptions{
+	Concurrency:   8,
+	ProgressEvery: 100,
+}
+
+// SetEntityMappingOptions overrides the entity mapping concurrency/progress settings
+func (c *Client) SetEntityMappingOptions(opts EntityMappingOptions) {
+	c.mappingOpts = opts
+}
+
+func (c *Client) entityMappingOptions() EntityMappingOptions {
+	opts := c.mappingOpts
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultEntityMappingOptions.Concurrency
+	}
+	if opts.ProgressEvery <= 0 {
+		opts.ProgressEvery = DefaultEntityMappingOptions.ProgressEvery
+	}
+	return opts
 }
 
 // EntityCreateRequest represents request to create an entity
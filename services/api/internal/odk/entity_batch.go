@@ -0,0 +1,118 @@
+package odk
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// DefaultEntityBatchSize is the number of entities sent per bulk request when none is configured
+const DefaultEntityBatchSize = 100
+
+// EntityBatchOptions controls CreateEntitiesBatched
+type EntityBatchOptions struct {
+	BatchSize int // entities per bulk request (0 = DefaultEntityBatchSize)
+	// FallbackPerEntity retries a rejected batch one entity at a time, so only the
+	// malformed entities fail and the rest are still created
+	FallbackPerEntity bool
+}
+
+// EntityCreateOutcome identifies an entity of a CreateEntitiesBatched call
+type EntityCreateOutcome struct {
+	Index int    `json:"index"` // position in the input slice
+	UUID  string `json:"uuid,omitempty"`
+	Label string `json:"label"`
+	Error string `json:"error,omitempty"`
+}
+
+// EntityBatchResult is the combined result of CreateEntitiesBatched
+type EntityBatchResult struct {
+	Created []EntityCreateOutcome `json:"created"`
+	Failed  []EntityCreateOutcome `json:"failed"`
+}
+
+// CreateEntitiesBatched creates entities in chunks of opts.BatchSize with CreateEntitiesBulk.
+// ODK Central rejects a whole bulk request if one entity is malformed; with FallbackPerEntity
+// such a batch is retried entity by entity to isolate and report the bad ones.
+// Batches that fail for other reasons (network, auth) are reported as failed without a retry,
+// since it is unknown whether ODK Central created them.
+func (c *Client) CreateEntitiesBatched(datasetName string, entities []EntityCreateRequest, sourceName string, opts EntityBatchOptions) *EntityBatchResult {
+	return c.CreateEntitiesBatchedContext(context.Background(), datasetName, entities, sourceName, opts)
+}
+
+// CreateEntitiesBatchedContext is CreateEntitiesBatched bounded by ctx
+func (c *Client) CreateEntitiesBatchedContext(ctx context.Context, datasetName string, entities []EntityCreateRequest, sourceName string, opts EntityBatchOptions) *EntityBatchResult {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultEntityBatchSize
+	}
+
+	result := &EntityBatchResult{
+		Created: []EntityCreateOutcome{},
+		Failed:  []EntityCreateOutcome{},
+	}
+	batches := (len(entities) + batchSize - 1) / batchSize
+
+	for start := 0; start < len(entities); start += batchSize {
+		end := min(start+batchSize, len(entities))
+		batch := entities[start:end]
+		batchNo := start/batchSize + 1
+
+		_, err := c.CreateEntitiesBulkContext(ctx, datasetName, batch, sourceName)
+		if err == nil {
+			for i, entity := range batch {
+				result.Created = append(result.Created, entityOutcome(start+i, entity, nil))
+			}
+			continue
+		}
+
+		if !opts.FallbackPerEntity || !isEntityRejection(err) {
+			log.Printf("Entity batch %d/%d (%d entities) failed: %v", batchNo, batches, len(batch), err)
+			for i, entity := range batch {
+				result.Failed = append(result.Failed, entityOutcome(start+i, entity, err))
+			}
+			continue
+		}
+
+		log.Printf("Entity batch %d/%d rejected (%v), creating %d entities one by one", batchNo, batches, err, len(batch))
+		for i, entity := range batch {
+			if _, err := c.CreateEntityContext(ctx, datasetName, entity); err != nil {
+				result.Failed = append(result.Failed, entityOutcome(start+i, entity, err))
+			} else {
+				result.Created = append(result.Created, entityOutcome(start+i, entity, nil))
+			}
+		}
+	}
+
+	if len(result.Failed) > 0 {
+		log.Printf("Created %d/%d entities in %s, %d failed", len(result.Created), len(entities), datasetName, len(result.Failed))
+	}
+	return result
+}
+
+// isEntityRejection reports whether ODK Central rejected the request content (4xx other than
+// auth), i.e. nothing was created and retrying per entity can isolate the cause
+func isEntityRejection(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false
+	}
+	return statusErr.StatusCode >= http.StatusBadRequest && statusErr.StatusCode < http.StatusInternalServerError
+}
+
+func entityOutcome(index int, entity EntityCreateRequest, err error) EntityCreateOutcome {
+	outcome := EntityCreateOutcome{
+		Index: index,
+		UUID:  entity.UUID,
+		Label: entity.Label,
+	}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	return outcome
+}
@@ -0,0 +1,171 @@
+package odk
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseDelay is the first backoff delay when ODKConfig.RetryBaseDelay is unset
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the backoff and any Retry-After asked for by ODK Central
+	maxRetryDelay = 60 * time.Second
+)
+
+// retryableStatus reports whether ODK Central answered with a transient error
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotentRequest reports whether a request may be sent again after it failed midway:
+// reads, and creating a session (an extra session is harmless). Other requests (creating
+// entities) are only retried on 429/503, which ODK Central returns without processing them.
+func idempotentRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead || isSessionRequest(req)
+}
+
+func isSessionRequest(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/v1/sessions")
+}
+
+// doWithRetry sends req, retrying connection errors and 429/502/503/504 responses up to
+// ODKConfig.MaxRetries times with exponential backoff and jitter (or the Retry-After the
+// server asks for). After the last attempt the final response or error is returned as is.
+// A 401 on an email/password session renews the session once and resends the request,
+// instead of retrying with the expired token.
+func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.GetBody == nil {
+		return c.send(req) // body cannot be replayed
+	}
+
+	sent, renewed := false, false
+	for attempt := 0; ; attempt++ {
+		if sent && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		sent = true
+
+		resp, err := c.send(req)
+
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !renewed && c.canRenewSession(req) {
+			drainAndClose(resp)
+			renewed = true
+			if err := c.renewSession(req); err != nil {
+				return nil, err
+			}
+			attempt-- // the renewal does not count as a retry
+			continue
+		}
+
+		retry := false
+		var wait time.Duration
+		switch {
+		case err != nil:
+			retry = idempotentRequest(req) && req.Context().Err() == nil
+		case retryableStatus(resp.StatusCode):
+			retry = idempotentRequest(req) || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+			wait = retryAfter(resp.Header.Get("Retry-After"))
+		}
+		if !retry || attempt >= c.config.MaxRetries {
+			return resp, err
+		}
+
+		if wait <= 0 {
+			wait = c.backoff(attempt)
+		}
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+			drainAndClose(resp)
+		}
+		log.Printf("ODK %s %s failed (%s), retry %d/%d in %s", req.Method, req.URL.Path, reason, attempt+1, c.config.MaxRetries, wait.Round(time.Millisecond))
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// backoff returns the delay before retry attempt+1: the base delay doubled per attempt,
+// plus up to 50% jitter so parallel workers do not retry in lockstep
+func (c *Client) backoff(attempt int) time.Duration {
+	base := c.config.RetryBaseDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// retryAfter parses a Retry-After header (seconds or an HTTP date); 0 when absent or invalid
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	var wait time.Duration
+	if secs, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		wait = time.Until(at)
+	}
+	if wait > maxRetryDelay {
+		wait = maxRetryDelay
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// canRenewSession reports whether a 401 on req may be an expired email/password session
+func (c *Client) canRenewSession(req *http.Request) bool {
+	return c.config.Token == "" && !isSessionRequest(req) && strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+// renewSession replaces an expired session token and updates req to use the new one.
+// Concurrent requests failing with the same token share one renewal.
+func (c *Client) renewSession(req *http.Request) error {
+	used := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	c.authMu.Lock()
+	if c.token == used {
+		c.token = ""
+	}
+	c.authMu.Unlock()
+	if c.config.TokenCache != nil {
+		c.config.TokenCache.invalidate(tokenCacheKey(c.config), used)
+	}
+
+	if err := c.authenticate(req.Context()); err != nil {
+		return fmt.Errorf("failed to renew ODK session: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
+	return nil
+}
+
+func drainAndClose(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,157 @@
+package odk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultStreamPageSize is the OData page size used by StreamApprovedSubmissions
+const DefaultStreamPageSize = 250
+
+// StreamApprovedSubmissions calls fn with each approved submission as raw JSON, following
+// @odata.nextLink page by page. Submissions are decoded one at a time, so neither the form
+// nor a page is held in memory. Returns the number of submissions passed to fn.
+// An error from fn stops the stream and is returned as is.
+func (c *Client) StreamApprovedSubmissions(ctx context.Context, pageSize int, fn func(json.RawMessage) error) (int, error) {
+	if err := c.authenticate(ctx); err != nil {
+		return 0, err
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultStreamPageSize
+	}
+
+	params := url.Values{}
+	params.Set("$filter", "__system/reviewState eq 'approved'")
+	params.Set("$top", fmt.Sprintf("%d", pageSize))
+	pageURL := fmt.Sprintf("%s/v1/projects/%d/forms/%s.svc/Submissions?%s",
+		c.config.BaseURL, c.config.ProjectID, c.config.FormID, params.Encode())
+
+	count := 0
+	for pageURL != "" {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		nextLink, err := c.streamSubmissionPage(ctx, pageURL, func(raw json.RawMessage) error {
+			count++
+			return fn(raw)
+		})
+		if err != nil {
+			return count, err
+		}
+		pageURL = c.resolveNextLink(nextLink)
+	}
+
+	return count, nil
+}
+
+// GetApprovedSubmissionsStream is StreamApprovedSubmissions with each submission decoded
+// like GetApprovedSubmissions does, so only one OData page is in flight at a time.
+// An error from fn stops the stream and is returned; ctx is checked between pages.
+func (c *Client) GetApprovedSubmissionsStream(ctx context.Context, pageSize int, fn func(map[string]interface{}) error) error {
+	_, err := c.StreamApprovedSubmissions(ctx, pageSize, func(raw json.RawMessage) error {
+		var submission map[string]interface{}
+		if err := json.Unmarshal(raw, &submission); err != nil {
+			return fmt.Errorf("failed to decode submission: %w", err)
+		}
+		return fn(submission)
+	})
+	return err
+}
+
+// streamSubmissionPage decodes one OData page, calling fn per element of "value",
+// and returns the page's @odata.nextLink (empty on the last page)
+func (c *Client) streamSubmissionPage(ctx context.Context, pageURL string, fn func(json.RawMessage) error) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.currentToken())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch submissions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("request failed with %w", &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", err
+	}
+
+	var nextLink string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		switch tok {
+		case "value":
+			if err := expectDelim(dec, '['); err != nil {
+				return "", err
+			}
+			for dec.More() {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					return "", fmt.Errorf("failed to decode submission: %w", err)
+				}
+				if err := fn(raw); err != nil {
+					return "", err
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return "", err
+			}
+		case "@odata.nextLink":
+			if err := dec.Decode(&nextLink); err != nil {
+				return "", fmt.Errorf("failed to decode nextLink: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+	}
+
+	return nextLink, nil
+}
+
+// resolveNextLink makes a relative @odata.nextLink absolute against the base URL
+func (c *Client) resolveNextLink(nextLink string) string {
+	if nextLink == "" || strings.HasPrefix(nextLink, "http://") || strings.HasPrefix(nextLink, "https://") {
+		return nextLink
+	}
+	base, err := url.Parse(c.config.BaseURL)
+	if err != nil {
+		return nextLink
+	}
+	ref, err := url.Parse(nextLink)
+	if err != nil {
+		return nextLink
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if tok != want {
+		return fmt.Errorf("failed to decode response: expected %q, got %v", want, tok)
+	}
+	return nil
+}
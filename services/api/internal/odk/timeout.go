@@ -0,0 +1,61 @@
+package odk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Default per-request timeouts, used when the ODKConfig field is unset
+const (
+	DefaultAuthTimeout       = 30 * time.Second
+	DefaultQueryTimeout      = 30 * time.Second
+	DefaultAttachmentTimeout = 30 * time.Second
+)
+
+// requestTimeout returns the deadline of one attempt of req, by the kind of operation:
+// creating a session, downloading an attachment, or any other API call
+func (c *Client) requestTimeout(req *http.Request) time.Duration {
+	timeout, fallback := c.config.QueryTimeout, DefaultQueryTimeout
+	switch {
+	case isSessionRequest(req):
+		timeout, fallback = c.config.AuthTimeout, DefaultAuthTimeout
+	case isAttachmentRequest(req):
+		timeout, fallback = c.config.AttachmentTimeout, DefaultAttachmentTimeout
+	}
+	if timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
+
+func isAttachmentRequest(req *http.Request) bool {
+	return strings.Contains(req.URL.Path, "/attachments/")
+}
+
+// send makes one attempt of req bounded by its requestTimeout. Like http.Client.Timeout
+// the deadline also covers reading the response body; it is released when the body is closed.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.requestTimeout(req))
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's deadline once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
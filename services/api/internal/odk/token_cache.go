@@ -0,0 +1,58 @@
+package odk
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenCache shares email/password sessions between clients, so the clients of the
+// different forms authenticate once instead of each creating its own session.
+// Sessions are kept per server and credentials; a client only ever gets a session
+// created with its own. Safe for concurrent use.
+type TokenCache struct {
+	mu       sync.Mutex
+	sessions map[string]cachedSession
+}
+
+type cachedSession struct {
+	token string
+	exp   time.Time
+}
+
+// NewTokenCache creates an empty session cache to set as ODKConfig.TokenCache
+func NewTokenCache() *TokenCache {
+	return &TokenCache{sessions: make(map[string]cachedSession)}
+}
+
+// get returns the cached session for key while it is valid, otherwise creates one with
+// create. Holding the lock during create makes concurrent clients share one new session.
+func (tc *TokenCache) get(key string, create func() (string, time.Time, error)) (string, time.Time, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if s, ok := tc.sessions[key]; ok && time.Now().Before(s.exp) {
+		return s.token, s.exp, nil
+	}
+	token, exp, err := create()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	tc.sessions[key] = cachedSession{token: token, exp: exp}
+	return token, exp, nil
+}
+
+// invalidate drops the session for key if it is still token, e.g. after ODK Central
+// rejected it before its expiry
+func (tc *TokenCache) invalidate(key, token string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if s, ok := tc.sessions[key]; ok && s.token == token {
+		delete(tc.sessions, key)
+	}
+}
+
+// tokenCacheKey identifies the server and credentials a session belongs to
+func tokenCacheKey(config *ODKConfig) string {
+	return config.BaseURL + "\x00" + config.Email + "\x00" + config.Password
+}
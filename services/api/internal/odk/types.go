@@ -9,6 +9,25 @@ type ODKConfig struct {
 	Password  string
 	ProjectID int
 	FormID    string
+
+	// Token is a pre-issued ODK token (e.g. an App User token) used instead of email/password
+	Token string
+
+	// TokenCache, when set, shares email/password sessions with the other clients using
+	// the same cache; nil gives the client its own session
+	TokenCache *TokenCache
+
+	// MaxRetries is how often a request failing with a connection error or 429/502/503/504
+	// is retried (0 = never); RetryBaseDelay is the first backoff delay, doubled per retry
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+
+	// Per-request timeouts of creating a session, of downloading an attachment and of
+	// every other API call; each covers one attempt including reading the response.
+	// Unset uses DefaultAuthTimeout, DefaultAttachmentTimeout and DefaultQueryTimeout.
+	AuthTimeout       time.Duration
+	AttachmentTimeout time.Duration
+	QueryTimeout      time.Duration
 }
 
 // ODataResponse represents the OData response from ODK Central
@@ -194,3 +213,16 @@ type SyncState struct {
 func (SyncState) TableName() string {
 	return "sync_state"
 }
+
+// EntityMapping persists the entity UUID -> source submission mapping of a dataset,
+// so restarts don't have to re-fetch every entity's versions from ODK Central
+type EntityMapping struct {
+	Dataset      string    `json:"dataset" gorm:"primaryKey;type:varchar(255)"`
+	EntityUUID   string    `json:"entity_uuid" gorm:"primaryKey;type:varchar(255)"`
+	SubmissionID string    `json:"submission_id" gorm:"type:varchar(255);not null"`
+	FetchedAt    time.Time `json:"fetched_at" gorm:"not null"`
+}
+
+func (EntityMapping) TableName() string {
+	return "entity_mapping"
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ActivityRepository reads recent record changes across all entity types
+type ActivityRepository struct {
+	db *gorm.DB
+}
+
+func NewActivityRepository(db *gorm.DB) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+// ActivityItem is a single create/update of a posko, faskes, feed or infrastruktur record
+type ActivityItem struct {
+	Type      string
+	ID        uuid.UUID
+	Name      string
+	Action    string // "created" or "updated"
+	UpdatedAt time.Time
+}
+
+// activitySQL unions the most recent rows of each table (each branch limited on its own, so
+// no branch returns more than the page) and merges them by updated_at. A row counts as
+// "created" when it hasn't been touched since its insert.
+const activitySQL = `
+SELECT type, id, name,
+	CASE WHEN updated_at - created_at < interval '1 second' THEN 'created' ELSE 'updated' END AS action,
+	updated_at
+FROM (
+	(SELECT 'posko' AS type, id, nama AS name, created_at, updated_at FROM locations
+		WHERE deleted_at IS NULL AND updated_at > @since ORDER BY updated_at DESC LIMIT @limit)
+	UNION ALL
+	(SELECT 'faskes', id, nama, created_at, updated_at FROM faskes
+		WHERE deleted_at IS NULL AND updated_at > @since ORDER BY updated_at DESC LIMIT @limit)
+	UNION ALL
+	(SELECT 'feed', id, LEFT(content, 80), created_at, updated_at FROM information_feeds
+		WHERE deleted_at IS NULL AND updated_at > @since ORDER BY updated_at DESC LIMIT @limit)
+	UNION ALL
+	(SELECT 'infrastruktur', id, nama, created_at, updated_at FROM infrastruktur
+		WHERE deleted_at IS NULL AND updated_at > @since ORDER BY updated_at DESC LIMIT @limit)
+) AS activity
+ORDER BY updated_at DESC
+LIMIT @limit`
+
+// MaxActivityLimit caps how many changes FindRecent returns
+const MaxActivityLimit = 200
+
+// FindRecent returns up to limit changes newer than since, most recent first
+func (r *ActivityRepository) FindRecent(since time.Time, limit int) ([]ActivityItem, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > MaxActivityLimit {
+		limit = MaxActivityLimit
+	}
+
+	var items []ActivityItem
+	err := r.db.Raw(activitySQL, map[string]interface{}{
+		"since": since,
+		"limit": limit,
+	}).Scan(&items).Error
+	return items, err
+}
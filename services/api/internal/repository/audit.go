@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"gorm.io/gorm"
+)
+
+// AuditRepository stores and reads the admin audit log
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create records one audit entry
+func (r *AuditRepository) Create(entry *model.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// FindRecent returns the latest entries, newest first
+func (r *AuditRepository) FindRecent(limit int) ([]model.AuditLog, error) {
+	var entries []model.AuditLog
+	err := r.db.Order("created_at DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxCountCacheEntries bounds the cache; expired entries are dropped when it is full
+const maxCountCacheEntries = 1000
+
+// TotalCount is the number of rows matching a list filter
+type TotalCount struct {
+	Value     int64
+	Estimated bool // from the query planner's row estimate instead of a COUNT
+}
+
+// CountCache keeps list totals per filter for a short time so paging through a filtered
+// list does not re-run the COUNT for every page. Above estimateAbove rows the planner's
+// estimate is returned instead of counting. A nil cache always counts exactly.
+type CountCache struct {
+	ttl           time.Duration
+	estimateAbove int64
+
+	mu      sync.Mutex
+	entries map[string]countEntry
+}
+
+type countEntry struct {
+	total     TotalCount
+	expiresAt time.Time
+}
+
+// NewCountCache creates a count cache keeping totals for ttl and estimating totals of
+// more than estimateAbove rows (0 = always exact). Returns nil when both are off.
+func NewCountCache(ttl time.Duration, estimateAbove int64) *CountCache {
+	if ttl <= 0 && estimateAbove <= 0 {
+		return nil
+	}
+	return &CountCache{
+		ttl:           ttl,
+		estimateAbove: estimateAbove,
+		entries:       make(map[string]countEntry),
+	}
+}
+
+// countFilterKey builds the cache key of a list filter; callers clear the pagination
+// and sort fields first so every page of the same filter shares one entry
+func countFilterKey(table string, filter interface{}) string {
+	b, _ := json.Marshal(filter)
+	return table + ":" + string(b)
+}
+
+// count returns the total of the query built by newQuery, from the cache when fresh.
+// newQuery must return a new query each call (a counted query cannot be reused).
+func (c *CountCache) count(key string, newQuery func() *gorm.DB) (TotalCount, error) {
+	if c == nil {
+		var total int64
+		err := newQuery().Count(&total).Error
+		return TotalCount{Value: total}, err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expiresAt) {
+		c.mu.Unlock()
+		return e.total, nil
+	}
+	c.mu.Unlock()
+
+	var total TotalCount
+	if c.estimateAbove > 0 {
+		// A failed EXPLAIN falls back to the exact count
+		if estimate, err := estimateRows(newQuery()); err == nil && estimate > c.estimateAbove {
+			total = TotalCount{Value: estimate, Estimated: true}
+		}
+	}
+	if !total.Estimated {
+		if err := newQuery().Count(&total.Value).Error; err != nil {
+			return TotalCount{}, err
+		}
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		if len(c.entries) >= maxCountCacheEntries {
+			for k, e := range c.entries {
+				if !now.Before(e.expiresAt) {
+					delete(c.entries, k)
+				}
+			}
+			if len(c.entries) >= maxCountCacheEntries {
+				c.entries = make(map[string]countEntry)
+			}
+		}
+		c.entries[key] = countEntry{total: total, expiresAt: now.Add(c.ttl)}
+		c.mu.Unlock()
+	}
+
+	return total, nil
+}
+
+// estimateRows returns the planner's row estimate for query without running it
+func estimateRows(query *gorm.DB) (int64, error) {
+	stmt := query.Session(&gorm.Session{DryRun: true}).Select("1").Find(&[]map[string]interface{}{}).Statement
+
+	var plan string
+	if err := query.Session(&gorm.Session{NewDB: true}).
+		Raw("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).
+		Row().Scan(&plan); err != nil {
+		return 0, err
+	}
+
+	var explain []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &explain); err != nil || len(explain) == 0 {
+		return 0, err
+	}
+	return int64(explain[0].Plan.PlanRows), nil
+}
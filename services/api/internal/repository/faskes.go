@@ -1,30 +1,60 @@
 package repository
 
 import (
+	"fmt"
+
 	"github.com/google/uuid"
 	"github.com/leksa/datamapper-senyar/internal/model"
 	"gorm.io/gorm"
 )
 
 type FaskesRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	countCache *CountCache
 }
 
 func NewFaskesRepository(db *gorm.DB) *FaskesRepository {
 	return &FaskesRepository{db: db}
 }
 
+// SetCountCache caches FindAll totals per filter (nil counts every request)
+func (r *FaskesRepository) SetCountCache(cache *CountCache) {
+	r.countCache = cache
+}
+
 type FaskesFilter struct {
 	JenisFaskes   string
 	StatusFaskes  string
 	KondisiFaskes string
 	Search        string
+	Sort          string // key of faskesSortOrders
 	MinLng        *float64
 	MinLat        *float64
 	MaxLng        *float64
 	MaxLat        *float64
 	Page          int
 	Limit         int
+
+	// SRID of the returned coordinates (0 = DefaultSRID); bbox stays in WGS 84
+	SRID int
+}
+
+// faskesSortOrders maps ?sort= keys to ORDER BY clauses
+var faskesSortOrders = map[string]string{
+	"updated_at_desc": "updated_at DESC",
+	"updated_at_asc":  "updated_at ASC",
+	"nama_asc":        "nama ASC",
+}
+
+// FaskesSortKeys returns the accepted ?sort= values for faskes
+func FaskesSortKeys() []string {
+	return sortKeys(faskesSortOrders)
+}
+
+// IsValidFaskesSort reports whether key is an accepted ?sort= value for faskes
+func IsValidFaskesSort(key string) bool {
+	_, ok := faskesSortOrders[key]
+	return ok
 }
 
 type FaskesWithCoords struct {
@@ -33,25 +63,62 @@ type FaskesWithCoords struct {
 	Latitude  float64 `json:"latitude"`
 }
 
-func (r *FaskesRepository) FindAll(filter FaskesFilter) ([]FaskesWithCoords, int64, error) {
+func (r *FaskesRepository) FindAll(filter FaskesFilter) ([]FaskesWithCoords, TotalCount, error) {
 	var faskesList []FaskesWithCoords
-	var total int64
 
-	// Base query with coordinates extraction
+	// Base query with coordinates extraction (in the requested SRID)
 	query := r.db.Table("faskes").
-		Select(`
-			faskes.*,
-			ST_X(geom) as longitude,
-			ST_Y(geom) as latitude
-		`).
+		Select("faskes.*, " + coordsSelect("geom", filter.SRID)).
 		Where("deleted_at IS NULL")
 
-	// Apply filters
+	query = applyFaskesFilters(query, filter)
+
+	// Count total (cached per filter, shared by all pages)
+	countFilter := filter
+	countFilter.Page, countFilter.Limit, countFilter.Sort, countFilter.SRID = 0, 0, "", 0
+	total, err := r.countCache.count(countFilterKey("faskes", countFilter), func() *gorm.DB {
+		return applyFaskesFilters(r.db.Table("faskes").Where("deleted_at IS NULL"), filter)
+	})
+	if err != nil {
+		return nil, TotalCount{}, err
+	}
+
+	// Pagination
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 500 {
+		filter.Limit = 500
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	order, ok := faskesSortOrders[filter.Sort]
+	if !ok {
+		order = faskesSortOrders["updated_at_desc"]
+	}
+	query = query.Offset(offset).Limit(filter.Limit).Order(order)
+
+	err = query.Find(&faskesList).Error
+	return faskesList, total, err
+}
+
+// Count returns the number of faskes matching the filter (pagination is ignored)
+func (r *FaskesRepository) Count(filter FaskesFilter) (int64, error) {
+	var total int64
+	err := applyFaskesFilters(r.db.Table("faskes").Where("deleted_at IS NULL"), filter).Count(&total).Error
+	return total, err
+}
+
+// applyFaskesFilters adds the filter's WHERE clauses to a faskes query
+func applyFaskesFilters(query *gorm.DB, filter FaskesFilter) *gorm.DB {
 	if filter.JenisFaskes != "" {
 		query = query.Where("jenis_faskes = ?", filter.JenisFaskes)
 	}
 	if filter.StatusFaskes != "" {
-		query = query.Where("status_faskes = ?", filter.StatusFaskes)
+		query = query.Where("status_faskes = ?", model.NormalizeStatus(filter.StatusFaskes))
 	}
 	if filter.KondisiFaskes != "" {
 		query = query.Where("kondisi_faskes = ?", filter.KondisiFaskes)
@@ -70,38 +137,7 @@ func (r *FaskesRepository) FindAll(filter FaskesFilter) ([]FaskesWithCoords, int
 		`, *filter.MinLng, *filter.MinLat, *filter.MaxLng, *filter.MaxLat)
 	}
 
-	// Count total
-	countQuery := r.db.Table("faskes").Where("deleted_at IS NULL")
-	if filter.JenisFaskes != "" {
-		countQuery = countQuery.Where("jenis_faskes = ?", filter.JenisFaskes)
-	}
-	if filter.StatusFaskes != "" {
-		countQuery = countQuery.Where("status_faskes = ?", filter.StatusFaskes)
-	}
-	if filter.KondisiFaskes != "" {
-		countQuery = countQuery.Where("kondisi_faskes = ?", filter.KondisiFaskes)
-	}
-	if filter.Search != "" {
-		countQuery = countQuery.Where("nama ILIKE ?", "%"+filter.Search+"%")
-	}
-	countQuery.Count(&total)
-
-	// Pagination
-	if filter.Page <= 0 {
-		filter.Page = 1
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 50
-	}
-	if filter.Limit > 500 {
-		filter.Limit = 500
-	}
-
-	offset := (filter.Page - 1) * filter.Limit
-	query = query.Offset(offset).Limit(filter.Limit).Order("updated_at DESC")
-
-	err := query.Find(&faskesList).Error
-	return faskesList, total, err
+	return query
 }
 
 func (r *FaskesRepository) FindByID(id uuid.UUID) (*FaskesWithCoords, error) {
@@ -128,3 +164,70 @@ func (r *FaskesRepository) FindPhotos(faskesID uuid.UUID) ([]model.FaskesPhoto,
 	err := r.db.Where("faskes_id = ?", faskesID).Find(&photos).Error
 	return photos, err
 }
+
+// CountPhotos returns photo counts for multiple faskes (batch query)
+func (r *FaskesRepository) CountPhotos(faskesIDs []uuid.UUID) (map[uuid.UUID]PhotoCount, error) {
+	return countPhotos(r.db, "faskes_photos", "faskes_id", faskesIDs)
+}
+
+// FaskesExportGroups are the JSONB groups the full CSV export can flatten into columns
+var FaskesExportGroups = []string{"identitas", "infrastruktur", "sdm", "perbekalan", "klaster"}
+
+// IsFaskesExportGroup reports whether group is one of FaskesExportGroups
+func IsFaskesExportGroup(group string) bool {
+	for _, g := range FaskesExportGroups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// FaskesExportRow is one faskes of the full CSV export
+type FaskesExportRow struct {
+	FaskesWithCoords
+	PhotoCount int `json:"photo_count"`
+}
+
+// GroupKeys returns the sorted keys found in a JSONB group over the faskes matching the
+// filter, i.e. the columns that group flattens into
+func (r *FaskesRepository) GroupKeys(group string, filter FaskesFilter) ([]string, error) {
+	if !IsFaskesExportGroup(group) {
+		return nil, fmt.Errorf("unknown faskes group %q", group)
+	}
+	var keys []string
+	query := applyFaskesFilters(r.db.Table("faskes").Where("deleted_at IS NULL"), filter).
+		Where("jsonb_typeof(" + group + ") = 'object'").
+		Select("jsonb_object_keys(" + group + ")")
+	err := r.db.Raw("SELECT DISTINCT key FROM (?) AS k(key) ORDER BY key", query).Scan(&keys).Error
+	return keys, err
+}
+
+// StreamExport calls fn for each faskes matching the filter (ordered by nama) with its
+// coordinates and photo count, reading one row at a time. An error from fn stops the export.
+func (r *FaskesRepository) StreamExport(filter FaskesFilter, fn func(row *FaskesExportRow) error) error {
+	rows, err := applyFaskesFilters(r.db.Table("faskes").Where("deleted_at IS NULL"), filter).
+		Select(`
+			faskes.*,
+			ST_X(geom) as longitude,
+			ST_Y(geom) as latitude,
+			(SELECT COUNT(*) FROM faskes_photos p WHERE p.faskes_id = faskes.id) as photo_count
+		`).
+		Order("nama ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row FaskesExportRow
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -1,24 +1,33 @@
 package repository
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 	"github.com/leksa/datamapper-senyar/internal/model"
 	"gorm.io/gorm"
 )
 
 type FeedRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	countCache *CountCache
 }
 
 func NewFeedRepository(db *gorm.DB) *FeedRepository {
 	return &FeedRepository{db: db}
 }
 
+// SetCountCache caches FindAll totals per filter (nil counts every request)
+func (r *FeedRepository) SetCountCache(cache *CountCache) {
+	r.countCache = cache
+}
+
 type FeedFilter struct {
 	LocationID   string
 	LocationName string
 	Category     string
 	Type         string
+	Tags         []string // matches feeds having any of these tags
 	Search       string
 	Since        string // ISO date string for filtering feeds since a date
 	// Region filters - uses calc_nama_* fields in raw_data JSONB
@@ -28,8 +37,43 @@ type FeedFilter struct {
 	Desa      string
 	Page      int
 	Limit     int
+
+	// Organization matches the submitting organization, case-insensitively
+	Organization string
+	// Sort is a key of feedSortOrders (empty: newest first)
+	Sort string
+
+	// Bounding box in WGS 84, applied when all four are set
+	MinLng *float64
+	MinLat *float64
+	MaxLng *float64
+	MaxLat *float64
+	// NearLng/NearLat with RadiusKm > 0 select feeds within RadiusKm kilometres of the point
+	NearLng  *float64
+	NearLat  *float64
+	RadiusKm float64
+}
+
+// feedSortOrders maps ?sort= keys to ORDER BY clauses
+var feedSortOrders = map[string]string{
+	"submitted_at_desc": feedOrder,
+	"organization_asc":  "f.organization ASC NULLS LAST, " + feedOrder,
+}
+
+// FeedSortKeys returns the accepted ?sort= values for feeds
+func FeedSortKeys() []string {
+	return sortKeys(feedSortOrders)
+}
+
+// IsValidFeedSort reports whether key is an accepted ?sort= value for feeds
+func IsValidFeedSort(key string) bool {
+	_, ok := feedSortOrders[key]
+	return ok
 }
 
+// feedTagsMatchSQL matches feeds whose comma/space separated type column shares any tag with the given list
+const feedTagsMatchSQL = `regexp_split_to_array(trim(f.type), '[\s,]+') && string_to_array(?, ',')`
+
 type FeedWithCoords struct {
 	model.Feed
 	Longitude    *float64 `json:"longitude"`
@@ -61,11 +105,54 @@ func (r *FeedRepository) GetPhotosForFeeds(feedIDs []uuid.UUID) (map[uuid.UUID][
 	return result, nil
 }
 
-func (r *FeedRepository) FindAll(filter FeedFilter) ([]FeedWithCoords, int64, error) {
+func (r *FeedRepository) FindAll(filter FeedFilter) ([]FeedWithCoords, TotalCount, error) {
 	var feeds []FeedWithCoords
-	var total int64
 
-	query := r.db.Table("information_feeds f").
+	query := applyFeedFilters(r.feedQuery(), filter)
+
+	// Count total (cached per filter, shared by all pages)
+	countFilter := filter
+	countFilter.Page, countFilter.Limit = 0, 0
+	countFilter.Sort = ""
+	total, err := r.countCache.count(countFilterKey("information_feeds", countFilter), func() *gorm.DB {
+		return applyFeedFilters(r.db.Table("information_feeds f").
+			Joins("LEFT JOIN locations l ON l.id = f.location_id AND l.deleted_at IS NULL").
+			Joins("LEFT JOIN faskes fk ON fk.id = f.faskes_id AND fk.deleted_at IS NULL").
+			Where("f.deleted_at IS NULL"), filter)
+	})
+	if err != nil {
+		return nil, TotalCount{}, err
+	}
+
+	// Pagination
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	order := feedOrder
+	if o, ok := feedSortOrders[filter.Sort]; ok {
+		order = o
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query = query.Offset(offset).Limit(filter.Limit).Order(order)
+
+	err = query.Find(&feeds).Error
+	return feeds, total, err
+}
+
+// feedOrder lists the newest feeds first
+const feedOrder = "f.submitted_at DESC NULLS LAST, f.created_at DESC"
+
+// feedQuery selects feeds with coordinates and linked posko/faskes names
+func (r *FeedRepository) feedQuery() *gorm.DB {
+	return r.db.Table("information_feeds f").
 		Select(`
 			f.*,
 			ST_X(f.geom) as longitude,
@@ -73,10 +160,40 @@ func (r *FeedRepository) FindAll(filter FeedFilter) ([]FeedWithCoords, int64, er
 			l.nama as location_name,
 			fk.nama as faskes_name
 		`).
-		Joins("LEFT JOIN locations l ON l.id = f.location_id").
-		Joins("LEFT JOIN faskes fk ON fk.id = f.faskes_id")
+		Joins("LEFT JOIN locations l ON l.id = f.location_id AND l.deleted_at IS NULL").
+		Joins("LEFT JOIN faskes fk ON fk.id = f.faskes_id AND fk.deleted_at IS NULL").
+		Where("f.deleted_at IS NULL")
+}
 
-	// Apply filters
+// Organizations returns the distinct organizations that submitted feeds, sorted by name
+func (r *FeedRepository) Organizations() ([]string, error) {
+	organizations := []string{}
+	err := r.db.Table("information_feeds").
+		Distinct("organization").
+		Where("deleted_at IS NULL AND organization IS NOT NULL AND organization <> ''").
+		Order("organization").
+		Pluck("organization", &organizations).Error
+	return organizations, err
+}
+
+// FindLatestByLocation returns the newest feeds linked to a location, without a total count
+func (r *FeedRepository) FindLatestByLocation(locationID uuid.UUID, limit int) ([]FeedWithCoords, error) {
+	var feeds []FeedWithCoords
+	err := r.feedQuery().Where("f.location_id = ?", locationID).Order(feedOrder).Limit(limit).Find(&feeds).Error
+	return feeds, err
+}
+
+// Count returns the number of feeds matching the filter (pagination is ignored)
+func (r *FeedRepository) Count(filter FeedFilter) (int64, error) {
+	var total int64
+	err := applyFeedFilters(r.db.Table("information_feeds f").
+		Joins("LEFT JOIN locations l ON l.id = f.location_id AND l.deleted_at IS NULL").
+		Where("f.deleted_at IS NULL"), filter).Count(&total).Error
+	return total, err
+}
+
+// applyFeedFilters adds the filter's WHERE clauses to a feeds query (aliased f, joined with locations l)
+func applyFeedFilters(query *gorm.DB, filter FeedFilter) *gorm.DB {
 	if filter.LocationID != "" {
 		query = query.Where("f.location_id = ?", filter.LocationID)
 	}
@@ -89,12 +206,28 @@ func (r *FeedRepository) FindAll(filter FeedFilter) ([]FeedWithCoords, int64, er
 	if filter.Type != "" {
 		query = query.Where("f.type = ?", filter.Type)
 	}
+	if len(filter.Tags) > 0 {
+		query = query.Where(feedTagsMatchSQL, strings.Join(filter.Tags, ","))
+	}
 	if filter.Search != "" {
 		query = query.Where("f.content ILIKE ?", "%"+filter.Search+"%")
 	}
 	if filter.Since != "" {
 		query = query.Where("COALESCE(f.submitted_at, f.created_at) >= ?", filter.Since)
 	}
+	if filter.Organization != "" {
+		query = query.Where("f.organization ILIKE ?", filter.Organization)
+	}
+	// Spatial filters; feeds without geometry never match
+	if filter.MinLng != nil && filter.MinLat != nil && filter.MaxLng != nil && filter.MaxLat != nil {
+		query = query.Where("f.geom IS NOT NULL AND ST_Within(f.geom, ST_MakeEnvelope(?, ?, ?, ?, 4326))",
+			*filter.MinLng, *filter.MinLat, *filter.MaxLng, *filter.MaxLat)
+	}
+	if filter.NearLng != nil && filter.NearLat != nil && filter.RadiusKm > 0 {
+		// geography so the radius is in metres rather than degrees
+		query = query.Where("f.geom IS NOT NULL AND ST_DWithin(f.geom::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)",
+			*filter.NearLng, *filter.NearLat, filter.RadiusKm*1000)
+	}
 	// Region filters - filter by calc_nama_* fields in raw_data JSONB
 	if filter.Provinsi != "" {
 		query = query.Where("f.raw_data->>'calc_nama_provinsi' ILIKE ?", "%"+filter.Provinsi+"%")
@@ -109,56 +242,7 @@ func (r *FeedRepository) FindAll(filter FeedFilter) ([]FeedWithCoords, int64, er
 		query = query.Where("f.raw_data->>'calc_nama_desa' ILIKE ?", "%"+filter.Desa+"%")
 	}
 
-	// Count total
-	countQuery := r.db.Table("information_feeds f").
-		Joins("LEFT JOIN locations l ON l.id = f.location_id").
-		Joins("LEFT JOIN faskes fk ON fk.id = f.faskes_id")
-	if filter.LocationID != "" {
-		countQuery = countQuery.Where("f.location_id = ?", filter.LocationID)
-	}
-	if filter.LocationName != "" {
-		countQuery = countQuery.Where("l.nama ILIKE ?", "%"+filter.LocationName+"%")
-	}
-	if filter.Category != "" {
-		countQuery = countQuery.Where("f.category = ?", filter.Category)
-	}
-	if filter.Type != "" {
-		countQuery = countQuery.Where("f.type = ?", filter.Type)
-	}
-	if filter.Since != "" {
-		countQuery = countQuery.Where("COALESCE(f.submitted_at, f.created_at) >= ?", filter.Since)
-	}
-	// Region filters for count
-	if filter.Provinsi != "" {
-		countQuery = countQuery.Where("f.raw_data->>'calc_nama_provinsi' ILIKE ?", "%"+filter.Provinsi+"%")
-	}
-	if filter.KotaKab != "" {
-		countQuery = countQuery.Where("f.raw_data->>'calc_nama_kota_kab' ILIKE ?", "%"+filter.KotaKab+"%")
-	}
-	if filter.Kecamatan != "" {
-		countQuery = countQuery.Where("f.raw_data->>'calc_nama_kecamatan' ILIKE ?", "%"+filter.Kecamatan+"%")
-	}
-	if filter.Desa != "" {
-		countQuery = countQuery.Where("f.raw_data->>'calc_nama_desa' ILIKE ?", "%"+filter.Desa+"%")
-	}
-	countQuery.Count(&total)
-
-	// Pagination
-	if filter.Page <= 0 {
-		filter.Page = 1
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 20
-	}
-	if filter.Limit > 100 {
-		filter.Limit = 100
-	}
-
-	offset := (filter.Page - 1) * filter.Limit
-	query = query.Offset(offset).Limit(filter.Limit).Order("f.submitted_at DESC NULLS LAST, f.created_at DESC")
-
-	err := query.Find(&feeds).Error
-	return feeds, total, err
+	return query
 }
 
 func (r *FeedRepository) FindByLocationID(locationID uuid.UUID, limit int) ([]FeedWithCoords, error) {
@@ -174,7 +258,7 @@ func (r *FeedRepository) FindByLocationID(locationID uuid.UUID, limit int) ([]Fe
 			ST_X(f.geom) as longitude,
 			ST_Y(f.geom) as latitude
 		`).
-		Where("f.location_id = ?", locationID).
+		Where("f.location_id = ? AND f.deleted_at IS NULL", locationID).
 		Order("f.submitted_at DESC NULLS LAST").
 		Limit(limit).
 		Find(&feeds).Error
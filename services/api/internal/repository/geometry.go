@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultSRID is the SRID geometry is stored and returned in (WGS 84 longitude/latitude)
+const DefaultSRID = 4326
+
+// IsSupportedSRID reports whether coordinates can be returned in srid: WGS 84 (4326),
+// Web Mercator (3857) or a WGS 84 / UTM zone covering Indonesia (32646-32654 north,
+// 32746-32754 south)
+func IsSupportedSRID(srid int) bool {
+	switch {
+	case srid == DefaultSRID, srid == 3857:
+		return true
+	case srid >= 32646 && srid <= 32654, srid >= 32746 && srid <= 32754:
+		return true
+	}
+	return false
+}
+
+// coordsSelect selects the coordinates of a point column as longitude/latitude (x/y),
+// transformed to srid unless it is the stored DefaultSRID (0 means DefaultSRID)
+func coordsSelect(column string, srid int) string {
+	if srid != 0 && srid != DefaultSRID {
+		column = fmt.Sprintf("ST_Transform(%s, %d)", column, srid)
+	}
+	return fmt.Sprintf("ST_X(%[1]s) as longitude, ST_Y(%[1]s) as latitude", column)
+}
+
+// MissingGeometryRecord is a record without usable coordinates, for data-quality worklists
+type MissingGeometryRecord struct {
+	ID              uuid.UUID
+	ODKSubmissionID *string
+	Nama            string
+	SubmitterName   *string
+	SubmittedAt     *time.Time
+}
+
+// missingGeometrySQL matches NULL/empty geometry and the 0,0 placeholder older syncs wrote for submissions without coordinates
+const missingGeometrySQL = "(geom IS NULL OR ST_IsEmpty(geom) OR (ST_X(geom) = 0 AND ST_Y(geom) = 0))"
+
+// findMissingGeometry lists non-deleted rows of table whose geometry is missing
+func findMissingGeometry(db *gorm.DB, table string) ([]MissingGeometryRecord, error) {
+	var records []MissingGeometryRecord
+	err := db.Table(table).
+		Select("id, odk_submission_id, nama, submitter_name, submitted_at").
+		Where("deleted_at IS NULL AND " + missingGeometrySQL).
+		Order("submitted_at DESC NULLS LAST").
+		Find(&records).Error
+	return records, err
+}
+
+// FindMissingGeometry lists posko without valid coordinates
+func (r *LocationRepository) FindMissingGeometry() ([]MissingGeometryRecord, error) {
+	return findMissingGeometry(r.db, "locations")
+}
+
+// FindMissingGeometry lists faskes without valid coordinates
+func (r *FaskesRepository) FindMissingGeometry() ([]MissingGeometryRecord, error) {
+	return findMissingGeometry(r.db, "faskes")
+}
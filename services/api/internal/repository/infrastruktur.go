@@ -7,13 +7,19 @@ import (
 )
 
 type InfrastrukturRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	countCache *CountCache
 }
 
 func NewInfrastrukturRepository(db *gorm.DB) *InfrastrukturRepository {
 	return &InfrastrukturRepository{db: db}
 }
 
+// SetCountCache caches FindAll totals per filter (nil counts every request)
+func (r *InfrastrukturRepository) SetCountCache(cache *CountCache) {
+	r.countCache = cache
+}
+
 type InfrastrukturFilter struct {
 	Jenis            string // "Jalan" or "Jembatan"
 	StatusJln        string // "Nasional" or "Daerah"
@@ -27,6 +33,9 @@ type InfrastrukturFilter struct {
 	MaxLat           *float64
 	Page             int
 	Limit            int
+
+	// SRID of the returned coordinates (0 = DefaultSRID); bbox stays in WGS 84
+	SRID int
 }
 
 type InfrastrukturWithCoords struct {
@@ -35,20 +44,53 @@ type InfrastrukturWithCoords struct {
 	Latitude  float64 `json:"latitude"`
 }
 
-func (r *InfrastrukturRepository) FindAll(filter InfrastrukturFilter) ([]InfrastrukturWithCoords, int64, error) {
+func (r *InfrastrukturRepository) FindAll(filter InfrastrukturFilter) ([]InfrastrukturWithCoords, TotalCount, error) {
 	var items []InfrastrukturWithCoords
-	var total int64
 
-	// Base query with coordinates extraction
+	// Base query with coordinates extraction (in the requested SRID)
 	query := r.db.Table("infrastruktur").
-		Select(`
-			infrastruktur.*,
-			ST_X(geom) as longitude,
-			ST_Y(geom) as latitude
-		`).
+		Select("infrastruktur.*, " + coordsSelect("geom", filter.SRID)).
 		Where("deleted_at IS NULL")
 
-	// Apply filters
+	query = applyInfrastrukturFilters(query, filter)
+
+	// Count total (cached per filter, shared by all pages)
+	countFilter := filter
+	countFilter.Page, countFilter.Limit, countFilter.SRID = 0, 0, 0
+	total, err := r.countCache.count(countFilterKey("infrastruktur", countFilter), func() *gorm.DB {
+		return applyInfrastrukturFilters(r.db.Table("infrastruktur").Where("deleted_at IS NULL"), filter)
+	})
+	if err != nil {
+		return nil, TotalCount{}, err
+	}
+
+	// Pagination
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 500 {
+		filter.Limit = 500
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query = query.Offset(offset).Limit(filter.Limit).Order("updated_at DESC")
+
+	err = query.Find(&items).Error
+	return items, total, err
+}
+
+// Count returns the number of infrastruktur records matching the filter (pagination is ignored)
+func (r *InfrastrukturRepository) Count(filter InfrastrukturFilter) (int64, error) {
+	var total int64
+	err := applyInfrastrukturFilters(r.db.Table("infrastruktur").Where("deleted_at IS NULL"), filter).Count(&total).Error
+	return total, err
+}
+
+// applyInfrastrukturFilters adds the filter's WHERE clauses to an infrastruktur query
+func applyInfrastrukturFilters(query *gorm.DB, filter InfrastrukturFilter) *gorm.DB {
 	if filter.Jenis != "" {
 		query = query.Where("jenis = ?", filter.Jenis)
 	}
@@ -78,44 +120,7 @@ func (r *InfrastrukturRepository) FindAll(filter InfrastrukturFilter) ([]Infrast
 		`, *filter.MinLng, *filter.MinLat, *filter.MaxLng, *filter.MaxLat)
 	}
 
-	// Count total
-	countQuery := r.db.Table("infrastruktur").Where("deleted_at IS NULL")
-	if filter.Jenis != "" {
-		countQuery = countQuery.Where("jenis = ?", filter.Jenis)
-	}
-	if filter.StatusJln != "" {
-		countQuery = countQuery.Where("status_jln = ?", filter.StatusJln)
-	}
-	if filter.StatusAkses != "" {
-		countQuery = countQuery.Where("status_akses = ?", filter.StatusAkses)
-	}
-	if filter.StatusPenanganan != "" {
-		countQuery = countQuery.Where("status_penanganan = ?", filter.StatusPenanganan)
-	}
-	if filter.NamaKabupaten != "" {
-		countQuery = countQuery.Where("nama_kabupaten ILIKE ?", "%"+filter.NamaKabupaten+"%")
-	}
-	if filter.Search != "" {
-		countQuery = countQuery.Where("nama ILIKE ?", "%"+filter.Search+"%")
-	}
-	countQuery.Count(&total)
-
-	// Pagination
-	if filter.Page <= 0 {
-		filter.Page = 1
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 50
-	}
-	if filter.Limit > 500 {
-		filter.Limit = 500
-	}
-
-	offset := (filter.Page - 1) * filter.Limit
-	query = query.Offset(offset).Limit(filter.Limit).Order("updated_at DESC")
-
-	err := query.Find(&items).Error
-	return items, total, err
+	return query
 }
 
 func (r *InfrastrukturRepository) FindByID(id uuid.UUID) (*InfrastrukturWithCoords, error) {
@@ -143,6 +148,15 @@ func (r *InfrastrukturRepository) FindPhotos(infrastrukturID uuid.UUID) ([]model
 	return photos, err
 }
 
+// FindHistory returns the recorded progress updates of an infrastruktur, oldest first
+func (r *InfrastrukturRepository) FindHistory(infrastrukturID uuid.UUID) ([]model.InfrastrukturHistory, error) {
+	var history []model.InfrastrukturHistory
+	err := r.db.Where("infrastruktur_id = ?", infrastrukturID).
+		Order("submitted_at ASC NULLS FIRST, created_at ASC").
+		Find(&history).Error
+	return history, err
+}
+
 // GetStats returns statistics about infrastructure
 func (r *InfrastrukturRepository) GetStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -1,29 +1,86 @@
 package repository
 
 import (
+	"regexp"
+	"sort"
+
 	"github.com/google/uuid"
 	"github.com/leksa/datamapper-senyar/internal/model"
 	"gorm.io/gorm"
 )
 
 type LocationRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	countCache *CountCache
 }
 
 func NewLocationRepository(db *gorm.DB) *LocationRepository {
 	return &LocationRepository{db: db}
 }
 
+// SetCountCache caches FindAll totals per filter (nil counts every request)
+func (r *LocationRepository) SetCountCache(cache *CountCache) {
+	r.countCache = cache
+}
+
 type LocationFilter struct {
-	Type   string
-	Status string
-	Search string
+	Type    string
+	Status  string
+	Search  string
+	MinJiwa *int   // minimum total_jiwa
+	Sort    string // key of locationSortOrders
 	MinLng *float64
 	MinLat *float64
 	MaxLng *float64
 	MaxLat *float64
 	Page   int
 	Limit  int
+
+	// HasFacility lists fasilitas keys that must all be "yes" (e.g. dapur_umum)
+	HasFacility []string
+	// Provinsi matches the alamat province code (id_provinsi) or name, case-insensitively
+	Provinsi string
+	// SRID of the returned coordinates (0 = DefaultSRID); bbox stays in WGS 84
+	SRID int
+}
+
+// facilityKeyPattern matches fasilitas keys accepted by ?has_facility=
+var facilityKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// IsValidFacilityKey reports whether key can be used as a ?has_facility= value
+func IsValidFacilityKey(key string) bool {
+	return facilityKeyPattern.MatchString(key)
+}
+
+// locationSortOrders maps ?sort= keys to ORDER BY clauses
+var locationSortOrders = map[string]string{
+	"updated_at_desc": "updated_at DESC",
+	"updated_at_asc":  "updated_at ASC",
+	"total_jiwa_desc": "total_jiwa DESC, updated_at DESC",
+	"total_jiwa_asc":  "total_jiwa ASC, updated_at DESC",
+	"jumlah_kk_desc":  "jumlah_kk DESC, updated_at DESC",
+	"nama_asc":        "nama ASC",
+}
+
+// LocationSortKeys returns the accepted ?sort= values for locations
+func LocationSortKeys() []string {
+	return sortKeys(locationSortOrders)
+}
+
+// IsValidLocationSort reports whether key is an accepted ?sort= value for locations
+func IsValidLocationSort(key string) bool {
+	_, ok := locationSortOrders[key]
+	return ok
+}
+
+// sortKeys returns the keys of a sort order map in stable order
+func sortKeys(orders map[string]string) []string {
+	keys := make([]string, 0, len(orders))
+	for k := range orders {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 type LocationWithCoords struct {
@@ -32,29 +89,76 @@ type LocationWithCoords struct {
 	Latitude  float64 `json:"latitude"`
 }
 
-func (r *LocationRepository) FindAll(filter LocationFilter) ([]LocationWithCoords, int64, error) {
+func (r *LocationRepository) FindAll(filter LocationFilter) ([]LocationWithCoords, TotalCount, error) {
 	var locations []LocationWithCoords
-	var total int64
 
-	// Base query with coordinates extraction
+	// Base query with coordinates extraction (in the requested SRID)
 	query := r.db.Table("locations").
-		Select(`
-			locations.*,
-			ST_X(geom) as longitude,
-			ST_Y(geom) as latitude
-		`).
+		Select("locations.*, " + coordsSelect("geom", filter.SRID)).
 		Where("deleted_at IS NULL")
 
-	// Apply filters
+	query = applyLocationFilters(query, filter)
+
+	// Count total (cached per filter, shared by all pages)
+	countFilter := filter
+	countFilter.Page, countFilter.Limit, countFilter.Sort, countFilter.SRID = 0, 0, "", 0
+	total, err := r.countCache.count(countFilterKey("locations", countFilter), func() *gorm.DB {
+		return applyLocationFilters(r.db.Table("locations").Where("deleted_at IS NULL"), filter)
+	})
+	if err != nil {
+		return nil, TotalCount{}, err
+	}
+
+	// Pagination
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 200 {
+		filter.Limit = 200
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	order, ok := locationSortOrders[filter.Sort]
+	if !ok {
+		order = locationSortOrders["updated_at_desc"]
+	}
+	query = query.Offset(offset).Limit(filter.Limit).Order(order)
+
+	err = query.Find(&locations).Error
+	return locations, total, err
+}
+
+// Count returns the number of locations matching the filter (pagination is ignored)
+func (r *LocationRepository) Count(filter LocationFilter) (int64, error) {
+	var total int64
+	err := applyLocationFilters(r.db.Table("locations").Where("deleted_at IS NULL"), filter).Count(&total).Error
+	return total, err
+}
+
+// applyLocationFilters adds the filter's WHERE clauses to a locations query
+func applyLocationFilters(query *gorm.DB, filter LocationFilter) *gorm.DB {
 	if filter.Type != "" {
 		query = query.Where("type = ?", filter.Type)
 	}
 	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
+		query = query.Where("status = ?", model.NormalizeStatus(filter.Status))
 	}
 	if filter.Search != "" {
 		query = query.Where("nama ILIKE ?", "%"+filter.Search+"%")
 	}
+	if filter.MinJiwa != nil {
+		query = query.Where("total_jiwa >= ?", *filter.MinJiwa)
+	}
+	if filter.Provinsi != "" {
+		query = query.Where("(alamat->>'id_provinsi' = ? OR LOWER(alamat->>'nama_provinsi') = LOWER(?))", filter.Provinsi, filter.Provinsi)
+	}
+	for _, facility := range filter.HasFacility {
+		// Seeded/dump data stores booleans, ODK stores "yes"
+		query = query.Where("fasilitas->>? IN ('yes', 'true')", facility)
+	}
 
 	// Bounding box filter
 	if filter.MinLng != nil && filter.MinLat != nil && filter.MaxLng != nil && filter.MaxLat != nil {
@@ -66,35 +170,7 @@ func (r *LocationRepository) FindAll(filter LocationFilter) ([]LocationWithCoord
 		`, *filter.MinLng, *filter.MinLat, *filter.MaxLng, *filter.MaxLat)
 	}
 
-	// Count total
-	countQuery := r.db.Table("locations").Where("deleted_at IS NULL")
-	if filter.Type != "" {
-		countQuery = countQuery.Where("type = ?", filter.Type)
-	}
-	if filter.Status != "" {
-		countQuery = countQuery.Where("status = ?", filter.Status)
-	}
-	if filter.Search != "" {
-		countQuery = countQuery.Where("nama ILIKE ?", "%"+filter.Search+"%")
-	}
-	countQuery.Count(&total)
-
-	// Pagination
-	if filter.Page <= 0 {
-		filter.Page = 1
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 50
-	}
-	if filter.Limit > 200 {
-		filter.Limit = 200
-	}
-
-	offset := (filter.Page - 1) * filter.Limit
-	query = query.Offset(offset).Limit(filter.Limit).Order("updated_at DESC")
-
-	err := query.Find(&locations).Error
-	return locations, total, err
+	return query
 }
 
 func (r *LocationRepository) FindByID(id uuid.UUID) (*LocationWithCoords, error) {
@@ -116,8 +192,37 @@ func (r *LocationRepository) FindByID(id uuid.UUID) (*LocationWithCoords, error)
 	return &location, nil
 }
 
+// SetManualStatus overrides a location's status by hand. The ODK status it replaces is
+// recorded in raw_data so syncs keep the override until ODK reports a different status.
+// Returns gorm.ErrRecordNotFound when the location does not exist.
+func (r *LocationRepository) SetManualStatus(id uuid.UUID, status string) error {
+	result := r.db.Exec(`
+		UPDATE locations SET
+			status = ?,
+			raw_data = COALESCE(raw_data, '{}'::jsonb) || jsonb_build_object(
+				?::text, true,
+				?::text, COALESCE(raw_data->>?, raw_data->>?, status)
+			),
+			updated_at = NOW()
+		WHERE id = ? AND deleted_at IS NULL
+	`, status, model.RawDataManualOverride, model.RawDataManualOverrideODKBase,
+		model.RawDataODKStatus, model.RawDataManualOverrideODKBase, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *LocationRepository) FindPhotos(locationID uuid.UUID) ([]model.LocationPhoto, error) {
 	var photos []model.LocationPhoto
 	err := r.db.Where("location_id = ?", locationID).Find(&photos).Error
 	return photos, err
 }
+
+// CountPhotos returns photo counts for multiple locations (batch query)
+func (r *LocationRepository) CountPhotos(locationIDs []uuid.UUID) (map[uuid.UUID]PhotoCount, error) {
+	return countPhotos(r.db, "location_photos", "location_id", locationIDs)
+}
@@ -0,0 +1,63 @@
+package repository
+
+// LocationStats are aggregate posko figures over the locations matching a filter
+type LocationStats struct {
+	TotalPosko        int64
+	TotalJiwa         int64
+	JumlahKK          int64
+	KebutuhanAirLiter float64
+	ByStatus          []StatusCount
+	ByProvinsi        []RegionCount
+}
+
+// StatusCount is the number of posko with one status
+type StatusCount struct {
+	Status string
+	Count  int64
+}
+
+// kebutuhanAirExpr reads fasilitas.kebutuhan_air (liters per day) as a number; values that
+// are not plain numbers count as 0 instead of failing the cast
+const kebutuhanAirExpr = `CASE WHEN fasilitas->>'kebutuhan_air' ~ '^[0-9]+([.][0-9]+){0,1}$'
+	THEN (fasilitas->>'kebutuhan_air')::numeric ELSE 0 END`
+
+// GetStats aggregates the locations matching the filter: totals, counts per status and per
+// province. total_jiwa and jumlah_kk are the columns precomputed from data_pengungsi at sync.
+func (r *LocationRepository) GetStats(filter LocationFilter) (*LocationStats, error) {
+	stats := &LocationStats{}
+
+	var totals struct {
+		TotalPosko        int64
+		TotalJiwa         int64
+		JumlahKK          int64
+		KebutuhanAirLiter float64
+	}
+	err := applyLocationFilters(r.db.Table("locations").Where("deleted_at IS NULL"), filter).
+		Select(`COUNT(*) AS total_posko,
+			COALESCE(SUM(total_jiwa), 0) AS total_jiwa,
+			COALESCE(SUM(jumlah_kk), 0) AS jumlah_kk,
+			COALESCE(SUM(` + kebutuhanAirExpr + `), 0) AS kebutuhan_air_liter`).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalPosko = totals.TotalPosko
+	stats.TotalJiwa = totals.TotalJiwa
+	stats.JumlahKK = totals.JumlahKK
+	stats.KebutuhanAirLiter = totals.KebutuhanAirLiter
+
+	err = applyLocationFilters(r.db.Table("locations").Where("deleted_at IS NULL"), filter).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Order("count DESC, status").
+		Scan(&stats.ByStatus).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats.ByProvinsi, err = r.CountByRegion("provinsi", filter)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PhotoCount is the number of photos attached to one record
+type PhotoCount struct {
+	Cached int
+	Total  int
+}
+
+// countPhotos counts photos of table grouped by parentColumn for the given parent IDs (batch query)
+func countPhotos(db *gorm.DB, table, parentColumn string, parentIDs []uuid.UUID) (map[uuid.UUID]PhotoCount, error) {
+	result := make(map[uuid.UUID]PhotoCount)
+	if len(parentIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		ParentID uuid.UUID
+		Total    int
+		Cached   int
+	}
+	err := db.Table(table).
+		Select(parentColumn+" AS parent_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE is_cached) AS cached").
+		Where(parentColumn+" IN ?", parentIDs).
+		Group(parentColumn).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.ParentID] = PhotoCount{Cached: row.Cached, Total: row.Total}
+	}
+	return result, nil
+}
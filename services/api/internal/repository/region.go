@@ -0,0 +1,39 @@
+package repository
+
+import "fmt"
+
+// RegionLevels are the admin levels locations can be grouped by (alamat id_/nama_ keys)
+var RegionLevels = []string{"provinsi", "kota_kab", "kecamatan"}
+
+// RegionCount is the number of posko and their total_jiwa in one region
+type RegionCount struct {
+	Kode      string
+	Nama      string
+	Count     int64
+	TotalJiwa int64
+}
+
+// CountByRegion groups locations matching the filter by the alamat code of level
+// (see RegionLevels). Locations without a code at that level are left out.
+func (r *LocationRepository) CountByRegion(level string, filter LocationFilter) ([]RegionCount, error) {
+	valid := false
+	for _, l := range RegionLevels {
+		valid = valid || l == level
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid region level %q", level)
+	}
+
+	kode := fmt.Sprintf("alamat->>'id_%s'", level)
+	var counts []RegionCount
+	err := applyLocationFilters(r.db.Table("locations").Where("deleted_at IS NULL"), filter).
+		Select(fmt.Sprintf(`%s AS kode,
+			COALESCE(MAX(alamat->>'nama_%s'), '') AS nama,
+			COUNT(*) AS count,
+			COALESCE(SUM(total_jiwa), 0) AS total_jiwa`, kode, level)).
+		Where(fmt.Sprintf("COALESCE(%s, '') <> ''", kode)).
+		Group(kode).
+		Order("count DESC, kode").
+		Find(&counts).Error
+	return counts, err
+}
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"log"
+	"math/rand/v2"
 	"sync"
 	"time"
 
@@ -26,6 +27,7 @@ type Config struct {
 	ActiveInterval time.Duration // Default: 30 seconds
 	IdleStartHour  int           // Default: 22 (10 PM)
 	IdleEndHour    int           // Default: 6 (6 AM)
+	MaxJitter      time.Duration // Random delay per form before a scheduled sync, capped at half the interval. Default: 10 seconds
 }
 
 // DefaultConfig returns default scheduler configuration
@@ -36,9 +38,18 @@ func DefaultConfig() *Config {
 		ActiveInterval: 30 * time.Second,
 		IdleStartHour:  22,
 		IdleEndHour:    6,
+		MaxJitter:      10 * time.Second,
 	}
 }
 
+// Forms synced by the scheduler, each started with its own jitter
+const (
+	formPosko = "posko"
+	formFeed  = "feed"
+)
+
+var scheduledForms = []string{formPosko, formFeed}
+
 // Scheduler handles automatic sync scheduling
 type Scheduler struct {
 	config          *Config
@@ -53,6 +64,15 @@ type Scheduler struct {
 	lastFeedSync  time.Time
 	syncCount     int
 	feedSyncCount int
+	pausedUntil   time.Time     // Scheduled syncs are suspended until this time
+	pauseChanged  chan struct{} // Wakes the run loop when the pause is changed
+
+	// Next scheduled cycle and the jittered start of each form within it
+	nextRun     time.Time
+	nextFormRun map[string]time.Time
+
+	// inMaintenance, when set, reports read-only maintenance mode, during which cycles are skipped
+	inMaintenance func() bool
 
 	mu     sync.RWMutex
 	ctx    context.Context
@@ -76,6 +96,7 @@ func NewScheduler(
 		feedSyncService: feedSyncService,
 		sseHub:          sseHub,
 		currentMode:     ModeNormal,
+		pauseChanged:    make(chan struct{}, 1),
 	}
 }
 
@@ -94,7 +115,7 @@ func (s *Scheduler) Start() {
 	log.Println("[Scheduler] Starting...")
 
 	// Initial sync
-	go s.runSyncCycle()
+	go s.runSyncCycle(s.pickJitter(s.getIntervalForMode(s.determineMode())))
 
 	// Main loop
 	go s.run()
@@ -117,22 +138,53 @@ func (s *Scheduler) Stop() {
 // run is the main scheduler loop
 func (s *Scheduler) run() {
 	for {
+		// While paused, wait for the pause to end, then catch up with an immediate sync
+		if until := s.PausedUntil(); !until.IsZero() {
+			log.Printf("[Scheduler] Paused until %s", until.Format(time.RFC3339))
+			select {
+			case <-s.ctx.Done():
+				log.Println("[Scheduler] Stopped")
+				return
+			case <-s.pauseChanged:
+				continue
+			case <-time.After(time.Until(until)):
+			}
+			if !s.PausedUntil().IsZero() {
+				continue
+			}
+			log.Println("[Scheduler] Pause ended, resuming")
+			s.runSyncCycle(nil)
+			continue
+		}
+
 		// Determine current mode and interval
 		mode := s.determineMode()
 		interval := s.getIntervalForMode(mode)
+		jitter := s.pickJitter(interval)
+
+		nextRun := time.Now().Add(interval)
+		nextFormRun := make(map[string]time.Time, len(jitter))
+		for form, d := range jitter {
+			nextFormRun[form] = nextRun.Add(d)
+		}
 
 		s.mu.Lock()
 		s.currentMode = mode
+		s.nextRun = nextRun
+		s.nextFormRun = nextFormRun
 		s.mu.Unlock()
 
-		log.Printf("[Scheduler] Mode: %s, Next sync in: %v", mode, interval)
+		log.Printf("[Scheduler] Mode: %s, Next sync in: %v (jitter posko %v, feed %v)",
+			mode, interval, jitter[formPosko].Round(time.Millisecond), jitter[formFeed].Round(time.Millisecond))
 
 		select {
 		case <-s.ctx.Done():
 			log.Println("[Scheduler] Stopped")
 			return
+		case <-s.pauseChanged:
+			// Re-evaluate: a pause was set during the wait
 		case <-time.After(interval):
-			s.runSyncCycle()
+			s.runSyncCycle(jitter)
 		}
 	}
 }
@@ -172,8 +224,44 @@ func (s *Scheduler) getIntervalForMode(mode Mode) time.Duration {
 	}
 }
 
-// runSyncCycle runs a complete sync cycle
-func (s *Scheduler) runSyncCycle() {
+// pickJitter draws a random start delay for each scheduled form, so forms on the same
+// interval don't hit ODK Central and the database at the same moment
+func (s *Scheduler) pickJitter(interval time.Duration) map[string]time.Duration {
+	maxJitter := s.config.MaxJitter
+	if maxJitter > interval/2 {
+		maxJitter = interval / 2
+	}
+
+	jitter := make(map[string]time.Duration, len(scheduledForms))
+	for _, form := range scheduledForms {
+		if maxJitter > 0 {
+			jitter[form] = rand.N(maxJitter)
+		} else {
+			jitter[form] = 0
+		}
+	}
+	return jitter
+}
+
+// waitJitter sleeps for d unless the scheduler stops first; returns false when stopped
+func (s *Scheduler) waitJitter(d time.Duration) bool {
+	if d <= 0 || s.ctx == nil {
+		return true
+	}
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// runSyncCycle runs a complete sync cycle. Each form starts after its jitter (nil: immediately).
+func (s *Scheduler) runSyncCycle(jitter map[string]time.Duration) {
+	if s.maintenanceMode() {
+		log.Println("[Scheduler] Maintenance mode, skipping sync cycle")
+		return
+	}
 	log.Println("[Scheduler] Running sync cycle...")
 
 	// Broadcast sync start
@@ -183,6 +271,7 @@ func (s *Scheduler) runSyncCycle() {
 		})
 	}
 
+	ctx := s.cycleContext()
 	var wg sync.WaitGroup
 	var poskoResult, feedResult interface{}
 	var poskoErr, feedErr error
@@ -191,7 +280,10 @@ func (s *Scheduler) runSyncCycle() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		poskoResult, poskoErr = s.syncService.SyncAll()
+		if !s.waitJitter(jitter[formPosko]) {
+			return
+		}
+		poskoResult, poskoErr = s.syncService.SyncAllContext(ctx)
 		if poskoErr != nil {
 			log.Printf("[Scheduler] Posko sync error: %v", poskoErr)
 		} else {
@@ -207,7 +299,10 @@ func (s *Scheduler) runSyncCycle() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		feedResult, feedErr = s.feedSyncService.SyncAll()
+		if !s.waitJitter(jitter[formFeed]) {
+			return
+		}
+		feedResult, feedErr = s.feedSyncService.SyncAllContext(ctx)
 		if feedErr != nil {
 			log.Printf("[Scheduler] Feed sync error: %v", feedErr)
 		} else {
@@ -235,6 +330,62 @@ func (s *Scheduler) runSyncCycle() {
 	log.Println("[Scheduler] Sync cycle completed")
 }
 
+// cycleContext returns the context the syncs of a cycle run under: the scheduler's while it
+// is running, so Stop also stops the ODK requests of a cycle in flight
+func (s *Scheduler) cycleContext() context.Context {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.isRunning {
+		return context.Background() // TriggerSync on a stopped scheduler
+	}
+	return s.ctx
+}
+
+// SetMaintenanceCheck makes the scheduler skip its sync cycles, scheduled or triggered,
+// while inMaintenance reports true. Meant to be called before Start.
+func (s *Scheduler) SetMaintenanceCheck(inMaintenance func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inMaintenance = inMaintenance
+}
+
+// maintenanceMode reports whether sync cycles are suspended by maintenance mode
+func (s *Scheduler) maintenanceMode() bool {
+	s.mu.RLock()
+	inMaintenance := s.inMaintenance
+	s.mu.RUnlock()
+	return inMaintenance != nil && inMaintenance()
+}
+
+// PauseUntil suspends scheduled syncs until t, after which the scheduler resumes on its own.
+// A zero or past t clears the pause. Manual TriggerSync still works while paused.
+func (s *Scheduler) PauseUntil(t time.Time) {
+	s.mu.Lock()
+	if t.After(time.Now()) {
+		s.pausedUntil = t
+		log.Printf("[Scheduler] Paused until %s", t.Format(time.RFC3339))
+	} else {
+		s.pausedUntil = time.Time{}
+		log.Println("[Scheduler] Pause cleared")
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.pauseChanged <- struct{}{}:
+	default:
+	}
+}
+
+// PausedUntil returns the end of the current pause, or the zero time if not paused
+func (s *Scheduler) PausedUntil() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if time.Now().Before(s.pausedUntil) {
+		return s.pausedUntil
+	}
+	return time.Time{}
+}
+
 // SetMode manually sets the scheduler mode
 func (s *Scheduler) SetMode(mode Mode) {
 	s.mu.Lock()
@@ -283,13 +434,24 @@ func (s *Scheduler) GetStatus() map[string]interface{} {
 	if s.manualMode != nil {
 		status["manual_mode"] = *s.manualMode
 	}
+	paused := time.Now().Before(s.pausedUntil)
+	status["paused"] = paused
+	if paused {
+		status["paused_until"] = s.pausedUntil
+	}
+	if s.isRunning && !paused && !s.nextRun.IsZero() {
+		status["next_run"] = s.nextRun
+		status["next_runs"] = s.nextFormRun
+	}
+	status["max_jitter"] = s.config.MaxJitter.String()
+	status["maintenance"] = s.inMaintenance != nil && s.inMaintenance()
 
 	return status
 }
 
 // TriggerSync manually triggers a sync cycle
 func (s *Scheduler) TriggerSync() {
-	go s.runSyncCycle()
+	go s.runSyncCycle(nil)
 }
 
 func errorToString(err error) string {
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// CoordinateBounds is the box a mapped point must fall in to be stored
+type CoordinateBounds struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// DefaultCoordinateBounds are the plausible bounds of a point in Indonesia
+var DefaultCoordinateBounds = CoordinateBounds{MinLat: -11, MaxLat: 6, MinLon: 95, MaxLon: 141}
+
+// coordinateBounds are used by the mappers to catch swapped and out-of-range coordinates
+var coordinateBounds = DefaultCoordinateBounds
+
+// SetCoordinateBounds replaces the bounds mapped coordinates are validated against.
+// Meant to be called once at startup, before any sync runs.
+func SetCoordinateBounds(bounds CoordinateBounds) {
+	coordinateBounds = bounds
+}
+
+// ParseCoordinateBounds parses "min_lat,max_lat,min_lon,max_lon" (e.g. "-11,6,95,141").
+// An empty value returns DefaultCoordinateBounds.
+func ParseCoordinateBounds(value string) (CoordinateBounds, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultCoordinateBounds, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return CoordinateBounds{}, fmt.Errorf("want min_lat,max_lat,min_lon,max_lon, got %q", value)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return CoordinateBounds{}, fmt.Errorf("invalid number %q", part)
+		}
+		v[i] = f
+	}
+	bounds := CoordinateBounds{MinLat: v[0], MaxLat: v[1], MinLon: v[2], MaxLon: v[3]}
+	if bounds.MinLat < -90 || bounds.MaxLat > 90 || bounds.MinLat >= bounds.MaxLat {
+		return CoordinateBounds{}, fmt.Errorf("latitude range %v..%v must be increasing within -90..90", bounds.MinLat, bounds.MaxLat)
+	}
+	if bounds.MinLon < -180 || bounds.MaxLon > 180 || bounds.MinLon >= bounds.MaxLon {
+		return CoordinateBounds{}, fmt.Errorf("longitude range %v..%v must be increasing within -180..180", bounds.MinLon, bounds.MaxLon)
+	}
+	return bounds, nil
+}
+
+// contains reports whether lat/lon fall inside the bounds
+func (b CoordinateBounds) contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat &&
+		lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// fixSwappedCoords swaps lat and lon in place when they are implausible as given but
+// form a valid point swapped: forms and mappers disagree on "lat lon" vs "lon lat"
+// order. Coordinates invalid either way are left alone. Returns true on a swap.
+func fixSwappedCoords(kind, submissionID string, lat, lon *float64) bool {
+	if lat == nil || lon == nil || coordinateBounds.contains(*lat, *lon) || !coordinateBounds.contains(*lon, *lat) {
+		return false
+	}
+	log.Printf("Swapped %s coordinates of submission %s: lat %v / lon %v looked reversed", kind, submissionID, *lat, *lon)
+	*lat, *lon = *lon, *lat
+	return true
+}
+
+// validateCoords clears coordinates that are outside coordinateBounds or only half present,
+// so no bogus point is stored for them. Missing coordinates are valid. Returns false, after
+// logging a warning, when the coordinates were cleared.
+func validateCoords(kind, submissionID string, lat, lon **float64) bool {
+	if *lat == nil && *lon == nil {
+		return true
+	}
+	if *lat != nil && *lon != nil && coordinateBounds.contains(**lat, **lon) {
+		return true
+	}
+	log.Printf("Warning: dropped invalid %s coordinates of submission %s: lat %s / lon %s",
+		kind, submissionID, formatCoord(*lat), formatCoord(*lon))
+	*lat, *lon = nil, nil
+	return false
+}
+
+func formatCoord(v *float64) string {
+	if v == nil {
+		return "missing"
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
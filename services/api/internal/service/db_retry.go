@@ -0,0 +1,69 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbRetryAttempts is the total number of attempts for a per-record write
+const dbRetryAttempts = 3
+
+// dbRetryBackoff is the wait before the first retry; it doubles on each further retry
+const dbRetryBackoff = 200 * time.Millisecond
+
+// withDBRetry runs fn, retrying with a short backoff when it fails with a transient
+// connection error. Other errors (constraint violations, bad SQL) are returned immediately.
+func withDBRetry(op string, fn func() error) error {
+	backoff := dbRetryBackoff
+	var err error
+	for attempt := 1; attempt <= dbRetryAttempts; attempt++ {
+		err = fn()
+		if err == nil || !isTransientDBError(err) {
+			return err
+		}
+		if attempt < dbRetryAttempts {
+			log.Printf("Transient DB error during %s (attempt %d/%d), retrying in %v: %v", op, attempt, dbRetryAttempts, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// isTransientDBError reports whether err is a connection-level failure worth retrying
+func isTransientDBError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"): // connection_exception class
+			return true
+		case pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03": // admin/crash shutdown, cannot connect now
+			return true
+		case pgErr.Code == "40001", pgErr.Code == "40P01": // serialization failure, deadlock
+			return true
+		default: // constraint violations, syntax errors, etc.
+			return false
+		}
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/leksa/datamapper-senyar/internal/model"
+)
+
+// EnrichWilayahResult reports a wilayah re-enrichment pass over stored records
+type EnrichWilayahResult struct {
+	Checked      int      `json:"checked"`
+	Enriched     int      `json:"enriched"` // records that gained region names/IDs
+	Errors       int      `json:"errors"`
+	Duration     string   `json:"duration"`
+	ErrorDetails []string `json:"error_details,omitempty"`
+}
+
+// Region keys filled in by enrichment, per form
+var (
+	locationWilayahKeys = []string{"nama_provinsi", "nama_kota_kab", "nama_kecamatan", "nama_desa"}
+	faskesWilayahKeys   = []string{"id_provinsi", "id_kota_kab"}
+)
+
+// EnrichWilayah re-runs the wilayah name lookup over every location's stored alamat, e.g. for
+// records synced while the wilayah reference tables were still empty. Only alamat is rewritten.
+func (s *SyncService) EnrichWilayah() (*EnrichWilayahResult, error) {
+	defer s.lockSync()()
+
+	start := time.Now()
+	var locations []model.Location
+	if err := s.db.Select("id", "alamat").
+		Where("deleted_at IS NULL AND alamat IS NOT NULL").
+		Find(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load locations: %w", err)
+	}
+
+	result := &EnrichWilayahResult{Checked: len(locations)}
+	for _, loc := range locations {
+		before := snapshotKeys(loc.Alamat, locationWilayahKeys)
+		s.enrichAlamatWithWilayah(loc.Alamat)
+
+		changed, gained := compareSnapshot(before, loc.Alamat)
+		if !changed {
+			continue
+		}
+		err := withDBRetry("update location alamat", func() error {
+			return s.db.Model(&model.Location{}).Where("id = ?", loc.ID).Update("alamat", loc.Alamat).Error
+		})
+		if err != nil {
+			result.Errors++
+			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to update location %s: %v", loc.ID, err))
+			continue
+		}
+		if gained {
+			result.Enriched++
+		}
+	}
+
+	result.Duration = time.Since(start).String()
+	log.Printf("Enrich wilayah posko completed: %d records, %d enriched, %d errors", result.Checked, result.Enriched, result.Errors)
+
+	return result, nil
+}
+
+// EnrichWilayah re-runs the region ID lookup over every faskes' stored alamat
+func (s *FaskesSyncService) EnrichWilayah() (*EnrichWilayahResult, error) {
+	defer s.lockSync()()
+
+	start := time.Now()
+	var faskesList []model.Faskes
+	if err := s.db.Select("id", "alamat").
+		Where("deleted_at IS NULL AND alamat IS NOT NULL").
+		Find(&faskesList).Error; err != nil {
+		return nil, fmt.Errorf("failed to load faskes: %w", err)
+	}
+
+	result := &EnrichWilayahResult{Checked: len(faskesList)}
+	for i := range faskesList {
+		faskes := &faskesList[i]
+		before := snapshotKeys(faskes.Alamat, faskesWilayahKeys)
+		s.injectRegionIDs(faskes)
+
+		changed, gained := compareSnapshot(before, faskes.Alamat)
+		if !changed {
+			continue
+		}
+		err := withDBRetry("update faskes alamat", func() error {
+			return s.db.Model(&model.Faskes{}).Where("id = ?", faskes.ID).Update("alamat", faskes.Alamat).Error
+		})
+		if err != nil {
+			result.Errors++
+			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to update faskes %s: %v", faskes.ID, err))
+			continue
+		}
+		if gained {
+			result.Enriched++
+		}
+	}
+
+	result.Duration = time.Since(start).String()
+	log.Printf("Enrich wilayah faskes completed: %d records, %d enriched, %d errors", result.Checked, result.Enriched, result.Errors)
+
+	return result, nil
+}
+
+// snapshotKeys copies the string values of keys from alamat
+func snapshotKeys(alamat model.JSONB, keys []string) map[string]string {
+	snapshot := make(map[string]string, len(keys))
+	for _, key := range keys {
+		snapshot[key], _ = alamat[key].(string)
+	}
+	return snapshot
+}
+
+// compareSnapshot reports whether any snapshotted key changed, and whether any went from blank to set
+func compareSnapshot(before map[string]string, alamat model.JSONB) (changed, gained bool) {
+	for key, old := range before {
+		now, _ := alamat[key].(string)
+		if now == old {
+			continue
+		}
+		changed = true
+		if old == "" && now != "" {
+			gained = true
+		}
+	}
+	return changed, gained
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntityKeyStrategy resolves the entity an ODK submission belongs to, for forms where a
+// registration and its later updates make up one record.
+//
+// With a ModeField, submissions whose mode equals UpdateMode take their entity ID from
+// UpdateKeys and all others from NewKeys; without one, UpdateKeys are used for every
+// submission. Keys are tried in order and may be nested with dots
+// ("grp_identifikasi.sel_jembatan"). An empty result leaves the choice to the form's
+// fallback (posko: the entity mapping, then the submission ID; faskes: calc_nama_faskes).
+//
+// Feeds are not entity based: every feed submission is its own record and grp_relasi
+// only links it to a posko or faskes.
+type EntityKeyStrategy struct {
+	ModeField  string
+	UpdateMode string
+	UpdateKeys []string
+	NewKeys    []string
+}
+
+// Default entity key strategies of the entity based forms
+var (
+	DefaultPoskoEntityKeys = EntityKeyStrategy{
+		ModeField:  "mode",
+		UpdateMode: "update",
+		UpdateKeys: []string{"sel_posko"},
+	}
+	DefaultFaskesEntityKeys = EntityKeyStrategy{
+		UpdateKeys: []string{"sel_faskes"},
+	}
+	DefaultInfrastrukturEntityKeys = EntityKeyStrategy{
+		UpdateKeys: []string{"grp_identifikasi.sel_jembatan", "sel_jembatan"},
+	}
+)
+
+// Resolve returns the entity ID of a submission, or "" when none of the keys is set
+func (k EntityKeyStrategy) Resolve(submission map[string]interface{}) string {
+	keys := k.UpdateKeys
+	if k.ModeField != "" {
+		if mode, _ := lookupPath(submission, k.ModeField).(string); mode != k.UpdateMode {
+			keys = k.NewKeys
+		}
+	}
+
+	for _, key := range keys {
+		if id, ok := lookupPath(submission, key).(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// ParseEntityKeyStrategy parses "name=value" settings separated by semicolons, e.g.
+// "mode_field=mode;update_mode=update;update_keys=sel_posko;new_keys=", on top of the
+// defaults. Key lists are separated by "|"; an empty value clears the setting and
+// settings that are not given keep their default. An empty spec returns the defaults.
+func ParseEntityKeyStrategy(spec string, defaults EntityKeyStrategy) (EntityKeyStrategy, error) {
+	k := defaults
+	for _, setting := range strings.Split(spec, ";") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return defaults, fmt.Errorf("invalid entity key setting %q (want name=value)", setting)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(name) {
+		case "mode_field":
+			k.ModeField = value
+		case "update_mode":
+			k.UpdateMode = value
+		case "update_keys":
+			k.UpdateKeys = splitEntityKeys(value)
+		case "new_keys":
+			k.NewKeys = splitEntityKeys(value)
+		default:
+			return defaults, fmt.Errorf("unknown entity key setting %q (want mode_field, update_mode, update_keys or new_keys)", name)
+		}
+	}
+
+	if k.ModeField != "" && k.UpdateMode == "" {
+		return defaults, fmt.Errorf("entity key strategy with mode_field %q needs an update_mode", k.ModeField)
+	}
+	if len(k.UpdateKeys) == 0 && len(k.NewKeys) == 0 {
+		return defaults, fmt.Errorf("entity key strategy needs update_keys or new_keys")
+	}
+	return k, nil
+}
+
+func splitEntityKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, "|") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
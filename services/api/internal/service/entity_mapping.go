@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/leksa/datamapper-senyar/internal/odk"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEntityMappingTTL     = 6 * time.Hour
+	defaultEntityMappingTimeout = 5 * time.Minute
+)
+
+// Modes for a posko entity dataset that does not exist in ODK Central
+const (
+	EntityFallbackSubmission = "submission" // sync using submission IDs as entity IDs (default)
+	EntityFallbackFail       = "fail"       // fail the sync until the dataset is created
+)
+
+// ErrEntityDatasetMissing is returned by posko syncs in EntityFallbackFail mode
+// when the entity dataset does not exist in ODK Central
+var ErrEntityDatasetMissing = errors.New("entity dataset not found in ODK Central")
+
+// SetEntityFallback sets what a sync does when the entity dataset is missing
+// (EntityFallbackSubmission or EntityFallbackFail). An empty mode keeps the default.
+func (s *SyncService) SetEntityFallback(mode string) error {
+	switch mode {
+	case "":
+		return nil
+	case EntityFallbackSubmission, EntityFallbackFail:
+		s.entityFallback = mode
+		return nil
+	}
+	return fmt.Errorf("unknown entity fallback %q (want %s or %s)", mode, EntityFallbackSubmission, EntityFallbackFail)
+}
+
+// SubmissionMode reports whether posko syncs run without the entity dataset, using
+// submission IDs as entity IDs: every edit of a posko then becomes a new record
+func (s *SyncService) SubmissionMode() bool {
+	return s.submissionMode.Load()
+}
+
+// isDatasetMissing reports whether a mapping fetch failed because ODK Central has no such dataset
+func isDatasetMissing(err error) bool {
+	var statusErr *odk.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// SetEntityMappingCache sets how long a persisted entity mapping is reused (0 always refetches)
+// and the deadline for fetching it from ODK Central
+func (s *SyncService) SetEntityMappingCache(ttl, timeout time.Duration) {
+	if ttl >= 0 {
+		s.entityMappingTTL = ttl
+	}
+	if timeout > 0 {
+		s.entityMappingTimeout = timeout
+	}
+}
+
+// loadEntityMapping makes the submission-to-entity lookup available, preferring (in order)
+// the in-memory cache, the persisted mapping if younger than the TTL, and a fetch from ODK.
+// A failed fetch leaves an empty cache so getEntityID falls back to submission IDs. A missing
+// dataset returns ErrEntityDatasetMissing in EntityFallbackFail mode, otherwise it switches to
+// submission mode and is looked up again on every sync until it has been created.
+func (s *SyncService) loadEntityMapping(ctx context.Context) error {
+	if s.submissionToEntityCache != nil && !s.SubmissionMode() {
+		return nil // Already loaded
+	}
+
+	if s.entityMappingTTL > 0 {
+		stored, fetchedAt, err := s.loadStoredEntityMapping()
+		if err != nil {
+			log.Printf("Warning: could not read stored entity mapping: %v", err)
+		} else if len(stored) > 0 && time.Since(fetchedAt) < s.entityMappingTTL {
+			s.submissionToEntityCache = stored
+			log.Printf("Loaded stored entity mapping: %d entities (fetched %s ago)", len(stored), time.Since(fetchedAt).Round(time.Second))
+			return nil
+		}
+	}
+
+	if err := s.refreshEntityMapping(ctx); err != nil {
+		if isDatasetMissing(err) {
+			if s.entityFallback == EntityFallbackFail {
+				return fmt.Errorf("%w: %s (create it in ODK Central or set ENTITY_DATASET_FALLBACK=%s)",
+					ErrEntityDatasetMissing, s.entityDataset, EntityFallbackSubmission)
+			}
+			log.Printf("WARNING: entity dataset %s not found in ODK Central - syncing posko in SUBMISSION MODE: "+
+				"every edit of a posko becomes a new record. Create the dataset in ODK Central to fix this.", s.entityDataset)
+			s.submissionMode.Store(true)
+		} else {
+			log.Printf("Warning: could not load entity mapping: %v (will use submission ID as fallback)", err)
+		}
+		s.submissionToEntityCache = make(map[string]string) // empty cache
+	}
+	return nil
+}
+
+// refreshEntityMapping fetches the entity-to-submission mapping from ODK Central, inverts it
+// to submission-to-entity for efficient lookup and persists it. On error the current cache is kept.
+func (s *SyncService) refreshEntityMapping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.entityMappingTimeout)
+	defer cancel()
+
+	// Get entity -> submission mapping from ODK
+	entityToSubmission, err := s.odkClient.GetEntitySubmissionMappingContext(ctx, s.entityDataset)
+	if err != nil {
+		return err
+	}
+
+	// Invert to submission -> entity mapping
+	submissionToEntity := make(map[string]string, len(entityToSubmission))
+	for entityUUID, submissionID := range entityToSubmission {
+		submissionToEntity[submissionID] = entityUUID
+	}
+	s.submissionToEntityCache = submissionToEntity
+	s.submissionMode.Store(false)
+
+	if err := s.storeEntityMapping(entityToSubmission); err != nil {
+		log.Printf("Warning: could not persist entity mapping: %v", err)
+	}
+
+	log.Printf("Loaded entity mapping: %d entities", len(s.submissionToEntityCache))
+	return nil
+}
+
+// RefreshEntityMapping refetches the entity mapping from ODK Central now, replacing the
+// persisted copy. Returns the number of mapped entities.
+func (s *SyncService) RefreshEntityMapping() (int, error) {
+	defer s.lockSync()()
+
+	if err := s.refreshEntityMapping(s.syncCtx()); err != nil {
+		return 0, err
+	}
+	return len(s.submissionToEntityCache), nil
+}
+
+// StoredEntitySources returns the persisted entity UUID -> source submission ID mapping
+// of the posko dataset, as last fetched from ODK Central (empty if never fetched)
+func (s *SyncService) StoredEntitySources() (map[string]string, error) {
+	var rows []odk.EntityMapping
+	if err := s.db.Where("dataset = ?", s.entityDataset).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string, len(rows))
+	for _, row := range rows {
+		sources[row.EntityUUID] = row.SubmissionID
+	}
+	return sources, nil
+}
+
+// loadStoredEntityMapping reads the persisted mapping (inverted to submission -> entity)
+// and when it was fetched
+func (s *SyncService) loadStoredEntityMapping() (map[string]string, time.Time, error) {
+	var rows []odk.EntityMapping
+	if err := s.db.Where("dataset = ?", s.entityDataset).Find(&rows).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+
+	mapping := make(map[string]string, len(rows))
+	var fetchedAt time.Time
+	for i, row := range rows {
+		mapping[row.SubmissionID] = row.EntityUUID
+		if i == 0 || row.FetchedAt.Before(fetchedAt) {
+			fetchedAt = row.FetchedAt
+		}
+	}
+	return mapping, fetchedAt, nil
+}
+
+// storeEntityMapping replaces the persisted mapping of the dataset
+func (s *SyncService) storeEntityMapping(entityToSubmission map[string]string) error {
+	now := time.Now()
+	rows := make([]odk.EntityMapping, 0, len(entityToSubmission))
+	for entityUUID, submissionID := range entityToSubmission {
+		rows = append(rows, odk.EntityMapping{
+			Dataset:      s.entityDataset,
+			EntityUUID:   entityUUID,
+			SubmissionID: submissionID,
+			FetchedAt:    now,
+		})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dataset = ?", s.entityDataset).Delete(&odk.EntityMapping{}).Error; err != nil {
+			return fmt.Errorf("failed to clear entity mapping: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(rows, 500).Error; err != nil {
+			return fmt.Errorf("failed to store entity mapping: %w", err)
+		}
+		return nil
+	})
+}
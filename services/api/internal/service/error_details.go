@@ -0,0 +1,44 @@
+package service
+
+// DefaultMaxErrorDetails is how many error messages a sync result keeps by default
+const DefaultMaxErrorDetails = 50
+
+// maxErrorDetails caps the error_details of sync results, so a systemic failure (ODK
+// Central down, every record failing) doesn't produce thousands of entries. 0 = no cap.
+var maxErrorDetails = DefaultMaxErrorDetails
+
+// SetMaxErrorDetails sets how many error messages sync results keep (0 = all); the
+// others are only counted in truncated_errors. Meant to be called once at startup.
+func SetMaxErrorDetails(n int) {
+	maxErrorDetails = n
+}
+
+// appendErrorDetail appends detail unless details is full, in which case it counts the
+// omitted message in *truncated instead
+func appendErrorDetail(details []string, truncated *int, detail string) []string {
+	if maxErrorDetails > 0 && len(details) >= maxErrorDetails {
+		*truncated++
+		return details
+	}
+	return append(details, detail)
+}
+
+func (r *SyncResult) addErrorDetail(detail string) {
+	r.ErrorDetails = appendErrorDetail(r.ErrorDetails, &r.TruncatedErrors, detail)
+}
+
+func (r *FeedSyncResult) addErrorDetail(detail string) {
+	r.ErrorDetails = appendErrorDetail(r.ErrorDetails, &r.TruncatedErrors, detail)
+}
+
+func (r *PhotoSyncResult) addErrorDetail(detail string) {
+	r.ErrorDetails = appendErrorDetail(r.ErrorDetails, &r.TruncatedErrors, detail)
+}
+
+// mergeErrorDetails adds the error messages of a partial result, keeping the cap
+func (r *PhotoSyncResult) mergeErrorDetails(other *PhotoSyncResult) {
+	for _, detail := range other.ErrorDetails {
+		r.addErrorDetail(detail)
+	}
+	r.TruncatedErrors += other.TruncatedErrors
+}
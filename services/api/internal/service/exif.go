@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// EXIF orientations (tag 0x0112): how the stored pixels must be transformed for display.
+// Phone cameras store portrait photos as landscape pixels with orientation 6 or 8.
+const (
+	orientationNormal     = 1
+	orientationTranspose  = 5 // orientations 5-8 swap width and height
+	orientationTransverse = 7
+)
+
+// exifHeaderSize bounds how much of a file is read for its EXIF orientation: the APP1
+// segment holding it is at most 64 KiB and comes before the image data
+const exifHeaderSize = 64 << 10
+
+// exifOrientation returns the EXIF orientation (1-8) of a JPEG from its leading bytes,
+// orientationNormal when it has none or data is not a JPEG
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return orientationNormal
+	}
+
+	// Walk the marker segments up to the image data
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return orientationNormal
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // fill byte
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return orientationNormal
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return orientationNormal
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return orientationNormal
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of an EXIF TIFF block
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return orientationNormal
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		// SHORT value, stored in the first bytes of the value field
+		if o := int(order.Uint16(tiff[entry+8:])); o >= orientationNormal && o <= 8 {
+			return o
+		}
+		break
+	}
+	return orientationNormal
+}
+
+// orientedSize returns the display size of a w x h image stored with the given orientation
+func orientedSize(w, h, orientation int) (int, int) {
+	if orientation >= orientationTranspose {
+		return h, w
+	}
+	return w, h
+}
+
+// applyOrientation returns img transformed as its EXIF orientation says, so it displays
+// upright without the tag (thumbnails are encoded without EXIF)
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= orientationNormal || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := orientedSize(w, h, orientation)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case orientationTranspose:
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise for display
+				sx, sy = y, h-1-x
+			case orientationTransverse:
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90 counter-clockwise for display
+				sx, sy = w-1-y, x
+			}
+			dst.SetRGBA(x, y, img.RGBAAt(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
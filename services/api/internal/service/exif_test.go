@@ -0,0 +1,140 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// orientedJPEG encodes a w x h JPEG, red on the left half and blue on the right, with an
+// EXIF APP1 segment holding the orientation (none when orientation is 0)
+func orientedJPEG(t *testing.T, w, h, orientation int, order binary.ByteOrder) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 0xff, A: 0xff}
+			if x >= w/2 {
+				c = color.RGBA{B: 0xff, A: 0xff}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	data := encoded.Bytes()
+	if orientation == 0 {
+		return data
+	}
+
+	// TIFF header, IFD0 with a single orientation entry
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], uint16(orientation))
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...) // SOI
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", orientedJPEG(t, 8, 4, 0, binary.BigEndian), 1},
+		{"big endian rotated", orientedJPEG(t, 8, 4, 6, binary.BigEndian), 6},
+		{"little endian rotated", orientedJPEG(t, 8, 4, 8, binary.LittleEndian), 8},
+		{"not a jpeg", []byte("%PDF-1.4"), 1},
+		{"truncated", orientedJPEG(t, 8, 4, 6, binary.BigEndian)[:12], 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("exifOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDimensionsApplyOrientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation int
+		wantW       int
+		wantH       int
+	}{
+		{"none", 0, 80, 40},
+		{"rotated 180", 3, 80, 40},
+		{"rotated 90", 6, 40, 80},
+		{"rotated 270", 8, 40, 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := imageDimensions(orientedJPEG(t, 80, 40, tt.orientation, binary.BigEndian))
+			if w == nil || h == nil {
+				t.Fatal("no dimensions")
+			}
+			if *w != tt.wantW || *h != tt.wantH {
+				t.Errorf("dimensions = %dx%d, want %dx%d", *w, *h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailApplyOrientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation int
+		wantW       int
+		wantH       int
+		redAt       image.Point // a pixel from the red (left) half of the stored image
+	}{
+		{"none", 0, 400, 200, image.Pt(10, 100)},
+		{"rotated 90 clockwise", 6, 200, 400, image.Pt(100, 10)},
+		{"rotated 90 counter-clockwise", 8, 200, 400, image.Pt(100, 390)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, ok := makeThumbnail(orientedJPEG(t, 800, 400, tt.orientation, binary.BigEndian))
+			if !ok {
+				t.Fatal("no thumbnail")
+			}
+			img, err := jpeg.Decode(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("decode thumbnail: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Fatalf("thumbnail = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			r, _, b, _ := img.At(tt.redAt.X, tt.redAt.Y).RGBA()
+			if r < b {
+				t.Errorf("pixel %v is not red (r=%d b=%d)", tt.redAt, r>>8, b>>8)
+			}
+		})
+	}
+}
@@ -11,7 +11,7 @@ import (
 // MapSubmissionToFaskes converts an ODK submission to a Faskes model
 func MapSubmissionToFaskes(submission map[string]interface{}) (*model.Faskes, error) {
 	faskes := &model.Faskes{
-		StatusFaskes: "operasional",
+		StatusFaskes: model.FaskesStatusOperasional,
 	}
 
 	// Extract __id as ODK submission ID
@@ -67,8 +67,8 @@ func MapSubmissionToFaskes(submission map[string]interface{}) (*model.Faskes, er
 		if jenis, ok := grpIdentitas["jenis_faskes"].(string); ok {
 			faskes.JenisFaskes = jenis
 		}
-		if status, ok := grpIdentitas["status_faskes"].(string); ok {
-			faskes.StatusFaskes = status
+		if status, ok := grpIdentitas["status_faskes"].(string); ok && status != "" {
+			faskes.StatusFaskes = model.NormalizeStatus(status)
 		}
 		if kondisi, ok := grpIdentitas["kondisi_faskes"].(string); ok {
 			faskes.KondisiFaskes = &kondisi
@@ -189,45 +189,26 @@ func MapSubmissionToFaskes(submission map[string]interface{}) (*model.Faskes, er
 		}
 	}
 
+	// Some submissions have lat/lon in the wrong order; points still out of range are dropped
+	submissionID, _ := submission["__id"].(string)
+	fixSwappedCoords("faskes", submissionID, faskes.Latitude, faskes.Longitude)
+	faskes.InvalidCoordinates = !validateCoords("faskes", submissionID, &faskes.Latitude, &faskes.Longitude)
+
 	// Store raw submission data
 	faskes.RawData = model.JSONB(submission)
 
 	return faskes, nil
 }
 
-// ExtractFaskesPhotos extracts photo information from a faskes submission
-func ExtractFaskesPhotos(submission map[string]interface{}) []PhotoInfo {
-	var photos []PhotoInfo
-
-	grpFoto, ok := submission["grp_foto"].(map[string]interface{})
-	if !ok {
-		return photos
-	}
-
-	photoFields := []struct {
-		field     string
-		photoType string
-	}{
-		{"foto_depan", "tampak_depan"},
-		{"foto_area1", "area_1"},
-		{"foto_area2", "area_2"},
-		{"foto_area3", "area_3"},
-	}
-
-	submissionID := ""
-	if id, ok := submission["__id"].(string); ok {
-		submissionID = id
-	}
-
-	for _, pf := range photoFields {
-		if filename, ok := grpFoto[pf.field].(string); ok && filename != "" {
-			photos = append(photos, PhotoInfo{
-				Filename:     filename,
-				PhotoType:    pf.photoType,
-				SubmissionID: submissionID,
-			})
-		}
-	}
+// DefaultFaskesPhotoFields are the faskes form's photo slots in grp_foto
+var DefaultFaskesPhotoFields = []PhotoField{
+	{Field: "foto_depan", PhotoType: "tampak_depan"},
+	{Field: "foto_area1", PhotoType: "area_1"},
+	{Field: "foto_area2", PhotoType: "area_2"},
+	{Field: "foto_area3", PhotoType: "area_3"},
+}
 
-	return photos
+// ExtractFaskesPhotos extracts photo information from a faskes submission using the default slots
+func ExtractFaskesPhotos(submission map[string]interface{}) []PhotoInfo {
+	return extractPhotoFields(submission, DefaultFaskesPhotoFields)
 }
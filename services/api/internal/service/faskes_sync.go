@@ -15,31 +15,67 @@ import (
 
 // FaskesSyncService handles synchronization of faskes data from ODK Central
 type FaskesSyncService struct {
-	db        *gorm.DB
-	odkClient *odk.Client
-	formID    string
+	syncHooks
+	syncLock
+	syncEvents
+	hardSyncGuard
+	syncContext
+	syncRunHistory
+	db         *gorm.DB
+	odkClient  *odk.Client
+	formID     string
+	entityKeys EntityKeyStrategy // resolves the faskes a submission belongs to
+
+	// photoFields are the grp_foto slots downloaded as faskes photos
+	photoFields []PhotoField
 }
 
 // NewFaskesSyncService creates a new faskes sync service
 func NewFaskesSyncService(db *gorm.DB, odkClient *odk.Client, formID string) *FaskesSyncService {
 	return &FaskesSyncService{
-		db:        db,
-		odkClient: odkClient,
-		formID:    formID,
+		db:         db,
+		odkClient:  odkClient,
+		formID:     formID,
+		entityKeys: DefaultFaskesEntityKeys,
+
+		photoFields: DefaultFaskesPhotoFields,
+
+		syncEvents:    syncEvents{form: "faskes"},
+		hardSyncGuard: hardSyncGuard{minPercent: defaultHardSyncMinPercent},
 	}
 }
 
+// SetEntityKeys overrides how the entity of a faskes submission is resolved
+func (s *FaskesSyncService) SetEntityKeys(keys EntityKeyStrategy) {
+	s.entityKeys = keys
+}
+
+// SetPhotoFields overrides which grp_foto fields are synced as faskes photos
+func (s *FaskesSyncService) SetPhotoFields(fields []PhotoField) {
+	if len(fields) == 0 {
+		return
+	}
+	s.photoFields = fields
+}
+
 // SyncAll performs a full synchronization of all approved faskes submissions
 func (s *FaskesSyncService) SyncAll() (*SyncResult, error) {
+	defer s.lockSync()()
+	return s.syncAll()
+}
+
+// syncAll is SyncAll for callers already holding the sync lock
+func (s *FaskesSyncService) syncAll() (*SyncResult, error) {
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
 
 	// Update sync state to "syncing"
 	s.updateSyncState("syncing", nil)
+	s.publishStarted(model.SyncRunModeFull)
 
 	// Fetch all approved submissions
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(s.syncCtx())
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch faskes submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -55,12 +91,14 @@ func (s *FaskesSyncService) SyncAll() (*SyncResult, error) {
 	log.Printf("Filtered to %d latest submissions (by entity)", len(latestSubmissions))
 
 	// Process each submission
+	progress := s.newProgress(model.SyncRunModeFull, len(latestSubmissions))
 	for _, submission := range latestSubmissions {
 		if err := s.processSubmission(submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing faskes submission: %v", err)
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
 	result.EndTime = time.Now()
@@ -68,6 +106,9 @@ func (s *FaskesSyncService) SyncAll() (*SyncResult, error) {
 
 	// Update sync state
 	s.updateSyncStateSuccess(len(latestSubmissions))
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeFull, result)
+	s.publishCompleted(model.SyncRunModeFull, result)
+	s.runAfterSync()
 
 	log.Printf("Faskes sync completed: %d fetched, %d filtered, %d created, %d updated, %d errors",
 		result.TotalFetched, len(latestSubmissions), result.Created, result.Updated, result.Errors)
@@ -89,8 +130,8 @@ func (s *FaskesSyncService) filterLatestPerEntity(submissions []map[string]inter
 			continue
 		}
 
-		// Get entity ID (sel_faskes) - this is the unique identifier for the faskes
-		entityID, _ := submission["sel_faskes"].(string)
+		// Get entity ID (sel_faskes by default) - this is the unique identifier for the faskes
+		entityID := s.entityKeys.Resolve(submission)
 		if entityID == "" {
 			// Fallback to calc_nama_faskes if no entity ID
 			entityID = calcNama
@@ -143,25 +184,29 @@ func (s *FaskesSyncService) processSubmission(submission map[string]interface{},
 	if err != nil {
 		return fmt.Errorf("failed to map faskes submission %s: %w", odkID, err)
 	}
+	if faskes.InvalidCoordinates {
+		result.InvalidCoordinates++
+	}
 
 	// Inject region IDs from wilayah reference if not present
 	s.injectRegionIDs(faskes)
 
 	// Check if faskes already exists
 	var existingFaskes model.Faskes
-	err = s.db.Where("odk_submission_id = ?", odkID).First(&existingFaskes).Error
+	err = withDBRetry("lookup", func() error { return s.db.Where("odk_submission_id = ?", odkID).First(&existingFaskes).Error })
 
 	if err == gorm.ErrRecordNotFound {
 		// Create new faskes
-		if err := s.createFaskes(faskes); err != nil {
+		if err := withDBRetry("create faskes", func() error { return s.createFaskes(faskes) }); err != nil {
 			return fmt.Errorf("failed to create faskes for %s: %w", odkID, err)
 		}
 		result.Created++
+		noteName(&result.Changes.Created, faskes.Nama)
 		log.Printf("Created faskes: %s (%s)", faskes.Nama, odkID)
 	} else if err == nil {
 		// Update existing faskes
 		faskes.ID = existingFaskes.ID
-		if err := s.updateFaskes(faskes); err != nil {
+		if err := withDBRetry("update faskes", func() error { return s.updateFaskes(faskes) }); err != nil {
 			return fmt.Errorf("failed to update faskes for %s: %w", odkID, err)
 		}
 		result.Updated++
@@ -171,7 +216,7 @@ func (s *FaskesSyncService) processSubmission(submission map[string]interface{},
 	}
 
 	// Process photos
-	photos := ExtractFaskesPhotos(submission)
+	photos := extractPhotoFields(submission, s.photoFields)
 	for _, photo := range photos {
 		if err := s.processPhoto(faskes.ID, photo); err != nil {
 			log.Printf("Warning: failed to process faskes photo %s: %v", photo.Filename, err)
@@ -189,7 +234,7 @@ func (s *FaskesSyncService) createFaskes(faskes *model.Faskes) error {
 	faskes.UpdatedAt = now
 	faskes.SyncedAt = &now
 
-	// Build SQL with geometry
+	// Build SQL with geometry (NULL without coordinates: ST_MakePoint is strict)
 	sql := `
 		INSERT INTO faskes (
 			id, odk_submission_id, nama, jenis_faskes, status_faskes, kondisi_faskes,
@@ -202,18 +247,9 @@ func (s *FaskesSyncService) createFaskes(faskes *model.Faskes) error {
 		)
 	`
 
-	lon := float64(0)
-	lat := float64(0)
-	if faskes.Longitude != nil {
-		lon = *faskes.Longitude
-	}
-	if faskes.Latitude != nil {
-		lat = *faskes.Latitude
-	}
-
 	return s.db.Exec(sql,
 		faskes.ID, faskes.ODKSubmissionID, faskes.Nama, faskes.JenisFaskes, faskes.StatusFaskes, faskes.KondisiFaskes,
-		lon, lat, faskes.Alamat, faskes.Identitas, faskes.Isolasi, faskes.Infrastruktur, faskes.SDM, faskes.Perbekalan, faskes.Klaster, faskes.RawData,
+		faskes.Longitude, faskes.Latitude, faskes.Alamat, faskes.Identitas, faskes.Isolasi, faskes.Infrastruktur, faskes.SDM, faskes.Perbekalan, faskes.Klaster, faskes.RawData,
 		faskes.SubmitterName, faskes.SubmittedAt, faskes.CreatedAt, faskes.UpdatedAt, faskes.SyncedAt,
 	).Error
 }
@@ -242,25 +278,17 @@ func (s *FaskesSyncService) updateFaskes(faskes *model.Faskes) error {
 			submitter_name = ?,
 			submitted_at = ?,
 			updated_at = ?,
-			synced_at = ?
+			synced_at = ?,
+			deleted_at = NULL
 		WHERE id = ?
 	`
 
-	lon := float64(0)
-	lat := float64(0)
-	if faskes.Longitude != nil {
-		lon = *faskes.Longitude
-	}
-	if faskes.Latitude != nil {
-		lat = *faskes.Latitude
-	}
-
 	return s.db.Exec(sql,
 		faskes.Nama,
 		faskes.JenisFaskes,
 		faskes.StatusFaskes,
 		faskes.KondisiFaskes,
-		lon, lat,
+		faskes.Longitude, faskes.Latitude,
 		faskes.Alamat,
 		faskes.Identitas,
 		faskes.Isolasi,
@@ -303,55 +331,16 @@ func (s *FaskesSyncService) processPhoto(faskesID uuid.UUID, photo PhotoInfo) er
 
 // updateSyncState updates the sync_state table
 func (s *FaskesSyncService) updateSyncState(status string, errorMsg *string) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:       s.formID,
-			Status:       status,
-			ErrorMessage: errorMsg,
-			CreatedAt:    now,
-			UpdatedAt:    now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = status
-		syncState.ErrorMessage = errorMsg
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
+	if status == "error" {
+		s.publishFailed(errorMsg)
 	}
+
+	saveSyncState(s.db, s.formID, status, errorMsg)
 }
 
 // updateSyncStateSuccess updates sync state after successful sync
 func (s *FaskesSyncService) updateSyncStateSuccess(recordCount int) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:          s.formID,
-			Status:          "idle",
-			LastSyncTime:    &now,
-			LastRecordCount: recordCount,
-			TotalRecords:    recordCount,
-			CreatedAt:       now,
-			UpdatedAt:       now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = "idle"
-		syncState.LastSyncTime = &now
-		syncState.LastRecordCount = recordCount
-		syncState.TotalRecords += recordCount
-		syncState.ErrorMessage = nil
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
-	}
+	saveSyncStateSuccess(s.db, s.formID, recordCount)
 }
 
 // GetSyncState returns the current sync state for faskes form
@@ -370,16 +359,19 @@ func (s *FaskesSyncService) GetSyncState() (*odk.SyncState, error) {
 	return &syncState, nil
 }
 
-// HardSync performs a full sync and deletes faskes that are not in the latest submissions
+// HardSync performs a full sync and soft-deletes faskes that are not in the latest submissions
 func (s *FaskesSyncService) HardSync() (*SyncResult, error) {
+	defer s.lockSync()()
+
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
 
 	s.updateSyncState("hard_syncing", nil)
+	s.publishStarted(model.SyncRunModeHard)
 
 	// Fetch all approved submissions from ODK Central
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(s.syncCtx())
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch faskes submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -402,20 +394,27 @@ func (s *FaskesSyncService) HardSync() (*SyncResult, error) {
 	}
 
 	// Process each latest submission (create/update)
+	progress := s.newProgress(model.SyncRunModeHard, len(latestSubmissions))
 	for _, submission := range latestSubmissions {
 		if err := s.processSubmission(submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing faskes submission: %v", err)
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
-	// Find and delete faskes that are not in the latest submissions
+	// Find and soft-delete faskes that are not in the latest submissions
 	// This handles: duplicates, old submissions, and incomplete submissions
 	var faskesItems []model.Faskes
-	if err := s.db.Where("odk_submission_id IS NOT NULL").Find(&faskesItems).Error; err != nil {
+	if err := s.db.Where("odk_submission_id IS NOT NULL AND deleted_at IS NULL").Find(&faskesItems).Error; err != nil {
+		result.Errors++
+		result.addErrorDetail(fmt.Sprintf("failed to fetch existing faskes: %v", err))
+	} else if err := s.checkHardSyncDeletes(len(validODKIDSet), len(faskesItems)); err != nil {
+		log.Printf("Faskes HardSync: %v", err)
 		result.Errors++
-		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to fetch existing faskes: %v", err))
+		result.addErrorDetail(err.Error())
+		result.DeletesAborted = true
 	} else {
 		for _, faskes := range faskesItems {
 			if faskes.ODKSubmissionID != nil && !validODKIDSet[*faskes.ODKSubmissionID] {
@@ -427,12 +426,13 @@ func (s *FaskesSyncService) HardSync() (*SyncResult, error) {
 					log.Printf("Warning: failed to delete photos for faskes %s: %v", faskes.ID, err)
 				}
 
-				// Delete the faskes
-				if err := s.db.Delete(&faskes).Error; err != nil {
+				// Soft-delete the faskes
+				if err := softDelete(s.db, "faskes", faskes.ID); err != nil {
 					result.Errors++
-					result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to delete faskes %s: %v", faskes.ID, err))
+					result.addErrorDetail(fmt.Sprintf("failed to delete faskes %s: %v", faskes.ID, err))
 				} else {
 					result.Deleted++
+					noteName(&result.Changes.Deleted, faskes.Nama)
 				}
 			}
 		}
@@ -442,6 +442,9 @@ func (s *FaskesSyncService) HardSync() (*SyncResult, error) {
 	result.Duration = result.EndTime.Sub(result.StartTime).String()
 
 	s.updateSyncStateSuccess(len(latestSubmissions))
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeHard, result)
+	s.publishCompleted(model.SyncRunModeHard, result)
+	s.runAfterSync()
 
 	log.Printf("Faskes HardSync completed: %d fetched, %d filtered, %d created, %d updated, %d deleted, %d errors",
 		result.TotalFetched, len(latestSubmissions), result.Created, result.Updated, result.Deleted, result.Errors)
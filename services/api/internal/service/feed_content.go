@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// Feed content sanitize modes
+const (
+	FeedContentSanitizeNone   = "none"   // store content verbatim
+	FeedContentSanitizeStrip  = "strip"  // remove HTML tags
+	FeedContentSanitizeEscape = "escape" // HTML-escape <, >, &, ' and "
+)
+
+// FeedContentPolicy limits and sanitizes feed content before it is stored
+type FeedContentPolicy struct {
+	MaxLength int    // in characters, 0 = unlimited
+	Sanitize  string // FeedContentSanitize*
+}
+
+// SetContentPolicy sets how feed content is limited and sanitized at sync
+func (s *FeedSyncService) SetContentPolicy(policy FeedContentPolicy) error {
+	switch policy.Sanitize {
+	case "":
+		policy.Sanitize = FeedContentSanitizeNone
+	case FeedContentSanitizeNone, FeedContentSanitizeStrip, FeedContentSanitizeEscape:
+	default:
+		return fmt.Errorf("unknown feed content sanitize mode %q (want %s, %s or %s)",
+			policy.Sanitize, FeedContentSanitizeNone, FeedContentSanitizeStrip, FeedContentSanitizeEscape)
+	}
+	if policy.MaxLength < 0 {
+		policy.MaxLength = 0
+	}
+	s.contentPolicy = policy
+	return nil
+}
+
+// apply returns the sanitized content and whether it was truncated. Tags are stripped
+// before and HTML is escaped after truncating, so the limit counts visible characters
+// and an entity is never cut in half.
+func (p FeedContentPolicy) apply(content string) (string, bool) {
+	if p.Sanitize == FeedContentSanitizeStrip {
+		content = strings.TrimSpace(stripHTML(content))
+	}
+
+	truncated := false
+	if runes := []rune(content); p.MaxLength > 0 && len(runes) > p.MaxLength {
+		content = strings.TrimRight(string(runes[:p.MaxLength]), " \t\r\n") + "…"
+		truncated = true
+	}
+
+	if p.Sanitize == FeedContentSanitizeEscape {
+		content = html.EscapeString(content)
+	}
+	return content, truncated
+}
+
+// stripHTML removes the tags, comments and script/style bodies of content. It is
+// tokenized like a browser would, so text that merely looks like a tag ("<5 tahun>",
+// "a < b") is kept. Text keeps its entities as written.
+func stripHTML(content string) string {
+	var b strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(content))
+	skip := "" // the script or style element being skipped
+	for {
+		switch z.Next() {
+		case nethtml.ErrorToken:
+			// io.EOF: a strings.Reader fails no other way
+			return b.String()
+		case nethtml.TextToken:
+			if skip == "" {
+				b.Write(z.Raw())
+			}
+		case nethtml.StartTagToken:
+			if name, _ := z.TagName(); skip == "" && (string(name) == "script" || string(name) == "style") {
+				skip = string(name)
+			}
+		case nethtml.EndTagToken:
+			if name, _ := z.TagName(); string(name) == skip {
+				skip = ""
+			}
+		}
+	}
+}
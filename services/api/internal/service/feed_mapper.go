@@ -122,6 +122,10 @@ func MapFeedSubmission(submission map[string]interface{}) (*model.Feed, error) {
 		}
 	}
 
+	// Some submissions have lat/lon in the wrong order
+	submissionID, _ := submission["__id"].(string)
+	fixSwappedCoords("feed", submissionID, feed.Latitude, feed.Longitude)
+
 	// Store raw submission data
 	feed.RawData = model.JSONB(submission)
 
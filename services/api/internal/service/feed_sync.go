@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -14,9 +15,27 @@ import (
 
 // FeedSyncService handles synchronization of feeds from ODK Central to PostgreSQL
 type FeedSyncService struct {
+	syncHooks
+	syncLock
+	syncEvents
+	hardSyncGuard
+	syncContext
+	syncRunHistory
 	db        *gorm.DB
 	odkClient *odk.Client
 	formID    string
+
+	// inheritLocationGeom gives feeds without their own coordinates the geometry of their linked posko
+	inheritLocationGeom bool
+
+	// contentPolicy limits and sanitizes content before it is stored
+	contentPolicy FeedContentPolicy
+}
+
+// SetInheritLocationGeometry enables copying the linked posko's geometry into feeds
+// that have no coordinates of their own (otherwise they are stored with geom NULL)
+func (s *FeedSyncService) SetInheritLocationGeometry(enabled bool) {
+	s.inheritLocationGeom = enabled
 }
 
 // NewFeedSyncService creates a new feed sync service
@@ -25,6 +44,9 @@ func NewFeedSyncService(db *gorm.DB, odkClient *odk.Client, formID string) *Feed
 		db:        db,
 		odkClient: odkClient,
 		formID:    formID,
+
+		syncEvents:    syncEvents{form: "feed"},
+		hardSyncGuard: hardSyncGuard{minPercent: defaultHardSyncMinPercent},
 	}
 }
 
@@ -40,19 +62,34 @@ type FeedSyncResult struct {
 	EndTime      time.Time `json:"end_time"`
 	Duration     string    `json:"duration"`
 	ErrorDetails []string  `json:"error_details,omitempty"`
+
+	// TruncatedErrors counts error messages left out of ErrorDetails (see SetMaxErrorDetails)
+	TruncatedErrors int `json:"truncated_errors,omitempty"`
+	// DeletesAborted is set when a hard sync skipped its delete phase because ODK Central
+	// returned far fewer feeds than are stored
+	DeletesAborted bool `json:"deletes_aborted,omitempty"`
 }
 
 // SyncAll performs a full synchronization of all approved feed submissions
 func (s *FeedSyncService) SyncAll() (*FeedSyncResult, error) {
+	return s.SyncAllContext(s.syncCtx())
+}
+
+// SyncAllContext is SyncAll with its ODK Central requests bounded by ctx, e.g. the
+// scheduler's, so stopping the caller stops the sync
+func (s *FeedSyncService) SyncAllContext(ctx context.Context) (*FeedSyncResult, error) {
+	defer s.lockSync()()
+
 	result := &FeedSyncResult{
 		StartTime: time.Now(),
 	}
 
 	// Update sync state to "syncing"
 	s.updateSyncState("syncing", nil)
+	s.publishStarted(model.SyncRunModeFull)
 
 	// Fetch all approved submissions
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(ctx)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch feed submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -62,13 +99,18 @@ func (s *FeedSyncService) SyncAll() (*FeedSyncResult, error) {
 	result.TotalFetched = len(submissions)
 	log.Printf("Fetched %d feed submissions from ODK Central", result.TotalFetched)
 
+	// ODK can return the same __id twice in a page; process each submission once
+	submissions = dedupeSubmissionsByID(submissions)
+
 	// Process each submission
+	progress := s.newProgress(model.SyncRunModeFull, len(submissions))
 	for _, submission := range submissions {
 		if err := s.processSubmission(submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing feed submission: %v", err)
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
 	result.EndTime = time.Now()
@@ -76,6 +118,9 @@ func (s *FeedSyncService) SyncAll() (*FeedSyncResult, error) {
 
 	// Update sync state
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordFeedSyncRun(s.db, s.formID, model.SyncRunModeFull, result)
+	s.publishCompleted(model.SyncRunModeFull, result)
+	s.runAfterSync()
 
 	log.Printf("Feed sync completed: %d fetched, %d created, %d updated, %d skipped, %d errors",
 		result.TotalFetched, result.Created, result.Updated, result.Skipped, result.Errors)
@@ -107,13 +152,16 @@ func (s *FeedSyncService) processSubmission(submission map[string]interface{}, r
 	}
 	feed := feedResult.Feed
 
+	// Content is served verbatim to web clients: cap its length and strip/escape HTML
+	feed.Content, feed.ContentTruncated = s.contentPolicy.apply(feed.Content)
+
 	// Resolve location_id: the calc_location_id from ODK is the entity name, not our DB UUID
 	// We need to lookup the location by matching the nama_posko
 	if feed.LocationID != nil {
 		// Try to find the location by looking up calc_nama_posko in raw_data
 		if namaPosko, ok := submission["calc_nama_posko"].(string); ok && namaPosko != "" {
 			var location model.Location
-			if err := s.db.Where("nama = ?", namaPosko).First(&location).Error; err == nil {
+			if err := s.db.Where("nama = ? AND deleted_at IS NULL", namaPosko).First(&location).Error; err == nil {
 				feed.LocationID = &location.ID
 				log.Printf("Resolved location_id for '%s' -> %s", namaPosko, location.ID)
 			} else {
@@ -130,7 +178,7 @@ func (s *FeedSyncService) processSubmission(submission map[string]interface{}, r
 	if feed.FaskesID != nil {
 		if namaFaskes, ok := submission["calc_nama_faskes"].(string); ok && namaFaskes != "" {
 			var faskes model.Faskes
-			if err := s.db.Where("nama = ?", namaFaskes).First(&faskes).Error; err == nil {
+			if err := s.db.Where("nama = ? AND deleted_at IS NULL", namaFaskes).First(&faskes).Error; err == nil {
 				feed.FaskesID = &faskes.ID
 				log.Printf("Resolved faskes_id for '%s' -> %s", namaFaskes, faskes.ID)
 			} else {
@@ -143,13 +191,17 @@ func (s *FeedSyncService) processSubmission(submission map[string]interface{}, r
 		}
 	}
 
+	if s.inheritLocationGeom && feed.LocationID != nil && !hasFeedCoords(feed) {
+		s.inheritLocationCoords(feed)
+	}
+
 	// Check if feed already exists
 	var existingFeed model.Feed
-	err = s.db.Where("odk_submission_id = ?", odkID).First(&existingFeed).Error
+	err = withDBRetry("lookup", func() error { return s.db.Where("odk_submission_id = ?", odkID).First(&existingFeed).Error })
 
 	if err == gorm.ErrRecordNotFound {
 		// Create new feed
-		if err := s.createFeed(feed); err != nil {
+		if err := withDBRetry("create feed", func() error { return s.createFeed(feed) }); err != nil {
 			return fmt.Errorf("failed to create feed for %s: %w", odkID, err)
 		}
 
@@ -165,7 +217,7 @@ func (s *FeedSyncService) processSubmission(submission map[string]interface{}, r
 	} else if err == nil {
 		// Update existing feed
 		feed.ID = existingFeed.ID
-		if err := s.updateFeed(feed); err != nil {
+		if err := withDBRetry("update feed", func() error { return s.updateFeed(feed) }); err != nil {
 			return fmt.Errorf("failed to update feed for %s: %w", odkID, err)
 		}
 
@@ -258,6 +310,35 @@ func (s *FeedSyncService) upsertFeedPhotos(feedID uuid.UUID, photos []FeedPhotoI
 	return nil
 }
 
+// hasFeedCoords reports whether the feed carries its own (non-zero) coordinates
+func hasFeedCoords(feed *model.Feed) bool {
+	return feed.Longitude != nil && feed.Latitude != nil && *feed.Longitude != 0 && *feed.Latitude != 0
+}
+
+// inheritLocationCoords copies the linked posko's coordinates into the feed.
+// Leaves the feed untouched if the posko has no geometry.
+func (s *FeedSyncService) inheritLocationCoords(feed *model.Feed) {
+	var coords struct {
+		Longitude float64
+		Latitude  float64
+	}
+	err := s.db.Raw(`
+		SELECT ST_X(geom) AS longitude, ST_Y(geom) AS latitude
+		FROM locations
+		WHERE id = ? AND geom IS NOT NULL AND deleted_at IS NULL
+	`, feed.LocationID).Scan(&coords).Error
+	if err != nil {
+		log.Printf("Warning: failed to load geometry of posko %s for feed: %v", *feed.LocationID, err)
+		return
+	}
+	if coords.Longitude == 0 && coords.Latitude == 0 {
+		return
+	}
+
+	feed.Longitude = &coords.Longitude
+	feed.Latitude = &coords.Latitude
+}
+
 // createFeed creates a new feed with PostGIS geometry
 func (s *FeedSyncService) createFeed(feed *model.Feed) error {
 	feed.ID = uuid.New()
@@ -266,7 +347,7 @@ func (s *FeedSyncService) createFeed(feed *model.Feed) error {
 	feed.UpdatedAt = now
 
 	// Check if we have valid coordinates
-	hasCoords := feed.Longitude != nil && feed.Latitude != nil && *feed.Longitude != 0 && *feed.Latitude != 0
+	hasCoords := hasFeedCoords(feed)
 
 	var sql string
 	var args []interface{}
@@ -275,34 +356,34 @@ func (s *FeedSyncService) createFeed(feed *model.Feed) error {
 		sql = `
 			INSERT INTO information_feeds (
 				id, location_id, faskes_id, odk_submission_id,
-				content, category, type, username, organization,
+				content, content_truncated, category, type, username, organization,
 				geom, raw_data, submitted_at, created_at, updated_at
 			) VALUES (
 				?, ?, ?, ?,
-				?, ?, ?, ?, ?,
+				?, ?, ?, ?, ?, ?,
 				ST_SetSRID(ST_MakePoint(?, ?), 4326), ?, ?, ?, ?
 			)
 		`
 		args = []interface{}{
 			feed.ID, feed.LocationID, feed.FaskesID, feed.ODKSubmissionID,
-			feed.Content, feed.Category, feed.Type, feed.Username, feed.Organization,
+			feed.Content, feed.ContentTruncated, feed.Category, feed.Type, feed.Username, feed.Organization,
 			*feed.Longitude, *feed.Latitude, feed.RawData, feed.SubmittedAt, feed.CreatedAt, feed.UpdatedAt,
 		}
 	} else {
 		sql = `
 			INSERT INTO information_feeds (
 				id, location_id, faskes_id, odk_submission_id,
-				content, category, type, username, organization,
+				content, content_truncated, category, type, username, organization,
 				geom, raw_data, submitted_at, created_at, updated_at
 			) VALUES (
 				?, ?, ?, ?,
-				?, ?, ?, ?, ?,
+				?, ?, ?, ?, ?, ?,
 				NULL, ?, ?, ?, ?
 			)
 		`
 		args = []interface{}{
 			feed.ID, feed.LocationID, feed.FaskesID, feed.ODKSubmissionID,
-			feed.Content, feed.Category, feed.Type, feed.Username, feed.Organization,
+			feed.Content, feed.ContentTruncated, feed.Category, feed.Type, feed.Username, feed.Organization,
 			feed.RawData, feed.SubmittedAt, feed.CreatedAt, feed.UpdatedAt,
 		}
 	}
@@ -316,7 +397,7 @@ func (s *FeedSyncService) updateFeed(feed *model.Feed) error {
 	feed.UpdatedAt = now
 
 	// Check if we have valid coordinates
-	hasCoords := feed.Longitude != nil && feed.Latitude != nil && *feed.Longitude != 0 && *feed.Latitude != 0
+	hasCoords := hasFeedCoords(feed)
 
 	var sql string
 	var args []interface{}
@@ -327,19 +408,22 @@ func (s *FeedSyncService) updateFeed(feed *model.Feed) error {
 				location_id = ?,
 				faskes_id = ?,
 				content = ?,
+				content_truncated = ?,
 				category = ?,
 				type = ?,
 				username = ?,
 				geom = ST_SetSRID(ST_MakePoint(?, ?), 4326),
 				raw_data = ?,
 				submitted_at = ?,
-				updated_at = ?
+				updated_at = ?,
+				deleted_at = NULL
 			WHERE id = ?
 		`
 		args = []interface{}{
 			feed.LocationID,
 			feed.FaskesID,
 			feed.Content,
+			feed.ContentTruncated,
 			feed.Category,
 			feed.Type,
 			feed.Username,
@@ -355,19 +439,22 @@ func (s *FeedSyncService) updateFeed(feed *model.Feed) error {
 				location_id = ?,
 				faskes_id = ?,
 				content = ?,
+				content_truncated = ?,
 				category = ?,
 				type = ?,
 				username = ?,
 				geom = NULL,
 				raw_data = ?,
 				submitted_at = ?,
-				updated_at = ?
+				updated_at = ?,
+				deleted_at = NULL
 			WHERE id = ?
 		`
 		args = []interface{}{
 			feed.LocationID,
 			feed.FaskesID,
 			feed.Content,
+			feed.ContentTruncated,
 			feed.Category,
 			feed.Type,
 			feed.Username,
@@ -383,55 +470,16 @@ func (s *FeedSyncService) updateFeed(feed *model.Feed) error {
 
 // updateSyncState updates the sync_state table for feed form
 func (s *FeedSyncService) updateSyncState(status string, errorMsg *string) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:       s.formID,
-			Status:       status,
-			ErrorMessage: errorMsg,
-			CreatedAt:    now,
-			UpdatedAt:    now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = status
-		syncState.ErrorMessage = errorMsg
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
+	if status == "error" {
+		s.publishFailed(errorMsg)
 	}
+
+	saveSyncState(s.db, s.formID, status, errorMsg)
 }
 
 // updateSyncStateSuccess updates sync state after successful sync
 func (s *FeedSyncService) updateSyncStateSuccess(recordCount int) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:          s.formID,
-			Status:          "idle",
-			LastSyncTime:    &now,
-			LastRecordCount: recordCount,
-			TotalRecords:    recordCount,
-			CreatedAt:       now,
-			UpdatedAt:       now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = "idle"
-		syncState.LastSyncTime = &now
-		syncState.LastRecordCount = recordCount
-		syncState.TotalRecords += recordCount
-		syncState.ErrorMessage = nil
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
-	}
+	saveSyncStateSuccess(s.db, s.formID, recordCount)
 }
 
 // GetSyncState returns the current sync state for the feed form
@@ -450,16 +498,19 @@ func (s *FeedSyncService) GetSyncState() (*odk.SyncState, error) {
 	return &syncState, nil
 }
 
-// HardSync performs a full sync and deletes feeds that no longer exist in ODK Central
+// HardSync performs a full sync and soft-deletes feeds that no longer exist in ODK Central
 func (s *FeedSyncService) HardSync() (*FeedSyncResult, error) {
+	defer s.lockSync()()
+
 	result := &FeedSyncResult{
 		StartTime: time.Now(),
 	}
 
 	s.updateSyncState("hard_syncing", nil)
+	s.publishStarted(model.SyncRunModeHard)
 
 	// Fetch all approved submissions from ODK Central
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(s.syncCtx())
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch feed submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -469,6 +520,8 @@ func (s *FeedSyncService) HardSync() (*FeedSyncResult, error) {
 	result.TotalFetched = len(submissions)
 	log.Printf("Feed HardSync: Fetched %d submissions from ODK Central", result.TotalFetched)
 
+	submissions = dedupeSubmissionsByID(submissions)
+
 	// Build a set of ODK submission IDs from ODK Central
 	odkIDSet := make(map[string]bool)
 	for _, submission := range submissions {
@@ -478,19 +531,26 @@ func (s *FeedSyncService) HardSync() (*FeedSyncResult, error) {
 	}
 
 	// Process each submission (create/update)
+	progress := s.newProgress(model.SyncRunModeHard, len(submissions))
 	for _, submission := range submissions {
 		if err := s.processSubmission(submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing feed submission: %v", err)
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
-	// Find and delete feeds that no longer exist in ODK Central
+	// Find and soft-delete feeds that no longer exist in ODK Central
 	var feeds []model.Feed
-	if err := s.db.Where("odk_submission_id IS NOT NULL").Find(&feeds).Error; err != nil {
+	if err := s.db.Where("odk_submission_id IS NOT NULL AND deleted_at IS NULL").Find(&feeds).Error; err != nil {
+		result.Errors++
+		result.addErrorDetail(fmt.Sprintf("failed to fetch existing feeds: %v", err))
+	} else if err := s.checkHardSyncDeletes(len(odkIDSet), len(feeds)); err != nil {
+		log.Printf("Feed HardSync: %v", err)
 		result.Errors++
-		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to fetch existing feeds: %v", err))
+		result.addErrorDetail(err.Error())
+		result.DeletesAborted = true
 	} else {
 		for _, feed := range feeds {
 			if feed.ODKSubmissionID != nil && !odkIDSet[*feed.ODKSubmissionID] {
@@ -502,10 +562,10 @@ func (s *FeedSyncService) HardSync() (*FeedSyncResult, error) {
 					log.Printf("Warning: failed to delete photos for feed %s: %v", feed.ID, err)
 				}
 
-				// Delete the feed
-				if err := s.db.Delete(&feed).Error; err != nil {
+				// Soft-delete the feed
+				if err := softDelete(s.db, "information_feeds", feed.ID); err != nil {
 					result.Errors++
-					result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to delete feed %s: %v", feed.ID, err))
+					result.addErrorDetail(fmt.Sprintf("failed to delete feed %s: %v", feed.ID, err))
 				} else {
 					result.Deleted++
 				}
@@ -517,6 +577,9 @@ func (s *FeedSyncService) HardSync() (*FeedSyncResult, error) {
 	result.Duration = result.EndTime.Sub(result.StartTime).String()
 
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordFeedSyncRun(s.db, s.formID, model.SyncRunModeHard, result)
+	s.publishCompleted(model.SyncRunModeHard, result)
+	s.runAfterSync()
 
 	log.Printf("Feed HardSync completed: %d fetched, %d created, %d updated, %d deleted, %d errors",
 		result.TotalFetched, result.Created, result.Updated, result.Deleted, result.Errors)
@@ -0,0 +1,29 @@
+package service
+
+import "fmt"
+
+// defaultHardSyncMinPercent is the share of the stored records a hard sync must fetch
+// from ODK Central before it may delete the rest
+const defaultHardSyncMinPercent = 50
+
+// hardSyncGuard keeps a hard sync from mass-deleting after an unexpectedly small ODK
+// response (a filter bug, an API hiccup). Embedded in each sync service.
+type hardSyncGuard struct {
+	minPercent int
+}
+
+// SetHardSyncMinPercent sets the minimum share (0-100) of the stored records a hard sync
+// must find in ODK Central to run its delete phase; 0 disables the guard
+func (g *hardSyncGuard) SetHardSyncMinPercent(percent int) {
+	g.minPercent = percent
+}
+
+// checkHardSyncDeletes returns an error when the records fetched from ODK Central are
+// fewer than the minimum share of the existing ones, in which case nothing is deleted
+func (g *hardSyncGuard) checkHardSyncDeletes(fetched, existing int) error {
+	if g.minPercent == 0 || existing == 0 || fetched*100 >= existing*g.minPercent {
+		return nil
+	}
+	return fmt.Errorf("hard sync deletes aborted: ODK Central returned %d records for %d stored (below the %d%% minimum), nothing was deleted",
+		fetched, existing, g.minPercent)
+}
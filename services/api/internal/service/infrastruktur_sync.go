@@ -14,10 +14,16 @@ import (
 
 // InfrastrukturSyncService handles synchronization of infrastruktur data from ODK Central
 type InfrastrukturSyncService struct {
+	syncHooks
+	syncLock
+	hardSyncGuard
+	syncContext
+	syncRunHistory
 	db            *gorm.DB
 	odkClient     *odk.Client
 	formID        string
 	entityDataset string
+	entityKeys    EntityKeyStrategy // resolves the jembatan a submission belongs to
 }
 
 // NewInfrastrukturSyncService creates a new infrastruktur sync service
@@ -27,11 +33,30 @@ func NewInfrastrukturSyncService(db *gorm.DB, odkClient *odk.Client, formID stri
 		odkClient:     odkClient,
 		formID:        formID,
 		entityDataset: "jembatan_entities",
+		entityKeys:    DefaultInfrastrukturEntityKeys,
+
+		hardSyncGuard: hardSyncGuard{minPercent: defaultHardSyncMinPercent},
 	}
 }
 
+// SetEntityKeys overrides how the entity of an infrastruktur submission is resolved
+func (s *InfrastrukturSyncService) SetEntityKeys(keys EntityKeyStrategy) {
+	s.entityKeys = keys
+}
+
+// EntityDataset returns the ODK entity dataset the infrastruktur entities live in
+func (s *InfrastrukturSyncService) EntityDataset() string {
+	return s.entityDataset
+}
+
 // SyncAll performs a full synchronization of all approved infrastruktur submissions
 func (s *InfrastrukturSyncService) SyncAll() (*SyncResult, error) {
+	defer s.lockSync()()
+	return s.syncAll()
+}
+
+// syncAll is SyncAll for callers already holding the sync lock
+func (s *InfrastrukturSyncService) syncAll() (*SyncResult, error) {
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
@@ -40,7 +65,7 @@ func (s *InfrastrukturSyncService) SyncAll() (*SyncResult, error) {
 	s.updateSyncState("syncing", nil)
 
 	// Fetch all approved submissions
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(s.syncCtx())
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch infrastruktur submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -58,16 +83,21 @@ func (s *InfrastrukturSyncService) SyncAll() (*SyncResult, error) {
 	for entityID, submission := range latestByEntity {
 		if err := s.processEntitySubmission(entityID, submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing infrastruktur entity %s: %v", entityID, err)
 		}
 	}
 
+	// Keep the progress timeline of every update, not just the latest
+	s.recordHistory(submissions)
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime).String()
 
 	// Update sync state
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeFull, result)
+	s.runAfterSync()
 
 	log.Printf("Infrastruktur sync completed: %d fetched, %d entities, %d created, %d updated, %d errors",
 		result.TotalFetched, len(latestByEntity), result.Created, result.Updated, result.Errors)
@@ -75,7 +105,7 @@ func (s *InfrastrukturSyncService) SyncAll() (*SyncResult, error) {
 	return result, nil
 }
 
-// groupByEntityLatest groups submissions by entity_id (sel_jembatan) and returns only the latest per entity
+// groupByEntityLatest groups submissions by entity_id (sel_jembatan by default) and returns only the latest per entity
 func (s *InfrastrukturSyncService) groupByEntityLatest(submissions []map[string]interface{}) map[string]map[string]interface{} {
 	latestByEntity := make(map[string]map[string]interface{})
 	latestTimeByEntity := make(map[string]time.Time)
@@ -91,15 +121,7 @@ func (s *InfrastrukturSyncService) groupByEntityLatest(submissions []map[string]
 			}
 		}
 
-		// Get entity ID from sel_jembatan (the entity being updated)
-		// Check in grp_identifikasi first, then root
-		var entityID string
-		if grpIdentifikasi, ok := submission["grp_identifikasi"].(map[string]interface{}); ok {
-			entityID, _ = grpIdentifikasi["sel_jembatan"].(string)
-		}
-		if entityID == "" {
-			entityID, _ = submission["sel_jembatan"].(string)
-		}
+		entityID := s.entityKeys.Resolve(submission)
 		if entityID == "" {
 			continue
 		}
@@ -114,6 +136,61 @@ func (s *InfrastrukturSyncService) groupByEntityLatest(submissions []map[string]
 	return latestByEntity
 }
 
+// recordHistory stores the progress of every approved submission in infrastruktur_history,
+// so earlier updates stay visible after the record itself moved on to the latest one.
+// Submissions already recorded are skipped.
+func (s *InfrastrukturSyncService) recordHistory(submissions []map[string]interface{}) {
+	infraIDs := make(map[string]*uuid.UUID) // entity ID -> infrastruktur ID (nil if not synced)
+	recorded := 0
+
+	for _, submission := range submissions {
+		odkID, _ := submission["__id"].(string)
+		entityID := s.entityKeys.Resolve(submission)
+		if odkID == "" || entityID == "" {
+			continue
+		}
+		if system, ok := submission["__system"].(map[string]interface{}); ok {
+			if reviewState, ok := system["reviewState"].(string); ok && reviewState != "approved" {
+				continue
+			}
+		}
+
+		infraID, seen := infraIDs[entityID]
+		if !seen {
+			var existing model.Infrastruktur
+			if err := s.db.Select("id").Where("entity_id = ? AND deleted_at IS NULL", entityID).First(&existing).Error; err == nil {
+				infraID = &existing.ID
+			}
+			infraIDs[entityID] = infraID
+		}
+		if infraID == nil {
+			continue
+		}
+
+		infra, err := MapSubmissionToInfrastruktur(submission)
+		if err != nil {
+			log.Printf("Warning: failed to map infrastruktur submission %s for history: %v", odkID, err)
+			continue
+		}
+
+		res := s.db.Exec(`
+			INSERT INTO infrastruktur_history (
+				id, infrastruktur_id, odk_submission_id, progress, status_penanganan, update_by, submitted_at, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (infrastruktur_id, odk_submission_id) DO NOTHING
+		`, uuid.New(), *infraID, odkID, infra.Progress, infra.StatusPenanganan, infra.UpdateBy, infra.SubmittedAt, time.Now())
+		if res.Error != nil {
+			log.Printf("Warning: failed to record infrastruktur history for submission %s: %v", odkID, res.Error)
+			continue
+		}
+		recorded += int(res.RowsAffected)
+	}
+
+	if recorded > 0 {
+		log.Printf("Recorded %d new infrastruktur progress updates", recorded)
+	}
+}
+
 // processEntitySubmission processes a submission for a specific entity
 func (s *InfrastrukturSyncService) processEntitySubmission(entityID string, submission map[string]interface{}, result *SyncResult) error {
 	// Get submission ID for logging
@@ -141,22 +218,27 @@ func (s *InfrastrukturSyncService) processEntitySubmission(entityID string, subm
 
 	// Check if infrastruktur already exists by entity_id
 	var existingInfra model.Infrastruktur
-	err = s.db.Where("entity_id = ?", entityID).First(&existingInfra).Error
+	err = withDBRetry("lookup", func() error { return s.db.Where("entity_id = ?", entityID).First(&existingInfra).Error })
 
 	if err == gorm.ErrRecordNotFound {
 		// Create new infrastruktur
-		if err := s.createInfrastruktur(infra); err != nil {
+		if err := withDBRetry("create infrastruktur", func() error { return s.createInfrastruktur(infra) }); err != nil {
 			return fmt.Errorf("failed to create infrastruktur for entity %s: %w", entityID, err)
 		}
 		result.Created++
+		noteName(&result.Changes.Created, infra.Nama)
 		log.Printf("Created infrastruktur: %s (entity: %s, submission: %s)", infra.Nama, entityID, odkID)
 	} else if err == nil {
 		// Update existing infrastruktur
 		infra.ID = existingInfra.ID
-		if err := s.updateInfrastruktur(infra); err != nil {
+		if err := withDBRetry("update infrastruktur", func() error { return s.updateInfrastruktur(infra) }); err != nil {
 			return fmt.Errorf("failed to update infrastruktur for entity %s: %w", entityID, err)
 		}
 		result.Updated++
+		if existingInfra.ODKSubmissionID == nil || *existingInfra.ODKSubmissionID != odkID {
+			result.Changed++
+			noteName(&result.Changes.Changed, infra.Nama)
+		}
 		log.Printf("Updated infrastruktur: %s (entity: %s, submission: %s)", infra.Nama, entityID, odkID)
 	} else {
 		return fmt.Errorf("database error checking infrastruktur entity %s: %w", entityID, err)
@@ -242,7 +324,8 @@ func (s *InfrastrukturSyncService) updateInfrastruktur(infra *model.Infrastruktu
 			submitter_name = ?,
 			submitted_at = ?,
 			updated_at = ?,
-			synced_at = ?
+			synced_at = ?,
+			deleted_at = NULL
 		WHERE id = ?
 	`
 
@@ -302,55 +385,12 @@ func (s *InfrastrukturSyncService) processPhoto(infrastrukturID uuid.UUID, photo
 
 // updateSyncState updates the sync_state table
 func (s *InfrastrukturSyncService) updateSyncState(status string, errorMsg *string) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:       s.formID,
-			Status:       status,
-			ErrorMessage: errorMsg,
-			CreatedAt:    now,
-			UpdatedAt:    now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = status
-		syncState.ErrorMessage = errorMsg
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
-	}
+	saveSyncState(s.db, s.formID, status, errorMsg)
 }
 
 // updateSyncStateSuccess updates sync state after successful sync
 func (s *InfrastrukturSyncService) updateSyncStateSuccess(recordCount int) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:          s.formID,
-			Status:          "idle",
-			LastSyncTime:    &now,
-			LastRecordCount: recordCount,
-			TotalRecords:    recordCount,
-			CreatedAt:       now,
-			UpdatedAt:       now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = "idle"
-		syncState.LastSyncTime = &now
-		syncState.LastRecordCount = recordCount
-		syncState.TotalRecords += recordCount
-		syncState.ErrorMessage = nil
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
-	}
+	saveSyncStateSuccess(s.db, s.formID, recordCount)
 }
 
 // GetSyncState returns the current sync state
@@ -369,8 +409,10 @@ func (s *InfrastrukturSyncService) GetSyncState() (*odk.SyncState, error) {
 	return &syncState, nil
 }
 
-// HardSync performs a full sync and deletes records that no longer exist in ODK Central
+// HardSync performs a full sync and soft-deletes records that no longer exist in ODK Central
 func (s *InfrastrukturSyncService) HardSync() (*SyncResult, error) {
+	defer s.lockSync()()
+
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
@@ -378,7 +420,7 @@ func (s *InfrastrukturSyncService) HardSync() (*SyncResult, error) {
 	s.updateSyncState("hard_syncing", nil)
 
 	// Fetch all approved submissions from ODK Central
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(s.syncCtx())
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch infrastruktur submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -402,16 +444,24 @@ func (s *InfrastrukturSyncService) HardSync() (*SyncResult, error) {
 	for entityID, submission := range latestByEntity {
 		if err := s.processEntitySubmission(entityID, submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing infrastruktur entity %s: %v", entityID, err)
 		}
 	}
 
-	// Find and delete infrastruktur that no longer exist in ODK Central
+	// Keep the progress timeline of every update, not just the latest
+	s.recordHistory(submissions)
+
+	// Find and soft-delete infrastruktur that no longer exist in ODK Central
 	var infraList []model.Infrastruktur
 	if err := s.db.Where("entity_id != '' AND deleted_at IS NULL").Find(&infraList).Error; err != nil {
 		result.Errors++
-		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to fetch existing infrastruktur: %v", err))
+		result.addErrorDetail(fmt.Sprintf("failed to fetch existing infrastruktur: %v", err))
+	} else if err := s.checkHardSyncDeletes(len(entityIDSet), len(infraList)); err != nil {
+		log.Printf("HardSync Infrastruktur: %v", err)
+		result.Errors++
+		result.addErrorDetail(err.Error())
+		result.DeletesAborted = true
 	} else {
 		for _, infra := range infraList {
 			if infra.EntityID != "" && !entityIDSet[infra.EntityID] {
@@ -422,12 +472,13 @@ func (s *InfrastrukturSyncService) HardSync() (*SyncResult, error) {
 					log.Printf("Warning: failed to delete photos for infrastruktur %s: %v", infra.ID, err)
 				}
 
-				// Delete the infrastruktur
-				if err := s.db.Delete(&infra).Error; err != nil {
+				// Soft-delete the infrastruktur
+				if err := softDelete(s.db, "infrastruktur", infra.ID); err != nil {
 					result.Errors++
-					result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to delete infrastruktur %s: %v", infra.ID, err))
+					result.addErrorDetail(fmt.Sprintf("failed to delete infrastruktur %s: %v", infra.ID, err))
 				} else {
 					result.Deleted++
+					noteName(&result.Changes.Deleted, infra.Nama)
 				}
 			}
 		}
@@ -437,6 +488,8 @@ func (s *InfrastrukturSyncService) HardSync() (*SyncResult, error) {
 	result.Duration = result.EndTime.Sub(result.StartTime).String()
 
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeHard, result)
+	s.runAfterSync()
 
 	log.Printf("HardSync Infrastruktur completed: %d fetched, %d entities, %d created, %d updated, %d deleted, %d errors",
 		result.TotalFetched, len(latestByEntity), result.Created, result.Updated, result.Deleted, result.Errors)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"log"
 	"strconv"
 	"strings"
 	"time"
@@ -9,14 +10,32 @@ import (
 	"github.com/leksa/datamapper-senyar/internal/model"
 )
 
+// DefaultPoskoNameFields are the submission fields tried, in order, for a posko's nama
+var DefaultPoskoNameFields = []string{"calc_nama_posko", "nama_posko"}
+
 // MapSubmissionToLocation converts an ODK submission to a Location model
 // Uses final_* calculated fields from XLSForm v2, with fallback to nested grp_* fields for dump data
 func MapSubmissionToLocation(submission map[string]interface{}) (*model.Location, error) {
+	return mapSubmissionToLocation(submission, DefaultPoskoNameFields, nil)
+}
+
+// mapSubmissionToLocation is MapSubmissionToLocation with a custom nama source field list
+// and per form version mapping profiles (nil uses DefaultMappingProfile for every version)
+func mapSubmissionToLocation(submission map[string]interface{}, nameFields []string, profiles MappingProfiles) (*model.Location, error) {
 	location := &model.Location{
 		Type:   "posko",
-		Status: "operational",
+		Status: model.LocationStatusOperasional,
 	}
 
+	// Pick the field map for the submission's form version
+	profile := profiles.ForSubmission(submission)
+	field := func(key, finalKey string, group map[string]interface{}, groupKey string) interface{} {
+		return profile.value(submission, key, finalKey, group, groupKey)
+	}
+
+	// Record whether this is a live submission or dump data, which explains missing final_* fields
+	location.Source = submissionSource(submission)
+
 	// Extract nested groups for fallback (dump data doesn't have final_* fields)
 	grpIdentitas, _ := submission["grp_identitas"].(map[string]interface{})
 	grpDemografi, _ := submission["grp_demografi"].(map[string]interface{})
@@ -31,12 +50,8 @@ func MapSubmissionToLocation(submission map[string]interface{}) (*model.Location
 		location.ODKSubmissionID = &id
 	}
 
-	// Extract nama from calc_nama_posko or nama_posko (fallback for data dump)
-	if nama, ok := submission["calc_nama_posko"].(string); ok && nama != "" {
-		location.Nama = nama
-	} else if nama, ok := submission["nama_posko"].(string); ok && nama != "" {
-		location.Nama = nama
-	}
+	// Extract nama from the configured fields (default: calc_nama_posko, then nama_posko for data dump)
+	location.Nama = resolveLocationName(submission, nameFields)
 
 	// Extract system metadata
 	if system, ok := submission["__system"].(map[string]interface{}); ok {
@@ -51,7 +66,7 @@ func MapSubmissionToLocation(submission map[string]interface{}) (*model.Location
 	}
 
 	// Extract coordinates - try final_geometry first, then grp_identitas.koordinat
-	if geom, ok := submission["final_geometry"].(string); ok && geom != "" {
+	if geom, ok := submission["final_geometry"].(string); ok && geom != "" && profile.readsFinal() {
 		coords := strings.Fields(geom)
 		if len(coords) >= 2 {
 			if lat, err := strconv.ParseFloat(coords[0], 64); err == nil {
@@ -61,7 +76,7 @@ func MapSubmissionToLocation(submission map[string]interface{}) (*model.Location
 				location.Longitude = &lon
 			}
 		}
-	} else if grpIdentitas != nil {
+	} else if grpIdentitas != nil && profile.readsGroup() {
 		// Fallback: try koordinat from grp_identitas (can be GeoJSON or string)
 		if koordinat, ok := grpIdentitas["koordinat"].(map[string]interface{}); ok {
 			// GeoJSON format: {"type": "Point", "coordinates": [lon, lat, alt]}
@@ -101,86 +116,87 @@ func MapSubmissionToLocation(submission map[string]interface{}) (*model.Location
 
 	// Build Identitas JSONB - try final_* first, fallback to grp_identitas
 	location.Identitas = model.JSONB{
-		"nama_penanggungjawab":    getWithFallback(submission, "final_nama_penanggungjawab", grpIdentitas, "nama_penanggungjawab"),
-		"contact_penanggungjawab": getWithFallback(submission, "final_contact_penanggungjawab", grpIdentitas, "contact_penanggungjawab"),
-		"nama_relawan":            getWithFallback(submission, "final_nama_relawan", grpIdentitas, "nama_relawan"),
-		"contact_relawan":         getWithFallback(submission, "final_contact_relawan", grpIdentitas, "contact_relawan"),
-		"alamat_dusun":            getWithFallback(submission, "final_alamat_dusun", grpIdentitas, "alamat_dusun"),
-		"institusi":               getWithFallback(submission, "final_institusi", grpIdentitas, "institusi"),
-		"mulai_tanggal":           getWithFallback(submission, "final_mulai_tanggal", grpIdentitas, "mulai_tanggal"),
-		"kota_terdekat":           getWithFallback(submission, "final_kota_terdekat", grpIdentitas, "kota_terdekat"),
-		"baseline_sumber":         getWithFallback(submission, "final_baseline_sumber", grpBaseline, "baseline_sumber"),
+		"nama_penanggungjawab":    field("nama_penanggungjawab", "final_nama_penanggungjawab", grpIdentitas, "nama_penanggungjawab"),
+		"contact_penanggungjawab": field("contact_penanggungjawab", "final_contact_penanggungjawab", grpIdentitas, "contact_penanggungjawab"),
+		"nama_relawan":            field("nama_relawan", "final_nama_relawan", grpIdentitas, "nama_relawan"),
+		"contact_relawan":         field("contact_relawan", "final_contact_relawan", grpIdentitas, "contact_relawan"),
+		"alamat_dusun":            field("alamat_dusun", "final_alamat_dusun", grpIdentitas, "alamat_dusun"),
+		"institusi":               field("institusi", "final_institusi", grpIdentitas, "institusi"),
+		"mulai_tanggal":           field("mulai_tanggal", "final_mulai_tanggal", grpIdentitas, "mulai_tanggal"),
+		"kota_terdekat":           field("kota_terdekat", "final_kota_terdekat", grpIdentitas, "kota_terdekat"),
+		"baseline_sumber":         field("baseline_sumber", "final_baseline_sumber", grpBaseline, "baseline_sumber"),
 	}
 
 	// Extract status_posko - try final_status_posko first, fallback to grp_identitas
-	if statusPosko, ok := submission["final_status_posko"].(string); ok && statusPosko != "" {
-		location.Status = statusPosko
-	} else if grpIdentitas != nil {
+	if statusPosko, ok := submission["final_status_posko"].(string); ok && statusPosko != "" && profile.readsFinal() {
+		location.Status = model.NormalizeStatus(statusPosko)
+	} else if grpIdentitas != nil && profile.readsGroup() {
 		if statusPosko, ok := grpIdentitas["status_posko"].(string); ok && statusPosko != "" {
-			location.Status = statusPosko
+			location.Status = model.NormalizeStatus(statusPosko)
 		}
 	}
 
 	// Build DataPengungsi JSONB - try final_* first, fallback to grp_pengungsian and grp_demografi
-	totalPengungsi := getWithFallback(submission, "final_total_pengungsi", grpPengungsian, "total_pengungsi")
+	totalPengungsi := field("total_pengungsi", "final_total_pengungsi", grpPengungsian, "total_pengungsi")
 	dataPengungsi := model.JSONB{
-		"jenis_pengungsian":   getWithFallback(submission, "final_jenis_pengungsian", grpPengungsian, "jenis_pengungsian"),
-		"detail_pengungsian":  getWithFallback(submission, "final_detail_pengungsian", grpPengungsian, "detail_pengungsian"),
-		"persen_keterlibatan": getWithFallback(submission, "final_persen_keterlibatan", grpPengungsian, "persen_keterlibatan"),
+		"jenis_pengungsian":   field("jenis_pengungsian", "final_jenis_pengungsian", grpPengungsian, "jenis_pengungsian"),
+		"detail_pengungsian":  field("detail_pengungsian", "final_detail_pengungsian", grpPengungsian, "detail_pengungsian"),
+		"persen_keterlibatan": field("persen_keterlibatan", "final_persen_keterlibatan", grpPengungsian, "persen_keterlibatan"),
 		"total_pengungsi":     totalPengungsi,
 		"total_jiwa":          totalPengungsi, // alias
-		"jumlah_kk":           getWithFallback(submission, "final_jumlah_kk", grpDemografi, "jumlah_kk"),
-		"kk_perempuan":        getWithFallback(submission, "final_kk_perempuan", grpDemografi, "kk_perempuan"),
-		"kk_anak":             getWithFallback(submission, "final_kk_anak", grpDemografi, "kk_anak"),
-		"dewasa_perempuan":    getWithFallback(submission, "final_dewasa_perempuan", grpDemografi, "dewasa_perempuan"),
-		"dewasa_laki":         getWithFallback(submission, "final_dewasa_laki", grpDemografi, "dewasa_laki"),
-		"remaja_perempuan":    getWithFallback(submission, "final_remaja_perempuan", grpDemografi, "remaja_perempuan"),
-		"remaja_laki":         getWithFallback(submission, "final_remaja_laki", grpDemografi, "remaja_laki"),
-		"anak_perempuan":      getWithFallback(submission, "final_anak_perempuan", grpDemografi, "anak_perempuan"),
-		"anak_laki":           getWithFallback(submission, "final_anak_laki", grpDemografi, "anak_laki"),
-		"balita_perempuan":    getWithFallback(submission, "final_balita_perempuan", grpDemografi, "balita_perempuan"),
-		"balita_laki":         getWithFallback(submission, "final_balita_laki", grpDemografi, "balita_laki"),
-		"bayi_perempuan":      getWithFallback(submission, "final_bayi_perempuan", grpDemografi, "bayi_perempuan"),
-		"bayi_laki":           getWithFallback(submission, "final_bayi_laki", grpDemografi, "bayi_laki"),
-		"lansia":              getWithFallback(submission, "final_lansia", grpDemografi, "lansia"),
-		"ibu_menyusui":        getWithFallback(submission, "final_ibu_menyusui", grpDemografi, "ibu_menyusui"),
-		"ibu_hamil":           getWithFallback(submission, "final_ibu_hamil", grpDemografi, "ibu_hamil"),
-		"remaja_tanpa_ortu":   getWithFallback(submission, "final_remaja_tanpa_ortu", grpDemografi, "remaja_tanpa_ortu"),
-		"anak_tanpa_ortu":     getWithFallback(submission, "final_anak_tanpa_ortu", grpDemografi, "anak_tanpa_ortu"),
-		"bayi_tanpa_ibu":      getWithFallback(submission, "final_bayi_tanpa_ibu", grpDemografi, "bayi_tanpa_ibu"),
-		"difabel":             getWithFallback(submission, "final_difabel", grpDemografi, "difabel"),
-		"komorbid":            getWithFallback(submission, "final_komorbid", grpDemografi, "komorbid"),
+		"jumlah_kk":           field("jumlah_kk", "final_jumlah_kk", grpDemografi, "jumlah_kk"),
+		"kk_perempuan":        field("kk_perempuan", "final_kk_perempuan", grpDemografi, "kk_perempuan"),
+		"kk_anak":             field("kk_anak", "final_kk_anak", grpDemografi, "kk_anak"),
+		"dewasa_perempuan":    field("dewasa_perempuan", "final_dewasa_perempuan", grpDemografi, "dewasa_perempuan"),
+		"dewasa_laki":         field("dewasa_laki", "final_dewasa_laki", grpDemografi, "dewasa_laki"),
+		"remaja_perempuan":    field("remaja_perempuan", "final_remaja_perempuan", grpDemografi, "remaja_perempuan"),
+		"remaja_laki":         field("remaja_laki", "final_remaja_laki", grpDemografi, "remaja_laki"),
+		"anak_perempuan":      field("anak_perempuan", "final_anak_perempuan", grpDemografi, "anak_perempuan"),
+		"anak_laki":           field("anak_laki", "final_anak_laki", grpDemografi, "anak_laki"),
+		"balita_perempuan":    field("balita_perempuan", "final_balita_perempuan", grpDemografi, "balita_perempuan"),
+		"balita_laki":         field("balita_laki", "final_balita_laki", grpDemografi, "balita_laki"),
+		"bayi_perempuan":      field("bayi_perempuan", "final_bayi_perempuan", grpDemografi, "bayi_perempuan"),
+		"bayi_laki":           field("bayi_laki", "final_bayi_laki", grpDemografi, "bayi_laki"),
+		"lansia":              field("lansia", "final_lansia", grpDemografi, "lansia"),
+		"ibu_menyusui":        field("ibu_menyusui", "final_ibu_menyusui", grpDemografi, "ibu_menyusui"),
+		"ibu_hamil":           field("ibu_hamil", "final_ibu_hamil", grpDemografi, "ibu_hamil"),
+		"remaja_tanpa_ortu":   field("remaja_tanpa_ortu", "final_remaja_tanpa_ortu", grpDemografi, "remaja_tanpa_ortu"),
+		"anak_tanpa_ortu":     field("anak_tanpa_ortu", "final_anak_tanpa_ortu", grpDemografi, "anak_tanpa_ortu"),
+		"bayi_tanpa_ibu":      field("bayi_tanpa_ibu", "final_bayi_tanpa_ibu", grpDemografi, "bayi_tanpa_ibu"),
+		"difabel":             field("difabel", "final_difabel", grpDemografi, "difabel"),
+		"komorbid":            field("komorbid", "final_komorbid", grpDemografi, "komorbid"),
 	}
 	location.DataPengungsi = dataPengungsi
+	ApplyDemographicTotals(location)
 
 	// Build Fasilitas JSONB - try final_* first, fallback to grp_fasilitas
 	location.Fasilitas = model.JSONB{
-		"posko_logistik":      getWithFallback(submission, "final_posko_logistik", grpFasilitas, "posko_logistik"),
-		"posko_faskes":        getWithFallback(submission, "final_posko_faskes", grpFasilitas, "posko_faskes"),
-		"dapur_umum":          getWithFallback(submission, "final_dapur_umum", grpFasilitas, "dapur_umum"),
-		"kapasitas_dapur":     getWithFallback(submission, "final_kapasitas_dapur", grpFasilitas, "kapasitas_dapur"),
-		"ketersediaan_air":    getWithFallback(submission, "final_ketersediaan_air", grpFasilitas, "ketersediaan_air"),
+		"posko_logistik":      field("posko_logistik", "final_posko_logistik", grpFasilitas, "posko_logistik"),
+		"posko_faskes":        field("posko_faskes", "final_posko_faskes", grpFasilitas, "posko_faskes"),
+		"dapur_umum":          field("dapur_umum", "final_dapur_umum", grpFasilitas, "dapur_umum"),
+		"kapasitas_dapur":     field("kapasitas_dapur", "final_kapasitas_dapur", grpFasilitas, "kapasitas_dapur"),
+		"ketersediaan_air":    field("ketersediaan_air", "final_ketersediaan_air", grpFasilitas, "ketersediaan_air"),
 		"kebutuhan_air":       submission["kebutuhan_air"], // root level calculated field
-		"saluran_limbah":      getWithFallback(submission, "final_saluran_limbah", grpFasilitas, "saluran_limbah"),
-		"sumber_air":          getWithFallback(submission, "final_sumber_air", grpFasilitas, "sumber_air"),
-		"toilet_perempuan":    getWithFallback(submission, "final_toilet_perempuan", grpFasilitas, "toilet_perempuan"),
-		"toilet_laki":         getWithFallback(submission, "final_toilet_laki", grpFasilitas, "toilet_laki"),
-		"toilet_campur":       getWithFallback(submission, "final_toilet_campur", grpFasilitas, "toilet_campur"),
-		"tempat_sampah":       getWithFallback(submission, "final_tempat_sampah", grpFasilitas, "tempat_sampah"),
-		"sumber_listrik":      getWithFallback(submission, "final_sumber_listrik", grpFasilitas, "sumber_listrik"),
-		"kondisi_penerangan":  getWithFallback(submission, "final_kondisi_penerangan", grpFasilitas, "kondisi_penerangan"),
-		"titik_akses_listrik": getWithFallback(submission, "final_titik_akses_listrik", grpFasilitas, "titik_akses_listrik"),
-		"posko_tenaga_medis":  getWithFallback(submission, "final_posko_kesehatan", grpFasilitas, "posko_kesehatan"),
-		"posko_obat":          getWithFallback(submission, "final_posko_obat", grpFasilitas, "posko_obat"),
-		"posko_psikososial":   getWithFallback(submission, "final_posko_psikososial", grpFasilitas, "posko_psikososial"),
-		"ruang_laktasi":       getWithFallback(submission, "final_ruang_laktasi", grpFasilitas, "ruang_laktasi"),
-		"layanan_lansia":      getWithFallback(submission, "final_layanan_lansia", grpFasilitas, "layanan_lansia"),
-		"layanan_keluarga":    getWithFallback(submission, "final_layanan_keluarga", grpFasilitas, "layanan_keluarga"),
-		"sekolah_darurat":     getWithFallback(submission, "final_sekolah_darurat", grpFasilitas, "sekolah_darurat"),
-		"program_pengganti":   getWithFallback(submission, "final_program_pengganti", grpFasilitas, "program_pengganti"),
-		"petugas_keamanan":    getWithFallback(submission, "final_petugas_keamanan", grpFasilitas, "petugas_keamanan"),
-		"area_interaksi":      getWithFallback(submission, "final_area_interaksi", grpFasilitas, "area_interaksi"),
-		"area_bermain":        getWithFallback(submission, "final_area_bermain", grpFasilitas, "area_bermain"),
+		"saluran_limbah":      field("saluran_limbah", "final_saluran_limbah", grpFasilitas, "saluran_limbah"),
+		"sumber_air":          field("sumber_air", "final_sumber_air", grpFasilitas, "sumber_air"),
+		"toilet_perempuan":    field("toilet_perempuan", "final_toilet_perempuan", grpFasilitas, "toilet_perempuan"),
+		"toilet_laki":         field("toilet_laki", "final_toilet_laki", grpFasilitas, "toilet_laki"),
+		"toilet_campur":       field("toilet_campur", "final_toilet_campur", grpFasilitas, "toilet_campur"),
+		"tempat_sampah":       field("tempat_sampah", "final_tempat_sampah", grpFasilitas, "tempat_sampah"),
+		"sumber_listrik":      field("sumber_listrik", "final_sumber_listrik", grpFasilitas, "sumber_listrik"),
+		"kondisi_penerangan":  field("kondisi_penerangan", "final_kondisi_penerangan", grpFasilitas, "kondisi_penerangan"),
+		"titik_akses_listrik": field("titik_akses_listrik", "final_titik_akses_listrik", grpFasilitas, "titik_akses_listrik"),
+		"posko_tenaga_medis":  field("posko_tenaga_medis", "final_posko_kesehatan", grpFasilitas, "posko_kesehatan"),
+		"posko_obat":          field("posko_obat", "final_posko_obat", grpFasilitas, "posko_obat"),
+		"posko_psikososial":   field("posko_psikososial", "final_posko_psikososial", grpFasilitas, "posko_psikososial"),
+		"ruang_laktasi":       field("ruang_laktasi", "final_ruang_laktasi", grpFasilitas, "ruang_laktasi"),
+		"layanan_lansia":      field("layanan_lansia", "final_layanan_lansia", grpFasilitas, "layanan_lansia"),
+		"layanan_keluarga":    field("layanan_keluarga", "final_layanan_keluarga", grpFasilitas, "layanan_keluarga"),
+		"sekolah_darurat":     field("sekolah_darurat", "final_sekolah_darurat", grpFasilitas, "sekolah_darurat"),
+		"program_pengganti":   field("program_pengganti", "final_program_pengganti", grpFasilitas, "program_pengganti"),
+		"petugas_keamanan":    field("petugas_keamanan", "final_petugas_keamanan", grpFasilitas, "petugas_keamanan"),
+		"area_interaksi":      field("area_interaksi", "final_area_interaksi", grpFasilitas, "area_interaksi"),
+		"area_bermain":        field("area_bermain", "final_area_bermain", grpFasilitas, "area_bermain"),
 	}
 
 	// Calculate kebutuhan_air from total_pengungsi if not already set
@@ -194,28 +210,117 @@ func MapSubmissionToLocation(submission map[string]interface{}) (*model.Location
 
 	// Build Komunikasi JSONB - try final_* first, fallback to grp_komunikasi
 	location.Komunikasi = model.JSONB{
-		"ketersediaan_sinyal":   getWithFallback(submission, "final_ketersediaan_sinyal", grpKomunikasi, "ketersediaan_sinyal"),
-		"jaringan_orari":        getWithFallback(submission, "final_jaringan_orari", grpKomunikasi, "jaringan_orari"),
-		"ketersediaan_internet": getWithFallback(submission, "final_ketersediaan_internet", grpKomunikasi, "ketersediaan_internet"),
+		"ketersediaan_sinyal":   field("ketersediaan_sinyal", "final_ketersediaan_sinyal", grpKomunikasi, "ketersediaan_sinyal"),
+		"jaringan_orari":        field("jaringan_orari", "final_jaringan_orari", grpKomunikasi, "jaringan_orari"),
+		"ketersediaan_internet": field("ketersediaan_internet", "final_ketersediaan_internet", grpKomunikasi, "ketersediaan_internet"),
 	}
 
 	// Build Akses JSONB - try final_* first, fallback to grp_akses
 	location.Akses = model.JSONB{
-		"jarak_pkm":            getWithFallback(submission, "final_jarak_pkm", grpAkses, "jarak_pkm"),
-		"jarak_posko_logistik": getWithFallback(submission, "final_jarak_posko_logistik", grpAkses, "jarak_posko_logistik"),
-		"nama_faskes_terdekat": getWithFallback(submission, "final_nama_faskes_terdekat", grpAkses, "nama_faskes_terdekat"),
-		"terisolir":            getWithFallback(submission, "final_terisolir", grpAkses, "terisolir"),
-		"akses_via":            getWithFallback(submission, "final_akses_via", grpAkses, "akses_via"),
+		"jarak_pkm":            field("jarak_pkm", "final_jarak_pkm", grpAkses, "jarak_pkm"),
+		"jarak_posko_logistik": field("jarak_posko_logistik", "final_jarak_posko_logistik", grpAkses, "jarak_posko_logistik"),
+		"nama_faskes_terdekat": field("nama_faskes_terdekat", "final_nama_faskes_terdekat", grpAkses, "nama_faskes_terdekat"),
+		"terisolir":            field("terisolir", "final_terisolir", grpAkses, "terisolir"),
+		"akses_via":            field("akses_via", "final_akses_via", grpAkses, "akses_via"),
 	}
 
+	// Some submissions have lat/lon in the wrong order; points still out of range are dropped
+	submissionID, _ := submission["__id"].(string)
+	fixSwappedCoords("posko", submissionID, location.Latitude, location.Longitude)
+	location.InvalidCoordinates = !validateCoords("posko", submissionID, &location.Latitude, &location.Longitude)
+
 	// Store raw submission data
 	location.RawData = model.JSONB(submission)
 
 	return location, nil
 }
 
+// resolveLocationName returns the first non-empty name field. Fields may be nested paths
+// ("grp_identitas.nama_posko"). If none is set, falls back to the entity label
+// (meta.entity.label) and finally the submission ID, so no posko is left nameless.
+func resolveLocationName(submission map[string]interface{}, nameFields []string) string {
+	for _, field := range nameFields {
+		if nama, ok := lookupPath(submission, field).(string); ok && strings.TrimSpace(nama) != "" {
+			return strings.TrimSpace(nama)
+		}
+	}
+
+	odkID, _ := submission["__id"].(string)
+	if label, ok := lookupPath(submission, "meta.entity.label").(string); ok && strings.TrimSpace(label) != "" {
+		log.Printf("Warning: submission %s has no nama in %v, using entity label", odkID, nameFields)
+		return strings.TrimSpace(label)
+	}
+	if odkID != "" {
+		log.Printf("Warning: submission %s has no nama in %v or entity label, using submission ID", odkID, nameFields)
+	}
+	return odkID
+}
+
+// lookupPath reads a dot-separated path (e.g. "meta.entity.label") from nested submission maps
+func lookupPath(data map[string]interface{}, path string) interface{} {
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
 // ExtractPhotos extracts photo information from a submission
 func ExtractPhotos(submission map[string]interface{}) []PhotoInfo {
+	return extractPhotoFields(submission, []PhotoField{
+		{Field: "foto_depan", PhotoType: "tampak_depan"},
+		{Field: "foto_area1", PhotoType: "area_1"},
+		{Field: "foto_area2", PhotoType: "area_2"},
+		{Field: "foto_area3", PhotoType: "area_3"},
+		{Field: "foto_toilet", PhotoType: "toilet"},
+		{Field: "foto_sampah", PhotoType: "sampah"},
+		{Field: "foto_faskes", PhotoType: "faskes"},
+		{Field: "foto_dapur", PhotoType: "dapur"},
+	})
+}
+
+// PhotoField maps a grp_foto field in the submission to a stored photo type
+type PhotoField struct {
+	Field     string
+	PhotoType string
+}
+
+// maxPhotoTypeLength matches the photo_type VARCHAR(50) columns
+const maxPhotoTypeLength = 50
+
+// ParsePhotoFields parses "field:photo_type" entries (e.g. "foto_alat:peralatan").
+// An entry without ":photo_type" uses the field name as the type.
+func ParsePhotoFields(entries []string) ([]PhotoField, error) {
+	fields := make([]PhotoField, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		field, photoType, _ := strings.Cut(entry, ":")
+		field = strings.TrimSpace(field)
+		photoType = strings.TrimSpace(photoType)
+		if field == "" {
+			return nil, fmt.Errorf("invalid photo field %q: missing field name", entry)
+		}
+		if photoType == "" {
+			photoType = field
+		}
+		if len(photoType) > maxPhotoTypeLength {
+			return nil, fmt.Errorf("invalid photo field %q: photo type longer than %d characters", entry, maxPhotoTypeLength)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate photo field %q", field)
+		}
+		seen[field] = true
+		fields = append(fields, PhotoField{Field: field, PhotoType: photoType})
+	}
+	return fields, nil
+}
+
+// extractPhotoFields collects the non-empty photo slots of grp_foto
+func extractPhotoFields(submission map[string]interface{}, photoFields []PhotoField) []PhotoInfo {
 	var photos []PhotoInfo
 
 	grpFoto, ok := submission["grp_foto"].(map[string]interface{})
@@ -223,30 +328,16 @@ func ExtractPhotos(submission map[string]interface{}) []PhotoInfo {
 		return photos
 	}
 
-	photoFields := []struct {
-		field     string
-		photoType string
-	}{
-		{"foto_depan", "tampak_depan"},
-		{"foto_area1", "area_1"},
-		{"foto_area2", "area_2"},
-		{"foto_area3", "area_3"},
-		{"foto_toilet", "toilet"},
-		{"foto_sampah", "sampah"},
-		{"foto_faskes", "faskes"},
-		{"foto_dapur", "dapur"},
-	}
-
 	submissionID := ""
 	if id, ok := submission["__id"].(string); ok {
 		submissionID = id
 	}
 
 	for _, pf := range photoFields {
-		if filename, ok := grpFoto[pf.field].(string); ok && filename != "" {
+		if filename, ok := grpFoto[pf.Field].(string); ok && filename != "" {
 			photos = append(photos, PhotoInfo{
 				Filename:     filename,
-				PhotoType:    pf.photoType,
+				PhotoType:    pf.PhotoType,
 				SubmissionID: submissionID,
 			})
 		}
@@ -264,6 +355,16 @@ type PhotoInfo struct {
 
 // Helper functions
 
+// submissionSource tells live form submissions (carrying final_* calculated fields) from dump data
+func submissionSource(submission map[string]interface{}) string {
+	for key := range submission {
+		if strings.HasPrefix(key, "final_") {
+			return model.SourceODKLive
+		}
+	}
+	return model.SourceODKDump
+}
+
 // getWithFallback tries to get value from primary map first, then falls back to secondary map
 func getWithFallback(primary map[string]interface{}, primaryKey string, fallback map[string]interface{}, fallbackKey string) interface{} {
 	if val := primary[primaryKey]; val != nil {
@@ -304,3 +405,23 @@ func getFloatValue(m map[string]interface{}, key string) *float64 {
 func BuildGeomSQL(lat, lon float64) string {
 	return fmt.Sprintf("ST_SetSRID(ST_MakePoint(%f, %f), 4326)", lon, lat)
 }
+
+// ApplyDemographicTotals precomputes the demographic columns from DataPengungsi.
+// Numeric and string-encoded numeric values are counted; DataPengungsi remains the source of truth.
+func ApplyDemographicTotals(location *model.Location) {
+	sum := func(keys ...string) int {
+		total := 0
+		for _, key := range keys {
+			if v, ok := location.DataPengungsi.Number(key); ok {
+				total += int(v)
+			}
+		}
+		return total
+	}
+
+	location.JumlahKK = sum("jumlah_kk")
+	location.TotalJiwa = sum("total_jiwa")
+	location.JumlahPerempuan = sum("dewasa_perempuan", "remaja_perempuan", "anak_perempuan", "balita_perempuan", "bayi_perempuan")
+	location.JumlahLaki = sum("dewasa_laki", "remaja_laki", "anak_laki", "balita_laki", "bayi_laki")
+	location.JumlahBalita = sum("balita_perempuan", "balita_laki", "bayi_perempuan", "bayi_laki")
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field sources a posko mapping profile reads from
+const (
+	FieldSourceAuto  = "auto"  // final_* calculated field, falling back to the nested grp_* field (default)
+	FieldSourceFinal = "final" // final_* calculated fields only (XLSForm v2+)
+	FieldSourceGroup = "grp"   // nested grp_* fields only (v1 forms and dump data)
+)
+
+// MappingProfile tells the posko mapper where to read fields for one form version
+type MappingProfile struct {
+	Name   string
+	Source string
+	// Fields overrides individual output keys (e.g. "nama_relawan") with a submission
+	// path ("grp_identitas.relawan_nama"), for fields renamed between form versions
+	Fields map[string]string
+}
+
+// DefaultMappingProfile is used for form versions without a profile: the final_* → grp_* fallback chain
+var DefaultMappingProfile = &MappingProfile{Name: "default", Source: FieldSourceAuto}
+
+// MappingProfiles maps a form version (__system.formVersion) to its mapping profile
+type MappingProfiles map[string]*MappingProfile
+
+// ForSubmission returns the profile for the submission's form version, or DefaultMappingProfile
+func (p MappingProfiles) ForSubmission(submission map[string]interface{}) *MappingProfile {
+	if profile, ok := p[submissionFormVersion(submission)]; ok {
+		return profile
+	}
+	return DefaultMappingProfile
+}
+
+// value reads one output key: a field override first, then the profile's source
+func (p *MappingProfile) value(submission map[string]interface{}, key, finalKey string, group map[string]interface{}, groupKey string) interface{} {
+	if path, ok := p.Fields[key]; ok {
+		return lookupPath(submission, path)
+	}
+	switch p.Source {
+	case FieldSourceFinal:
+		return submission[finalKey]
+	case FieldSourceGroup:
+		if group != nil {
+			return group[groupKey]
+		}
+		return nil
+	}
+	return getWithFallback(submission, finalKey, group, groupKey)
+}
+
+// readsFinal reports whether final_* calculated fields are used
+func (p *MappingProfile) readsFinal() bool {
+	return p.Source != FieldSourceGroup
+}
+
+// readsGroup reports whether nested grp_* fields are used
+func (p *MappingProfile) readsGroup() bool {
+	return p.Source != FieldSourceFinal
+}
+
+// submissionFormVersion returns __system.formVersion, or "" when missing (e.g. dump data)
+func submissionFormVersion(submission map[string]interface{}) string {
+	if system, ok := submission["__system"].(map[string]interface{}); ok {
+		if version, ok := system["formVersion"].(string); ok {
+			return version
+		}
+	}
+	return ""
+}
+
+// ParseMappingProfiles builds profiles from "formVersion=source" entries (source: auto, final, grp)
+// and "formVersion:key=path" field overrides. A version with only overrides uses the auto source.
+func ParseMappingProfiles(versions, fields []string) (MappingProfiles, error) {
+	profiles := make(MappingProfiles)
+	profileFor := func(version string) *MappingProfile {
+		if profiles[version] == nil {
+			profiles[version] = &MappingProfile{Name: version, Source: FieldSourceAuto}
+		}
+		return profiles[version]
+	}
+
+	for _, entry := range versions {
+		version, source, ok := strings.Cut(entry, "=")
+		version, source = strings.TrimSpace(version), strings.TrimSpace(source)
+		if !ok || version == "" {
+			return nil, fmt.Errorf("invalid mapping profile %q: expected formVersion=source", entry)
+		}
+		switch source {
+		case FieldSourceAuto, FieldSourceFinal, FieldSourceGroup:
+		default:
+			return nil, fmt.Errorf("invalid mapping profile %q: source must be %s, %s or %s", entry, FieldSourceAuto, FieldSourceFinal, FieldSourceGroup)
+		}
+		profileFor(version).Source = source
+	}
+
+	for _, entry := range fields {
+		version, override, ok := strings.Cut(entry, ":")
+		key, path, ok2 := strings.Cut(override, "=")
+		version, key, path = strings.TrimSpace(version), strings.TrimSpace(key), strings.TrimSpace(path)
+		if !ok || !ok2 || version == "" || key == "" || path == "" {
+			return nil, fmt.Errorf("invalid mapping field %q: expected formVersion:key=path", entry)
+		}
+		profile := profileFor(version)
+		if profile.Fields == nil {
+			profile.Fields = make(map[string]string)
+		}
+		profile.Fields[key] = path
+	}
+
+	return profiles, nil
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,6 +28,108 @@ type PhotoService struct {
 	storagePath string
 	s3Storage   *storage.S3Storage
 	useS3       bool
+
+	// allowedContentTypes is the attachment MIME allowlist (supports "image/*" wildcards)
+	allowedContentTypes []string
+
+	// signedURLTTL is how long signed URLs stay valid (private-bucket mode)
+	signedURLTTL time.Duration
+
+	// publicBaseURL prefixes fallback file-endpoint URLs (empty keeps them relative)
+	publicBaseURL string
+
+	// formIDs are the ODK forms feed, faskes and infrastruktur attachments are downloaded from
+	formIDs PhotoFormIDs
+
+	// migrationMu allows one MigrateToS3 at a time
+	migrationMu sync.Mutex
+}
+
+// PhotoFormIDs are the ODK form IDs holding each photo type's attachments
+// (posko photos use the form of the service's ODK client)
+type PhotoFormIDs struct {
+	Feed          string
+	Faskes        string
+	Infrastruktur string
+}
+
+// DefaultPhotoFormIDs match the default ODK_*_FORM_ID configuration
+var DefaultPhotoFormIDs = PhotoFormIDs{
+	Feed:          "form_feed_v1",
+	Faskes:        "form_faskes_v1",
+	Infrastruktur: "form_jembatan_v1",
+}
+
+// DefaultAllowedContentTypes is the default attachment allowlist: images and PDF
+var DefaultAllowedContentTypes = []string{"image/*", "application/pdf"}
+
+// ErrContentTypeNotAllowed is returned when an attachment's type is not in the allowlist
+var ErrContentTypeNotAllowed = errors.New("content type not allowed")
+
+// ErrPhotoNotFound is returned when a photo record does not exist
+var ErrPhotoNotFound = errors.New("photo not found")
+
+// SetAllowedContentTypes overrides the attachment MIME allowlist
+func (s *PhotoService) SetAllowedContentTypes(types []string) {
+	if len(types) == 0 {
+		return
+	}
+	s.allowedContentTypes = types
+}
+
+// checkContentType sniffs the attachment data (falling back to the extension when the
+// content is not recognised) and returns the type, with ErrContentTypeNotAllowed for
+// types outside the allowlist
+func (s *PhotoService) checkContentType(data []byte, filename string) (string, error) {
+	contentType := storage.SniffContentType(data, filename)
+	if !s.contentTypeAllowed(contentType) {
+		return contentType, fmt.Errorf("%w: %s (%s)", ErrContentTypeNotAllowed, filename, contentType)
+	}
+	return contentType, nil
+}
+
+// contentTypeAllowed reports whether the allowlist admits contentType
+func (s *PhotoService) contentTypeAllowed(contentType string) bool {
+	for _, allowed := range s.allowedContentTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// skipRejected returns ErrContentTypeNotAllowed, without downloading the attachment again,
+// when it was rejected before and the allowlist still does not admit its type
+func (s *PhotoService) skipRejected(rejectedContentType *string, filename string) error {
+	if rejectedContentType == nil || s.contentTypeAllowed(*rejectedContentType) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (%s, rejected before)", ErrContentTypeNotAllowed, filename, *rejectedContentType)
+}
+
+// markRejected records on a photo record (of any photo table) the type its attachment
+// was rejected with, so later syncs skip it
+func (s *PhotoService) markRejected(photo interface{}, contentType string) {
+	if err := s.db.Model(photo).Update("rejected_content_type", contentType).Error; err != nil {
+		log.Printf("Warning: failed to mark rejected attachment: %v", err)
+	}
+}
+
+// SetSignedURLTTL overrides how long signed photo URLs stay valid
+func (s *PhotoService) SetSignedURLTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	s.signedURLTTL = ttl
+}
+
+// SetPublicBaseURL makes generated file-endpoint URLs absolute
+func (s *PhotoService) SetPublicBaseURL(base string) {
+	s.publicBaseURL = strings.TrimRight(base, "/")
 }
 
 // NewPhotoService creates a new photo service with local storage
@@ -38,6 +144,10 @@ func NewPhotoService(db *gorm.DB, odkClient *odk.Client, storagePath string) *Ph
 		odkClient:   odkClient,
 		storagePath: storagePath,
 		useS3:       false,
+
+		allowedContentTypes: DefaultAllowedContentTypes,
+		signedURLTTL:        defaultSignedURLTTL,
+		formIDs:             DefaultPhotoFormIDs,
 	}
 
 	// Validate cache on startup - verify files exist for cached photos
@@ -54,6 +164,10 @@ func NewPhotoServiceWithS3(db *gorm.DB, odkClient *odk.Client, storagePath strin
 		storagePath: storagePath,
 		s3Storage:   s3Storage,
 		useS3:       s3Storage != nil,
+
+		allowedContentTypes: DefaultAllowedContentTypes,
+		signedURLTTL:        defaultSignedURLTTL,
+		formIDs:             DefaultPhotoFormIDs,
 	}
 
 	// Validate cache on startup - verify files exist for cached photos
@@ -62,14 +176,57 @@ func NewPhotoServiceWithS3(db *gorm.DB, odkClient *odk.Client, storagePath strin
 	return svc
 }
 
+// SetFormIDs sets the ODK forms feed, faskes and infrastruktur photos are downloaded from.
+// Empty IDs keep the current value.
+func (s *PhotoService) SetFormIDs(ids PhotoFormIDs) {
+	if ids.Feed != "" {
+		s.formIDs.Feed = ids.Feed
+	}
+	if ids.Faskes != "" {
+		s.formIDs.Faskes = ids.Faskes
+	}
+	if ids.Infrastruktur != "" {
+		s.formIDs.Infrastruktur = ids.Infrastruktur
+	}
+}
+
+// FormIDs returns the ODK forms photos are downloaded from
+func (s *PhotoService) FormIDs() PhotoFormIDs {
+	return s.formIDs
+}
+
 // DownloadAndSavePhoto downloads a photo from ODK Central and saves it to storage (S3 or local)
 func (s *PhotoService) DownloadAndSavePhoto(photo *model.LocationPhoto, submissionID string) error {
+	if err := s.skipRejected(photo.RejectedContentType, photo.Filename); err != nil {
+		return err
+	}
+
 	// Download from ODK Central
 	data, err := s.odkClient.GetAttachment(submissionID, photo.Filename)
 	if err != nil {
 		return fmt.Errorf("failed to download attachment: %w", err)
 	}
 
+	// Reject attachments outside the MIME allowlist
+	contentType, err := s.checkContentType(data, photo.Filename)
+	if err != nil {
+		s.markRejected(photo, contentType)
+		return err
+	}
+	photo.RejectedContentType = nil
+
+	// A re-download (e.g. a newer submission reusing the filename) of an unchanged image
+	// keeps the cached file
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	previousPath, previousThumb := photo.StoragePath, photo.ThumbnailPath
+	if photo.Checksum != nil && *photo.Checksum == checksum && previousPath != nil && *previousPath != "" {
+		photo.IsCached = true
+		photo.Width, photo.Height = imageDimensions(data)
+		photo.ContentType = &contentType
+		return s.db.Save(photo).Error
+	}
+
 	// Generate unique filename
 	ext := filepath.Ext(photo.Filename)
 	newFilename := fmt.Sprintf("%s_%s%s", photo.PhotoType, uuid.New().String()[:8], ext)
@@ -80,7 +237,6 @@ func (s *PhotoService) DownloadAndSavePhoto(photo *model.LocationPhoto, submissi
 	if s.useS3 {
 		// Upload to S3
 		key := fmt.Sprintf("locations/%s/%s", photo.LocationID.String(), newFilename)
-		contentType := getContentType(ext)
 		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
 		if err != nil {
 			return fmt.Errorf("failed to upload to S3: %w", err)
@@ -99,14 +255,20 @@ func (s *PhotoService) DownloadAndSavePhoto(photo *model.LocationPhoto, submissi
 		}
 		log.Printf("Downloaded photo: %s -> %s", photo.Filename, storagePath)
 	}
+	thumbPath := s.storeThumbnail(data, "locations/"+photo.LocationID.String(), filepath.Join(s.storagePath, photo.LocationID.String()), newFilename)
 
 	// Update database record
 	photo.StoragePath = &storagePath
+	photo.ThumbnailPath = thumbPath
 	photo.IsCached = true
 	photo.FileSize = &fileSize
+	photo.ContentType = &contentType
+	photo.Width, photo.Height = imageDimensions(data)
+	photo.Checksum = &checksum
 
 	if err := s.db.Save(photo).Error; err != nil {
 		// Clean up if database update fails
+		s.removeThumbnail(thumbPath)
 		if s.useS3 {
 			key := fmt.Sprintf("locations/%s/%s", photo.LocationID.String(), newFilename)
 			s.s3Storage.Delete(context.Background(), key)
@@ -116,9 +278,37 @@ func (s *PhotoService) DownloadAndSavePhoto(photo *model.LocationPhoto, submissi
 		return fmt.Errorf("failed to update database: %w", err)
 	}
 
+	// The photo was replaced by a changed image: drop the old file
+	if previousPath != nil && *previousPath != "" && *previousPath != storagePath {
+		s.removeStoredFile(*previousPath)
+		s.removeThumbnail(previousThumb)
+	}
+
 	return nil
 }
 
+// removeStoredFile deletes a cached file by its storage_path (S3 URL or local path)
+func (s *PhotoService) removeStoredFile(storagePath string) {
+	var err error
+	if s.useS3 && strings.HasPrefix(storagePath, "http") {
+		err = s.s3Storage.Delete(context.Background(), extractS3Key(storagePath))
+	} else {
+		err = os.Remove(storagePath)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: failed to remove replaced photo file %s: %v", storagePath, err)
+	}
+}
+
+// photoContentType is the MIME type a cached photo is served with: the one sniffed at
+// download, or the extension's for photos cached before it was recorded
+func photoContentType(contentType *string, filename string) string {
+	if contentType != nil && *contentType != "" {
+		return *contentType
+	}
+	return getContentType(filepath.Ext(filename))
+}
+
 // getContentType returns the MIME type based on file extension
 func getContentType(ext string) string {
 	switch strings.ToLower(ext) {
@@ -156,6 +346,12 @@ func (s *PhotoService) SyncPhotos(locationID uuid.UUID, submissionID string) (in
 
 // SyncAllPhotos syncs all uncached photos across all locations
 func (s *PhotoService) SyncAllPhotos() (*PhotoSyncResult, error) {
+	return s.syncLocationPhotos(time.Time{})
+}
+
+// syncLocationPhotos downloads uncached posko photos, only those of locations submitted
+// after since unless since is zero
+func (s *PhotoService) syncLocationPhotos(since time.Time) (*PhotoSyncResult, error) {
 	result := &PhotoSyncResult{
 		StartTime: time.Now(),
 	}
@@ -163,14 +359,17 @@ func (s *PhotoService) SyncAllPhotos() (*PhotoSyncResult, error) {
 	// Get all uncached photos with their location's submission ID
 	var photos []struct {
 		model.LocationPhoto
-		ODKSubmissionID string `gorm:"column:odk_submission_id"`
+		ParentSubmissionID string `gorm:"column:parent_submission_id"`
 	}
 
-	err := s.db.Table("location_photos").
-		Select("location_photos.*, locations.odk_submission_id").
+	query := s.db.Table("location_photos").
+		Select("location_photos.*, locations.odk_submission_id AS parent_submission_id").
 		Joins("LEFT JOIN locations ON locations.id = location_photos.location_id").
-		Where("location_photos.is_cached = false").
-		Find(&photos).Error
+		Where("location_photos.is_cached = false")
+	if !since.IsZero() {
+		query = query.Where("locations.submitted_at > ?", since)
+	}
+	err := query.Find(&photos).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch uncached photos: %w", err)
@@ -180,9 +379,14 @@ func (s *PhotoService) SyncAllPhotos() (*PhotoSyncResult, error) {
 
 	for _, p := range photos {
 		photo := p.LocationPhoto
-		if err := s.DownloadAndSavePhoto(&photo, p.ODKSubmissionID); err != nil {
+		if err := s.DownloadAndSavePhoto(&photo, p.ParentSubmissionID); err != nil {
+			if errors.Is(err, ErrContentTypeNotAllowed) {
+				result.Skipped++
+				log.Printf("Skipping attachment: %v", err)
+				continue
+			}
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: %v", photo.Filename, err))
 			continue
 		}
 		result.Downloaded++
@@ -198,11 +402,117 @@ func (s *PhotoService) SyncAllPhotos() (*PhotoSyncResult, error) {
 type PhotoSyncResult struct {
 	TotalFound   int       `json:"total_found"`
 	Downloaded   int       `json:"downloaded"`
+	Skipped      int       `json:"skipped"` // rejected by the content type allowlist
 	Errors       int       `json:"errors"`
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 	Duration     string    `json:"duration"`
 	ErrorDetails []string  `json:"error_details,omitempty"`
+
+	// TruncatedErrors counts error messages left out of ErrorDetails (see SetMaxErrorDetails)
+	TruncatedErrors int `json:"truncated_errors,omitempty"`
+}
+
+// SyncPhotosSince downloads the uncached photos of every type whose posko, feed, faskes
+// or infrastruktur record was submitted after since, instead of walking the whole
+// uncached set. The per-type results are added up into one result.
+func (s *PhotoService) SyncPhotosSince(since time.Time) (*PhotoSyncResult, error) {
+	result := &PhotoSyncResult{
+		StartTime: time.Now(),
+	}
+
+	jobs := []struct {
+		name string
+		run  func() (*PhotoSyncResult, error)
+	}{
+		{"location", func() (*PhotoSyncResult, error) { return s.syncLocationPhotos(since) }},
+		{"feed", func() (*PhotoSyncResult, error) { return s.syncFeedPhotos("", since) }},
+		{"faskes", func() (*PhotoSyncResult, error) { return s.syncFaskesPhotos("", since) }},
+		{"infrastruktur", func() (*PhotoSyncResult, error) { return s.syncInfraPhotos("", since) }},
+	}
+	for _, job := range jobs {
+		res, err := job.run()
+		if err != nil {
+			return nil, fmt.Errorf("%s photos: %w", job.name, err)
+		}
+		result.TotalFound += res.TotalFound
+		result.Downloaded += res.Downloaded
+		result.Skipped += res.Skipped
+		result.Errors += res.Errors
+		result.mergeErrorDetails(res)
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	return result, nil
+}
+
+// PhotoSyncAllResult holds the per-type results of syncing every photo type
+type PhotoSyncAllResult struct {
+	LocationPhotos      *PhotoSyncResult `json:"location_photos"`
+	FeedPhotos          *PhotoSyncResult `json:"feed_photos"`
+	FaskesPhotos        *PhotoSyncResult `json:"faskes_photos"`
+	InfrastrukturPhotos *PhotoSyncResult `json:"infrastruktur_photos"`
+	TotalDownloaded     int              `json:"total_downloaded"`
+	TotalErrors         int              `json:"total_errors"`
+	Workers             int              `json:"workers"`
+	Duration            string           `json:"duration"`
+}
+
+// SyncAllPhotoTypes downloads uncached posko, feed, faskes and infrastruktur photos,
+// syncing up to workers photo types at a time (1 runs them one after another)
+func (s *PhotoService) SyncAllPhotoTypes(workers int) *PhotoSyncAllResult {
+	start := time.Now()
+	result := &PhotoSyncAllResult{}
+
+	jobs := []struct {
+		name   string
+		run    func() (*PhotoSyncResult, error)
+		result **PhotoSyncResult
+	}{
+		{"location", s.SyncAllPhotos, &result.LocationPhotos},
+		{"feed", func() (*PhotoSyncResult, error) { return s.SyncFeedPhotos("") }, &result.FeedPhotos},
+		{"faskes", func() (*PhotoSyncResult, error) { return s.SyncFaskesPhotos("") }, &result.FaskesPhotos},
+		{"infrastruktur", func() (*PhotoSyncResult, error) { return s.SyncInfraPhotos("") }, &result.InfrastrukturPhotos},
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	result.Workers = workers
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := job.run()
+			if err != nil {
+				// A type that could not be listed still gets a result, so one failure doesn't hide the others
+				log.Printf("Photo sync all: %s photos failed: %v", job.name, err)
+				res = &PhotoSyncResult{Errors: 1, ErrorDetails: []string{err.Error()}}
+			}
+			*job.result = res
+		}()
+	}
+	wg.Wait()
+
+	for _, job := range jobs {
+		result.TotalDownloaded += (*job.result).Downloaded
+		result.TotalErrors += (*job.result).Errors
+	}
+	result.Duration = time.Since(start).String()
+
+	log.Printf("Photo sync all completed: %d downloaded, %d errors in %s", result.TotalDownloaded, result.TotalErrors, result.Duration)
+
+	return result
 }
 
 // GetPhotoPath returns the storage path for a photo
@@ -219,6 +529,15 @@ func (s *PhotoService) GetPhotoPath(photoID uuid.UUID) (string, error) {
 	return *photo.StoragePath, nil
 }
 
+// GetPhotoByID returns a location photo by ID
+func (s *PhotoService) GetPhotoByID(photoID uuid.UUID) (*model.LocationPhoto, error) {
+	var photo model.LocationPhoto
+	if err := s.db.First(&photo, photoID).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPhotoNotFound, err)
+	}
+	return &photo, nil
+}
+
 // GetPhotosByLocation returns all photos for a location
 func (s *PhotoService) GetPhotosByLocation(locationID uuid.UUID) ([]model.LocationPhoto, error) {
 	var photos []model.LocationPhoto
@@ -228,15 +547,123 @@ func (s *PhotoService) GetPhotosByLocation(locationID uuid.UUID) ([]model.Locati
 	return photos, nil
 }
 
+// PhotoURL is a directly fetchable URL for a photo. ExpiresAt is set for signed URLs only
+type PhotoURL struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	SignedURL string     `json:"signed_url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Width     *int       `json:"width,omitempty"`
+	Height    *int       `json:"height,omitempty"`
+}
+
+// defaultSignedURLTTL is the signed URL lifetime when none is configured
+const defaultSignedURLTTL = 15 * time.Minute
+
+// GetPhotoURLsByLocation builds URLs for all of a location's photos in one pass.
+// Private S3 objects get pre-signed URLs, public S3 objects their public URL, and
+// anything else (local files, not yet cached) falls back to the file endpoint.
+func (s *PhotoService) GetPhotoURLsByLocation(locationID uuid.UUID) ([]PhotoURL, error) {
+	photos, err := s.GetPhotosByLocation(locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	urls := make([]PhotoURL, 0, len(photos))
+	for _, photo := range photos {
+		pu := PhotoURL{
+			ID:        photo.ID,
+			Type:      photo.PhotoType,
+			Width:     photo.Width,
+			Height:    photo.Height,
+			SignedURL: s.publicBaseURL + "/api/v1/photos/" + photo.ID.String() + "/file",
+		}
+		if err := s.applyS3URL(ctx, &pu, photo.IsCached, photo.StoragePath); err != nil {
+			return nil, err
+		}
+		urls = append(urls, pu)
+	}
+
+	return urls, nil
+}
+
+// GetPhotoURLsByFaskes builds URLs for all of a faskes' photos, like GetPhotoURLsByLocation
+func (s *PhotoService) GetPhotoURLsByFaskes(faskesID uuid.UUID) ([]PhotoURL, error) {
+	photos, err := s.GetFaskesPhotosByFaskesID(faskesID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	urls := make([]PhotoURL, 0, len(photos))
+	for _, photo := range photos {
+		pu := PhotoURL{
+			ID:        photo.ID,
+			Type:      photo.PhotoType,
+			Width:     photo.Width,
+			Height:    photo.Height,
+			SignedURL: s.publicBaseURL + "/api/v1/faskes/photos/" + photo.ID.String() + "/file",
+		}
+		if err := s.applyS3URL(ctx, &pu, photo.IsCached, photo.StoragePath); err != nil {
+			return nil, err
+		}
+		urls = append(urls, pu)
+	}
+
+	return urls, nil
+}
+
+// applyS3URL points pu at the S3 object of a cached photo: a pre-signed URL when the
+// object's prefix is private, its public URL otherwise. Other photos keep the file endpoint.
+func (s *PhotoService) applyS3URL(ctx context.Context, pu *PhotoURL, isCached bool, storagePath *string) error {
+	if !s.useS3 || !isCached || storagePath == nil || !strings.HasPrefix(*storagePath, "http") {
+		return nil
+	}
+
+	key := extractS3Key(*storagePath)
+	if !s.s3Storage.IsPrivateKey(key) {
+		pu.SignedURL = s.s3Storage.GetPublicURL(key)
+		return nil
+	}
+
+	signed, err := s.s3Storage.GetSignedURL(ctx, key, s.signedURLTTL)
+	if err != nil {
+		return fmt.Errorf("failed to sign URL for photo %s: %w", pu.ID, err)
+	}
+	expiresAt := time.Now().Add(s.signedURLTTL)
+	pu.SignedURL = signed
+	pu.ExpiresAt = &expiresAt
+	return nil
+}
+
+// S3RedirectURL returns the URL a photo stored at storagePath (an S3 object URL) can
+// be fetched from: a pre-signed URL when the object's prefix is private, the stored
+// URL otherwise
+func (s *PhotoService) S3RedirectURL(ctx context.Context, storagePath string) (string, error) {
+	if !s.useS3 {
+		return storagePath, nil
+	}
+	key := extractS3Key(storagePath)
+	if !s.s3Storage.IsPrivateKey(key) {
+		return storagePath, nil
+	}
+	signed, err := s.s3Storage.GetSignedURL(ctx, key, s.signedURLTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign URL for %s: %w", key, err)
+	}
+	return signed, nil
+}
+
 // GetPhotoReader returns a reader for the photo file
-func (s *PhotoService) GetPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, error) {
+func (s *PhotoService) GetPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, string, error) {
 	var photo model.LocationPhoto
 	if err := s.db.First(&photo, photoID).Error; err != nil {
-		return nil, "", fmt.Errorf("photo not found: %w", err)
+		return nil, "", "", fmt.Errorf("photo not found: %w", err)
 	}
 
 	if photo.StoragePath == nil || *photo.StoragePath == "" {
-		return nil, "", fmt.Errorf("photo not cached")
+		return nil, "", "", fmt.Errorf("photo not cached")
 	}
 
 	storagePath := *photo.StoragePath
@@ -245,21 +672,50 @@ func (s *PhotoService) GetPhotoReader(photoID uuid.UUID) (io.ReadCloser, string,
 	if s.useS3 && strings.HasPrefix(storagePath, "http") {
 		// Extract key from URL and get from S3
 		key := extractS3Key(storagePath)
-		reader, contentType, err := s.s3Storage.GetReader(context.Background(), key)
+		reader, _, err := s.s3Storage.GetReader(context.Background(), key)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to get from S3: %w", err)
+			return nil, "", "", fmt.Errorf("failed to get from S3: %w", err)
 		}
-		_ = contentType // We return filename, not content type
-		return reader, filepath.Base(key), nil
+		return reader, filepath.Base(key), photoContentType(photo.ContentType, photo.Filename), nil
 	}
 
 	// Local file
 	file, err := os.Open(storagePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+		return nil, "", "", fmt.Errorf("failed to open file: %w", err)
 	}
 
-	return file, filepath.Base(storagePath), nil
+	return file, filepath.Base(storagePath), photoContentType(photo.ContentType, photo.Filename), nil
+}
+
+// OpenPhotoOriginal streams a location photo straight from ODK Central, bypassing the
+// cache. Returns the body (caller closes), the original filename and the content type.
+func (s *PhotoService) OpenPhotoOriginal(photoID uuid.UUID) (io.ReadCloser, string, string, error) {
+	var row struct {
+		model.LocationPhoto
+		ParentSubmissionID string `gorm:"column:parent_submission_id"`
+	}
+	err := s.db.Table("location_photos").
+		Select("location_photos.*, locations.odk_submission_id AS parent_submission_id").
+		Joins("LEFT JOIN locations ON locations.id = location_photos.location_id").
+		Where("location_photos.id = ?", photoID).
+		Take(&row).Error
+	if err != nil {
+		return nil, "", "", fmt.Errorf("%w: %v", ErrPhotoNotFound, err)
+	}
+	if row.ParentSubmissionID == "" {
+		return nil, "", "", fmt.Errorf("missing submission ID")
+	}
+
+	body, contentType, err := s.odkClient.OpenAttachment("", row.ParentSubmissionID, row.Filename)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = getContentType(filepath.Ext(row.Filename))
+	}
+
+	return body, row.Filename, contentType, nil
 }
 
 // extractS3Key extracts the S3 key from a full URL
@@ -296,6 +752,9 @@ func (s *PhotoService) DeletePhoto(photoID uuid.UUID) error {
 	if photo.StoragePath != nil && *photo.StoragePath != "" {
 		os.Remove(*photo.StoragePath)
 	}
+	if photo.ThumbnailPath != nil && *photo.ThumbnailPath != "" {
+		os.Remove(*photo.ThumbnailPath)
+	}
 
 	// Delete database record
 	return s.db.Delete(&photo).Error
@@ -310,12 +769,12 @@ func (s *PhotoService) CleanupOrphanedFiles() (int, error) {
 			return err
 		}
 
-		// Check if file exists in database (location photos or feed photos)
+		// Check if file exists in database (location photos or feed photos, or their thumbnails)
 		var count int64
-		s.db.Model(&model.LocationPhoto{}).Where("storage_path = ?", path).Count(&count)
+		s.db.Model(&model.LocationPhoto{}).Where("storage_path = ? OR thumbnail_path = ?", path, path).Count(&count)
 
 		if count == 0 {
-			s.db.Model(&model.FeedPhoto{}).Where("storage_path = ?", path).Count(&count)
+			s.db.Model(&model.FeedPhoto{}).Where("storage_path = ? OR thumbnail_path = ?", path, path).Count(&count)
 		}
 
 		if count == 0 {
@@ -332,13 +791,30 @@ func (s *PhotoService) CleanupOrphanedFiles() (int, error) {
 }
 
 // DownloadAndSaveFeedPhoto downloads a feed photo from ODK Central and saves it to storage (S3 or local)
-func (s *PhotoService) DownloadAndSaveFeedPhoto(photo *model.FeedPhoto, submissionID string, formID string) error {
+func (s *PhotoService) DownloadAndSaveFeedPhoto(photo *model.FeedPhoto, submissionID string) error {
+	return s.downloadAndSaveFeedPhoto(photo, submissionID, s.formIDs.Feed)
+}
+
+// downloadAndSaveFeedPhoto is DownloadAndSaveFeedPhoto from the given form
+func (s *PhotoService) downloadAndSaveFeedPhoto(photo *model.FeedPhoto, submissionID string, formID string) error {
+	if err := s.skipRejected(photo.RejectedContentType, photo.Filename); err != nil {
+		return err
+	}
+
 	// Download from ODK Central using the feed form
 	data, err := s.odkClient.GetAttachmentForForm(formID, submissionID, photo.Filename)
 	if err != nil {
 		return fmt.Errorf("failed to download feed attachment: %w", err)
 	}
 
+	// Reject attachments outside the MIME allowlist
+	contentType, err := s.checkContentType(data, photo.Filename)
+	if err != nil {
+		s.markRejected(photo, contentType)
+		return err
+	}
+	photo.RejectedContentType = nil
+
 	// Generate unique filename
 	ext := filepath.Ext(photo.Filename)
 	newFilename := fmt.Sprintf("%s_%s%s", photo.PhotoType, uuid.New().String()[:8], ext)
@@ -349,7 +825,6 @@ func (s *PhotoService) DownloadAndSaveFeedPhoto(photo *model.FeedPhoto, submissi
 	if s.useS3 {
 		// Upload to S3
 		key := fmt.Sprintf("feeds/%s/%s", photo.FeedID.String(), newFilename)
-		contentType := getContentType(ext)
 		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
 		if err != nil {
 			return fmt.Errorf("failed to upload feed photo to S3: %w", err)
@@ -368,14 +843,19 @@ func (s *PhotoService) DownloadAndSaveFeedPhoto(photo *model.FeedPhoto, submissi
 		}
 		log.Printf("Downloaded feed photo: %s -> %s", photo.Filename, storagePath)
 	}
+	thumbPath := s.storeThumbnail(data, "feeds/"+photo.FeedID.String(), filepath.Join(s.storagePath, "feeds", photo.FeedID.String()), newFilename)
 
 	// Update database record
 	photo.StoragePath = &storagePath
+	photo.ThumbnailPath = thumbPath
 	photo.IsCached = true
 	photo.FileSize = &fileSize
+	photo.ContentType = &contentType
+	photo.Width, photo.Height = imageDimensions(data)
 
 	if err := s.db.Save(photo).Error; err != nil {
 		// Clean up if database update fails
+		s.removeThumbnail(thumbPath)
 		if s.useS3 {
 			key := fmt.Sprintf("feeds/%s/%s", photo.FeedID.String(), newFilename)
 			s.s3Storage.Delete(context.Background(), key)
@@ -389,7 +869,18 @@ func (s *PhotoService) DownloadAndSaveFeedPhoto(photo *model.FeedPhoto, submissi
 }
 
 // SyncFeedPhotos downloads all uncached feed photos
+// An empty formID uses the configured form (see SetFormIDs).
 func (s *PhotoService) SyncFeedPhotos(formID string) (*PhotoSyncResult, error) {
+	return s.syncFeedPhotos(formID, time.Time{})
+}
+
+// syncFeedPhotos downloads uncached feed photos, only those of records submitted after
+// since unless since is zero
+func (s *PhotoService) syncFeedPhotos(formID string, since time.Time) (*PhotoSyncResult, error) {
+	if formID == "" {
+		formID = s.formIDs.Feed
+	}
+
 	result := &PhotoSyncResult{
 		StartTime: time.Now(),
 	}
@@ -397,14 +888,17 @@ func (s *PhotoService) SyncFeedPhotos(formID string) (*PhotoSyncResult, error) {
 	// Get all uncached feed photos with their feed's submission ID
 	var photos []struct {
 		model.FeedPhoto
-		ODKSubmissionID string `gorm:"column:odk_submission_id"`
+		ParentSubmissionID string `gorm:"column:parent_submission_id"`
 	}
 
-	err := s.db.Table("feed_photos").
-		Select("feed_photos.*, information_feeds.odk_submission_id").
+	query := s.db.Table("feed_photos").
+		Select("feed_photos.*, information_feeds.odk_submission_id AS parent_submission_id").
 		Joins("LEFT JOIN information_feeds ON information_feeds.id = feed_photos.feed_id").
-		Where("feed_photos.is_cached = false").
-		Find(&photos).Error
+		Where("feed_photos.is_cached = false")
+	if !since.IsZero() {
+		query = query.Where("information_feeds.submitted_at > ?", since)
+	}
+	err := query.Find(&photos).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch uncached feed photos: %w", err)
@@ -414,14 +908,19 @@ func (s *PhotoService) SyncFeedPhotos(formID string) (*PhotoSyncResult, error) {
 
 	for _, p := range photos {
 		photo := p.FeedPhoto
-		if p.ODKSubmissionID == "" {
+		if p.ParentSubmissionID == "" {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: missing submission ID", photo.Filename))
+			result.addErrorDetail(fmt.Sprintf("%s: missing submission ID", photo.Filename))
 			continue
 		}
-		if err := s.DownloadAndSaveFeedPhoto(&photo, p.ODKSubmissionID, formID); err != nil {
+		if err := s.downloadAndSaveFeedPhoto(&photo, p.ParentSubmissionID, formID); err != nil {
+			if errors.Is(err, ErrContentTypeNotAllowed) {
+				result.Skipped++
+				log.Printf("Skipping attachment: %v", err)
+				continue
+			}
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: %v", photo.Filename, err))
 			continue
 		}
 		result.Downloaded++
@@ -448,14 +947,14 @@ func (s *PhotoService) GetFeedPhotoPath(photoID uuid.UUID) (string, error) {
 }
 
 // GetFeedPhotoReader returns a reader for the feed photo file
-func (s *PhotoService) GetFeedPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, error) {
+func (s *PhotoService) GetFeedPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, string, error) {
 	var photo model.FeedPhoto
 	if err := s.db.First(&photo, photoID).Error; err != nil {
-		return nil, "", fmt.Errorf("feed photo not found: %w", err)
+		return nil, "", "", fmt.Errorf("feed photo not found: %w", err)
 	}
 
 	if photo.StoragePath == nil || *photo.StoragePath == "" {
-		return nil, "", fmt.Errorf("feed photo not cached")
+		return nil, "", "", fmt.Errorf("feed photo not cached")
 	}
 
 	storagePath := *photo.StoragePath
@@ -465,18 +964,18 @@ func (s *PhotoService) GetFeedPhotoReader(photoID uuid.UUID) (io.ReadCloser, str
 		key := extractS3Key(storagePath)
 		reader, _, err := s.s3Storage.GetReader(context.Background(), key)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to get feed photo from S3: %w", err)
+			return nil, "", "", fmt.Errorf("failed to get feed photo from S3: %w", err)
 		}
-		return reader, filepath.Base(key), nil
+		return reader, filepath.Base(key), photoContentType(photo.ContentType, photo.Filename), nil
 	}
 
 	// Local file
 	file, err := os.Open(storagePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+		return nil, "", "", fmt.Errorf("failed to open file: %w", err)
 	}
 
-	return file, filepath.Base(storagePath), nil
+	return file, filepath.Base(storagePath), photoContentType(photo.ContentType, photo.Filename), nil
 }
 
 // GetFeedPhotoByID returns a feed photo by ID
@@ -493,13 +992,30 @@ func (s *PhotoService) GetFeedPhotoByID(photoID uuid.UUID) (*model.FeedPhoto, er
 // ========================================
 
 // DownloadAndSaveFaskesPhoto downloads a faskes photo from ODK Central and saves it to storage (S3 or local)
-func (s *PhotoService) DownloadAndSaveFaskesPhoto(photo *model.FaskesPhoto, submissionID string, formID string) error {
+func (s *PhotoService) DownloadAndSaveFaskesPhoto(photo *model.FaskesPhoto, submissionID string) error {
+	return s.downloadAndSaveFaskesPhoto(photo, submissionID, s.formIDs.Faskes)
+}
+
+// downloadAndSaveFaskesPhoto is DownloadAndSaveFaskesPhoto from the given form
+func (s *PhotoService) downloadAndSaveFaskesPhoto(photo *model.FaskesPhoto, submissionID string, formID string) error {
+	if err := s.skipRejected(photo.RejectedContentType, photo.Filename); err != nil {
+		return err
+	}
+
 	// Download from ODK Central using the faskes form
 	data, err := s.odkClient.GetAttachmentForForm(formID, submissionID, photo.Filename)
 	if err != nil {
 		return fmt.Errorf("failed to download faskes attachment: %w", err)
 	}
 
+	// Reject attachments outside the MIME allowlist
+	contentType, err := s.checkContentType(data, photo.Filename)
+	if err != nil {
+		s.markRejected(photo, contentType)
+		return err
+	}
+	photo.RejectedContentType = nil
+
 	// Generate unique filename
 	ext := filepath.Ext(photo.Filename)
 	newFilename := fmt.Sprintf("%s_%s%s", photo.PhotoType, uuid.New().String()[:8], ext)
@@ -510,7 +1026,6 @@ func (s *PhotoService) DownloadAndSaveFaskesPhoto(photo *model.FaskesPhoto, subm
 	if s.useS3 {
 		// Upload to S3
 		key := fmt.Sprintf("faskes/%s/%s", photo.FaskesID.String(), newFilename)
-		contentType := getContentType(ext)
 		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
 		if err != nil {
 			return fmt.Errorf("failed to upload faskes photo to S3: %w", err)
@@ -529,14 +1044,19 @@ func (s *PhotoService) DownloadAndSaveFaskesPhoto(photo *model.FaskesPhoto, subm
 		}
 		log.Printf("Downloaded faskes photo: %s -> %s", photo.Filename, storagePath)
 	}
+	thumbPath := s.storeThumbnail(data, "faskes/"+photo.FaskesID.String(), filepath.Join(s.storagePath, "faskes", photo.FaskesID.String()), newFilename)
 
 	// Update database record
 	photo.StoragePath = &storagePath
+	photo.ThumbnailPath = thumbPath
 	photo.IsCached = true
 	photo.FileSize = &fileSize
+	photo.ContentType = &contentType
+	photo.Width, photo.Height = imageDimensions(data)
 
 	if err := s.db.Save(photo).Error; err != nil {
 		// Clean up if database update fails
+		s.removeThumbnail(thumbPath)
 		if s.useS3 {
 			key := fmt.Sprintf("faskes/%s/%s", photo.FaskesID.String(), newFilename)
 			s.s3Storage.Delete(context.Background(), key)
@@ -550,7 +1070,18 @@ func (s *PhotoService) DownloadAndSaveFaskesPhoto(photo *model.FaskesPhoto, subm
 }
 
 // SyncFaskesPhotos downloads all uncached faskes photos
+// An empty formID uses the configured form (see SetFormIDs).
 func (s *PhotoService) SyncFaskesPhotos(formID string) (*PhotoSyncResult, error) {
+	return s.syncFaskesPhotos(formID, time.Time{})
+}
+
+// syncFaskesPhotos downloads uncached faskes photos, only those of records submitted after
+// since unless since is zero
+func (s *PhotoService) syncFaskesPhotos(formID string, since time.Time) (*PhotoSyncResult, error) {
+	if formID == "" {
+		formID = s.formIDs.Faskes
+	}
+
 	result := &PhotoSyncResult{
 		StartTime: time.Now(),
 	}
@@ -558,14 +1089,17 @@ func (s *PhotoService) SyncFaskesPhotos(formID string) (*PhotoSyncResult, error)
 	// Get all uncached faskes photos with their faskes's submission ID
 	var photos []struct {
 		model.FaskesPhoto
-		ODKSubmissionID string `gorm:"column:odk_submission_id"`
+		ParentSubmissionID string `gorm:"column:parent_submission_id"`
 	}
 
-	err := s.db.Table("faskes_photos").
-		Select("faskes_photos.*, faskes.odk_submission_id").
+	query := s.db.Table("faskes_photos").
+		Select("faskes_photos.*, faskes.odk_submission_id AS parent_submission_id").
 		Joins("LEFT JOIN faskes ON faskes.id = faskes_photos.faskes_id").
-		Where("faskes_photos.is_cached = false").
-		Find(&photos).Error
+		Where("faskes_photos.is_cached = false")
+	if !since.IsZero() {
+		query = query.Where("faskes.submitted_at > ?", since)
+	}
+	err := query.Find(&photos).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch uncached faskes photos: %w", err)
@@ -575,14 +1109,19 @@ func (s *PhotoService) SyncFaskesPhotos(formID string) (*PhotoSyncResult, error)
 
 	for _, p := range photos {
 		photo := p.FaskesPhoto
-		if p.ODKSubmissionID == "" {
+		if p.ParentSubmissionID == "" {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: missing submission ID", photo.Filename))
+			result.addErrorDetail(fmt.Sprintf("%s: missing submission ID", photo.Filename))
 			continue
 		}
-		if err := s.DownloadAndSaveFaskesPhoto(&photo, p.ODKSubmissionID, formID); err != nil {
+		if err := s.downloadAndSaveFaskesPhoto(&photo, p.ParentSubmissionID, formID); err != nil {
+			if errors.Is(err, ErrContentTypeNotAllowed) {
+				result.Skipped++
+				log.Printf("Skipping attachment: %v", err)
+				continue
+			}
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: %v", photo.Filename, err))
 			continue
 		}
 		result.Downloaded++
@@ -609,14 +1148,14 @@ func (s *PhotoService) GetFaskesPhotoPath(photoID uuid.UUID) (string, error) {
 }
 
 // GetFaskesPhotoReader returns a reader for the faskes photo file
-func (s *PhotoService) GetFaskesPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, error) {
+func (s *PhotoService) GetFaskesPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, string, error) {
 	var photo model.FaskesPhoto
 	if err := s.db.First(&photo, photoID).Error; err != nil {
-		return nil, "", fmt.Errorf("faskes photo not found: %w", err)
+		return nil, "", "", fmt.Errorf("faskes photo not found: %w", err)
 	}
 
 	if photo.StoragePath == nil || *photo.StoragePath == "" {
-		return nil, "", fmt.Errorf("faskes photo not cached")
+		return nil, "", "", fmt.Errorf("faskes photo not cached")
 	}
 
 	storagePath := *photo.StoragePath
@@ -626,18 +1165,18 @@ func (s *PhotoService) GetFaskesPhotoReader(photoID uuid.UUID) (io.ReadCloser, s
 		key := extractS3Key(storagePath)
 		reader, _, err := s.s3Storage.GetReader(context.Background(), key)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to get faskes photo from S3: %w", err)
+			return nil, "", "", fmt.Errorf("failed to get faskes photo from S3: %w", err)
 		}
-		return reader, filepath.Base(key), nil
+		return reader, filepath.Base(key), photoContentType(photo.ContentType, photo.Filename), nil
 	}
 
 	// Local file
 	file, err := os.Open(storagePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+		return nil, "", "", fmt.Errorf("failed to open file: %w", err)
 	}
 
-	return file, filepath.Base(storagePath), nil
+	return file, filepath.Base(storagePath), photoContentType(photo.ContentType, photo.Filename), nil
 }
 
 // GetFaskesPhotosByFaskesID returns all photos for a faskes
@@ -649,6 +1188,195 @@ func (s *PhotoService) GetFaskesPhotosByFaskesID(faskesID uuid.UUID) ([]model.Fa
 	return photos, nil
 }
 
+// ========================================
+// INFRASTRUKTUR PHOTOS
+// ========================================
+
+// DownloadAndSaveInfraPhoto downloads an infrastruktur photo from ODK Central and saves it to storage (S3 or local)
+func (s *PhotoService) DownloadAndSaveInfraPhoto(photo *model.InfrastrukturPhoto, submissionID string) error {
+	return s.downloadAndSaveInfraPhoto(photo, submissionID, s.formIDs.Infrastruktur)
+}
+
+// downloadAndSaveInfraPhoto is DownloadAndSaveInfraPhoto from the given form
+func (s *PhotoService) downloadAndSaveInfraPhoto(photo *model.InfrastrukturPhoto, submissionID string, formID string) error {
+	if err := s.skipRejected(photo.RejectedContentType, photo.Filename); err != nil {
+		return err
+	}
+
+	// Download from ODK Central using the infrastruktur form
+	data, err := s.odkClient.GetAttachmentForForm(formID, submissionID, photo.Filename)
+	if err != nil {
+		return fmt.Errorf("failed to download infrastruktur attachment: %w", err)
+	}
+
+	// Reject attachments outside the MIME allowlist
+	contentType, err := s.checkContentType(data, photo.Filename)
+	if err != nil {
+		s.markRejected(photo, contentType)
+		return err
+	}
+	photo.RejectedContentType = nil
+
+	// Generate unique filename
+	ext := filepath.Ext(photo.Filename)
+	newFilename := fmt.Sprintf("%s_%s%s", photo.PhotoType, uuid.New().String()[:8], ext)
+	fileSize := len(data)
+
+	var storagePath string
+
+	if s.useS3 {
+		// Upload to S3
+		key := fmt.Sprintf("infrastruktur/%s/%s", photo.InfrastrukturID.String(), newFilename)
+		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
+		if err != nil {
+			return fmt.Errorf("failed to upload infrastruktur photo to S3: %w", err)
+		}
+		storagePath = url
+		log.Printf("Uploaded infrastruktur photo to S3: %s -> %s", photo.Filename, url)
+	} else {
+		// Save to local filesystem
+		infraDir := filepath.Join(s.storagePath, "infrastruktur", photo.InfrastrukturID.String())
+		if err := os.MkdirAll(infraDir, 0755); err != nil {
+			return fmt.Errorf("failed to create infrastruktur directory: %w", err)
+		}
+		storagePath = filepath.Join(infraDir, newFilename)
+		if err := os.WriteFile(storagePath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		log.Printf("Downloaded infrastruktur photo: %s -> %s", photo.Filename, storagePath)
+	}
+
+	// Update database record
+	photo.StoragePath = &storagePath
+	photo.IsCached = true
+	photo.FileSize = &fileSize
+	photo.ContentType = &contentType
+	photo.Width, photo.Height = imageDimensions(data)
+
+	if err := s.db.Save(photo).Error; err != nil {
+		// Clean up if database update fails
+		if s.useS3 {
+			key := fmt.Sprintf("infrastruktur/%s/%s", photo.InfrastrukturID.String(), newFilename)
+			s.s3Storage.Delete(context.Background(), key)
+		} else {
+			os.Remove(storagePath)
+		}
+		return fmt.Errorf("failed to update database: %w", err)
+	}
+
+	return nil
+}
+
+// SyncInfraPhotos downloads all uncached infrastruktur photos
+// An empty formID uses the configured form (see SetFormIDs).
+func (s *PhotoService) SyncInfraPhotos(formID string) (*PhotoSyncResult, error) {
+	return s.syncInfraPhotos(formID, time.Time{})
+}
+
+// syncInfraPhotos downloads uncached infrastruktur photos, only those of records submitted after
+// since unless since is zero
+func (s *PhotoService) syncInfraPhotos(formID string, since time.Time) (*PhotoSyncResult, error) {
+	if formID == "" {
+		formID = s.formIDs.Infrastruktur
+	}
+
+	result := &PhotoSyncResult{
+		StartTime: time.Now(),
+	}
+
+	// Get all uncached infrastruktur photos with their record's submission ID
+	var photos []struct {
+		model.InfrastrukturPhoto
+		ParentSubmissionID *string `gorm:"column:parent_submission_id"`
+	}
+
+	query := s.db.Table("infrastruktur_photos").
+		Select("infrastruktur_photos.*, infrastruktur.odk_submission_id AS parent_submission_id").
+		Joins("LEFT JOIN infrastruktur ON infrastruktur.id = infrastruktur_photos.infrastruktur_id").
+		Where("infrastruktur_photos.is_cached = false")
+	if !since.IsZero() {
+		query = query.Where("infrastruktur.submitted_at > ?", since)
+	}
+	err := query.Find(&photos).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch uncached infrastruktur photos: %w", err)
+	}
+
+	result.TotalFound = len(photos)
+
+	for _, p := range photos {
+		photo := p.InfrastrukturPhoto
+		if p.ParentSubmissionID == nil || *p.ParentSubmissionID == "" {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("%s: missing submission ID", photo.Filename))
+			continue
+		}
+		if err := s.downloadAndSaveInfraPhoto(&photo, *p.ParentSubmissionID, formID); err != nil {
+			if errors.Is(err, ErrContentTypeNotAllowed) {
+				result.Skipped++
+				log.Printf("Skipping attachment: %v", err)
+				continue
+			}
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("%s: %v", photo.Filename, err))
+			continue
+		}
+		result.Downloaded++
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	return result, nil
+}
+
+// GetInfraPhotoPath returns the storage path for an infrastruktur photo
+func (s *PhotoService) GetInfraPhotoPath(photoID uuid.UUID) (string, error) {
+	var photo model.InfrastrukturPhoto
+	if err := s.db.First(&photo, photoID).Error; err != nil {
+		return "", fmt.Errorf("infrastruktur photo not found: %w", err)
+	}
+
+	if photo.StoragePath == nil || *photo.StoragePath == "" {
+		return "", fmt.Errorf("infrastruktur photo not cached")
+	}
+
+	return *photo.StoragePath, nil
+}
+
+// GetInfraPhotoReader returns a reader for the infrastruktur photo file
+func (s *PhotoService) GetInfraPhotoReader(photoID uuid.UUID) (io.ReadCloser, string, string, error) {
+	var photo model.InfrastrukturPhoto
+	if err := s.db.First(&photo, photoID).Error; err != nil {
+		return nil, "", "", fmt.Errorf("infrastruktur photo not found: %w", err)
+	}
+
+	if photo.StoragePath == nil || *photo.StoragePath == "" {
+		return nil, "", "", fmt.Errorf("infrastruktur photo not cached")
+	}
+
+	storagePath := *photo.StoragePath
+
+	// Check if it's an S3 URL
+	if s.useS3 && strings.HasPrefix(storagePath, "http") {
+		key := extractS3Key(storagePath)
+		reader, _, err := s.s3Storage.GetReader(context.Background(), key)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to get infrastruktur photo from S3: %w", err)
+		}
+		return reader, filepath.Base(key), photoContentType(photo.ContentType, photo.Filename), nil
+	}
+
+	// Local file
+	file, err := os.Open(storagePath)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, filepath.Base(storagePath), photoContentType(photo.ContentType, photo.Filename), nil
+}
+
 // ========================================
 // CACHE VALIDATION ON STARTUP
 // ========================================
@@ -912,41 +1640,69 @@ type MigrationResult struct {
 	LocationPhotos *PhotoSyncResult `json:"location_photos"`
 	FeedPhotos     *PhotoSyncResult `json:"feed_photos"`
 	FaskesPhotos   *PhotoSyncResult `json:"faskes_photos"`
+	InfraPhotos    *PhotoSyncResult `json:"infrastruktur_photos"`
 	TotalMigrated  int              `json:"total_migrated"`
 	TotalErrors    int              `json:"total_errors"`
 	Duration       string           `json:"duration"`
+
+	// LocalCleanup reports the local files removed after migrating (only with deleteLocal)
+	LocalCleanup *LocalCleanupResult `json:"local_cleanup,omitempty"`
+	// JobID is the migration job recording the progress (GET /migrate/s3/status)
+	JobID uuid.UUID `json:"job_id"`
 }
 
-// MigrateToS3 migrates all locally cached photos to S3
-func (s *PhotoService) MigrateToS3() (*MigrationResult, error) {
+// MigrateToS3 migrates all locally cached photos to S3. Local files are kept unless
+// deleteLocal is set, in which case each is removed once its upload is confirmed on S3.
+// Progress is saved per photo in a migration job (see LatestMigrationJob); photos already
+// on S3 are skipped, so a migration stopped midway continues where it left off when rerun.
+func (s *PhotoService) MigrateToS3(deleteLocal bool) (*MigrationResult, error) {
 	if !s.useS3 {
 		return nil, fmt.Errorf("S3 storage is not enabled")
 	}
+	if !s.migrationMu.TryLock() {
+		return nil, ErrMigrationRunning
+	}
+	defer s.migrationMu.Unlock()
+
+	progress, err := s.startMigrationJob()
+	if err != nil {
+		return nil, err
+	}
 
 	startTime := time.Now()
-	result := &MigrationResult{}
+	result := &MigrationResult{JobID: progress.job.ID}
+	if deleteLocal {
+		result.LocalCleanup = &LocalCleanupResult{}
+	}
 
 	// Migrate location photos
-	locationResult, err := s.migrateLocationPhotosToS3()
+	locationResult, err := s.migrateLocationPhotosToS3(result.LocalCleanup, progress)
 	if err != nil {
 		log.Printf("Error migrating location photos: %v", err)
 	}
 	result.LocationPhotos = locationResult
 
 	// Migrate feed photos
-	feedResult, err := s.migrateFeedPhotosToS3()
+	feedResult, err := s.migrateFeedPhotosToS3(result.LocalCleanup, progress)
 	if err != nil {
 		log.Printf("Error migrating feed photos: %v", err)
 	}
 	result.FeedPhotos = feedResult
 
 	// Migrate faskes photos
-	faskesResult, err := s.migrateFaskesPhotosToS3()
+	faskesResult, err := s.migrateFaskesPhotosToS3(result.LocalCleanup, progress)
 	if err != nil {
 		log.Printf("Error migrating faskes photos: %v", err)
 	}
 	result.FaskesPhotos = faskesResult
 
+	// Migrate infrastruktur photos
+	infraResult, err := s.migrateInfraPhotosToS3(result.LocalCleanup, progress)
+	if err != nil {
+		log.Printf("Error migrating infrastruktur photos: %v", err)
+	}
+	result.InfraPhotos = infraResult
+
 	// Calculate totals
 	if result.LocationPhotos != nil {
 		result.TotalMigrated += result.LocationPhotos.Downloaded
@@ -960,22 +1716,27 @@ func (s *PhotoService) MigrateToS3() (*MigrationResult, error) {
 		result.TotalMigrated += result.FaskesPhotos.Downloaded
 		result.TotalErrors += result.FaskesPhotos.Errors
 	}
+	if result.InfraPhotos != nil {
+		result.TotalMigrated += result.InfraPhotos.Downloaded
+		result.TotalErrors += result.InfraPhotos.Errors
+	}
 
 	result.Duration = time.Since(startTime).String()
+	progress.finish()
 
 	return result, nil
 }
 
-// migrateLocationPhotosToS3 migrates location photos from local storage to S3
-func (s *PhotoService) migrateLocationPhotosToS3() (*PhotoSyncResult, error) {
+// migrateLocationPhotosToS3 migrates location photos from local storage to S3, removing the
+// local files into cleanup when it is set and recording each photo in progress
+func (s *PhotoService) migrateLocationPhotosToS3(cleanup *LocalCleanupResult, progress *migrationProgress) (*PhotoSyncResult, error) {
 	result := &PhotoSyncResult{
 		StartTime: time.Now(),
 	}
 
 	// Find all cached photos that are NOT yet on S3 (storage_path doesn't start with http)
 	var photos []model.LocationPhoto
-	err := s.db.Where("is_cached = true AND storage_path IS NOT NULL AND storage_path NOT LIKE 'http%'").
-		Find(&photos).Error
+	err := s.db.Where(localPhotoFilter).Order("id").Find(&photos).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch local photos: %w", err)
 	}
@@ -994,20 +1755,21 @@ func (s *PhotoService) migrateLocationPhotosToS3() (*PhotoSyncResult, error) {
 		data, err := os.ReadFile(localPath)
 		if err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			progress.record("location_photos", photo.ID, false)
 			continue
 		}
 
 		// Generate S3 key
-		ext := filepath.Ext(localPath)
 		key := fmt.Sprintf("locations/%s/%s", photo.LocationID.String(), filepath.Base(localPath))
-		contentType := getContentType(ext)
+		contentType := storage.SniffContentType(data, localPath)
 
 		// Upload to S3
 		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
 		if err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			progress.record("location_photos", photo.ID, false)
 			continue
 		}
 
@@ -1015,14 +1777,20 @@ func (s *PhotoService) migrateLocationPhotosToS3() (*PhotoSyncResult, error) {
 		photo.StoragePath = &url
 		if err := s.db.Save(&photo).Error; err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
 			// Try to delete from S3 since we couldn't update the DB
 			s.s3Storage.Delete(context.Background(), key)
+			progress.record("location_photos", photo.ID, false)
 			continue
 		}
 
 		log.Printf("Migrated location photo to S3: %s -> %s", localPath, url)
 		result.Downloaded++
+		progress.record("location_photos", photo.ID, true)
+
+		if cleanup != nil {
+			s.removeMigratedLocal(key, localPath, cleanup)
+		}
 	}
 
 	result.EndTime = time.Now()
@@ -1031,15 +1799,15 @@ func (s *PhotoService) migrateLocationPhotosToS3() (*PhotoSyncResult, error) {
 	return result, nil
 }
 
-// migrateFeedPhotosToS3 migrates feed photos from local storage to S3
-func (s *PhotoService) migrateFeedPhotosToS3() (*PhotoSyncResult, error) {
+// migrateFeedPhotosToS3 migrates feed photos from local storage to S3, removing the
+// local files into cleanup when it is set and recording each photo in progress
+func (s *PhotoService) migrateFeedPhotosToS3(cleanup *LocalCleanupResult, progress *migrationProgress) (*PhotoSyncResult, error) {
 	result := &PhotoSyncResult{
 		StartTime: time.Now(),
 	}
 
 	var photos []model.FeedPhoto
-	err := s.db.Where("is_cached = true AND storage_path IS NOT NULL AND storage_path NOT LIKE 'http%'").
-		Find(&photos).Error
+	err := s.db.Where(localPhotoFilter).Order("id").Find(&photos).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch local feed photos: %w", err)
 	}
@@ -1057,31 +1825,38 @@ func (s *PhotoService) migrateFeedPhotosToS3() (*PhotoSyncResult, error) {
 		data, err := os.ReadFile(localPath)
 		if err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			progress.record("feed_photos", photo.ID, false)
 			continue
 		}
 
-		ext := filepath.Ext(localPath)
 		key := fmt.Sprintf("feeds/%s/%s", photo.FeedID.String(), filepath.Base(localPath))
-		contentType := getContentType(ext)
+		contentType := storage.SniffContentType(data, localPath)
 
 		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
 		if err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			progress.record("feed_photos", photo.ID, false)
 			continue
 		}
 
 		photo.StoragePath = &url
 		if err := s.db.Save(&photo).Error; err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
 			s.s3Storage.Delete(context.Background(), key)
+			progress.record("feed_photos", photo.ID, false)
 			continue
 		}
 
 		log.Printf("Migrated feed photo to S3: %s -> %s", localPath, url)
 		result.Downloaded++
+		progress.record("feed_photos", photo.ID, true)
+
+		if cleanup != nil {
+			s.removeMigratedLocal(key, localPath, cleanup)
+		}
 	}
 
 	result.EndTime = time.Now()
@@ -1090,15 +1865,15 @@ func (s *PhotoService) migrateFeedPhotosToS3() (*PhotoSyncResult, error) {
 	return result, nil
 }
 
-// migrateFaskesPhotosToS3 migrates faskes photos from local storage to S3
-func (s *PhotoService) migrateFaskesPhotosToS3() (*PhotoSyncResult, error) {
+// migrateFaskesPhotosToS3 migrates faskes photos from local storage to S3, removing the
+// local files into cleanup when it is set and recording each photo in progress
+func (s *PhotoService) migrateFaskesPhotosToS3(cleanup *LocalCleanupResult, progress *migrationProgress) (*PhotoSyncResult, error) {
 	result := &PhotoSyncResult{
 		StartTime: time.Now(),
 	}
 
 	var photos []model.FaskesPhoto
-	err := s.db.Where("is_cached = true AND storage_path IS NOT NULL AND storage_path NOT LIKE 'http%'").
-		Find(&photos).Error
+	err := s.db.Where(localPhotoFilter).Order("id").Find(&photos).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch local faskes photos: %w", err)
 	}
@@ -1116,31 +1891,104 @@ func (s *PhotoService) migrateFaskesPhotosToS3() (*PhotoSyncResult, error) {
 		data, err := os.ReadFile(localPath)
 		if err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			progress.record("faskes_photos", photo.ID, false)
 			continue
 		}
 
-		ext := filepath.Ext(localPath)
 		key := fmt.Sprintf("faskes/%s/%s", photo.FaskesID.String(), filepath.Base(localPath))
-		contentType := getContentType(ext)
+		contentType := storage.SniffContentType(data, localPath)
 
 		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
 		if err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			progress.record("faskes_photos", photo.ID, false)
 			continue
 		}
 
 		photo.StoragePath = &url
 		if err := s.db.Save(&photo).Error; err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
+			result.addErrorDetail(fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
 			s.s3Storage.Delete(context.Background(), key)
+			progress.record("faskes_photos", photo.ID, false)
 			continue
 		}
 
 		log.Printf("Migrated faskes photo to S3: %s -> %s", localPath, url)
 		result.Downloaded++
+		progress.record("faskes_photos", photo.ID, true)
+
+		if cleanup != nil {
+			s.removeMigratedLocal(key, localPath, cleanup)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	return result, nil
+}
+
+// migrateInfraPhotosToS3 migrates infrastruktur photos from local storage to S3, removing the
+// local files into cleanup when it is set and recording each photo in progress
+func (s *PhotoService) migrateInfraPhotosToS3(cleanup *LocalCleanupResult, progress *migrationProgress) (*PhotoSyncResult, error) {
+	result := &PhotoSyncResult{
+		StartTime: time.Now(),
+	}
+
+	var photos []model.InfrastrukturPhoto
+	err := s.db.Where(localPhotoFilter).Order("id").Find(&photos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch local infrastruktur photos: %w", err)
+	}
+
+	result.TotalFound = len(photos)
+	log.Printf("Found %d infrastruktur photos to migrate to S3", len(photos))
+
+	for _, photo := range photos {
+		if photo.StoragePath == nil {
+			continue
+		}
+
+		localPath := *photo.StoragePath
+
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("%s: failed to read local file: %v", photo.Filename, err))
+			progress.record("infrastruktur_photos", photo.ID, false)
+			continue
+		}
+
+		key := fmt.Sprintf("infrastruktur/%s/%s", photo.InfrastrukturID.String(), filepath.Base(localPath))
+		contentType := storage.SniffContentType(data, localPath)
+
+		url, err := s.s3Storage.Upload(context.Background(), key, data, contentType)
+		if err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("%s: failed to upload to S3: %v", photo.Filename, err))
+			progress.record("infrastruktur_photos", photo.ID, false)
+			continue
+		}
+
+		photo.StoragePath = &url
+		if err := s.db.Save(&photo).Error; err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("%s: failed to update database: %v", photo.Filename, err))
+			s.s3Storage.Delete(context.Background(), key)
+			progress.record("infrastruktur_photos", photo.ID, false)
+			continue
+		}
+
+		log.Printf("Migrated infrastruktur photo to S3: %s -> %s", localPath, url)
+		result.Downloaded++
+		progress.record("infrastruktur_photos", photo.ID, true)
+
+		if cleanup != nil {
+			s.removeMigratedLocal(key, localPath, cleanup)
+		}
 	}
 
 	result.EndTime = time.Now()
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// imageDimensions returns the displayed pixel size of an image from its header (width and
+// height swapped when the EXIF orientation rotates it), nil for non-images (PDFs) and
+// formats without a decoder (WebP)
+func imageDimensions(data []byte) (width, height *int) {
+	return readImageDimensions(bytes.NewReader(data))
+}
+
+// readImageDimensions is imageDimensions reading only as much of r as the header needs
+func readImageDimensions(r io.Reader) (width, height *int) {
+	br := bufio.NewReaderSize(r, exifHeaderSize)
+	header, _ := br.Peek(exifHeaderSize) // shorter for small files
+	orientation := exifOrientation(header)
+
+	cfg, _, err := image.DecodeConfig(br)
+	if err != nil {
+		return nil, nil
+	}
+	w, h := orientedSize(cfg.Width, cfg.Height, orientation)
+	return &w, &h
+}
+
+// DimensionBackfillResult reports cached photos given their width and height
+type DimensionBackfillResult struct {
+	Checked      int      `json:"checked"`
+	Updated      int      `json:"updated"`
+	NotImages    int      `json:"not_images"`
+	Errors       int      `json:"errors"`
+	ErrorDetails []string `json:"error_details,omitempty"`
+}
+
+// dimensionPhotoTables are the photo tables with width/height columns
+var dimensionPhotoTables = []string{"location_photos", "feed_photos", "faskes_photos", "infrastruktur_photos"}
+
+// BackfillDimensions records the width and height of photos cached before dimensions were
+// captured at download time. Only the image header is read from the stored file. Non-image
+// attachments keep NULL dimensions and are checked again on the next backfill.
+func (s *PhotoService) BackfillDimensions() (*DimensionBackfillResult, error) {
+	result := &DimensionBackfillResult{}
+	for _, table := range dimensionPhotoTables {
+		var photos []struct {
+			ID          uuid.UUID
+			StoragePath string
+		}
+		err := s.db.Table(table).
+			Select("id, storage_path").
+			Where("is_cached = true AND storage_path IS NOT NULL AND storage_path <> '' AND width IS NULL").
+			Find(&photos).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", table, err)
+		}
+
+		for _, photo := range photos {
+			result.Checked++
+			width, height, err := s.storedImageDimensions(photo.StoragePath)
+			if err != nil {
+				result.Errors++
+				result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s %s: %v", table, photo.ID, err))
+				continue
+			}
+			if width == nil {
+				result.NotImages++
+				continue
+			}
+			err = s.db.Table(table).Where("id = ?", photo.ID).
+				Updates(map[string]interface{}{"width": *width, "height": *height}).Error
+			if err != nil {
+				result.Errors++
+				result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s %s: failed to update: %v", table, photo.ID, err))
+				continue
+			}
+			result.Updated++
+		}
+	}
+
+	log.Printf("Photo dimension backfill: %d checked, %d updated, %d not images, %d errors",
+		result.Checked, result.Updated, result.NotImages, result.Errors)
+	return result, nil
+}
+
+// storedImageDimensions reads the dimensions of a cached photo by its storage_path
+// (S3 URL or local path)
+func (s *PhotoService) storedImageDimensions(storagePath string) (width, height *int, err error) {
+	var reader io.ReadCloser
+	if strings.HasPrefix(storagePath, "http") {
+		if !s.useS3 {
+			return nil, nil, fmt.Errorf("stored on S3 but S3 storage is not enabled")
+		}
+		reader, _, err = s.s3Storage.GetReader(context.Background(), extractS3Key(storagePath))
+	} else {
+		reader, err = os.Open(storagePath)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", storagePath, err)
+	}
+	defer reader.Close()
+
+	width, height = readImageDimensions(reader)
+	return width, height, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// LocalCleanupResult reports local photo files removed after their migration to S3
+type LocalCleanupResult struct {
+	Checked      int      `json:"checked"`
+	Deleted      int      `json:"deleted"`
+	BytesFreed   int64    `json:"bytes_freed"`
+	Errors       int      `json:"errors"`
+	ErrorDetails []string `json:"error_details,omitempty"`
+}
+
+// migratedPhotoTables are the photo tables MigrateToS3 moves, with the directory their
+// local files were cached in (relative to the storage path, one subdirectory per parent)
+var migratedPhotoTables = []struct {
+	table        string
+	parentColumn string
+	dir          string
+}{
+	{"location_photos", "location_id", ""},
+	{"feed_photos", "feed_id", "feeds"},
+	{"faskes_photos", "faskes_id", "faskes"},
+	{"infrastruktur_photos", "infrastruktur_id", "infrastruktur"},
+}
+
+// CleanupLocalMigrated removes the local files of photos whose storage_path now points at
+// S3. The local path is derived from the S3 key the same way MigrateToS3 built it, and a
+// file is only removed once its S3 object is confirmed to exist.
+func (s *PhotoService) CleanupLocalMigrated() (*LocalCleanupResult, error) {
+	if !s.useS3 {
+		return nil, fmt.Errorf("S3 storage is not enabled")
+	}
+
+	result := &LocalCleanupResult{}
+	for _, t := range migratedPhotoTables {
+		var photos []struct {
+			ParentID    string
+			StoragePath string
+		}
+		err := s.db.Table(t.table).
+			Select(t.parentColumn + " AS parent_id, storage_path").
+			Where("is_cached = true AND storage_path LIKE 'http%'").
+			Find(&photos).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", t.table, err)
+		}
+
+		for _, photo := range photos {
+			key := extractS3Key(photo.StoragePath)
+			localPath := filepath.Join(s.storagePath, t.dir, photo.ParentID, path.Base(key))
+			s.removeMigratedLocal(key, localPath, result)
+		}
+	}
+
+	log.Printf("Local cleanup after S3 migration: %d checked, %d deleted, %d bytes freed, %d errors",
+		result.Checked, result.Deleted, result.BytesFreed, result.Errors)
+	return result, nil
+}
+
+// removeMigratedLocal deletes localPath, the local copy of the photo stored at S3 key,
+// after checking that the S3 object exists. A missing local file is not an error.
+func (s *PhotoService) removeMigratedLocal(key, localPath string, result *LocalCleanupResult) {
+	result.Checked++
+
+	info, err := os.Stat(localPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		result.Errors++
+		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: %v", localPath, err))
+		return
+	}
+
+	exists, err := s.s3Storage.Exists(context.Background(), key)
+	if err != nil {
+		result.Errors++
+		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to check S3 object %s: %v, local file kept", localPath, key, err))
+		return
+	}
+	if !exists {
+		result.Errors++
+		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: S3 object %s not found, local file kept", localPath, key))
+		return
+	}
+
+	if err := os.Remove(localPath); err != nil {
+		result.Errors++
+		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("%s: failed to remove: %v", localPath, err))
+		return
+	}
+	result.Deleted++
+	result.BytesFreed += info.Size()
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrMigrationRunning is returned by MigrateToS3 while another migration is in progress
+var ErrMigrationRunning = errors.New("an S3 migration is already running")
+
+// localPhotoFilter selects cached photos still stored locally, i.e. not yet migrated
+const localPhotoFilter = "is_cached = true AND storage_path IS NOT NULL AND storage_path NOT LIKE 'http%'"
+
+// migrationProgress persists the progress of a running MigrateToS3 in its migration job
+type migrationProgress struct {
+	db  *gorm.DB
+	job *model.MigrationJob
+}
+
+// CloseInterruptedMigrationJobs marks migration jobs still recorded as running as
+// interrupted: their process stopped before finishing. Call at startup, before any
+// migration of this process runs.
+func (s *PhotoService) CloseInterruptedMigrationJobs() error {
+	return s.db.Model(&model.MigrationJob{}).
+		Where("status = ?", model.MigrationJobRunning).
+		Updates(map[string]interface{}{"status": model.MigrationJobInterrupted, "updated_at": time.Now()}).Error
+}
+
+// startMigrationJob records a new migration job covering all photos still stored locally.
+// Jobs left running by a process that stopped midway are marked interrupted first.
+func (s *PhotoService) startMigrationJob() (*migrationProgress, error) {
+	if err := s.CloseInterruptedMigrationJobs(); err != nil {
+		return nil, fmt.Errorf("failed to close previous migration jobs: %w", err)
+	}
+
+	total := 0
+	for _, t := range migratedPhotoTables {
+		var count int64
+		if err := s.db.Table(t.table).Where(localPhotoFilter).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", t.table, err)
+		}
+		total += int(count)
+	}
+
+	now := time.Now()
+	job := &model.MigrationJob{
+		Status:    model.MigrationJobRunning,
+		Total:     total,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create migration job: %w", err)
+	}
+	return &migrationProgress{db: s.db, job: job}, nil
+}
+
+// record saves the outcome of migrating one photo. A failed save only loses progress
+// reporting, never the migration.
+func (p *migrationProgress) record(table string, photoID uuid.UUID, migrated bool) {
+	if migrated {
+		p.job.Migrated++
+	} else {
+		p.job.Errors++
+	}
+	p.job.LastTable = &table
+	p.job.LastProcessedID = &photoID
+	p.save()
+}
+
+// finish marks the job completed
+func (p *migrationProgress) finish() {
+	now := time.Now()
+	p.job.Status = model.MigrationJobCompleted
+	p.job.FinishedAt = &now
+	p.save()
+}
+
+func (p *migrationProgress) save() {
+	p.job.UpdatedAt = time.Now()
+	if err := p.db.Save(p.job).Error; err != nil {
+		log.Printf("Warning: failed to save S3 migration progress: %v", err)
+	}
+}
+
+// LatestMigrationJob returns the most recent S3 migration job, nil if none has run
+func (s *PhotoService) LatestMigrationJob() (*model.MigrationJob, error) {
+	var job model.MigrationJob
+	err := s.db.Order("started_at DESC").First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/model"
+)
+
+// PhotoKind identifies which photo table a queued download belongs to
+type PhotoKind string
+
+const (
+	PhotoKindLocation      PhotoKind = "location"
+	PhotoKindFeed          PhotoKind = "feed"
+	PhotoKindFaskes        PhotoKind = "faskes"
+	PhotoKindInfrastruktur PhotoKind = "infrastruktur"
+)
+
+type photoJob struct {
+	kind    PhotoKind
+	photoID uuid.UUID
+}
+
+// PhotoQueue downloads uncached photos in the background with a bounded queue
+// and a fixed pool of workers, so data syncs don't block on attachments
+type PhotoQueue struct {
+	photoService *PhotoService
+
+	jobs    chan photoJob
+	workers int
+	quit    chan struct{}
+	wg      sync.WaitGroup
+
+	mu            sync.Mutex
+	pending       map[uuid.UUID]bool // queued or in progress, to avoid duplicate jobs
+	running       bool
+	inMaintenance func() bool // downloads are skipped while it reports true
+
+	// changedSince is, per kind, the time EnqueueChanged last listed changed records
+	changedSince map[PhotoKind]time.Time
+
+	processed atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+}
+
+// PhotoQueueStatus is a snapshot of the queue for status endpoints
+type PhotoQueueStatus struct {
+	Running   bool  `json:"running"`
+	Depth     int   `json:"depth"`
+	Capacity  int   `json:"capacity"`
+	Pending   int   `json:"pending"`
+	Workers   int   `json:"workers"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	Dropped   int64 `json:"dropped"` // queue full or maintenance mode
+}
+
+// NewPhotoQueue creates a photo download queue; call Start to run the workers
+func NewPhotoQueue(photoService *PhotoService, size, workers int) *PhotoQueue {
+	if size <= 0 {
+		size = 500
+	}
+	if workers <= 0 {
+		workers = 2
+	}
+	now := time.Now()
+	return &PhotoQueue{
+		photoService: photoService,
+		jobs:         make(chan photoJob, size),
+		workers:      workers,
+		pending:      make(map[uuid.UUID]bool),
+		changedSince: map[PhotoKind]time.Time{
+			PhotoKindLocation:      now,
+			PhotoKindFeed:          now,
+			PhotoKindFaskes:        now,
+			PhotoKindInfrastruktur: now,
+		},
+	}
+}
+
+// Start launches the worker pool
+func (q *PhotoQueue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running {
+		return
+	}
+	q.running = true
+	q.quit = make(chan struct{})
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	log.Printf("Photo queue started: %d workers, capacity %d", q.workers, cap(q.jobs))
+}
+
+// Stop signals the workers to exit and waits for in-progress downloads.
+// Jobs still queued are left for the next start or manual photo sync.
+func (q *PhotoQueue) Stop() {
+	q.mu.Lock()
+	if !q.running {
+		q.mu.Unlock()
+		return
+	}
+	q.running = false
+	close(q.quit)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+	log.Println("Photo queue stopped")
+}
+
+// SetMaintenanceCheck makes the queue skip downloads, queued or new, while inMaintenance
+// reports true, so maintenance mode writes nothing to the database or storage
+func (q *PhotoQueue) SetMaintenanceCheck(inMaintenance func() bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inMaintenance = inMaintenance
+}
+
+// maintenanceMode reports whether downloads are suspended by maintenance mode
+func (q *PhotoQueue) maintenanceMode() bool {
+	q.mu.Lock()
+	inMaintenance := q.inMaintenance
+	q.mu.Unlock()
+	return inMaintenance != nil && inMaintenance()
+}
+
+// Enqueue adds a photo download without blocking. Returns false if the photo is already
+// queued, the queue is full or maintenance mode is on (the photo stays uncached for a later sync).
+func (q *PhotoQueue) Enqueue(kind PhotoKind, photoID uuid.UUID) bool {
+	if q.maintenanceMode() {
+		q.dropped.Add(1)
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending[photoID] {
+		return false
+	}
+
+	select {
+	case q.jobs <- photoJob{kind: kind, photoID: photoID}:
+		q.pending[photoID] = true
+		return true
+	default:
+		q.dropped.Add(1)
+		return false
+	}
+}
+
+// EnqueueChanged enqueues the uncached photos of the records of the given kind created or
+// updated since the previous call for that kind (or since the queue was created), so each
+// sync only queues the photos of the records it touched
+func (q *PhotoQueue) EnqueueChanged(kind PhotoKind) int {
+	if q.maintenanceMode() {
+		return 0
+	}
+
+	var table, parentTable, parentColumn string
+	switch kind {
+	case PhotoKindLocation:
+		table, parentTable, parentColumn = "location_photos", "locations", "location_id"
+	case PhotoKindFeed:
+		table, parentTable, parentColumn = "feed_photos", "information_feeds", "feed_id"
+	case PhotoKindFaskes:
+		table, parentTable, parentColumn = "faskes_photos", "faskes", "faskes_id"
+	case PhotoKindInfrastruktur:
+		table, parentTable, parentColumn = "infrastruktur_photos", "infrastruktur", "infrastruktur_id"
+	default:
+		return 0
+	}
+
+	q.mu.Lock()
+	since := q.changedSince[kind]
+	q.mu.Unlock()
+	now := time.Now()
+
+	var ids []uuid.UUID
+	err := q.photoService.db.Table(table).
+		Joins("JOIN "+parentTable+" ON "+parentTable+".id = "+table+"."+parentColumn).
+		Where(table+".is_cached = false AND "+parentTable+".updated_at >= ?", since).
+		Pluck(table+".id", &ids).Error
+	if err != nil {
+		log.Printf("Warning: failed to list uncached %s photos: %v", kind, err)
+		return 0
+	}
+
+	q.mu.Lock()
+	q.changedSince[kind] = now
+	q.mu.Unlock()
+
+	enqueued := 0
+	for _, id := range ids {
+		if q.Enqueue(kind, id) {
+			enqueued++
+		}
+	}
+	if enqueued > 0 {
+		log.Printf("Photo queue: enqueued %d uncached %s photos", enqueued, kind)
+	}
+	return enqueued
+}
+
+// Status returns a snapshot of the queue
+func (q *PhotoQueue) Status() PhotoQueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return PhotoQueueStatus{
+		Running:   q.running,
+		Depth:     len(q.jobs),
+		Capacity:  cap(q.jobs),
+		Pending:   len(q.pending),
+		Workers:   q.workers,
+		Processed: q.processed.Load(),
+		Failed:    q.failed.Load(),
+		Dropped:   q.dropped.Load(),
+	}
+}
+
+func (q *PhotoQueue) worker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.quit:
+			return
+		case job := <-q.jobs:
+			// Jobs queued before maintenance mode was switched on are left uncached
+			if q.maintenanceMode() {
+				q.dropped.Add(1)
+			} else if err := q.download(job); err != nil {
+				q.failed.Add(1)
+				log.Printf("Warning: queued %s photo %s failed: %v", job.kind, job.photoID, err)
+			} else {
+				q.processed.Add(1)
+			}
+			q.mu.Lock()
+			delete(q.pending, job.photoID)
+			q.mu.Unlock()
+		}
+	}
+}
+
+// download fetches the photo record with its parent's submission ID and downloads it
+func (q *PhotoQueue) download(job photoJob) error {
+	db := q.photoService.db
+
+	switch job.kind {
+	case PhotoKindLocation:
+		var row struct {
+			model.LocationPhoto
+			ParentSubmissionID string `gorm:"column:parent_submission_id"`
+		}
+		err := db.Table("location_photos").
+			Select("location_photos.*, locations.odk_submission_id AS parent_submission_id").
+			Joins("LEFT JOIN locations ON locations.id = location_photos.location_id").
+			Where("location_photos.id = ?", job.photoID).
+			Take(&row).Error
+		if err != nil {
+			return fmt.Errorf("photo not found: %w", err)
+		}
+		if row.IsCached {
+			return nil
+		}
+		return q.photoService.DownloadAndSavePhoto(&row.LocationPhoto, row.ParentSubmissionID)
+
+	case PhotoKindFeed:
+		var row struct {
+			model.FeedPhoto
+			ParentSubmissionID string `gorm:"column:parent_submission_id"`
+		}
+		err := db.Table("feed_photos").
+			Select("feed_photos.*, information_feeds.odk_submission_id AS parent_submission_id").
+			Joins("LEFT JOIN information_feeds ON information_feeds.id = feed_photos.feed_id").
+			Where("feed_photos.id = ?", job.photoID).
+			Take(&row).Error
+		if err != nil {
+			return fmt.Errorf("feed photo not found: %w", err)
+		}
+		if row.IsCached {
+			return nil
+		}
+		if row.ParentSubmissionID == "" {
+			return fmt.Errorf("missing submission ID")
+		}
+		return q.photoService.DownloadAndSaveFeedPhoto(&row.FeedPhoto, row.ParentSubmissionID)
+
+	case PhotoKindFaskes:
+		var row struct {
+			model.FaskesPhoto
+			ParentSubmissionID string `gorm:"column:parent_submission_id"`
+		}
+		err := db.Table("faskes_photos").
+			Select("faskes_photos.*, faskes.odk_submission_id AS parent_submission_id").
+			Joins("LEFT JOIN faskes ON faskes.id = faskes_photos.faskes_id").
+			Where("faskes_photos.id = ?", job.photoID).
+			Take(&row).Error
+		if err != nil {
+			return fmt.Errorf("faskes photo not found: %w", err)
+		}
+		if row.IsCached {
+			return nil
+		}
+		if row.ParentSubmissionID == "" {
+			return fmt.Errorf("missing submission ID")
+		}
+		return q.photoService.DownloadAndSaveFaskesPhoto(&row.FaskesPhoto, row.ParentSubmissionID)
+
+	case PhotoKindInfrastruktur:
+		var row struct {
+			model.InfrastrukturPhoto
+			ParentSubmissionID string `gorm:"column:parent_submission_id"`
+		}
+		err := db.Table("infrastruktur_photos").
+			Select("infrastruktur_photos.*, infrastruktur.odk_submission_id AS parent_submission_id").
+			Joins("LEFT JOIN infrastruktur ON infrastruktur.id = infrastruktur_photos.infrastruktur_id").
+			Where("infrastruktur_photos.id = ?", job.photoID).
+			Take(&row).Error
+		if err != nil {
+			return fmt.Errorf("infrastruktur photo not found: %w", err)
+		}
+		if row.IsCached {
+			return nil
+		}
+		if row.ParentSubmissionID == "" {
+			return fmt.Errorf("missing submission ID")
+		}
+		return q.photoService.DownloadAndSaveInfraPhoto(&row.InfrastrukturPhoto, row.ParentSubmissionID)
+	}
+
+	return fmt.Errorf("unknown photo kind %q", job.kind)
+}
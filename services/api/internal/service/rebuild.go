@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RebuildCounts are the live records and photos of a form at one point of a rebuild
+type RebuildCounts struct {
+	Records int64 `json:"records"`
+	Photos  int64 `json:"photos"`
+}
+
+// RebuildResult reports a purge-and-resync of one form
+type RebuildResult struct {
+	Form          string        `json:"form"`
+	Before        RebuildCounts `json:"before"`
+	After         RebuildCounts `json:"after"`
+	SoftDeleted   int64         `json:"soft_deleted"`
+	PhotosDeleted int64         `json:"photos_deleted"`
+	Sync          *SyncResult   `json:"sync,omitempty"`
+	Duration      string        `json:"duration"`
+}
+
+// rebuildTables names the record and photo tables of a form
+type rebuildTables struct {
+	form         string
+	records      string
+	photos       string
+	parentColumn string
+}
+
+// Rebuild soft-deletes every posko and drops its photo rows, then runs a full sync.
+// Records still in ODK are revived by the sync; their photos are re-queued as uncached.
+func (s *SyncService) Rebuild() (*RebuildResult, error) {
+	defer s.lockSync()()
+	return rebuildForm(s.db, rebuildTables{"posko", "locations", "location_photos", "location_id"}, func() (*SyncResult, error) {
+		return s.syncAll(s.syncCtx())
+	})
+}
+
+// Rebuild soft-deletes every faskes and drops its photo rows, then runs a full sync
+func (s *FaskesSyncService) Rebuild() (*RebuildResult, error) {
+	defer s.lockSync()()
+	return rebuildForm(s.db, rebuildTables{"faskes", "faskes", "faskes_photos", "faskes_id"}, s.syncAll)
+}
+
+// Rebuild soft-deletes every infrastruktur record and drops its photo rows, then runs a full sync
+func (s *InfrastrukturSyncService) Rebuild() (*RebuildResult, error) {
+	defer s.lockSync()()
+	return rebuildForm(s.db, rebuildTables{"infrastruktur", "infrastruktur", "infrastruktur_photos", "infrastruktur_id"}, s.syncAll)
+}
+
+// rebuildForm purges a form's live records (soft delete) and photo rows in one transaction,
+// then runs syncAll. The caller must hold the form's sync lock.
+// Photo tables have no deleted_at, so photo rows are removed; cached files are left on
+// storage for the orphaned-file cleanup.
+func rebuildForm(db *gorm.DB, t rebuildTables, syncAll func() (*SyncResult, error)) (*RebuildResult, error) {
+	start := time.Now()
+	result := &RebuildResult{Form: t.form}
+
+	before, err := countRebuild(db, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count %s before rebuild: %w", t.form, err)
+	}
+	result.Before = before
+
+	log.Printf("REBUILD %s: purging %d records and %d photos, then running a full sync", t.form, before.Records, before.Photos)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(fmt.Sprintf(
+			"DELETE FROM %s WHERE %s IN (SELECT id FROM %s WHERE deleted_at IS NULL)",
+			t.photos, t.parentColumn, t.records))
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete photos: %w", res.Error)
+		}
+		result.PhotosDeleted = res.RowsAffected
+
+		res = tx.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = ? WHERE deleted_at IS NULL", t.records), time.Now())
+		if res.Error != nil {
+			return fmt.Errorf("failed to soft-delete records: %w", res.Error)
+		}
+		result.SoftDeleted = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rebuild %s purge failed: %w", t.form, err)
+	}
+
+	log.Printf("REBUILD %s: soft-deleted %d records, deleted %d photo rows", t.form, result.SoftDeleted, result.PhotosDeleted)
+
+	syncResult, syncErr := syncAll()
+	result.Sync = syncResult
+
+	after, err := countRebuild(db, t)
+	if err != nil {
+		log.Printf("REBUILD %s: failed to count records after rebuild: %v", t.form, err)
+	}
+	result.After = after
+	result.Duration = time.Since(start).String()
+
+	if syncErr != nil {
+		log.Printf("REBUILD %s: sync FAILED after purge, %d records live: %v", t.form, after.Records, syncErr)
+		return result, fmt.Errorf("rebuild %s sync failed: %w", t.form, syncErr)
+	}
+
+	log.Printf("REBUILD %s completed: %d -> %d records, %d -> %d photos in %s",
+		t.form, before.Records, after.Records, before.Photos, after.Photos, result.Duration)
+
+	return result, nil
+}
+
+// countRebuild counts a form's live records and their photos
+func countRebuild(db *gorm.DB, t rebuildTables) (RebuildCounts, error) {
+	var counts RebuildCounts
+	if err := db.Table(t.records).Where("deleted_at IS NULL").Count(&counts.Records).Error; err != nil {
+		return counts, err
+	}
+	err := db.Table(t.photos).
+		Where(fmt.Sprintf("%s IN (SELECT id FROM %s WHERE deleted_at IS NULL)", t.parentColumn, t.records)).
+		Count(&counts.Photos).Error
+	return counts, err
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/leksa/datamapper-senyar/internal/model"
+)
+
+// Remap re-runs the posko mapper over every location's stored raw_data and rewrites the
+// derived columns/JSONB, without contacting ODK Central. Entity linkage (_entity_id in
+// raw_data) and submission_count are kept as they are.
+func (s *SyncService) Remap() (*SyncResult, error) {
+	defer s.lockSync()()
+
+	result := &SyncResult{
+		StartTime: time.Now(),
+	}
+
+	var locations []model.Location
+	if err := s.db.Select("id", "status", "raw_data", "submission_count").
+		Where("deleted_at IS NULL AND raw_data IS NOT NULL").
+		Find(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load locations: %w", err)
+	}
+	result.TotalFetched = len(locations)
+
+	for _, existing := range locations {
+		location, err := mapSubmissionToLocation(existing.RawData, s.nameFields, s.mappingProfiles)
+		if err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to map location %s: %v", existing.ID, err))
+			continue
+		}
+		location.ID = existing.ID
+		location.SubmissionCount = existing.SubmissionCount
+		if location.InvalidCoordinates {
+			result.InvalidCoordinates++
+		}
+
+		if err := s.updateLocation(location, &existing); err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to update location %s: %v", existing.ID, err))
+			continue
+		}
+		result.Updated++
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	log.Printf("Remap posko completed: %d records, %d updated, %d errors", result.TotalFetched, result.Updated, result.Errors)
+
+	return result, nil
+}
+
+// Remap re-runs the faskes mapper over every faskes' stored raw_data
+func (s *FaskesSyncService) Remap() (*SyncResult, error) {
+	defer s.lockSync()()
+
+	result := &SyncResult{
+		StartTime: time.Now(),
+	}
+
+	var faskesList []model.Faskes
+	if err := s.db.Select("id", "raw_data").
+		Where("deleted_at IS NULL AND raw_data IS NOT NULL").
+		Find(&faskesList).Error; err != nil {
+		return nil, fmt.Errorf("failed to load faskes: %w", err)
+	}
+	result.TotalFetched = len(faskesList)
+
+	for _, existing := range faskesList {
+		faskes, err := MapSubmissionToFaskes(existing.RawData)
+		if err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to map faskes %s: %v", existing.ID, err))
+			continue
+		}
+		faskes.ID = existing.ID
+		s.injectRegionIDs(faskes)
+		if faskes.InvalidCoordinates {
+			result.InvalidCoordinates++
+		}
+
+		if err := s.updateFaskes(faskes); err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to update faskes %s: %v", existing.ID, err))
+			continue
+		}
+		result.Updated++
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	log.Printf("Remap faskes completed: %d records, %d updated, %d errors", result.TotalFetched, result.Updated, result.Errors)
+
+	return result, nil
+}
+
+// Remap re-runs the infrastruktur mapper over every record's stored raw_data.
+// The entity_id is kept from the existing row.
+func (s *InfrastrukturSyncService) Remap() (*SyncResult, error) {
+	defer s.lockSync()()
+
+	result := &SyncResult{
+		StartTime: time.Now(),
+	}
+
+	var infraList []model.Infrastruktur
+	if err := s.db.Select("id", "entity_id", "raw_data").
+		Where("deleted_at IS NULL AND raw_data IS NOT NULL").
+		Find(&infraList).Error; err != nil {
+		return nil, fmt.Errorf("failed to load infrastruktur: %w", err)
+	}
+	result.TotalFetched = len(infraList)
+
+	for _, existing := range infraList {
+		infra, err := MapSubmissionToInfrastruktur(existing.RawData)
+		if err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to map infrastruktur %s: %v", existing.ID, err))
+			continue
+		}
+		infra.ID = existing.ID
+		infra.EntityID = existing.EntityID
+
+		if err := s.updateInfrastruktur(infra); err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to update infrastruktur %s: %v", existing.ID, err))
+			continue
+		}
+		result.Updated++
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	log.Printf("Remap infrastruktur completed: %d records, %d updated, %d errors", result.TotalFetched, result.Updated, result.Errors)
+
+	return result, nil
+}
+
+// Remap re-runs the feed mapper over every feed's stored raw_data, applying the content
+// policy like a sync does. The resolved location_id/faskes_id are kept from the existing row.
+func (s *FeedSyncService) Remap() (*FeedSyncResult, error) {
+	defer s.lockSync()()
+
+	result := &FeedSyncResult{
+		StartTime: time.Now(),
+	}
+
+	var feeds []model.Feed
+	if err := s.db.Select("id", "location_id", "faskes_id", "raw_data").
+		Where("deleted_at IS NULL AND raw_data IS NOT NULL").
+		Find(&feeds).Error; err != nil {
+		return nil, fmt.Errorf("failed to load feeds: %w", err)
+	}
+	result.TotalFetched = len(feeds)
+
+	for _, existing := range feeds {
+		feed, err := MapFeedSubmission(existing.RawData)
+		if err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to map feed %s: %v", existing.ID, err))
+			continue
+		}
+		feed.ID = existing.ID
+		feed.LocationID = existing.LocationID
+		feed.FaskesID = existing.FaskesID
+		feed.Content, feed.ContentTruncated = s.contentPolicy.apply(feed.Content)
+
+		if s.inheritLocationGeom && feed.LocationID != nil && !hasFeedCoords(feed) {
+			s.inheritLocationCoords(feed)
+		}
+
+		if err := s.updateFeed(feed); err != nil {
+			result.Errors++
+			result.addErrorDetail(fmt.Sprintf("failed to update feed %s: %v", existing.ID, err))
+			continue
+		}
+		result.Updated++
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	log.Printf("Remap feed completed: %d records, %d updated, %d errors", result.TotalFetched, result.Updated, result.Errors)
+
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// A remap must store content the way a sync does, not put back the raw submission text
+func TestFeedRemapAppliesContentPolicy(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+
+	raw := model.JSONB{
+		"__id": "uuid:feed-1",
+		"grp_update": map[string]interface{}{
+			"deskripsi": "<b>Jembatan</b> putus<script>alert(1)</script>, warga mengungsi ke posko",
+		},
+	}
+	// The stored feed is loaded by the query, the remapped one captured from the UPDATE
+	db.Callback().Query().After("gorm:query").Register("test:stored_feeds", func(tx *gorm.DB) {
+		if feeds, ok := tx.Statement.Dest.(*[]model.Feed); ok {
+			*feeds = []model.Feed{{ID: uuid.New(), RawData: raw}}
+		}
+	})
+	var updates [][]interface{}
+	db.Callback().Raw().After("gorm:raw").Register("test:feed_updates", func(tx *gorm.DB) {
+		if strings.Contains(tx.Statement.SQL.String(), "UPDATE information_feeds") {
+			updates = append(updates, tx.Statement.Vars)
+		}
+	})
+
+	s := NewFeedSyncService(db, nil, "feed")
+	if err := s.SetContentPolicy(FeedContentPolicy{MaxLength: 20, Sanitize: FeedContentSanitizeStrip}); err != nil {
+		t.Fatalf("set content policy: %v", err)
+	}
+	result, err := s.Remap()
+	if err != nil {
+		t.Fatalf("remap: %v", err)
+	}
+	if result.Updated != 1 || len(updates) != 1 {
+		t.Fatalf("updated = %d, %d UPDATE statements, want 1", result.Updated, len(updates))
+	}
+
+	// content and content_truncated follow location_id and faskes_id
+	vars := updates[0]
+	if content, want := vars[2], "Jembatan putus, warg…"; content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	if truncated := vars[3]; truncated != true {
+		t.Errorf("content_truncated = %v, want true", truncated)
+	}
+}
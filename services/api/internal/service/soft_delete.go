@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Records removed by a hard sync or a rebuild are soft-deleted: deleted_at is set and the
+// row stays for auditing until PurgeDeleted removes it. This applies to the four synced
+// tables (locations, information_feeds, faskes, infrastruktur); a later sync that finds
+// the record in ODK again clears deleted_at. Photo tables have no deleted_at: photo rows
+// of a soft-deleted record are removed with it, and infrastruktur_history rows go when the
+// record is purged (ON DELETE CASCADE).
+
+// DefaultPurgeRetention is how long soft-deleted records are kept when no age is given
+const DefaultPurgeRetention = 30 * 24 * time.Hour
+
+// PurgeResult reports the permanent removal of a form's soft-deleted records
+type PurgeResult struct {
+	Form          string    `json:"form"`
+	DeletedBefore time.Time `json:"deleted_before"`
+	Purged        int64     `json:"purged"`
+}
+
+// softDelete marks one record of table as deleted. The models declare deleted_at as a
+// plain *time.Time, so gorm's Delete would remove the row instead.
+func softDelete(db *gorm.DB, table string, id uuid.UUID) error {
+	return db.Table(table).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", time.Now()).Error
+}
+
+// PurgeDeleted permanently removes posko soft-deleted more than olderThan ago
+func (s *SyncService) PurgeDeleted(olderThan time.Duration) (*PurgeResult, error) {
+	defer s.lockSync()()
+	return purgeDeleted(s.db, "posko", "locations", olderThan)
+}
+
+// PurgeDeleted permanently removes feeds soft-deleted more than olderThan ago
+func (s *FeedSyncService) PurgeDeleted(olderThan time.Duration) (*PurgeResult, error) {
+	defer s.lockSync()()
+	return purgeDeleted(s.db, "feed", "information_feeds", olderThan)
+}
+
+// PurgeDeleted permanently removes faskes soft-deleted more than olderThan ago
+func (s *FaskesSyncService) PurgeDeleted(olderThan time.Duration) (*PurgeResult, error) {
+	defer s.lockSync()()
+	return purgeDeleted(s.db, "faskes", "faskes", olderThan)
+}
+
+// PurgeDeleted permanently removes infrastruktur records soft-deleted more than olderThan ago
+func (s *InfrastrukturSyncService) PurgeDeleted(olderThan time.Duration) (*PurgeResult, error) {
+	defer s.lockSync()()
+	return purgeDeleted(s.db, "infrastruktur", "infrastruktur", olderThan)
+}
+
+// purgeDeleted deletes the rows of table whose deleted_at is older than olderThan.
+// The caller must hold the form's sync lock.
+func purgeDeleted(db *gorm.DB, form, table string, olderThan time.Duration) (*PurgeResult, error) {
+	result := &PurgeResult{Form: form, DeletedBefore: time.Now().Add(-olderThan)}
+
+	res := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at < ?", table), result.DeletedBefore)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to purge deleted %s: %w", form, res.Error)
+	}
+	result.Purged = res.RowsAffected
+
+	log.Printf("PURGE %s: removed %d records deleted before %s", form, result.Purged, result.DeletedBefore.Format(time.RFC3339))
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/leksa/datamapper-senyar/internal/odk"
+	"gorm.io/gorm"
+)
+
+// staleCheckTTL is how long a form's staleness is reused before sync_state is read again
+const staleCheckTTL = 30 * time.Second
+
+// StaleChecker reports whether a form's data may be outdated because its syncs keep
+// failing (e.g. ODK Central is unreachable). Reads keep serving the last synced data;
+// the flag lets clients warn users about it.
+type StaleChecker struct {
+	db     *gorm.DB
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	entries map[string]staleEntry
+}
+
+type staleEntry struct {
+	stale     bool
+	since     *time.Time
+	checkedAt time.Time
+}
+
+// NewStaleChecker creates a checker flagging forms whose last sync failed and whose last
+// successful sync is older than maxAge. Returns nil (never stale) when maxAge is not positive.
+func NewStaleChecker(db *gorm.DB, maxAge time.Duration) *StaleChecker {
+	if maxAge <= 0 {
+		return nil
+	}
+	return &StaleChecker{
+		db:      db,
+		maxAge:  maxAge,
+		entries: make(map[string]staleEntry),
+	}
+}
+
+// Check returns whether formID is stale and, if so, the time of its last successful
+// sync (nil when it never synced successfully). A nil checker is never stale.
+func (c *StaleChecker) Check(formID string) (bool, *time.Time) {
+	if c == nil || formID == "" {
+		return false, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if e, ok := c.entries[formID]; ok && now.Sub(e.checkedAt) < staleCheckTTL {
+		return e.stale, e.since
+	}
+
+	e := staleEntry{checkedAt: now}
+	var state odk.SyncState
+	// A missing sync_state row is a form that never ran, not an outage; a failed
+	// lookup is reported as not stale rather than failing the read
+	if err := c.db.Where("form_id = ?", formID).First(&state).Error; err == nil && state.Status == "error" {
+		if state.LastSyncTime == nil || now.Sub(*state.LastSyncTime) > c.maxAge {
+			e.stale = true
+			e.since = state.LastSyncTime
+		}
+	}
+	c.entries[formID] = e
+
+	return e.stale, e.since
+}
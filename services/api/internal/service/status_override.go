@@ -0,0 +1,42 @@
+package service
+
+import (
+	"log"
+
+	"github.com/leksa/datamapper-senyar/internal/model"
+)
+
+// applyManualStatus records the ODK status of a synced location in its raw_data and keeps
+// a status set by hand on existing (PATCH /locations/:id) while ODK still reports the
+// status it replaced. Once ODK reports a different status, that status applies and the
+// override is dropped. existing is nil for new locations.
+func applyManualStatus(location, existing *model.Location) {
+	var overridden bool
+	var base string
+	if existing != nil && existing.RawData != nil {
+		overridden, _ = existing.RawData[model.RawDataManualOverride].(bool)
+		base, _ = existing.RawData[model.RawDataManualOverrideODKBase].(string)
+	}
+
+	if location.RawData == nil {
+		location.RawData = model.JSONB{}
+	}
+	odkStatus := location.Status
+	location.RawData[model.RawDataODKStatus] = odkStatus
+
+	// Remapped locations share raw_data with existing, so the keys were read above
+	delete(location.RawData, model.RawDataManualOverride)
+	delete(location.RawData, model.RawDataManualOverrideODKBase)
+
+	if !overridden {
+		return
+	}
+	if base != odkStatus {
+		log.Printf("Location %s: ODK status changed from %q to %q, dropping manual status %q", existing.ID, base, odkStatus, existing.Status)
+		return
+	}
+
+	location.Status = existing.Status
+	location.RawData[model.RawDataManualOverride] = true
+	location.RawData[model.RawDataManualOverrideODKBase] = base
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/leksa/datamapper-senyar/internal/model"
@@ -14,11 +16,34 @@ import (
 
 // SyncService handles synchronization between ODK Central and PostgreSQL
 type SyncService struct {
+	syncHooks
+	syncLock
+	syncEvents
+	hardSyncGuard
+	syncContext
+	syncRunHistory
 	db                      *gorm.DB
 	odkClient               *odk.Client
 	formID                  string
 	entityDataset           string
 	submissionToEntityCache map[string]string // cache: submission ID -> entity UUID
+	nameFields              []string          // submission fields tried in order for nama
+	entityKeys              EntityKeyStrategy // resolves the entity of update submissions
+	mappingProfiles         MappingProfiles   // field maps per form version
+	photoDuplicatePolicy    string            // PhotoDuplicateSkip or PhotoDuplicateReplace
+
+	// entityMappingTTL is how long the mapping persisted in entity_mapping is reused
+	// (0 disables reuse); entityMappingTimeout bounds a fetch from ODK Central
+	entityMappingTTL     time.Duration
+	entityMappingTimeout time.Duration
+
+	// entityFallback is EntityFallbackSubmission or EntityFallbackFail; submissionMode is
+	// set while the entity dataset is missing and submission IDs stand in for entity IDs
+	entityFallback string
+	submissionMode atomic.Bool
+
+	// countEntityFallbacks, when set, receives each sync's entity ID fallbacks and submissions
+	countEntityFallbacks func(fallbacks, total int)
 }
 
 // NewSyncService creates a new sync service
@@ -28,7 +53,68 @@ func NewSyncService(db *gorm.DB, odkClient *odk.Client, formID string) *SyncServ
 		odkClient:     odkClient,
 		formID:        formID,
 		entityDataset: "posko_entities",
+		nameFields:    DefaultPoskoNameFields,
+		entityKeys:    DefaultPoskoEntityKeys,
+
+		photoDuplicatePolicy: PhotoDuplicateSkip,
+
+		entityMappingTTL:     defaultEntityMappingTTL,
+		entityMappingTimeout: defaultEntityMappingTimeout,
+		entityFallback:       EntityFallbackSubmission,
+
+		syncEvents:    syncEvents{form: "posko"},
+		hardSyncGuard: hardSyncGuard{minPercent: defaultHardSyncMinPercent},
+	}
+}
+
+// EntityDataset returns the ODK entity dataset the posko entities live in
+func (s *SyncService) EntityDataset() string {
+	return s.entityDataset
+}
+
+// SetNameFields overrides the submission fields tried, in order, for a posko's nama
+// (e.g. for form versions that store it elsewhere). Nested fields use dots: "grp_identitas.nama_posko"
+func (s *SyncService) SetNameFields(fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	s.nameFields = fields
+}
+
+// SetMappingProfiles sets the field maps selected by a submission's __system.formVersion.
+// Versions without a profile use DefaultMappingProfile.
+func (s *SyncService) SetMappingProfiles(profiles MappingProfiles) {
+	s.mappingProfiles = profiles
+}
+
+// SetEntityKeys overrides how the entity of a posko submission is resolved
+func (s *SyncService) SetEntityKeys(keys EntityKeyStrategy) {
+	s.entityKeys = keys
+}
+
+// SetEntityFallbackCounter sets the metric each sync reports its submissions and entity ID
+// fallbacks to. Meant to be called before the first sync.
+func (s *SyncService) SetEntityFallbackCounter(count func(fallbacks, total int)) {
+	s.countEntityFallbacks = count
+}
+
+// Policies for a synced photo whose filename already exists for the location
+const (
+	PhotoDuplicateSkip    = "skip"    // keep the existing photo (default)
+	PhotoDuplicateReplace = "replace" // from a newer submission: re-download, replacing the cached file if it changed
+)
+
+// SetPhotoDuplicatePolicy sets how photos with an already known filename are handled
+// (PhotoDuplicateSkip or PhotoDuplicateReplace). An empty policy keeps the default.
+func (s *SyncService) SetPhotoDuplicatePolicy(policy string) error {
+	switch policy {
+	case "":
+		return nil
+	case PhotoDuplicateSkip, PhotoDuplicateReplace:
+		s.photoDuplicatePolicy = policy
+		return nil
 	}
+	return fmt.Errorf("unknown photo duplicate policy %q (want %s or %s)", policy, PhotoDuplicateSkip, PhotoDuplicateReplace)
 }
 
 // SyncResult holds the result of a sync operation
@@ -43,45 +129,88 @@ type SyncResult struct {
 	EndTime      time.Time `json:"end_time"`
 	Duration     string    `json:"duration"`
 	ErrorDetails []string  `json:"error_details,omitempty"`
+
+	// TruncatedErrors counts error messages left out of ErrorDetails (see SetMaxErrorDetails)
+	TruncatedErrors int `json:"truncated_errors,omitempty"`
+	// EntityFallbacks counts submissions whose entity ID fell back to the submission ID
+	// (no entry in the entity mapping); a high rate means the mapping failed to load
+	EntityFallbacks int `json:"entity_fallbacks,omitempty"`
+	// SubmissionMode is set when the entity dataset is missing in ODK Central and every
+	// submission was synced as its own posko
+	SubmissionMode bool `json:"submission_mode,omitempty"`
+	// DeletesAborted is set when a hard sync skipped its delete phase because ODK Central
+	// returned far fewer records than are stored
+	DeletesAborted bool `json:"deletes_aborted,omitempty"`
+	// InvalidCoordinates counts submissions whose coordinates were out of range and were
+	// stored without a point
+	InvalidCoordinates int `json:"invalid_coordinates,omitempty"`
+
+	// Changed counts updated records that received a newer submission (a subset of Updated)
+	Changed int `json:"changed,omitempty"`
+	// Changes names the created/changed/deleted records for the sync run history
+	Changes model.SyncRunChanges `json:"-"`
 }
 
 // SyncAll performs a full synchronization of all approved submissions
 // Groups submissions by entity_id and only processes the latest submission per entity
 func (s *SyncService) SyncAll() (*SyncResult, error) {
+	return s.SyncAllContext(s.syncCtx())
+}
+
+// SyncAllContext is SyncAll with its ODK Central requests bounded by ctx, e.g. the
+// scheduler's, so stopping the caller stops the sync
+func (s *SyncService) SyncAllContext(ctx context.Context) (*SyncResult, error) {
+	defer s.lockSync()()
+	return s.syncAll(ctx)
+}
+
+// syncAll is SyncAllContext for callers already holding the sync lock
+func (s *SyncService) syncAll(ctx context.Context) (*SyncResult, error) {
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
 
 	// Update sync state to "syncing"
 	s.updateSyncState("syncing", nil)
+	s.publishStarted(model.SyncRunModeFull)
 
 	// Load entity mapping from ODK (for proper entity ID resolution)
-	if err := s.loadEntityMapping(); err != nil {
-		log.Printf("Warning: could not load entity mapping: %v", err)
+	if err := s.loadEntityMapping(ctx); err != nil {
+		errMsg := err.Error()
+		s.updateSyncState("error", &errMsg)
+		return nil, err
 	}
+	result.SubmissionMode = s.SubmissionMode()
 
-	// Fetch all approved submissions
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	// Stream approved submissions page by page, keeping only the latest per entity,
+	// so memory is bounded by the number of entities rather than submissions
+	grouper := newEntityGrouper()
+	err := s.odkClient.GetApprovedSubmissionsStream(ctx, odk.DefaultStreamPageSize, func(submission map[string]interface{}) error {
+		result.TotalFetched++
+		grouper.add(s, submission)
+		return nil
+	})
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
 		return nil, fmt.Errorf(errMsg)
 	}
-
-	result.TotalFetched = len(submissions)
 	log.Printf("Fetched %d submissions from ODK Central", result.TotalFetched)
 
-	// Group submissions by entity_id and keep only the latest per entity
-	latestByEntity := s.groupByEntityLatest(submissions)
+	latestByEntity, countByEntity := grouper.latest, grouper.count
 	log.Printf("Grouped into %d unique entities", len(latestByEntity))
+	result.EntityFallbacks = grouper.fallbacks
+	s.logEntityFallbacks(grouper.fallbacks, result.TotalFetched)
 
 	// Process each entity's latest submission
+	progress := s.newProgress(model.SyncRunModeFull, len(latestByEntity))
 	for entityID, submission := range latestByEntity {
-		if err := s.processEntitySubmission(entityID, submission, result); err != nil {
+		if err := s.processEntitySubmission(entityID, submission, countByEntity[entityID], result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing entity %s: %v", entityID, err)
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
 	result.EndTime = time.Now()
@@ -89,6 +218,9 @@ func (s *SyncService) SyncAll() (*SyncResult, error) {
 
 	// Update sync state
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeFull, result)
+	s.publishCompleted(model.SyncRunModeFull, result)
+	s.runAfterSync()
 
 	log.Printf("Sync completed: %d fetched, %d entities, %d created, %d updated, %d errors",
 		result.TotalFetched, len(latestByEntity), result.Created, result.Updated, result.Errors)
@@ -96,101 +228,110 @@ func (s *SyncService) SyncAll() (*SyncResult, error) {
 	return result, nil
 }
 
-// groupByEntityLatest groups submissions by entity_id and returns only the latest submission per entity
-// For mode="baru", entity_id is the ODK submission ID (__id)
-// For mode="update", entity_id is sel_posko (the entity being updated)
-func (s *SyncService) groupByEntityLatest(submissions []map[string]interface{}) map[string]map[string]interface{} {
-	latestByEntity := make(map[string]map[string]interface{})
-	latestTimeByEntity := make(map[string]time.Time)
-
+// groupByEntityLatest groups submissions by entity_id and returns only the latest submission per entity,
+// along with the number of submissions seen for each entity and how many used the submission ID fallback
+// For mode="baru", entity_id comes from the entity mapping (or the ODK submission ID, __id)
+// For mode="update", entity_id is sel_posko (the entity being updated), see EntityKeyStrategy
+func (s *SyncService) groupByEntityLatest(submissions []map[string]interface{}) (map[string]map[string]interface{}, map[string]int, int) {
+	g := newEntityGrouper()
 	for _, submission := range submissions {
-		// Get submission timestamp
-		var submittedAt time.Time
-		if system, ok := submission["__system"].(map[string]interface{}); ok {
-			if dateStr, ok := system["submissionDate"].(string); ok {
-				if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
-					submittedAt = t
-				}
-			}
-		}
+		g.add(s, submission)
+	}
+	return g.latest, g.count, g.fallbacks
+}
 
-		// Determine entity_id based on mode
-		entityID := s.getEntityID(submission)
-		if entityID == "" {
-			continue
-		}
+// entityGrouper is groupByEntityLatest for submissions that arrive one at a time:
+// only the latest submission of each entity is kept
+type entityGrouper struct {
+	latest     map[string]map[string]interface{}
+	latestTime map[string]time.Time
+	count      map[string]int
+	fallbacks  int
+}
 
-		// Keep only the latest submission per entity
-		if existingTime, exists := latestTimeByEntity[entityID]; !exists || submittedAt.After(existingTime) {
-			latestByEntity[entityID] = submission
-			latestTimeByEntity[entityID] = submittedAt
-		}
+func newEntityGrouper() *entityGrouper {
+	return &entityGrouper{
+		latest:     make(map[string]map[string]interface{}),
+		latestTime: make(map[string]time.Time),
+		count:      make(map[string]int),
 	}
-
-	return latestByEntity
 }
 
-// loadEntityMapping fetches the entity-to-submission mapping from ODK Central
-// and inverts it to submission-to-entity for efficient lookup
-func (s *SyncService) loadEntityMapping() error {
-	if s.submissionToEntityCache != nil {
-		return nil // Already loaded
+// add groups one submission under its entity, replacing the entity's kept submission if newer
+func (g *entityGrouper) add(s *SyncService, submission map[string]interface{}) {
+	// Get submission timestamp
+	var submittedAt time.Time
+	if system, ok := submission["__system"].(map[string]interface{}); ok {
+		if dateStr, ok := system["submissionDate"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
+				submittedAt = t
+			}
+		}
 	}
 
-	// Get entity -> submission mapping from ODK
-	entityToSubmission, err := s.odkClient.GetEntitySubmissionMapping(s.entityDataset)
-	if err != nil {
-		log.Printf("Warning: could not load entity mapping: %v (will use submission ID as fallback)", err)
-		s.submissionToEntityCache = make(map[string]string) // empty cache
-		return nil
+	// Determine entity_id based on mode
+	entityID, fallback := s.getEntityID(submission)
+	if entityID == "" {
+		return
+	}
+	if fallback {
+		g.fallbacks++
 	}
+	g.count[entityID]++
 
-	// Invert to submission -> entity mapping
-	s.submissionToEntityCache = make(map[string]string)
-	for entityUUID, submissionID := range entityToSubmission {
-		s.submissionToEntityCache[submissionID] = entityUUID
+	// Keep only the latest submission per entity
+	if existingTime, exists := g.latestTime[entityID]; !exists || submittedAt.After(existingTime) {
+		g.latest[entityID] = submission
+		g.latestTime[entityID] = submittedAt
 	}
+}
 
-	log.Printf("Loaded entity mapping: %d entities", len(s.submissionToEntityCache))
-	return nil
+// logEntityFallbacks reports how many submissions of a sync resolved their entity ID
+// through the submission ID fallback instead of the entity mapping, in the log and the metric
+func (s *SyncService) logEntityFallbacks(fallbacks, total int) {
+	if s.countEntityFallbacks != nil {
+		s.countEntityFallbacks(fallbacks, total)
+	}
+	if fallbacks == 0 || total == 0 {
+		return
+	}
+	log.Printf("Warning: entity ID fallback used for %d/%d submissions (%.1f%%, mapping has %d entries) - check GetEntitySubmissionMapping for dataset %s",
+		fallbacks, total, float64(fallbacks)*100/float64(total), len(s.submissionToEntityCache), s.entityDataset)
 }
 
 // getEntityID determines the entity ID for a submission
 // Priority:
-// 1. For mode="update": uses sel_posko (the entity being updated)
+// 1. The entity key strategy (default: sel_posko for mode="update")
 // 2. Look up in entity mapping cache (from ODK entity versions)
 // 3. Fallback: use submission ID (for dumped data where submission ID = entity ID)
-func (s *SyncService) getEntityID(submission map[string]interface{}) string {
-	mode, _ := submission["mode"].(string)
-
-	if mode == "update" {
-		// For updates, use sel_posko as the entity ID
-		if selPosko, ok := submission["sel_posko"].(string); ok && selPosko != "" {
-			return selPosko
-		}
+// The second return value reports whether the fallback was taken.
+func (s *SyncService) getEntityID(submission map[string]interface{}) (string, bool) {
+	if entityID := s.entityKeys.Resolve(submission); entityID != "" {
+		return entityID, false
 	}
 
 	// Get submission ID
 	odkID, ok := submission["__id"].(string)
 	if !ok || odkID == "" {
-		return ""
+		return "", false
 	}
 
 	// Check if we have entity UUID from mapping cache
 	if s.submissionToEntityCache != nil {
 		if entityUUID, exists := s.submissionToEntityCache[odkID]; exists {
-			return entityUUID
+			return entityUUID, false
 		}
 	}
 
 	// Fallback: use submission ID as entity ID
 	// This works for dumped submissions where we set entity ID = submission ID
-	return odkID
+	return odkID, true
 }
 
 // processEntitySubmission processes a submission for a specific entity
 // Uses entity_id for upsert: multiple submissions with same entity_id = one record in PostgreSQL
-func (s *SyncService) processEntitySubmission(entityID string, submission map[string]interface{}, result *SyncResult) error {
+// submissionCount is the number of approved submissions grouped under the entity
+func (s *SyncService) processEntitySubmission(entityID string, submission map[string]interface{}, submissionCount int, result *SyncResult) error {
 	// Get submission ID for logging
 	odkID, _ := submission["__id"].(string)
 
@@ -203,10 +344,13 @@ func (s *SyncService) processEntitySubmission(entityID string, submission map[st
 	}
 
 	// Map submission to location
-	location, err := MapSubmissionToLocation(submission)
+	location, err := mapSubmissionToLocation(submission, s.nameFields, s.mappingProfiles)
 	if err != nil {
 		return fmt.Errorf("failed to map submission %s: %w", odkID, err)
 	}
+	if location.InvalidCoordinates {
+		result.InvalidCoordinates++
+	}
 
 	// Store entity_id in raw_data for reference
 	if location.RawData == nil {
@@ -216,26 +360,34 @@ func (s *SyncService) processEntitySubmission(entityID string, submission map[st
 
 	// Update odk_submission_id to the latest submission ID
 	location.ODKSubmissionID = &odkID
+	location.SubmissionCount = submissionCount
 
 	// Check if location already exists by entity_id (entity-based upsert)
 	// This enables mode="update" submissions to update existing records
 	var existingLocation model.Location
-	err = s.db.Where("raw_data->>'_entity_id' = ?", entityID).First(&existingLocation).Error
+	err = withDBRetry("lookup", func() error {
+		return s.db.Where("raw_data->>'_entity_id' = ?", entityID).First(&existingLocation).Error
+	})
 
 	if err == gorm.ErrRecordNotFound {
 		// Create new location
-		if err := s.createLocation(location); err != nil {
+		if err := withDBRetry("create location", func() error { return s.createLocation(location) }); err != nil {
 			return fmt.Errorf("failed to create location for entity %s: %w", entityID, err)
 		}
 		result.Created++
+		noteName(&result.Changes.Created, location.Nama)
 		log.Printf("Created location: %s (entity: %s, submission: %s)", location.Nama, entityID, odkID)
 	} else if err == nil {
 		// Update existing location with latest submission data
 		location.ID = existingLocation.ID
-		if err := s.updateLocation(location); err != nil {
+		if err := withDBRetry("update location", func() error { return s.updateLocation(location, &existingLocation) }); err != nil {
 			return fmt.Errorf("failed to update location for entity %s: %w", entityID, err)
 		}
 		result.Updated++
+		if existingLocation.ODKSubmissionID == nil || *existingLocation.ODKSubmissionID != odkID {
+			result.Changed++
+			noteName(&result.Changes.Changed, location.Nama)
+		}
 		log.Printf("Updated location: %s (entity: %s, submission: %s)", location.Nama, entityID, odkID)
 	} else {
 		return fmt.Errorf("database error checking entity %s: %w", entityID, err)
@@ -244,7 +396,7 @@ func (s *SyncService) processEntitySubmission(entityID string, submission map[st
 	// Process photos
 	photos := ExtractPhotos(submission)
 	for _, photo := range photos {
-		if err := s.processPhoto(location.ID, photo); err != nil {
+		if err := s.processPhoto(location.ID, odkID, photo); err != nil {
 			log.Printf("Warning: failed to process photo %s: %v", photo.Filename, err)
 		}
 	}
@@ -254,13 +406,16 @@ func (s *SyncService) processEntitySubmission(entityID string, submission map[st
 
 // SyncSince performs incremental sync since last sync time
 func (s *SyncService) SyncSince(since time.Time) (*SyncResult, error) {
+	defer s.lockSync()()
+
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
 
 	s.updateSyncState("syncing", nil)
+	s.publishStarted(model.SyncRunModeIncremental)
 
-	submissions, err := s.odkClient.GetSubmissionsSince(since)
+	submissions, err := s.odkClient.GetSubmissionsSinceContext(s.syncCtx(), since)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -269,21 +424,161 @@ func (s *SyncService) SyncSince(since time.Time) (*SyncResult, error) {
 
 	result.TotalFetched = len(submissions)
 
+	// ODK can return the same __id twice in a page; process each submission once
+	submissions = dedupeSubmissionsByID(submissions)
+
+	progress := s.newProgress(model.SyncRunModeIncremental, len(submissions))
 	for _, submission := range submissions {
 		if err := s.processSubmission(submission, result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime).String()
 
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeIncremental, result)
+	s.publishCompleted(model.SyncRunModeIncremental, result)
+	s.runAfterSync()
 
 	return result, nil
 }
 
+// SyncSubmission re-syncs a single posko submission from ODK Central into its entity's record.
+// Returns the affected location, or nil if the submission was skipped (e.g. not approved, or
+// older than the submission already stored for the entity).
+func (s *SyncService) SyncSubmission(submissionID string) (*SyncResult, *model.Location, error) {
+	defer s.lockSync()()
+
+	result := &SyncResult{
+		StartTime: time.Now(),
+	}
+
+	if err := s.loadEntityMapping(s.syncCtx()); err != nil {
+		return nil, nil, err
+	}
+	result.SubmissionMode = s.SubmissionMode()
+
+	submission, err := s.odkClient.GetSubmissionContext(s.syncCtx(), submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	result.TotalFetched = 1
+
+	entityID, fallback := s.getEntityID(submission)
+	if entityID == "" {
+		return nil, nil, fmt.Errorf("could not determine entity for submission %s", submissionID)
+	}
+	if fallback {
+		result.EntityFallbacks = 1
+		log.Printf("Warning: entity ID fallback used for submission %s (not in entity mapping)", submissionID)
+	}
+	if s.countEntityFallbacks != nil {
+		s.countEntityFallbacks(result.EntityFallbacks, 1)
+	}
+
+	// Keep the entity's submission count; only entity-grouped syncs recompute it
+	submissionCount := 1
+	var existing model.Location
+	if err := s.db.Select("submission_count", "submitted_at").Where("raw_data->>'_entity_id' = ?", entityID).First(&existing).Error; err == nil {
+		submissionCount = existing.SubmissionCount
+
+		// The record holds the entity's latest submission; an older one must not overwrite it
+		if submittedAt := submissionDate(submission); existing.SubmittedAt != nil && !submittedAt.IsZero() && submittedAt.Before(*existing.SubmittedAt) {
+			log.Printf("Skipping submission %s: older than the stored submission for entity %s", submissionID, entityID)
+			result.Skipped++
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime).String()
+			return result, nil, nil
+		}
+	}
+
+	if err := s.processEntitySubmission(entityID, submission, submissionCount, result); err != nil {
+		return nil, nil, err
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+
+	if result.Created+result.Updated == 0 {
+		result.Skipped++
+		return result, nil, nil
+	}
+
+	var location model.Location
+	if err := s.db.Where("raw_data->>'_entity_id' = ?", entityID).First(&location).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load synced location for entity %s: %w", entityID, err)
+	}
+
+	return result, &location, nil
+}
+
+// dedupeSubmissionsByID drops repeated submissions with the same __id, keeping the most
+// recently updated copy (by __system.updatedAt, then submissionDate) at its first position.
+// Submissions without __id are kept as-is.
+func dedupeSubmissionsByID(submissions []map[string]interface{}) []map[string]interface{} {
+	indexByID := make(map[string]int, len(submissions))
+	deduped := make([]map[string]interface{}, 0, len(submissions))
+
+	for _, submission := range submissions {
+		odkID, _ := submission["__id"].(string)
+		if odkID == "" {
+			deduped = append(deduped, submission)
+			continue
+		}
+
+		i, seen := indexByID[odkID]
+		if !seen {
+			indexByID[odkID] = len(deduped)
+			deduped = append(deduped, submission)
+			continue
+		}
+		if !submissionUpdatedAt(submission).Before(submissionUpdatedAt(deduped[i])) {
+			deduped[i] = submission
+		}
+	}
+
+	if dropped := len(submissions) - len(deduped); dropped > 0 {
+		log.Printf("Dropped %d duplicate submission(s) by __id", dropped)
+	}
+
+	return deduped
+}
+
+// submissionUpdatedAt returns __system.updatedAt, falling back to submissionDate
+func submissionUpdatedAt(submission map[string]interface{}) time.Time {
+	system, ok := submission["__system"].(map[string]interface{})
+	if !ok {
+		return time.Time{}
+	}
+	for _, key := range []string{"updatedAt", "submissionDate"} {
+		if dateStr, ok := system[key].(string); ok {
+			if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// submissionDate returns a submission's __system.submissionDate, the time the mapper stores
+// as submitted_at, or the zero time if it is missing
+func submissionDate(submission map[string]interface{}) time.Time {
+	system, ok := submission["__system"].(map[string]interface{})
+	if !ok {
+		return time.Time{}
+	}
+	dateStr, _ := system["submissionDate"].(string)
+	t, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // processSubmission processes a single submission
 func (s *SyncService) processSubmission(submission map[string]interface{}, result *SyncResult) error {
 	// Get submission ID
@@ -301,26 +596,32 @@ func (s *SyncService) processSubmission(submission map[string]interface{}, resul
 	}
 
 	// Map submission to location
-	location, err := MapSubmissionToLocation(submission)
+	location, err := mapSubmissionToLocation(submission, s.nameFields, s.mappingProfiles)
 	if err != nil {
 		return fmt.Errorf("failed to map submission %s: %w", odkID, err)
 	}
+	if location.InvalidCoordinates {
+		result.InvalidCoordinates++
+	}
 
 	// Check if location already exists
 	var existingLocation model.Location
-	err = s.db.Where("odk_submission_id = ?", odkID).First(&existingLocation).Error
+	err = withDBRetry("lookup", func() error { return s.db.Where("odk_submission_id = ?", odkID).First(&existingLocation).Error })
 
 	if err == gorm.ErrRecordNotFound {
 		// Create new location
-		if err := s.createLocation(location); err != nil {
+		location.SubmissionCount = 1
+		if err := withDBRetry("create location", func() error { return s.createLocation(location) }); err != nil {
 			return fmt.Errorf("failed to create location for %s: %w", odkID, err)
 		}
 		result.Created++
+		noteName(&result.Changes.Created, location.Nama)
 		log.Printf("Created location: %s (%s)", location.Nama, odkID)
 	} else if err == nil {
-		// Update existing location
+		// Update existing location (submission count is only recomputed by entity-grouped syncs)
 		location.ID = existingLocation.ID
-		if err := s.updateLocation(location); err != nil {
+		location.SubmissionCount = existingLocation.SubmissionCount
+		if err := withDBRetry("update location", func() error { return s.updateLocation(location, &existingLocation) }); err != nil {
 			return fmt.Errorf("failed to update location for %s: %w", odkID, err)
 		}
 		result.Updated++
@@ -332,7 +633,7 @@ func (s *SyncService) processSubmission(submission map[string]interface{}, resul
 	// Process photos
 	photos := ExtractPhotos(submission)
 	for _, photo := range photos {
-		if err := s.processPhoto(location.ID, photo); err != nil {
+		if err := s.processPhoto(location.ID, odkID, photo); err != nil {
 			log.Printf("Warning: failed to process photo %s: %v", photo.Filename, err)
 		}
 	}
@@ -390,6 +691,7 @@ func (s *SyncService) createLocation(location *model.Location) error {
 	location.CreatedAt = now
 	location.UpdatedAt = now
 	location.SyncedAt = &now
+	applyManualStatus(location, nil)
 
 	// Enrich alamat with wilayah names if not already set
 	if location.Alamat != nil {
@@ -398,43 +700,42 @@ func (s *SyncService) createLocation(location *model.Location) error {
 		}
 	}
 
-	// Build SQL with geometry
+	// Build SQL with geometry (NULL without coordinates: ST_MakePoint is strict)
 	sql := `
 		INSERT INTO locations (
 			id, odk_submission_id, nama, type, status,
 			geom, geo_meta, identitas, alamat, data_pengungsi,
-			fasilitas, komunikasi, akses, raw_data,
-			submitter_name, submitted_at, created_at, updated_at, synced_at
+			fasilitas, komunikasi, akses, raw_data, submission_count,
+			jumlah_kk, total_jiwa, jumlah_perempuan, jumlah_laki, jumlah_balita,
+			submitter_name, submitted_at, created_at, updated_at, synced_at,
+			source
 		) VALUES (
 			?, ?, ?, ?, ?,
 			ST_SetSRID(ST_MakePoint(?, ?), 4326), ?, ?, ?, ?,
-			?, ?, ?, ?,
-			?, ?, ?, ?, ?
+			?, ?, ?, ?, ?,
+			?, ?, ?, ?, ?,
+			?, ?, ?, ?, ?,
+			?
 		)
 	`
 
-	lon := float64(0)
-	lat := float64(0)
-	if location.Longitude != nil {
-		lon = *location.Longitude
-	}
-	if location.Latitude != nil {
-		lat = *location.Latitude
-	}
-
 	return s.db.Exec(sql,
 		location.ID, location.ODKSubmissionID, location.Nama, location.Type, location.Status,
-		lon, lat, location.GeoMeta, location.Identitas, location.Alamat, location.DataPengungsi,
-		location.Fasilitas, location.Komunikasi, location.Akses, location.RawData,
+		location.Longitude, location.Latitude, location.GeoMeta, location.Identitas, location.Alamat, location.DataPengungsi,
+		location.Fasilitas, location.Komunikasi, location.Akses, location.RawData, location.SubmissionCount,
+		location.JumlahKK, location.TotalJiwa, location.JumlahPerempuan, location.JumlahLaki, location.JumlahBalita,
 		location.SubmitterName, location.SubmittedAt, location.CreatedAt, location.UpdatedAt, location.SyncedAt,
+		location.Source,
 	).Error
 }
 
-// updateLocation updates an existing location
-func (s *SyncService) updateLocation(location *model.Location) error {
+// updateLocation updates an existing location. A status set by hand on existing is kept
+// while the ODK status is unchanged (see applyManualStatus).
+func (s *SyncService) updateLocation(location, existing *model.Location) error {
 	now := time.Now()
 	location.UpdatedAt = now
 	location.SyncedAt = &now
+	applyManualStatus(location, existing)
 
 	// Enrich alamat with wilayah names if not already set
 	if location.Alamat != nil {
@@ -447,6 +748,7 @@ func (s *SyncService) updateLocation(location *model.Location) error {
 		UPDATE locations SET
 			odk_submission_id = ?,
 			nama = ?,
+			status = ?,
 			geom = ST_SetSRID(ST_MakePoint(?, ?), 4326),
 			geo_meta = ?,
 			identitas = ?,
@@ -456,26 +758,26 @@ func (s *SyncService) updateLocation(location *model.Location) error {
 			komunikasi = ?,
 			akses = ?,
 			raw_data = ?,
+			submission_count = ?,
+			jumlah_kk = ?,
+			total_jiwa = ?,
+			jumlah_perempuan = ?,
+			jumlah_laki = ?,
+			jumlah_balita = ?,
 			submitter_name = ?,
 			submitted_at = ?,
 			updated_at = ?,
-			synced_at = ?
+			synced_at = ?,
+			source = ?,
+			deleted_at = NULL
 		WHERE id = ?
 	`
 
-	lon := float64(0)
-	lat := float64(0)
-	if location.Longitude != nil {
-		lon = *location.Longitude
-	}
-	if location.Latitude != nil {
-		lat = *location.Latitude
-	}
-
 	return s.db.Exec(sql,
 		location.ODKSubmissionID,
 		location.Nama,
-		lon, lat,
+		location.Status,
+		location.Longitude, location.Latitude,
 		location.GeoMeta,
 		location.Identitas,
 		location.Alamat,
@@ -484,89 +786,83 @@ func (s *SyncService) updateLocation(location *model.Location) error {
 		location.Komunikasi,
 		location.Akses,
 		location.RawData,
+		location.SubmissionCount,
+		location.JumlahKK,
+		location.TotalJiwa,
+		location.JumlahPerempuan,
+		location.JumlahLaki,
+		location.JumlahBalita,
 		location.SubmitterName,
 		location.SubmittedAt,
 		location.UpdatedAt,
 		location.SyncedAt,
+		location.Source,
 		location.ID,
 	).Error
 }
 
 // processPhoto saves photo metadata (actual download can be done separately)
-func (s *SyncService) processPhoto(locationID uuid.UUID, photo PhotoInfo) error {
+func (s *SyncService) processPhoto(locationID uuid.UUID, submissionID string, photo PhotoInfo) error {
 	// Check if photo already exists
-	var count int64
-	s.db.Model(&model.LocationPhoto{}).
-		Where("location_id = ? AND filename = ?", locationID, photo.Filename).
-		Count(&count)
-
-	if count > 0 {
-		return nil // Photo already exists
+	var existing model.LocationPhoto
+	err := s.db.Where("location_id = ? AND filename = ?", locationID, photo.Filename).First(&existing).Error
+	if err == nil {
+		return s.processDuplicatePhoto(&existing, submissionID, photo)
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
 	}
 
 	locationPhoto := &model.LocationPhoto{
-		ID:         uuid.New(),
-		LocationID: locationID,
-		PhotoType:  photo.PhotoType,
-		Filename:   photo.Filename,
-		IsCached:   false,
-		CreatedAt:  time.Now(),
+		ID:              uuid.New(),
+		LocationID:      locationID,
+		PhotoType:       photo.PhotoType,
+		Filename:        photo.Filename,
+		IsCached:        false,
+		CreatedAt:       time.Now(),
+		ODKSubmissionID: &submissionID,
 	}
 
 	return s.db.Create(locationPhoto).Error
 }
 
-// updateSyncState updates the sync_state table
-func (s *SyncService) updateSyncState(status string, errorMsg *string) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
+// processDuplicatePhoto applies the duplicate policy to a photo whose filename the location
+// already has. With PhotoDuplicateReplace, a filename seen in a newer submission is marked
+// uncached so it is downloaded again; DownloadAndSavePhoto then compares checksums and only
+// replaces the cached file if the image changed.
+func (s *SyncService) processDuplicatePhoto(existing *model.LocationPhoto, submissionID string, photo PhotoInfo) error {
+	if s.photoDuplicatePolicy != PhotoDuplicateReplace {
+		return nil // Photo already exists
+	}
+	if existing.ODKSubmissionID != nil && *existing.ODKSubmissionID == submissionID {
+		return nil // Same submission, nothing new
+	}
 
-	now := time.Now()
+	updates := map[string]interface{}{
+		"odk_submission_id": submissionID,
+		"photo_type":        photo.PhotoType,
+	}
+	// Rows from before the source submission was tracked are taken as current
+	// instead of re-downloading every photo once
+	if existing.ODKSubmissionID != nil {
+		updates["is_cached"] = false
+		updates["rejected_content_type"] = nil
+	}
+	return s.db.Model(existing).Updates(updates).Error
+}
 
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:       s.formID,
-			Status:       status,
-			ErrorMessage: errorMsg,
-			CreatedAt:    now,
-			UpdatedAt:    now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = status
-		syncState.ErrorMessage = errorMsg
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
+// updateSyncState updates the sync_state table
+func (s *SyncService) updateSyncState(status string, errorMsg *string) {
+	if status == "error" {
+		s.publishFailed(errorMsg)
 	}
+
+	saveSyncState(s.db, s.formID, status, errorMsg)
 }
 
 // updateSyncStateSuccess updates sync state after successful sync
 func (s *SyncService) updateSyncStateSuccess(recordCount int) {
-	var syncState odk.SyncState
-	result := s.db.Where("form_id = ?", s.formID).First(&syncState)
-
-	now := time.Now()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		syncState = odk.SyncState{
-			FormID:          s.formID,
-			Status:          "idle",
-			LastSyncTime:    &now,
-			LastRecordCount: recordCount,
-			TotalRecords:    recordCount,
-			CreatedAt:       now,
-			UpdatedAt:       now,
-		}
-		s.db.Create(&syncState)
-	} else {
-		syncState.Status = "idle"
-		syncState.LastSyncTime = &now
-		syncState.LastRecordCount = recordCount
-		syncState.TotalRecords += recordCount
-		syncState.ErrorMessage = nil
-		syncState.UpdatedAt = now
-		s.db.Save(&syncState)
-	}
+	saveSyncStateSuccess(s.db, s.formID, recordCount)
 }
 
 // GetSyncState returns the current sync state for a form
@@ -585,24 +881,32 @@ func (s *SyncService) GetSyncState() (*odk.SyncState, error) {
 	return &syncState, nil
 }
 
-// HardSync performs a full sync and deletes records that no longer exist in ODK Central
+// HardSync performs a full sync and soft-deletes records that no longer exist in ODK Central
 // Uses entity-based grouping to properly handle ODK's append-only submission model
 func (s *SyncService) HardSync() (*SyncResult, error) {
+	defer s.lockSync()()
+
 	result := &SyncResult{
 		StartTime: time.Now(),
 	}
 
 	s.updateSyncState("hard_syncing", nil)
+	s.publishStarted(model.SyncRunModeHard)
 
 	// Load entity mapping from ODK (for proper entity ID resolution)
-	// Reset cache to get fresh mapping
-	s.submissionToEntityCache = nil
-	if err := s.loadEntityMapping(); err != nil {
-		log.Printf("Warning: could not load entity mapping: %v", err)
+	// Always refetch to get fresh mapping; the stored one is kept if that fails
+	if err := s.refreshEntityMapping(s.syncCtx()); err != nil {
+		log.Printf("Warning: could not refresh entity mapping: %v (using previous mapping)", err)
+		if err := s.loadEntityMapping(s.syncCtx()); err != nil {
+			errMsg := err.Error()
+			s.updateSyncState("error", &errMsg)
+			return nil, err
+		}
 	}
+	result.SubmissionMode = s.SubmissionMode()
 
 	// Fetch all approved submissions from ODK Central
-	submissions, err := s.odkClient.GetApprovedSubmissions()
+	submissions, err := s.odkClient.GetApprovedSubmissionsContext(s.syncCtx())
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch submissions: %v", err)
 		s.updateSyncState("error", &errMsg)
@@ -613,8 +917,10 @@ func (s *SyncService) HardSync() (*SyncResult, error) {
 	log.Printf("HardSync: Fetched %d submissions from ODK Central", result.TotalFetched)
 
 	// Group submissions by entity_id and keep only the latest per entity
-	latestByEntity := s.groupByEntityLatest(submissions)
+	latestByEntity, countByEntity, fallbacks := s.groupByEntityLatest(submissions)
 	log.Printf("HardSync: Grouped into %d unique entities", len(latestByEntity))
+	result.EntityFallbacks = fallbacks
+	s.logEntityFallbacks(fallbacks, len(submissions))
 
 	// Build a set of entity IDs from ODK Central
 	entityIDSet := make(map[string]bool)
@@ -623,20 +929,27 @@ func (s *SyncService) HardSync() (*SyncResult, error) {
 	}
 
 	// Process each entity's latest submission (create/update)
+	progress := s.newProgress(model.SyncRunModeHard, len(latestByEntity))
 	for entityID, submission := range latestByEntity {
-		if err := s.processEntitySubmission(entityID, submission, result); err != nil {
+		if err := s.processEntitySubmission(entityID, submission, countByEntity[entityID], result); err != nil {
 			result.Errors++
-			result.ErrorDetails = append(result.ErrorDetails, err.Error())
+			result.addErrorDetail(err.Error())
 			log.Printf("Error processing entity %s: %v", entityID, err)
 		}
+		progress.step(result.Created, result.Updated, result.Errors)
 	}
 
-	// Find and delete locations that no longer exist in ODK Central
+	// Find and soft-delete locations that no longer exist in ODK Central
 	// Use entity_id for matching (consistent with entity-based upsert)
 	var locations []model.Location
 	if err := s.db.Where("raw_data->>'_entity_id' IS NOT NULL AND deleted_at IS NULL").Find(&locations).Error; err != nil {
 		result.Errors++
-		result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to fetch existing locations: %v", err))
+		result.addErrorDetail(fmt.Sprintf("failed to fetch existing locations: %v", err))
+	} else if err := s.checkHardSyncDeletes(len(entityIDSet), len(locations)); err != nil {
+		log.Printf("HardSync: %v", err)
+		result.Errors++
+		result.addErrorDetail(err.Error())
+		result.DeletesAborted = true
 	} else {
 		for _, loc := range locations {
 			// Get entity_id from raw_data
@@ -656,12 +969,13 @@ func (s *SyncService) HardSync() (*SyncResult, error) {
 					log.Printf("Warning: failed to delete photos for location %s: %v", loc.ID, err)
 				}
 
-				// Delete the location
-				if err := s.db.Delete(&loc).Error; err != nil {
+				// Soft-delete the location
+				if err := softDelete(s.db, "locations", loc.ID); err != nil {
 					result.Errors++
-					result.ErrorDetails = append(result.ErrorDetails, fmt.Sprintf("failed to delete location %s: %v", loc.ID, err))
+					result.addErrorDetail(fmt.Sprintf("failed to delete location %s: %v", loc.ID, err))
 				} else {
 					result.Deleted++
+					noteName(&result.Changes.Deleted, loc.Nama)
 				}
 			}
 		}
@@ -671,6 +985,9 @@ func (s *SyncService) HardSync() (*SyncResult, error) {
 	result.Duration = result.EndTime.Sub(result.StartTime).String()
 
 	s.updateSyncStateSuccess(result.TotalFetched)
+	s.recordSyncRun(s.db, s.formID, model.SyncRunModeHard, result)
+	s.publishCompleted(model.SyncRunModeHard, result)
+	s.runAfterSync()
 
 	log.Printf("HardSync completed: %d fetched, %d entities, %d created, %d updated, %d deleted, %d errors",
 		result.TotalFetched, len(latestByEntity), result.Created, result.Updated, result.Deleted, result.Errors)
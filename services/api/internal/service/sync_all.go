@@ -0,0 +1,77 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// CombinedSyncResult holds the per-form results of a combined sync
+type CombinedSyncResult struct {
+	Posko         *SyncResult       `json:"posko,omitempty"`
+	Faskes        *SyncResult       `json:"faskes,omitempty"`
+	Feed          *FeedSyncResult   `json:"feed,omitempty"`
+	Infrastruktur *SyncResult       `json:"infrastruktur,omitempty"`
+	Errors        map[string]string `json:"errors,omitempty"`
+	StartTime     time.Time         `json:"start_time"`
+	EndTime       time.Time         `json:"end_time"`
+	Duration      string            `json:"duration"`
+}
+
+// SyncAllForms syncs every form in dependency order: posko and faskes run concurrently,
+// then feeds (which resolve their posko/faskes links), then infrastruktur.
+// A failing form does not stop the others; its error is reported in Errors.
+// infra may be nil. Each service's own sync lock keeps this from clashing with scheduled runs.
+func SyncAllForms(posko *SyncService, faskes *FaskesSyncService, feed *FeedSyncService, infra *InfrastrukturSyncService) *CombinedSyncResult {
+	result := &CombinedSyncResult{
+		StartTime: time.Now(),
+		Errors:    make(map[string]string),
+	}
+
+	// Stage 1: posko and faskes are independent. Errors are collected per form rather
+	// than returned, so one failure does not hide the other's result
+	var (
+		poskoErr, faskesErr error
+		g                   errgroup.Group
+	)
+	g.Go(func() error {
+		result.Posko, poskoErr = posko.SyncAll()
+		return nil
+	})
+	g.Go(func() error {
+		result.Faskes, faskesErr = faskes.SyncAll()
+		return nil
+	})
+	g.Wait()
+	recordSyncError(result.Errors, "posko", poskoErr)
+	recordSyncError(result.Errors, "faskes", faskesErr)
+
+	// Stage 2: feeds link to posko/faskes records
+	var err error
+	result.Feed, err = feed.SyncAll()
+	recordSyncError(result.Errors, "feed", err)
+
+	// Stage 3: infrastruktur
+	if infra != nil {
+		result.Infrastruktur, err = infra.SyncAll()
+		recordSyncError(result.Errors, "infrastruktur", err)
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime).String()
+	if len(result.Errors) == 0 {
+		result.Errors = nil
+	}
+
+	log.Printf("Combined sync completed in %s (%d form error(s))", result.Duration, len(result.Errors))
+
+	return result
+}
+
+func recordSyncError(errs map[string]string, form string, err error) {
+	if err != nil {
+		errs[form] = err.Error()
+		log.Printf("Combined sync: %s failed: %v", form, err)
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import "context"
+
+// syncContext bounds the ODK Central requests of a sync service, so an in-flight sync
+// stops promptly when the server shuts down. Embedded in each sync service.
+type syncContext struct {
+	ctx context.Context
+}
+
+// SetContext sets the context the service's syncs run under; once it is cancelled their
+// ODK Central requests fail and no new ones are made. Unset, syncs are never cancelled.
+func (sc *syncContext) SetContext(ctx context.Context) {
+	sc.ctx = ctx
+}
+
+// syncCtx returns the context for the ODK Central requests of a sync
+func (sc *syncContext) syncCtx() context.Context {
+	if sc.ctx == nil {
+		return context.Background()
+	}
+	return sc.ctx
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"github.com/leksa/datamapper-senyar/internal/sse"
+)
+
+// Sync lifecycle events published to the SSE hub, keyed by "form" (posko, feed, faskes)
+const (
+	EventSyncStarted   = "sync_started"
+	EventSyncProgress  = "sync_progress"
+	EventSyncCompleted = "sync_completed"
+	EventSyncFailed    = "sync_failed"
+)
+
+// syncProgressEvery throttles progress events to one per this many processed entities
+const syncProgressEvery = 50
+
+// syncEvents publishes a sync service's lifecycle so the dashboard can follow a sync
+// without polling /sync/status. Embedded in each sync service; without a hub it is a no-op.
+type syncEvents struct {
+	hub  *sse.Hub
+	form string
+}
+
+// SetEventHub sets the SSE hub sync events are published to (nil disables them)
+func (e *syncEvents) SetEventHub(hub *sse.Hub) {
+	e.hub = hub
+}
+
+func (e *syncEvents) publish(eventType string, data map[string]interface{}) {
+	if e.hub == nil {
+		return
+	}
+	data["form"] = e.form
+	e.hub.Broadcast(eventType, data)
+}
+
+// publishStarted announces a sync of mode (model.SyncRunMode*)
+func (e *syncEvents) publishStarted(mode string) {
+	e.publish(EventSyncStarted, map[string]interface{}{
+		"mode": mode,
+	})
+}
+
+// publishCompleted announces a finished sync with its full result
+func (e *syncEvents) publishCompleted(mode string, result interface{}) {
+	e.publish(EventSyncCompleted, map[string]interface{}{
+		"mode":   mode,
+		"result": result,
+	})
+}
+
+// publishFailed announces a sync that stopped with an error
+func (e *syncEvents) publishFailed(errorMsg *string) {
+	data := map[string]interface{}{}
+	if errorMsg != nil {
+		data["error"] = *errorMsg
+	}
+	e.publish(EventSyncFailed, data)
+}
+
+// syncProgress counts the entities processed by one sync run
+type syncProgress struct {
+	events    *syncEvents
+	mode      string
+	total     int
+	processed int
+}
+
+// newProgress starts counting the total entities of a sync run of mode
+func (e *syncEvents) newProgress(mode string, total int) *syncProgress {
+	return &syncProgress{events: e, mode: mode, total: total}
+}
+
+// step records one processed entity, publishing the running counts every syncProgressEvery
+func (p *syncProgress) step(created, updated, errors int) {
+	p.processed++
+	if p.processed%syncProgressEvery != 0 {
+		return
+	}
+	p.events.publish(EventSyncProgress, map[string]interface{}{
+		"mode":      p.mode,
+		"processed": p.processed,
+		"total":     p.total,
+		"created":   created,
+		"updated":   updated,
+		"errors":    errors,
+	})
+}
@@ -0,0 +1,27 @@
+package service
+
+// syncHooks lets callers react to a completed sync (e.g. enqueue photo downloads)
+// without the sync services depending on them. Embedded in each sync service.
+type syncHooks struct {
+	afterSync []func()
+}
+
+// SetAfterSyncHook registers a function run in the background after each successful sync,
+// replacing any hooks registered before
+func (h *syncHooks) SetAfterSyncHook(fn func()) {
+	h.afterSync = []func(){fn}
+}
+
+// AddAfterSyncHook registers an additional function run in the background after each successful sync
+func (h *syncHooks) AddAfterSyncHook(fn func()) {
+	h.afterSync = append(h.afterSync, fn)
+}
+
+// runAfterSync runs the hooks without blocking the sync response
+func (h *syncHooks) runAfterSync() {
+	for _, fn := range h.afterSync {
+		if fn != nil {
+			go fn()
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package service
+
+import "sync"
+
+// syncLock serializes runs of one form's sync so manual, scheduled and combined
+// syncs of the same form never write concurrently. Embedded in each sync service.
+type syncLock struct {
+	mu sync.Mutex
+}
+
+// lockSync blocks until no other sync of this form is running and returns the unlock func
+func (l *syncLock) lockSync() func() {
+	l.mu.Lock()
+	return l.mu.Unlock
+}
+
+// WaitSync blocks until the running sync of this form, if any, has finished
+func (l *syncLock) WaitSync() {
+	l.mu.Lock()
+	l.mu.Unlock()
+}
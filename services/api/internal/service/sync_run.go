@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/leksa/datamapper-senyar/internal/model"
+	"gorm.io/gorm"
+)
+
+// maxSyncRunNames caps each name list stored with a run; the counts stay exact.
+// A first sync creates every record, which would otherwise store the whole form.
+const maxSyncRunNames = 500
+
+// ErrNoSyncRuns is returned when a form has no recorded sync run yet
+var ErrNoSyncRuns = errors.New("no sync runs recorded")
+
+// SyncDelta compares the two most recent sync runs of a form. Counts and names
+// are what the latest run did to the records left by the previous one.
+type SyncDelta struct {
+	FormID   string         `json:"form_id"`
+	Latest   *model.SyncRun `json:"latest"`
+	Previous *model.SyncRun `json:"previous,omitempty"`
+
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Changed int `json:"changed"`
+	Deleted int `json:"deleted"`
+
+	Names     model.SyncRunChanges `json:"names"`
+	Truncated bool                 `json:"names_truncated,omitempty"`
+}
+
+// noteName appends a record name to one of the change lists, up to maxSyncRunNames
+func noteName(names *[]string, name string) {
+	if len(*names) < maxSyncRunNames {
+		*names = append(*names, name)
+	}
+}
+
+// syncRunHistory records completed syncs in sync_runs and prunes old ones.
+// Embedded in each sync service.
+type syncRunHistory struct {
+	// retention is how long runs are kept, 0 = forever
+	retention time.Duration
+}
+
+// SetSyncRunRetention sets how long sync runs are kept (0 = forever). The two most
+// recent runs of a form are always kept, so its delta survives a long sync outage.
+func (h *syncRunHistory) SetSyncRunRetention(retention time.Duration) {
+	h.retention = retention
+}
+
+// recordSyncRun stores a completed sync and prunes the form's expired runs. Failures
+// are logged only: the history is a report and must never fail the sync itself.
+func (h *syncRunHistory) recordSyncRun(db *gorm.DB, formID, mode string, result *SyncResult) {
+	run := &model.SyncRun{
+		FormID:       formID,
+		Mode:         mode,
+		StartedAt:    result.StartTime,
+		FinishedAt:   result.EndTime,
+		TotalFetched: result.TotalFetched,
+		Created:      result.Created,
+		Updated:      result.Updated,
+		Changed:      result.Changed,
+		Deleted:      result.Deleted,
+		Errors:       result.Errors,
+		Changes:      result.Changes,
+	}
+	if err := withDBRetry("record sync run", func() error { return db.Create(run).Error }); err != nil {
+		log.Printf("Warning: failed to record sync run for form %s: %v", formID, err)
+		return
+	}
+
+	if h.retention <= 0 {
+		return
+	}
+	latest := db.Table("sync_runs").Select("id").Where("form_id = ?", formID).Order("finished_at DESC").Limit(2)
+	err := db.Where("form_id = ? AND finished_at < ? AND id NOT IN (?)", formID, time.Now().Add(-h.retention), latest).
+		Delete(&model.SyncRun{}).Error
+	if err != nil {
+		log.Printf("Warning: failed to prune sync runs for form %s: %v", formID, err)
+	}
+}
+
+// recordFeedSyncRun stores a completed feed sync (counts only, feeds have no names)
+func (h *syncRunHistory) recordFeedSyncRun(db *gorm.DB, formID, mode string, result *FeedSyncResult) {
+	h.recordSyncRun(db, formID, mode, &SyncResult{
+		TotalFetched: result.TotalFetched,
+		Created:      result.Created,
+		Updated:      result.Updated,
+		Deleted:      result.Deleted,
+		Errors:       result.Errors,
+		StartTime:    result.StartTime,
+		EndTime:      result.EndTime,
+	})
+}
+
+// Delta compares the two most recent posko sync runs
+func (s *SyncService) Delta() (*SyncDelta, error) {
+	return syncDelta(s.db, s.formID)
+}
+
+// Delta compares the two most recent feed sync runs
+func (s *FeedSyncService) Delta() (*SyncDelta, error) {
+	return syncDelta(s.db, s.formID)
+}
+
+// Delta compares the two most recent faskes sync runs
+func (s *FaskesSyncService) Delta() (*SyncDelta, error) {
+	return syncDelta(s.db, s.formID)
+}
+
+// Delta compares the two most recent infrastruktur sync runs
+func (s *InfrastrukturSyncService) Delta() (*SyncDelta, error) {
+	return syncDelta(s.db, s.formID)
+}
+
+// syncDelta compares the two most recent runs of formID.
+// Returns ErrNoSyncRuns when the form has not been synced since the history was added.
+func syncDelta(db *gorm.DB, formID string) (*SyncDelta, error) {
+	var runs []model.SyncRun
+	if err := db.Where("form_id = ?", formID).Order("finished_at DESC").Limit(2).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrNoSyncRuns
+	}
+
+	latest := &runs[0]
+	delta := &SyncDelta{
+		FormID:  formID,
+		Latest:  latest,
+		Created: latest.Created,
+		Updated: latest.Updated,
+		Changed: latest.Changed,
+		Deleted: latest.Deleted,
+		Names:   latest.Changes,
+	}
+	if len(runs) > 1 {
+		delta.Previous = &runs[1]
+	}
+	// Only lists that hit the cap were cut; feed runs store no names at all
+	delta.Truncated = namesCut(latest.Changes.Created, latest.Created) ||
+		namesCut(latest.Changes.Changed, latest.Changed) ||
+		namesCut(latest.Changes.Deleted, latest.Deleted)
+
+	return delta, nil
+}
+
+// namesCut reports whether a name list stopped at maxSyncRunNames before count records
+func namesCut(names []string, count int) bool {
+	return len(names) >= maxSyncRunNames && count > len(names)
+}
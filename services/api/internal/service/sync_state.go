@@ -0,0 +1,43 @@
+package service
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// saveSyncState records the status of a form's sync (syncing, hard_syncing, error...)
+// in sync_state. A single upsert, so a manual and a scheduled sync of the same form
+// can't race to create its row. Failures are logged only, like recordSyncRun.
+func saveSyncState(db *gorm.DB, formID, status string, errorMsg *string) {
+	err := db.Exec(`
+		INSERT INTO sync_state (form_id, status, error_message, created_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+		ON CONFLICT (form_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			error_message = EXCLUDED.error_message,
+			updated_at = NOW()
+	`, formID, status, errorMsg).Error
+	if err != nil {
+		log.Printf("Warning: failed to update sync state of %s: %v", formID, err)
+	}
+}
+
+// saveSyncStateSuccess records a successful sync of recordCount records in sync_state:
+// status idle, error cleared and the count added to total_records, in one upsert
+func saveSyncStateSuccess(db *gorm.DB, formID string, recordCount int) {
+	err := db.Exec(`
+		INSERT INTO sync_state (form_id, status, last_sync_timestamp, last_record_count, total_records, created_at, updated_at)
+		VALUES (?, 'idle', NOW(), ?, ?, NOW(), NOW())
+		ON CONFLICT (form_id) DO UPDATE SET
+			status = 'idle',
+			last_sync_timestamp = EXCLUDED.last_sync_timestamp,
+			last_record_count = EXCLUDED.last_record_count,
+			total_records = COALESCE(sync_state.total_records, 0) + EXCLUDED.last_record_count,
+			error_message = NULL,
+			updated_at = NOW()
+	`, formID, recordCount, recordCount).Error
+	if err != nil {
+		log.Printf("Warning: failed to update sync state of %s: %v", formID, err)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// submission builds an ODK submission with an __id, a field to tell copies apart and
+// an optional __system.updatedAt
+func submission(id, value, updatedAt string) map[string]interface{} {
+	s := map[string]interface{}{"value": value}
+	if id != "" {
+		s["__id"] = id
+	}
+	if updatedAt != "" {
+		s["__system"] = map[string]interface{}{"updatedAt": updatedAt}
+	}
+	return s
+}
+
+func TestDedupeSubmissionsByID(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []map[string]interface{}
+		want  []string // values of the kept submissions, in order
+	}{
+		{
+			name:  "empty",
+			input: nil,
+			want:  []string{},
+		},
+		{
+			name: "no duplicates",
+			input: []map[string]interface{}{
+				submission("uuid:a", "a", ""),
+				submission("uuid:b", "b", ""),
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "later update wins at the first position",
+			input: []map[string]interface{}{
+				submission("uuid:a", "a-new", "2025-12-02T10:00:00Z"),
+				submission("uuid:b", "b", ""),
+				submission("uuid:a", "a-old", "2025-12-01T10:00:00Z"),
+			},
+			want: []string{"a-new", "b"},
+		},
+		{
+			name: "newer copy later in the page replaces the first",
+			input: []map[string]interface{}{
+				submission("uuid:a", "a-old", "2025-12-01T10:00:00Z"),
+				submission("uuid:b", "b", ""),
+				submission("uuid:a", "a-new", "2025-12-02T10:00:00Z"),
+			},
+			want: []string{"a-new", "b"},
+		},
+		{
+			name: "equal timestamps keep the last copy",
+			input: []map[string]interface{}{
+				submission("uuid:a", "a-1", ""),
+				submission("uuid:a", "a-2", ""),
+			},
+			want: []string{"a-2"},
+		},
+		{
+			name: "submissions without __id are all kept",
+			input: []map[string]interface{}{
+				submission("", "x", ""),
+				submission("", "y", ""),
+				submission("uuid:a", "a", ""),
+			},
+			want: []string{"x", "y", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dedupeSubmissionsByID(tt.input)
+			values := make([]string, 0, len(got))
+			for _, s := range got {
+				values = append(values, s["value"].(string))
+			}
+			if !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("kept %v, want %v", values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leksa/datamapper-senyar/internal/model"
+)
+
+const (
+	// thumbnailMaxEdge is the long edge of generated thumbnails, in pixels
+	thumbnailMaxEdge = 400
+	thumbnailQuality = 80
+)
+
+// makeThumbnail returns a JPEG of data scaled down to thumbnailMaxEdge on the long edge,
+// turned upright by its EXIF orientation. ok is false when no thumbnail is needed or possible: non-image attachments (PDFs),
+// images already that small, and formats without a decoder (WebP) or corrupt images;
+// those are served in full.
+func makeThumbnail(data []byte) (thumb []byte, ok bool) {
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil, false
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (cfg.Width <= thumbnailMaxEdge && cfg.Height <= thumbnailMaxEdge) {
+		return nil, false
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+
+	w, h := cfg.Width, cfg.Height
+	if w >= h {
+		w, h = thumbnailMaxEdge, max(1, h*thumbnailMaxEdge/w)
+	} else {
+		w, h = max(1, w*thumbnailMaxEdge/h), thumbnailMaxEdge
+	}
+
+	// Scale first, so only the small thumbnail is rotated
+	thumbImage := applyOrientation(scaleDown(src, w, h), exifOrientation(data))
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbImage, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// scaleDown resizes src to w x h by averaging the source pixels covered by each target
+// pixel (a box filter, good enough for downscaling photos). Transparent areas are
+// flattened onto white since JPEG has no alpha.
+func scaleDown(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/w)
+
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					// premultiplied: add the white background behind the uncovered part
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					bl += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// storeThumbnail generates a thumbnail of data and stores it next to the original
+// (S3 key under keyDir, or file in localDir). Returns nil when there is no thumbnail;
+// a failed upload or write only loses the thumbnail, never the photo.
+func (s *PhotoService) storeThumbnail(data []byte, keyDir, localDir, filename string) *string {
+	thumb, ok := makeThumbnail(data)
+	if !ok {
+		return nil
+	}
+	thumbFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + "_thumb.jpg"
+
+	var thumbPath string
+	if s.useS3 {
+		url, err := s.s3Storage.Upload(context.Background(), keyDir+"/"+thumbFilename, thumb, "image/jpeg")
+		if err != nil {
+			log.Printf("Warning: failed to upload thumbnail %s: %v", thumbFilename, err)
+			return nil
+		}
+		thumbPath = url
+	} else {
+		thumbPath = filepath.Join(localDir, thumbFilename)
+		if err := os.WriteFile(thumbPath, thumb, 0644); err != nil {
+			log.Printf("Warning: failed to write thumbnail %s: %v", thumbPath, err)
+			return nil
+		}
+	}
+	return &thumbPath
+}
+
+// removeThumbnail deletes a stored thumbnail, if any
+func (s *PhotoService) removeThumbnail(thumbPath *string) {
+	if thumbPath != nil && *thumbPath != "" {
+		s.removeStoredFile(*thumbPath)
+	}
+}
+
+// GetPhotoThumbnailReader returns a reader for a location photo's thumbnail. Photos
+// without one (non-images, small images, cached before thumbnails existed) fall back
+// to the full photo.
+func (s *PhotoService) GetPhotoThumbnailReader(photoID uuid.UUID) (io.ReadCloser, string, string, error) {
+	var photo model.LocationPhoto
+	if err := s.db.First(&photo, photoID).Error; err != nil {
+		return nil, "", "", fmt.Errorf("photo not found: %w", err)
+	}
+
+	if photo.ThumbnailPath != nil && *photo.ThumbnailPath != "" {
+		thumbPath := *photo.ThumbnailPath
+		if s.useS3 && strings.HasPrefix(thumbPath, "http") {
+			key := extractS3Key(thumbPath)
+			reader, _, err := s.s3Storage.GetReader(context.Background(), key)
+			if err == nil {
+				return reader, filepath.Base(key), "image/jpeg", nil
+			}
+			log.Printf("Warning: failed to get thumbnail from S3, serving full photo: %v", err)
+		} else {
+			file, err := os.Open(thumbPath)
+			if err == nil {
+				return file, filepath.Base(thumbPath), "image/jpeg", nil
+			}
+			log.Printf("Warning: failed to open thumbnail, serving full photo: %v", err)
+		}
+	}
+
+	return s.GetPhotoReader(photoID)
+}
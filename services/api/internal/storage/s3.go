@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
@@ -19,8 +21,13 @@ import (
 type S3Storage struct {
 	client     *s3.Client
 	bucket     string
-	baseURL    string // Public URL for serving files
-	pathPrefix string // Optional prefix for all keys
+	baseURL    string        // Public URL for serving files
+	pathPrefix string        // Optional prefix for all keys
+	private    bool          // Objects are uploaded without public-read ACL
+	timeout    time.Duration // Per-operation deadline
+
+	prefixPrivate map[string]bool // Per key prefix overrides of private
+	cacheControl  string          // Cache-Control metadata of uploaded objects
 }
 
 // S3Config holds S3 configuration
@@ -29,16 +36,41 @@ type S3Config struct {
 	Bucket          string
 	AccessKeyID     string
 	SecretAccessKey string
-	Region          string // Default: auto
-	PathPrefix      string // Optional: prefix for all keys (e.g., "photos/")
-	UsePathStyle    bool   // For S3-compatible services, usually true
+	Region          string        // Default: auto
+	PathPrefix      string        // Optional: prefix for all keys (e.g., "photos/")
+	UsePathStyle    bool          // For S3-compatible services, usually true
+	Private         bool          // Private bucket: skip public-read ACL, serve via signed URLs
+	Timeout         time.Duration // Per-operation deadline (0 = DefaultS3Timeout)
+
+	// PrefixPrivate overrides Private for keys under a top-level prefix,
+	// e.g. {"faskes": true} keeps faskes photos private in an otherwise public bucket
+	PrefixPrivate map[string]bool
+
+	// CacheControl is set on every uploaded public object so the CDN and browsers can cache it
+	// (empty = DefaultS3CacheControl)
+	CacheControl string
 }
 
+// DefaultS3Timeout bounds a single S3 operation so a hung endpoint can't block a sync
+const DefaultS3Timeout = 60 * time.Second
+
+// DefaultS3CacheControl lets photos, which never change after upload, be cached for a week
+const DefaultS3CacheControl = "public, max-age=604800"
+
+// PrivateS3CacheControl is set on objects under private prefixes instead of CacheControl
+const PrivateS3CacheControl = "private, no-store"
+
 // NewS3Storage creates a new S3 storage client
 func NewS3Storage(cfg S3Config) (*S3Storage, error) {
 	if cfg.Region == "" {
 		cfg.Region = "auto"
 	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultS3Timeout
+	}
+	if cfg.CacheControl == "" {
+		cfg.CacheControl = DefaultS3CacheControl
+	}
 
 	// Create custom resolver for S3-compatible endpoint
 	customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
@@ -79,20 +111,41 @@ func NewS3Storage(cfg S3Config) (*S3Storage, error) {
 		bucket:     cfg.Bucket,
 		baseURL:    baseURL,
 		pathPrefix: cfg.PathPrefix,
+		private:    cfg.Private,
+		timeout:    cfg.Timeout,
+
+		prefixPrivate: cfg.PrefixPrivate,
+		cacheControl:  cfg.CacheControl,
 	}, nil
 }
 
+// withTimeout derives the per-operation deadline from the caller's context
+func (s *S3Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.timeout)
+}
+
 // Upload uploads a file to S3
 func (s *S3Storage) Upload(ctx context.Context, key string, data []byte, contentType string) (string, error) {
 	fullKey := s.buildKey(key)
 
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(fullKey),
-		Body:        bytes.NewReader(data),
-		ContentType: aws.String(contentType),
-		ACL:         "public-read", // Make publicly readable
-	})
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	input := &s3.PutObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(fullKey),
+		Body:         bytes.NewReader(data),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String(s.cacheControl),
+	}
+	if s.IsPrivateKey(key) {
+		// Signed URLs must not leave copies in shared caches
+		input.CacheControl = aws.String(PrivateS3CacheControl)
+	} else {
+		input.ACL = "public-read" // Make publicly readable
+	}
+
+	_, err := s.client.PutObject(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload to S3: %w", err)
 	}
@@ -115,6 +168,9 @@ func (s *S3Storage) UploadFromReader(ctx context.Context, key string, reader io.
 func (s *S3Storage) Download(ctx context.Context, key string) ([]byte, error) {
 	fullKey := s.buildKey(key)
 
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fullKey),
@@ -127,15 +183,26 @@ func (s *S3Storage) Download(ctx context.Context, key string) ([]byte, error) {
 	return io.ReadAll(result.Body)
 }
 
-// GetReader returns a reader for streaming download
+// GetReader returns a reader for streaming download.
+// The timeout applies until the object response arrives; the body is then streamed
+// for as long as the caller needs and the context is released on Close.
 func (s *S3Storage) GetReader(ctx context.Context, key string) (io.ReadCloser, string, error) {
 	fullKey := s.buildKey(key)
 
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(s.timeout, cancel)
+
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fullKey),
 	})
+	if !timer.Stop() && err == nil {
+		// Deadline fired just as the response arrived; the body is unusable
+		result.Body.Close()
+		err = context.DeadlineExceeded
+	}
 	if err != nil {
+		cancel()
 		return nil, "", fmt.Errorf("failed to get object from S3: %w", err)
 	}
 
@@ -144,13 +211,27 @@ func (s *S3Storage) GetReader(ctx context.Context, key string) (io.ReadCloser, s
 		contentType = *result.ContentType
 	}
 
-	return result.Body, contentType, nil
+	return &cancelOnClose{ReadCloser: result.Body, cancel: cancel}, contentType, nil
+}
+
+// cancelOnClose releases a stream's context when the body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
 }
 
 // Delete deletes a file from S3
 func (s *S3Storage) Delete(ctx context.Context, key string) error {
 	fullKey := s.buildKey(key)
 
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fullKey),
@@ -166,6 +247,9 @@ func (s *S3Storage) Delete(ctx context.Context, key string) error {
 func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
 	fullKey := s.buildKey(key)
 
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fullKey),
@@ -217,11 +301,40 @@ func (s *S3Storage) GetBucket() string {
 	return s.bucket
 }
 
+// IsPrivate reports whether objects are private and must be served via signed URLs
+func (s *S3Storage) IsPrivate() bool {
+	return s.private
+}
+
+// IsPrivateKey reports whether the object at key (e.g. "faskes/<id>/foto.jpg") is private,
+// taking per-prefix overrides into account
+func (s *S3Storage) IsPrivateKey(key string) bool {
+	prefix, _, _ := strings.Cut(strings.TrimPrefix(key, "/"), "/")
+	if private, ok := s.prefixPrivate[prefix]; ok {
+		return private
+	}
+	return s.private
+}
+
 // GetBaseURL returns the base URL
 func (s *S3Storage) GetBaseURL() string {
 	return s.baseURL
 }
 
+// SniffContentType returns the MIME type of data from its first 512 bytes, without
+// parameters such as charset. Content that isn't recognised falls back to the extension
+// of filename, which ODK attachments don't always get right.
+func SniffContentType(data []byte, filename string) string {
+	contentType := http.DetectContentType(data)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	if contentType == "application/octet-stream" {
+		return DetectContentType(filename)
+	}
+	return contentType
+}
+
 // DetectContentType returns content type based on file extension
 func DetectContentType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -129,15 +129,15 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
-CREATE TRIGGER set_updated_at_locations
+CREATE OR REPLACE TRIGGER set_updated_at_locations
     BEFORE UPDATE ON locations
     FOR EACH ROW EXECUTE FUNCTION trigger_set_updated_at();
 
-CREATE TRIGGER set_updated_at_feeds
+CREATE OR REPLACE TRIGGER set_updated_at_feeds
     BEFORE UPDATE ON information_feeds
     FOR EACH ROW EXECUTE FUNCTION trigger_set_updated_at();
 
-CREATE TRIGGER set_updated_at_sync_state
+CREATE OR REPLACE TRIGGER set_updated_at_sync_state
     BEFORE UPDATE ON sync_state
     FOR EACH ROW EXECUTE FUNCTION trigger_set_updated_at();
 
@@ -75,7 +75,7 @@ CREATE INDEX IF NOT EXISTS idx_faskes_photos_faskes ON faskes_photos(faskes_id);
 -- ===========================================
 -- TRIGGER: Auto-update updated_at for faskes
 -- ===========================================
-CREATE TRIGGER set_updated_at_faskes
+CREATE OR REPLACE TRIGGER set_updated_at_faskes
     BEFORE UPDATE ON faskes
     FOR EACH ROW EXECUTE FUNCTION trigger_set_updated_at();
 
@@ -91,7 +91,7 @@ CREATE INDEX IF NOT EXISTS idx_infrastruktur_photos_infra ON infrastruktur_photo
 -- ===========================================
 -- TRIGGER: Auto-update updated_at for infrastruktur
 -- ===========================================
-CREATE TRIGGER set_updated_at_infrastruktur
+CREATE OR REPLACE TRIGGER set_updated_at_infrastruktur
     BEFORE UPDATE ON infrastruktur
     FOR EACH ROW EXECUTE FUNCTION trigger_set_updated_at();
 
@@ -0,0 +1,12 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Add submission_count to locations
+-- ===========================================
+
+-- Number of approved ODK submissions (baru + update) belonging to the posko entity
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS submission_count INTEGER NOT NULL DEFAULT 1;
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'submission_count column added to locations table!';
+END $$;
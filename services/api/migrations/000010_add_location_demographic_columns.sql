@@ -0,0 +1,39 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Precomputed demographic columns on locations
+-- ===========================================
+-- data_pengungsi JSONB stays the source of truth; these columns are
+-- recomputed by the sync on every create/update so lists can filter/sort.
+
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS jumlah_kk INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS total_jiwa INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS jumlah_perempuan INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS jumlah_laki INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS jumlah_balita INTEGER NOT NULL DEFAULT 0;
+
+-- Backfill from data_pengungsi (non-numeric values count as 0)
+CREATE OR REPLACE FUNCTION jsonb_num(j JSONB, k TEXT) RETURNS NUMERIC AS $$
+    SELECT CASE WHEN jsonb_typeof(j->k) = 'number' THEN trunc((j->>k)::numeric) ELSE 0 END;
+$$ LANGUAGE sql IMMUTABLE;
+
+UPDATE locations SET
+    jumlah_kk = jsonb_num(data_pengungsi, 'jumlah_kk'),
+    total_jiwa = jsonb_num(data_pengungsi, 'total_jiwa'),
+    jumlah_perempuan = jsonb_num(data_pengungsi, 'dewasa_perempuan') + jsonb_num(data_pengungsi, 'remaja_perempuan')
+        + jsonb_num(data_pengungsi, 'anak_perempuan') + jsonb_num(data_pengungsi, 'balita_perempuan')
+        + jsonb_num(data_pengungsi, 'bayi_perempuan'),
+    jumlah_laki = jsonb_num(data_pengungsi, 'dewasa_laki') + jsonb_num(data_pengungsi, 'remaja_laki')
+        + jsonb_num(data_pengungsi, 'anak_laki') + jsonb_num(data_pengungsi, 'balita_laki')
+        + jsonb_num(data_pengungsi, 'bayi_laki'),
+    jumlah_balita = jsonb_num(data_pengungsi, 'balita_perempuan') + jsonb_num(data_pengungsi, 'balita_laki')
+        + jsonb_num(data_pengungsi, 'bayi_perempuan') + jsonb_num(data_pengungsi, 'bayi_laki')
+WHERE data_pengungsi IS NOT NULL;
+
+DROP FUNCTION jsonb_num(JSONB, TEXT);
+
+CREATE INDEX IF NOT EXISTS idx_locations_total_jiwa ON locations(total_jiwa);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'Demographic columns added to locations table!';
+END $$;
@@ -0,0 +1,19 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Canonical status spellings
+-- ===========================================
+-- Posko used 'operational' while faskes used 'operasional'. Both now use the
+-- canonical values from model.NormalizeStatus (operasional, non_aktif, ...),
+-- which the mappers apply at sync time.
+
+UPDATE locations SET status = 'operasional' WHERE status IN ('operational', 'aktif', 'active');
+UPDATE locations SET status = 'non_aktif' WHERE status IN ('nonaktif', 'non-aktif', 'non aktif', 'tidak_aktif', 'inactive', 'tutup');
+ALTER TABLE locations ALTER COLUMN status SET DEFAULT 'operasional';
+
+UPDATE faskes SET status_faskes = 'operasional' WHERE status_faskes IN ('operational', 'aktif', 'active');
+UPDATE faskes SET status_faskes = 'non_aktif' WHERE status_faskes IN ('nonaktif', 'non-aktif', 'non aktif', 'tidak_aktif', 'inactive', 'tutup');
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'Status values normalized!';
+END $$;
@@ -0,0 +1,21 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Persisted entity -> submission mapping
+-- ===========================================
+-- Cache of GetEntitySubmissionMapping (one ODK request per entity), reused
+-- across restarts until it is older than ENTITY_MAPPING_TTL_MINUTES.
+
+CREATE TABLE IF NOT EXISTS entity_mapping (
+    dataset VARCHAR(255) NOT NULL,
+    entity_uuid VARCHAR(255) NOT NULL,
+    submission_id VARCHAR(255) NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (dataset, entity_uuid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entity_mapping_fetched ON entity_mapping(dataset, fetched_at);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'entity_mapping table created!';
+END $$;
@@ -0,0 +1,21 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Add source (provenance) to locations
+-- ===========================================
+-- odk_live: live form submission with final_* calculated fields
+-- odk_dump: data dump with nested grp_* fields only
+-- manual:   not created from an ODK submission
+
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'manual';
+
+-- Backfill from the stored submission, using the same rule as the mapper
+UPDATE locations SET source = CASE
+    WHEN EXISTS (SELECT 1 FROM jsonb_object_keys(raw_data) AS k WHERE k LIKE 'final\_%') THEN 'odk_live'
+    ELSE 'odk_dump'
+END
+WHERE raw_data IS NOT NULL AND jsonb_typeof(raw_data) = 'object';
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'source column added to locations table!';
+END $$;
@@ -0,0 +1,26 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Infrastruktur progress history
+-- ===========================================
+-- One row per approved ODK submission of a road/bridge, so repair progress
+-- can be shown over time (GET /infrastruktur/:id/timeline).
+
+CREATE TABLE IF NOT EXISTS infrastruktur_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    infrastruktur_id UUID NOT NULL REFERENCES infrastruktur(id) ON DELETE CASCADE,
+    odk_submission_id VARCHAR(255) NOT NULL,
+    progress INTEGER DEFAULT 0,
+    status_penanganan VARCHAR(100),
+    update_by VARCHAR(255),
+    submitted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT uq_infrastruktur_history_submission UNIQUE(infrastruktur_id, odk_submission_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_infrastruktur_history_submitted ON infrastruktur_history(infrastruktur_id, submitted_at);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'infrastruktur_history table created!';
+END $$;
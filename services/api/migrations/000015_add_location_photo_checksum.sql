@@ -0,0 +1,15 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Track source submission and checksum of posko photos
+-- ===========================================
+-- ODK edits can reuse an attachment filename for a different image. With
+-- PHOTO_DUPLICATE_POLICY=replace the photo is re-downloaded when a newer
+-- submission carries it, and replaced only if the checksum changed.
+
+ALTER TABLE location_photos ADD COLUMN IF NOT EXISTS odk_submission_id VARCHAR(255);
+ALTER TABLE location_photos ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'odk_submission_id and checksum columns added to location_photos!';
+END $$;
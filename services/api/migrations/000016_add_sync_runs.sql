@@ -0,0 +1,29 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Sync run history
+-- ===========================================
+-- One row per completed sync of a form (posko, feed, faskes, infrastruktur),
+-- with counts and the names of created/changed/deleted records, so the two
+-- most recent runs can be compared (GET /api/v1/sync/:form/delta).
+
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    form_id VARCHAR(255) NOT NULL,
+    mode VARCHAR(20) NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    total_fetched INTEGER NOT NULL DEFAULT 0,
+    created INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    changed INTEGER NOT NULL DEFAULT 0,
+    deleted INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    changes JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_form_finished ON sync_runs(form_id, finished_at DESC);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'sync_runs table created!';
+END $$;
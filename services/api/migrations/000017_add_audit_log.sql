@@ -0,0 +1,27 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Audit log of admin mutations
+-- ===========================================
+-- One row per authenticated mutating request (sync, rebuild, remap, scheduler
+-- changes, ...): which API key, what, when and the reported effect.
+-- Read via GET /api/v1/admin/audit.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    key_id VARCHAR(64),
+    client_ip VARCHAR(64),
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    query TEXT,
+    status INTEGER,
+    duration_ms BIGINT,
+    summary JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at DESC);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'audit_log table created!';
+END $$;
@@ -0,0 +1,16 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Store photo thumbnails
+-- ===========================================
+-- Downloaded images get a JPEG preview (max 400px on the long edge) stored next
+-- to the original, served by GET /api/v1/photos/:id/thumb for map markers.
+-- NULL for non-image attachments and photos cached before this migration.
+
+ALTER TABLE location_photos ADD COLUMN IF NOT EXISTS thumbnail_path VARCHAR(1000);
+ALTER TABLE feed_photos ADD COLUMN IF NOT EXISTS thumbnail_path VARCHAR(1000);
+ALTER TABLE faskes_photos ADD COLUMN IF NOT EXISTS thumbnail_path VARCHAR(1000);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'thumbnail_path column added to location_photos, feed_photos and faskes_photos!';
+END $$;
@@ -0,0 +1,19 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Store sniffed photo content types
+-- ===========================================
+-- MIME type detected from the first bytes of a photo when it is downloaded,
+-- since ODK attachment filenames can be misleading (a .jpg that is a PNG, or
+-- no extension at all). Photo files and S3 objects are served with it.
+-- NULL for photos cached before this migration, which keep the type of their
+-- filename extension.
+
+ALTER TABLE location_photos ADD COLUMN IF NOT EXISTS content_type VARCHAR(100);
+ALTER TABLE feed_photos ADD COLUMN IF NOT EXISTS content_type VARCHAR(100);
+ALTER TABLE faskes_photos ADD COLUMN IF NOT EXISTS content_type VARCHAR(100);
+ALTER TABLE infrastruktur_photos ADD COLUMN IF NOT EXISTS content_type VARCHAR(100);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'content_type column added to location_photos, feed_photos, faskes_photos and infrastruktur_photos!';
+END $$;
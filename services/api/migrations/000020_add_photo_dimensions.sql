@@ -0,0 +1,18 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Store photo dimensions
+-- ===========================================
+-- Width and height in pixels, read from the image header when a photo is
+-- downloaded, so galleries can lay out images before loading them.
+-- NULL for non-image attachments and photos cached before this migration
+-- (POST /api/v1/admin/photos/backfill-dimensions fills those in).
+
+ALTER TABLE location_photos ADD COLUMN IF NOT EXISTS width INTEGER, ADD COLUMN IF NOT EXISTS height INTEGER;
+ALTER TABLE feed_photos ADD COLUMN IF NOT EXISTS width INTEGER, ADD COLUMN IF NOT EXISTS height INTEGER;
+ALTER TABLE faskes_photos ADD COLUMN IF NOT EXISTS width INTEGER, ADD COLUMN IF NOT EXISTS height INTEGER;
+ALTER TABLE infrastruktur_photos ADD COLUMN IF NOT EXISTS width INTEGER, ADD COLUMN IF NOT EXISTS height INTEGER;
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'width and height columns added to location_photos, feed_photos, faskes_photos and infrastruktur_photos!';
+END $$;
@@ -0,0 +1,28 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Photo migration progress
+-- ===========================================
+-- One row per run of the local-to-S3 photo migration (POST /api/v1/migrate/s3),
+-- updated after every photo so a long migration can be followed with
+-- GET /api/v1/migrate/s3/status and an interrupted one is visible after a restart.
+-- A new run picks up where it stopped: migrated photos already point at S3.
+
+CREATE TABLE IF NOT EXISTS migration_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    status VARCHAR(20) NOT NULL,
+    total INTEGER NOT NULL DEFAULT 0,
+    migrated INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    last_table VARCHAR(50),
+    last_processed_id UUID,
+    started_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_migration_jobs_started ON migration_jobs(started_at DESC);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'migration_jobs table created!';
+END $$;
@@ -0,0 +1,18 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Soft delete for feeds
+-- ===========================================
+-- Hard sync soft-deletes records no longer in ODK Central (deleted_at is set)
+-- instead of removing them, so deletions stay auditable. locations, faskes and
+-- infrastruktur already have deleted_at; this adds it to information_feeds.
+-- Soft-deleted rows are removed for good by POST /api/v1/admin/purge-deleted.
+-- Photo tables keep no deleted_at: photo rows go with their soft-deleted record.
+
+ALTER TABLE information_feeds ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_feeds_deleted ON information_feeds(deleted_at) WHERE deleted_at IS NULL;
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'deleted_at column added to information_feeds!';
+END $$;
@@ -0,0 +1,19 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Feed content truncation flag
+-- ===========================================
+-- Feed sync cuts content longer than FEED_CONTENT_MAX_LENGTH. The flag was kept as
+-- "_content_truncated" inside raw_data, which is meant to hold the ODK submission
+-- as received; it gets its own column and is removed from raw_data.
+
+ALTER TABLE information_feeds ADD COLUMN IF NOT EXISTS content_truncated BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE information_feeds
+SET content_truncated = TRUE,
+    raw_data = raw_data - '_content_truncated'
+WHERE raw_data->>'_content_truncated' = 'true';
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'content_truncated column added to information_feeds!';
+END $$;
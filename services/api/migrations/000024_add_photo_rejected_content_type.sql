@@ -0,0 +1,18 @@
+-- ===========================================
+-- DAYAWARGA SENYAR 2025 - Remember attachments rejected by the content type allowlist
+-- ===========================================
+-- MIME type an attachment was sniffed as when it was rejected by
+-- ATTACHMENT_ALLOWED_TYPES. Photo syncs skip these rows without downloading
+-- them again until the allowlist admits the type. NULL for photos that were
+-- never rejected; cleared when the photo is downloaded.
+
+ALTER TABLE location_photos ADD COLUMN IF NOT EXISTS rejected_content_type VARCHAR(100);
+ALTER TABLE feed_photos ADD COLUMN IF NOT EXISTS rejected_content_type VARCHAR(100);
+ALTER TABLE faskes_photos ADD COLUMN IF NOT EXISTS rejected_content_type VARCHAR(100);
+ALTER TABLE infrastruktur_photos ADD COLUMN IF NOT EXISTS rejected_content_type VARCHAR(100);
+
+-- Success message
+DO $$
+BEGIN
+    RAISE NOTICE 'rejected_content_type column added to location_photos, feed_photos, faskes_photos and infrastruktur_photos!';
+END $$;
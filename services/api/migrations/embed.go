@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned SQL schema migrations.
+// Files are named <version>_<name>.sql and applied in version order by internal/migrate.
+// The same directory is mounted into postgres' docker-entrypoint-initdb.d for fresh databases.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
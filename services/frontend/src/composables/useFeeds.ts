@@ -55,7 +55,7 @@ export function useFeeds() {
       locationId: feed.location_id,
       content: feed.content,
       category: feed.category,
-      type: feed.type ?? '',
+      tags: feed.tags ?? [],
       coordinates: feed.coordinates,
     }))
   })
@@ -54,11 +54,7 @@ export interface LocationDetail {
   fasilitas: Record<string, unknown>
   komunikasi?: Record<string, unknown>
   akses?: Record<string, unknown>
-  photos: {
-    type: string
-    filename: string
-    url: string
-  }[]
+  photos: Photo[]
   meta: {
     submitted_at?: string
     updated_at: string
@@ -66,12 +62,7 @@ export interface LocationDetail {
   }
 }
 
-export interface FeedPhoto {
-  id: string
-  type: string
-  filename: string
-  url: string
-}
+export type FeedPhoto = Photo
 
 export interface FeedRegion {
   provinsi?: string
@@ -92,7 +83,7 @@ export interface Feed {
   faskes_name?: string
   content: string
   category: string
-  type?: string
+  tags?: string[]
   username?: string
   organization?: string
   submitted_at: string
@@ -108,7 +99,10 @@ export interface Photo {
   is_cached: boolean
   file_size?: number
   url?: string
+  thumbnail_url?: string
   created_at: string
+  width?: number
+  height?: number
 }
 
 export interface APIResponse<T> {
@@ -236,11 +230,7 @@ export interface FaskesDetail {
   sdm?: Record<string, unknown>
   perbekalan?: Record<string, unknown>
   klaster?: Record<string, unknown>
-  photos: {
-    type: string
-    filename: string
-    url: string
-  }[]
+  photos: Photo[]
   meta: {
     submitted_at?: string
     updated_at: string
@@ -309,11 +299,7 @@ export interface InfrastrukturDetail {
   target_selesai?: string
   baseline_sumber?: string
   update_by?: string
-  photos: {
-    type: string
-    filename: string
-    url: string
-  }[]
+  photos: Photo[]
   meta: {
     submitted_at?: string
     updated_at: string